@@ -1,13 +1,68 @@
 package authz
 
+import (
+	"fmt"
+	"net/http"
+)
+
 const (
 	// Access token scopes.
-	ScopeUserAll       = "user:all"        // Full control of all resources accessible to the user account.
-	ScopeSiteAdminSudo = "site-admin:sudo" // Ability to perform any action as any other user.
+	ScopeUserAll        = "user:all"        // Full control of all resources accessible to the user account.
+	ScopeSiteAdminSudo  = "site-admin:sudo" // Ability to perform any action as any other user.
+	ScopeSearchRead     = "search:read"     // Ability to run searches.
+	ScopeRepoRead       = "repo:read"       // Read access to repositories and their contents.
+	ScopeCampaignsWrite = "campaigns:write" // Ability to view, create, update and delete campaigns.
+	ScopeSettingsWrite  = "settings:write"  // Ability to view and edit settings.
 )
 
 // AllScopes is a list of all known access token scopes.
 var AllScopes = []string{
 	ScopeUserAll,
 	ScopeSiteAdminSudo,
+	ScopeSearchRead,
+	ScopeRepoRead,
+	ScopeCampaignsWrite,
+	ScopeSettingsWrite,
+}
+
+// SatisfyingScopes returns the scopes that each permit the operations of the
+// given scope. The scope "user:all" permits everything the narrower scopes
+// permit, but not the operations of "site-admin:sudo".
+func SatisfyingScopes(scope string) []string {
+	switch scope {
+	case ScopeSearchRead, ScopeRepoRead, ScopeCampaignsWrite, ScopeSettingsWrite:
+		return []string{scope, ScopeUserAll}
+	}
+	return []string{scope}
+}
+
+// HasScope reports whether the granted scopes permit the operations of the
+// given scope.
+func HasScope(granted []string, scope string) bool {
+	for _, s := range SatisfyingScopes(scope) {
+		for _, g := range granted {
+			if g == s {
+				return true
+			}
+		}
+	}
+	return false
 }
+
+// InsufficientScopeError is returned when an actor authenticated with an
+// access token attempts an operation that its scopes don't permit.
+type InsufficientScopeError struct {
+	// Operation is a description of the attempted operation, such as
+	// "Mutation.createCampaign".
+	Operation string
+	// Scope is the scope required by the operation.
+	Scope string
+	// Granted are the scopes of the access token.
+	Granted []string
+}
+
+func (e *InsufficientScopeError) Error() string {
+	return fmt.Sprintf("access token scopes %q do not permit %s (requires scope %q)", e.Granted, e.Operation, e.Scope)
+}
+
+func (e *InsufficientScopeError) HTTPStatusCode() int { return http.StatusForbidden }
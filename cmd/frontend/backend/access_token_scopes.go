@@ -0,0 +1,19 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+)
+
+// CheckActorHasScope returns an *authz.InsufficientScopeError if the current actor was authenticated
+// with an access token whose scopes don't permit the operation, which requires the given scope.
+// Actors that weren't authenticated with an access token are not restricted by scopes.
+func CheckActorHasScope(ctx context.Context, operation, scope string) error {
+	a := actor.FromContext(ctx)
+	if a.Scopes == nil || authz.HasScope(a.Scopes, scope) {
+		return nil
+	}
+	return &authz.InsufficientScopeError{Operation: operation, Scope: scope, Granted: a.Scopes}
+}
@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+)
+
+// LogAuditEvent records that the current actor performed an administrative or security-relevant
+// action (one of the db.Audit* constants) on the object with the given GraphQL ID (if any). The
+// data describes the action and is marshaled to JSON; it must never contain secrets.
+//
+// The action has already been performed by the time it is recorded, so failures are logged
+// rather than returned.
+func LogAuditEvent(ctx context.Context, action, subject string, data interface{}) {
+	LogAuditEventForUser(ctx, actor.FromContext(ctx).UID, action, subject, data)
+}
+
+// LogAuditEventForUser is like LogAuditEvent, except that it records the given user as the actor.
+// It is used when the user who performed the action differs from the current actor (e.g., for
+// sudo access tokens).
+func LogAuditEventForUser(ctx context.Context, actorUserID int32, action, subject string, data interface{}) {
+	e := &db.AuditLogEvent{
+		ActorUserID: actorUserID,
+		Action:      action,
+		Subject:     subject,
+	}
+	if data != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			log15.Error("Failed to marshal audit log event data.", "action", action, "error", err)
+			return
+		}
+		e.Data = b
+	}
+	if err := db.AuditLog.Create(ctx, e); err != nil {
+		log15.Error("Failed to record audit log event.", "action", action, "actor", actorUserID, "subject", subject, "error", err)
+	}
+}
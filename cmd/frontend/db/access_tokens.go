@@ -11,6 +11,7 @@ import (
 	"github.com/keegancsmith/sqlf"
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
 )
 
@@ -24,6 +25,7 @@ type AccessToken struct {
 	CreatorUserID int32
 	CreatedAt     time.Time
 	LastUsedAt    *time.Time
+	ExpiresAt     *time.Time // nil if the access token doesn't expire
 }
 
 // ErrAccessTokenNotFound occurs when a database operation expects a specific access token to exist
@@ -46,11 +48,13 @@ type accessTokens struct{}
 // space; also bcrypt is slow and would add noticeable latency to each request that supplied a
 // token.
 //
+// If expiresAt is non-nil, the access token is invalid after that time.
+//
 // 🚨 SECURITY: The caller must ensure that the actor is permitted to create tokens for the
 // specified user (i.e., that the actor is either the user or a site admin).
-func (s *accessTokens) Create(ctx context.Context, subjectUserID int32, scopes []string, note string, creatorUserID int32) (id int64, token string, err error) {
+func (s *accessTokens) Create(ctx context.Context, subjectUserID int32, scopes []string, note string, creatorUserID int32, expiresAt *time.Time) (id int64, token string, err error) {
 	if Mocks.AccessTokens.Create != nil {
-		return Mocks.AccessTokens.Create(subjectUserID, scopes, note, creatorUserID)
+		return Mocks.AccessTokens.Create(subjectUserID, scopes, note, creatorUserID, expiresAt)
 	}
 
 	var b [20]byte
@@ -76,25 +80,26 @@ creator_user AS (
   SELECT id FROM users WHERE id=$5 AND deleted_at IS NULL FOR UPDATE
 ),
 insert_values AS (
-  SELECT subject_user.id AS subject_user_id, $2::text[] AS scopes, $3::bytea AS value_sha256, $4::text AS note, creator_user.id AS creator_user_id
+  SELECT subject_user.id AS subject_user_id, $2::text[] AS scopes, $3::bytea AS value_sha256, $4::text AS note, creator_user.id AS creator_user_id, $6::timestamp with time zone AS expires_at
   FROM subject_user, creator_user
 )
-INSERT INTO access_tokens(subject_user_id, scopes, value_sha256, note, creator_user_id) SELECT * FROM insert_values RETURNING id
+INSERT INTO access_tokens(subject_user_id, scopes, value_sha256, note, creator_user_id, expires_at) SELECT * FROM insert_values RETURNING id
 `,
-		subjectUserID, pq.Array(scopes), toSHA256Bytes(b[:]), note, creatorUserID,
+		subjectUserID, pq.Array(scopes), toSHA256Bytes(b[:]), note, creatorUserID, expiresAt,
 	).Scan(&id); err != nil {
 		return 0, "", err
 	}
 	return id, token, nil
 }
 
-// Lookup looks up the access token. If it's valid and contains the required scope, it returns the
-// subject's user ID. Otherwise ErrAccessTokenNotFound is returned.
+// Lookup looks up the access token. If it's valid and contains the required scope (or a scope
+// that permits everything the required scope does, such as "user:all"), it returns the subject's
+// user ID. Otherwise ErrAccessTokenNotFound is returned.
 //
 // Calling Lookup also updates the access token's last-used-at date.
 //
 // 🚨 SECURITY: This returns a user ID if and only if the tokenHexEncoded corresponds to a valid,
-// non-deleted access token.
+// non-deleted and unexpired access token.
 func (s *accessTokens) Lookup(ctx context.Context, tokenHexEncoded string, requiredScope string) (subjectUserID int32, err error) {
 	if Mocks.AccessTokens.Lookup != nil {
 		return Mocks.AccessTokens.Lookup(tokenHexEncoded, requiredScope)
@@ -104,9 +109,30 @@ func (s *accessTokens) Lookup(ctx context.Context, tokenHexEncoded string, requi
 		return 0, errors.New("no scope provided in access token lookup")
 	}
 
+	subjectUserID, _, err = s.lookup(ctx, tokenHexEncoded, authz.SatisfyingScopes(requiredScope))
+	return subjectUserID, err
+}
+
+// LookupScopes looks up the access token. If it's valid and unexpired, it returns the subject's user ID and the
+// scopes of the token. Otherwise ErrAccessTokenNotFound is returned.
+//
+// Calling LookupScopes also updates the access token's last-used-at date.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is only permitted to perform the operations
+// of the returned scopes.
+func (s *accessTokens) LookupScopes(ctx context.Context, tokenHexEncoded string) (subjectUserID int32, scopes []string, err error) {
+	if Mocks.AccessTokens.LookupScopes != nil {
+		return Mocks.AccessTokens.LookupScopes(tokenHexEncoded)
+	}
+	return s.lookup(ctx, tokenHexEncoded, nil)
+}
+
+// lookup looks up the access token. If anyOfScopes is non-nil, the token must have at least one of
+// them.
+func (s *accessTokens) lookup(ctx context.Context, tokenHexEncoded string, anyOfScopes []string) (subjectUserID int32, scopes []string, err error) {
 	token, err := hex.DecodeString(tokenHexEncoded)
 	if err != nil {
-		return 0, errors.Wrap(err, "AccessTokens.Lookup")
+		return 0, nil, errors.Wrap(err, "AccessTokens.Lookup")
 	}
 
 	if err := dbconn.Global.QueryRowContext(ctx,
@@ -118,18 +144,19 @@ WHERE t.id IN (
 	JOIN users subject_user ON t2.subject_user_id=subject_user.id AND subject_user.deleted_at IS NULL
 	JOIN users creator_user ON t2.creator_user_id=creator_user.id AND creator_user.deleted_at IS NULL
 	WHERE t2.value_sha256=$1 AND t2.deleted_at IS NULL AND
-	$2 = ANY (t2.scopes)
+	(t2.expires_at IS NULL OR t2.expires_at > now()) AND
+	($2::text[] IS NULL OR t2.scopes && $2::text[])
 )
-RETURNING t.subject_user_id
+RETURNING t.subject_user_id, t.scopes
 `,
-		toSHA256Bytes(token), requiredScope,
-	).Scan(&subjectUserID); err != nil {
+		toSHA256Bytes(token), pq.Array(anyOfScopes),
+	).Scan(&subjectUserID, pq.Array(&scopes)); err != nil {
 		if err == sql.ErrNoRows {
-			return 0, ErrAccessTokenNotFound
+			return 0, nil, ErrAccessTokenNotFound
 		}
-		return 0, err
+		return 0, nil, err
 	}
-	return subjectUserID, nil
+	return subjectUserID, scopes, nil
 }
 
 // GetByID retrieves the access token (if any) given its ID.
@@ -182,7 +209,7 @@ func (s *accessTokens) List(ctx context.Context, opt AccessTokensListOptions) ([
 
 func (s *accessTokens) list(ctx context.Context, conds []*sqlf.Query, limitOffset *LimitOffset) ([]*AccessToken, error) {
 	q := sqlf.Sprintf(`
-SELECT id, subject_user_id, scopes, note, creator_user_id, created_at, last_used_at FROM access_tokens
+SELECT id, subject_user_id, scopes, note, creator_user_id, created_at, last_used_at, expires_at FROM access_tokens
 WHERE (%s)
 ORDER BY now() - created_at < interval '5 minutes' DESC, -- show recently created tokens first
 last_used_at DESC NULLS FIRST, -- ensure newly created tokens show first
@@ -201,7 +228,7 @@ created_at DESC
 	var results []*AccessToken
 	for rows.Next() {
 		var t AccessToken
-		if err := rows.Scan(&t.ID, &t.SubjectUserID, pq.Array(&t.Scopes), &t.Note, &t.CreatorUserID, &t.CreatedAt, &t.LastUsedAt); err != nil {
+		if err := rows.Scan(&t.ID, &t.SubjectUserID, pq.Array(&t.Scopes), &t.Note, &t.CreatorUserID, &t.CreatedAt, &t.LastUsedAt, &t.ExpiresAt); err != nil {
 			return nil, err
 		}
 		results = append(results, &t)
@@ -221,6 +248,35 @@ func (s *accessTokens) Count(ctx context.Context, opt AccessTokensListOptions) (
 	return count, nil
 }
 
+// ClaimExpiryReminders returns the unexpired access tokens that expire before the given time and
+// whose owner hasn't yet been reminded of their expiry, and records the reminder as sent for them.
+// Tokens are claimed atomically, so that concurrent callers never claim the same token and each
+// owner is reminded at most once.
+func (s *accessTokens) ClaimExpiryReminders(ctx context.Context, before time.Time) ([]*AccessToken, error) {
+	q := sqlf.Sprintf(`
+UPDATE access_tokens SET expiry_reminder_sent_at=now()
+WHERE deleted_at IS NULL AND expiry_reminder_sent_at IS NULL AND expires_at>now() AND expires_at<%s
+RETURNING id, subject_user_id, scopes, note, creator_user_id, created_at, last_used_at, expires_at`,
+		before,
+	)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*AccessToken
+	for rows.Next() {
+		var t AccessToken
+		if err := rows.Scan(&t.ID, &t.SubjectUserID, pq.Array(&t.Scopes), &t.Note, &t.CreatorUserID, &t.CreatedAt, &t.LastUsedAt, &t.ExpiresAt); err != nil {
+			return nil, err
+		}
+		results = append(results, &t)
+	}
+	return results, rows.Err()
+}
+
 // DeleteByID deletes an access token given its ID and associated subject user.
 //
 // 🚨 SECURITY: The caller must ensure that the actor is permitted to delete the token.
@@ -265,8 +321,9 @@ func toSHA256Bytes(input []byte) []byte {
 }
 
 type MockAccessTokens struct {
-	Create     func(subjectUserID int32, scopes []string, note string, creatorUserID int32) (id int64, token string, err error)
-	DeleteByID func(id int64, subjectUserID int32) error
-	Lookup     func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error)
-	GetByID    func(id int64) (*AccessToken, error)
+	Create       func(subjectUserID int32, scopes []string, note string, creatorUserID int32, expiresAt *time.Time) (id int64, token string, err error)
+	DeleteByID   func(id int64, subjectUserID int32) error
+	Lookup       func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error)
+	LookupScopes func(tokenHexEncoded string) (subjectUserID int32, scopes []string, err error)
+	GetByID      func(id int64) (*AccessToken, error)
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
 )
 
@@ -34,7 +36,7 @@ func TestAccessTokens_Create(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tid0, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a", "b"}, "n0", creator.ID)
+	tid0, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a", "b"}, "n0", creator.ID, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -108,11 +110,11 @@ func TestAccessTokens_List(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, _, err = AccessTokens.Create(ctx, subject1.ID, []string{"a", "b"}, "n0", subject1.ID)
+	_, _, err = AccessTokens.Create(ctx, subject1.ID, []string{"a", "b"}, "n0", subject1.ID, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, _, err = AccessTokens.Create(ctx, subject1.ID, []string{"a", "b"}, "n1", subject1.ID)
+	_, _, err = AccessTokens.Create(ctx, subject1.ID, []string{"a", "b"}, "n1", subject1.ID, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -187,7 +189,7 @@ func TestAccessTokens_Lookup(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tid0, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a", "b"}, "n0", creator.ID)
+	tid0, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a", "b"}, "n0", creator.ID, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -212,6 +214,30 @@ func TestAccessTokens_Lookup(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	gotSubjectUserID, gotScopes, err := AccessTokens.LookupScopes(ctx, tv0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := subject.ID; gotSubjectUserID != want {
+		t.Errorf("got %v, want %v", gotSubjectUserID, want)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(gotScopes, want) {
+		t.Errorf("got %v, want %v", gotScopes, want)
+	}
+
+	// Lookup a narrower scope with a "user:all" token and ensure it succeeds, but not for
+	// "site-admin:sudo".
+	_, tv1, err := AccessTokens.Create(ctx, subject.ID, []string{authz.ScopeUserAll}, "n1", creator.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AccessTokens.Lookup(ctx, tv1, authz.ScopeSearchRead); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AccessTokens.Lookup(ctx, tv1, authz.ScopeSiteAdminSudo); err == nil {
+		t.Fatal("want error for site-admin:sudo")
+	}
+
 	// Delete a token and ensure Lookup fails on it.
 	if err := AccessTokens.DeleteByID(ctx, tid0, subject.ID); err != nil {
 		t.Fatal(err)
@@ -255,7 +281,7 @@ func TestAccessTokens_Lookup_deletedUser(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		_, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a"}, "n0", creator.ID)
+		_, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a"}, "n0", creator.ID, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -266,7 +292,7 @@ func TestAccessTokens_Lookup_deletedUser(t *testing.T) {
 			t.Fatal("Lookup: want error looking up token for deleted subject user")
 		}
 
-		if _, _, err := AccessTokens.Create(ctx, subject.ID, nil, "n0", creator.ID); err == nil {
+		if _, _, err := AccessTokens.Create(ctx, subject.ID, nil, "n0", creator.ID, nil); err == nil {
 			t.Fatal("Create: want error creating token for deleted subject user")
 		}
 	})
@@ -291,7 +317,7 @@ func TestAccessTokens_Lookup_deletedUser(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		_, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a"}, "n0", creator.ID)
+		_, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a"}, "n0", creator.ID, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -302,8 +328,68 @@ func TestAccessTokens_Lookup_deletedUser(t *testing.T) {
 			t.Fatal("Lookup: want error looking up token for deleted creator user")
 		}
 
-		if _, _, err := AccessTokens.Create(ctx, subject.ID, nil, "n0", creator.ID); err == nil {
+		if _, _, err := AccessTokens.Create(ctx, subject.ID, nil, "n0", creator.ID, nil); err == nil {
 			t.Fatal("Create: want error creating token for deleted creator user")
 		}
 	})
 }
+
+// 🚨 SECURITY: This tests that expired access tokens are rejected.
+func TestAccessTokens_Lookup_expired(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	subject, err := Users.Create(ctx, NewUser{
+		Email:                 "u1@example.com",
+		Username:              "u1",
+		Password:              "p1",
+		EmailVerificationCode: "c1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	_, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a"}, "n0", subject.ID, &past)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AccessTokens.Lookup(ctx, tv0, "a"); err != ErrAccessTokenNotFound {
+		t.Fatalf("Lookup: got error %v, want %v", err, ErrAccessTokenNotFound)
+	}
+	if _, _, err := AccessTokens.LookupScopes(ctx, tv0); err != ErrAccessTokenNotFound {
+		t.Fatalf("LookupScopes: got error %v, want %v", err, ErrAccessTokenNotFound)
+	}
+
+	future := time.Now().Add(24 * time.Hour)
+	tid1, tv1, err := AccessTokens.Create(ctx, subject.ID, []string{"a"}, "n1", subject.ID, &future)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AccessTokens.Lookup(ctx, tv1, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the unexpired token that expires soon is claimed for a reminder, and only once.
+	soon := time.Now().Add(48 * time.Hour)
+	expiring, err := AccessTokens.ClaimExpiryReminders(ctx, soon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expiring) != 1 || expiring[0].ID != tid1 {
+		t.Fatalf("got %+v, want only token %d", expiring, tid1)
+	}
+	if expiring[0].ExpiresAt == nil || !expiring[0].ExpiresAt.Equal(future.Truncate(time.Microsecond)) {
+		t.Errorf("got ExpiresAt %v, want %v", expiring[0].ExpiresAt, future)
+	}
+	expiring, err = AccessTokens.ClaimExpiryReminders(ctx, soon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expiring) != 0 {
+		t.Errorf("got %d expiring tokens after reminder, want 0", len(expiring))
+	}
+}
@@ -0,0 +1,233 @@
+package db
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/pkg/errors"
+	"github.com/segmentio/fasthash/fnv1"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+)
+
+// auditLogKey is the key of the HMAC that chains the audit log events. It is not stored in the
+// database, so that anyone who can only write to the database can't forge a valid chain.
+var auditLogKey = env.Get("SRC_AUDIT_LOG_KEY", "", "secret key used to sign the chain of audit log events (must not change)")
+
+// auditLogLockKey is the key of the advisory lock that serializes appends to the audit log.
+var auditLogLockKey = int64(fnv1.HashString32("audit_log"))
+
+// Audit log actions. Actions are named "<noun>.<verb>".
+const (
+	AuditSiteConfigUpdate      = "site_config.update"
+	AuditSettingsUpdate        = "settings.update"
+	AuditUserSetSiteAdmin      = "user.set_site_admin"
+	AuditUserDelete            = "user.delete"
+	AuditOrgDelete             = "org.delete"
+	AuditAccessTokenCreate     = "access_token.create"
+	AuditAccessTokenDelete     = "access_token.delete"
+	AuditAccessTokenSudo       = "access_token.sudo"
+	AuditExternalServiceCreate = "external_service.create"
+	AuditExternalServiceUpdate = "external_service.update"
+	AuditExternalServiceDelete = "external_service.delete"
+)
+
+// AuditLogEvent is a record of an administrative or security-relevant action.
+type AuditLogEvent struct {
+	ID          int64
+	ActorUserID int32           // the user who performed the action, or 0 if none
+	Action      string          // one of the Audit* constants
+	Subject     string          // the GraphQL ID of the affected object, if any
+	Data        json.RawMessage // action-specific details (never secrets)
+	CreatedAt   time.Time
+
+	// Hash is the HMAC-SHA-256 of the event's fields and the previous event's hash, keyed by
+	// SRC_AUDIT_LOG_KEY. Recomputing the chain of hashes detects events that were modified or
+	// removed.
+	Hash []byte
+}
+
+type auditLog struct{}
+
+// Create appends an event to the audit log. It sets e.ID, e.CreatedAt and e.Hash.
+func (*auditLog) Create(ctx context.Context, e *AuditLogEvent) error {
+	if Mocks.AuditLog.Create != nil {
+		return Mocks.AuditLog.Create(e)
+	}
+
+	if len(e.Data) == 0 {
+		e.Data = json.RawMessage(`{}`)
+	}
+
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		// Serialize appends so that each event's hash covers the hash of the event before it. Only
+		// appends wait for the lock, reads of the audit log don't.
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", auditLogLockKey); err != nil {
+			return err
+		}
+		var prevHash []byte
+		if err := tx.QueryRowContext(ctx, "SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1").Scan(&prevHash); err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		var actorUserID *int32
+		if e.ActorUserID != 0 {
+			actorUserID = &e.ActorUserID
+		}
+		e.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		e.Hash = auditLogEventHash(prevHash, e)
+		return tx.QueryRowContext(ctx,
+			"INSERT INTO audit_log(actor_user_id, action, subject, data, created_at, hash) VALUES($1, $2, $3, $4, $5, $6) RETURNING id",
+			actorUserID, e.Action, e.Subject, string(e.Data), e.CreatedAt, e.Hash,
+		).Scan(&e.ID)
+	})
+}
+
+// auditLogEventHash returns the hash of the event chained to the hash of the previous event.
+func auditLogEventHash(prevHash []byte, e *AuditLogEvent) []byte {
+	h := hmac.New(sha256.New, []byte(auditLogKey))
+	for _, field := range [][]byte{
+		prevHash,
+		[]byte(e.Action),
+		[]byte(e.Subject),
+		e.Data,
+		[]byte(e.CreatedAt.UTC().Format(time.RFC3339Nano)),
+	} {
+		// Length-prefix each field so that fields can't bleed into their neighbors.
+		_ = binary.Write(h, binary.BigEndian, uint64(len(field)))
+		h.Write(field)
+	}
+	_ = binary.Write(h, binary.BigEndian, e.ActorUserID)
+	return h.Sum(nil)
+}
+
+// AuditLogListOptions contains options for listing audit log events.
+type AuditLogListOptions struct {
+	ActorUserID int32  // only list events performed by this user
+	Action      string // only list events with this action
+	Subject     string // only list events affecting this object
+	Since       *time.Time
+	Until       *time.Time
+	*LimitOffset
+}
+
+func (o AuditLogListOptions) sqlConditions() []*sqlf.Query {
+	conds := []*sqlf.Query{sqlf.Sprintf("TRUE")}
+	if o.ActorUserID != 0 {
+		conds = append(conds, sqlf.Sprintf("actor_user_id=%d", o.ActorUserID))
+	}
+	if o.Action != "" {
+		conds = append(conds, sqlf.Sprintf("action=%s", o.Action))
+	}
+	if o.Subject != "" {
+		conds = append(conds, sqlf.Sprintf("subject=%s", o.Subject))
+	}
+	if o.Since != nil {
+		conds = append(conds, sqlf.Sprintf("created_at>=%s", *o.Since))
+	}
+	if o.Until != nil {
+		conds = append(conds, sqlf.Sprintf("created_at<%s", *o.Until))
+	}
+	return conds
+}
+
+// List lists audit log events that satisfy the options, most recent first.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (l *auditLog) List(ctx context.Context, opt AuditLogListOptions) ([]*AuditLogEvent, error) {
+	if Mocks.AuditLog.List != nil {
+		return Mocks.AuditLog.List(opt)
+	}
+
+	q := sqlf.Sprintf("WHERE (%s) ORDER BY id DESC %s", sqlf.Join(opt.sqlConditions(), ") AND ("), opt.LimitOffset.SQL())
+	return l.list(ctx, q)
+}
+
+func (*auditLog) list(ctx context.Context, querySuffix *sqlf.Query) ([]*AuditLogEvent, error) {
+	q := sqlf.Sprintf("SELECT id, actor_user_id, action, subject, data, created_at, hash FROM audit_log %s", querySuffix)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*AuditLogEvent
+	for rows.Next() {
+		var (
+			e           AuditLogEvent
+			actorUserID sql.NullInt64
+			data        string
+		)
+		if err := rows.Scan(&e.ID, &actorUserID, &e.Action, &e.Subject, &data, &e.CreatedAt, &e.Hash); err != nil {
+			return nil, err
+		}
+		e.ActorUserID = int32(actorUserID.Int64)
+		e.Data = json.RawMessage(data)
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// Count counts audit log events that satisfy the options (ignoring limit and offset).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*auditLog) Count(ctx context.Context, opt AuditLogListOptions) (int, error) {
+	if Mocks.AuditLog.Count != nil {
+		return Mocks.AuditLog.Count(opt)
+	}
+
+	q := sqlf.Sprintf("SELECT COUNT(*) FROM audit_log WHERE (%s)", sqlf.Join(opt.sqlConditions(), ") AND ("))
+	var count int
+	if err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// auditLogVerifyBatchSize is the number of events that Verify reads at a time.
+const auditLogVerifyBatchSize = 1000
+
+// Verify recomputes the audit log's chain of hashes. If an event was modified or removed, it
+// returns the ID of the first event whose hash doesn't match. Otherwise it returns 0.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (l *auditLog) Verify(ctx context.Context) (firstInvalidID int64, err error) {
+	if Mocks.AuditLog.Verify != nil {
+		return Mocks.AuditLog.Verify()
+	}
+
+	var (
+		prevHash []byte
+		afterID  int64
+	)
+	for {
+		events, err := l.list(ctx, sqlf.Sprintf("WHERE id>%d ORDER BY id ASC LIMIT %d", afterID, auditLogVerifyBatchSize))
+		if err != nil {
+			return 0, errors.Wrap(err, "listing audit log events")
+		}
+		for _, e := range events {
+			if !hmac.Equal(auditLogEventHash(prevHash, e), e.Hash) {
+				return e.ID, nil
+			}
+			prevHash, afterID = e.Hash, e.ID
+		}
+		if len(events) < auditLogVerifyBatchSize {
+			return 0, nil
+		}
+	}
+}
+
+// MockAuditLog allows mocking the audit log store in tests.
+type MockAuditLog struct {
+	Create func(e *AuditLogEvent) error
+	List   func(opt AuditLogListOptions) ([]*AuditLogEvent, error)
+	Count  func(opt AuditLogListOptions) (int, error)
+	Verify func() (firstInvalidID int64, err error)
+}
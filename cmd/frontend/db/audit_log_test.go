@@ -0,0 +1,142 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+)
+
+func TestAuditLog_CreateList(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	events := []*AuditLogEvent{
+		{ActorUserID: 1, Action: AuditSiteConfigUpdate},
+		{ActorUserID: 2, Action: AuditUserSetSiteAdmin, Subject: "VXNlcjoz", Data: json.RawMessage(`{"siteAdmin":true}`)},
+		{ActorUserID: 1, Action: AuditAccessTokenCreate, Subject: "VXNlcjox"},
+	}
+	for _, e := range events {
+		if err := AuditLog.Create(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		opt     AuditLogListOptions
+		wantIDs []int64
+	}{
+		{name: "all", wantIDs: []int64{events[2].ID, events[1].ID, events[0].ID}},
+		{name: "actor", opt: AuditLogListOptions{ActorUserID: 1}, wantIDs: []int64{events[2].ID, events[0].ID}},
+		{name: "action", opt: AuditLogListOptions{Action: AuditUserSetSiteAdmin}, wantIDs: []int64{events[1].ID}},
+		{name: "subject", opt: AuditLogListOptions{Subject: "VXNlcjox"}, wantIDs: []int64{events[2].ID}},
+		{name: "since", opt: AuditLogListOptions{Since: &events[1].CreatedAt}, wantIDs: []int64{events[2].ID, events[1].ID}},
+		{name: "limit", opt: AuditLogListOptions{LimitOffset: &LimitOffset{Limit: 1}}, wantIDs: []int64{events[2].ID}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := AuditLog.List(ctx, test.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotIDs []int64
+			for _, e := range got {
+				gotIDs = append(gotIDs, e.ID)
+			}
+			if len(gotIDs) != len(test.wantIDs) {
+				t.Fatalf("got IDs %v, want %v", gotIDs, test.wantIDs)
+			}
+			for i := range gotIDs {
+				if gotIDs[i] != test.wantIDs[i] {
+					t.Fatalf("got IDs %v, want %v", gotIDs, test.wantIDs)
+				}
+			}
+		})
+	}
+
+	if count, err := AuditLog.Count(ctx, AuditLogListOptions{ActorUserID: 1}); err != nil {
+		t.Fatal(err)
+	} else if count != 2 {
+		t.Errorf("got count %d, want 2", count)
+	}
+
+	got, err := AuditLog.List(ctx, AuditLogListOptions{Action: AuditUserSetSiteAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"siteAdmin":true}`; string(got[0].Data) != want {
+		t.Errorf("got data %s, want %s", got[0].Data, want)
+	}
+}
+
+// 🚨 SECURITY: This tests that the audit log is append-only and that tampering is detected.
+func TestAuditLog_Verify(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	var events []*AuditLogEvent
+	for _, action := range []string{AuditUserDelete, AuditOrgDelete, AuditExternalServiceDelete} {
+		e := &AuditLogEvent{ActorUserID: 1, Action: action}
+		if err := AuditLog.Create(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+
+	if id, err := AuditLog.Verify(ctx); err != nil {
+		t.Fatal(err)
+	} else if id != 0 {
+		t.Fatalf("got first invalid ID %d, want 0", id)
+	}
+
+	if _, err := dbconn.Global.ExecContext(ctx, "UPDATE audit_log SET action='x' WHERE id=$1", events[1].ID); err == nil {
+		t.Fatal("want error updating audit log")
+	}
+	if _, err := dbconn.Global.ExecContext(ctx, "DELETE FROM audit_log WHERE id=$1", events[1].ID); err == nil {
+		t.Fatal("want error deleting from audit log")
+	}
+
+	// Remove an event behind the trigger's back and ensure the next event is reported.
+	if _, err := dbconn.Global.ExecContext(ctx, "ALTER TABLE audit_log DISABLE TRIGGER trig_audit_log_prevent_modification"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbconn.Global.ExecContext(ctx, "DELETE FROM audit_log WHERE id=$1", events[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbconn.Global.ExecContext(ctx, "ALTER TABLE audit_log ENABLE TRIGGER trig_audit_log_prevent_modification"); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := AuditLog.Verify(ctx); err != nil {
+		t.Fatal(err)
+	} else if id != events[2].ID {
+		t.Fatalf("got first invalid ID %d, want %d", id, events[2].ID)
+	}
+}
+
+func TestAuditLogEventHash(t *testing.T) {
+	defer func(key string) { auditLogKey = key }(auditLogKey)
+
+	e := &AuditLogEvent{ActorUserID: 1, Action: AuditUserDelete, Subject: "VXNlcjoy", Data: json.RawMessage(`{}`), CreatedAt: time.Unix(1, 0)}
+	hash := func(key string) []byte {
+		auditLogKey = key
+		return auditLogEventHash([]byte("prev"), e)
+	}
+
+	if !bytes.Equal(hash("a"), hash("a")) {
+		t.Error("hash is not deterministic")
+	}
+	if bytes.Equal(hash("a"), hash("b")) {
+		t.Error("hash doesn't depend on SRC_AUDIT_LOG_KEY")
+	}
+}
@@ -38,6 +38,7 @@ var ExternalServiceKinds = map[string]ExternalServiceKind{
 	"AWSCODECOMMIT":   {CodeHost: true, JSONSchema: schema.AWSCodeCommitSchemaJSON},
 	"BITBUCKETCLOUD":  {CodeHost: true, JSONSchema: schema.BitbucketCloudSchemaJSON},
 	"BITBUCKETSERVER": {CodeHost: true, JSONSchema: schema.BitbucketServerSchemaJSON},
+	"GERRIT":          {CodeHost: true, JSONSchema: schema.GerritSchemaJSON},
 	"GITHUB":          {CodeHost: true, JSONSchema: schema.GitHubSchemaJSON},
 	"GITLAB":          {CodeHost: true, JSONSchema: schema.GitLabSchemaJSON},
 	"GITOLITE":        {CodeHost: true, JSONSchema: schema.GitoliteSchemaJSON},
@@ -425,6 +426,17 @@ func (c *ExternalServicesStore) ListGitLabConnections(ctx context.Context) ([]*s
 	return connections, nil
 }
 
+// ListGerritConnections returns a list of GerritConnection configs.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *ExternalServicesStore) ListGerritConnections(ctx context.Context) ([]*schema.GerritConnection, error) {
+	var connections []*schema.GerritConnection
+	if err := c.listConfigs(ctx, "GERRIT", &connections); err != nil {
+		return nil, err
+	}
+	return connections, nil
+}
+
 // ListGitoliteConnections returns a list of GitoliteConnection configs.
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
@@ -26,5 +26,7 @@ type MockStores struct {
 
 	ExternalServices MockExternalServices
 
+	AuditLog MockAuditLog
+
 	Authz MockAuthz
 }
@@ -90,6 +90,7 @@ func TestSavedSearchesCreate(t *testing.T) {
 		NotifySlack: true,
 		UserID:      &userID,
 		OrgID:       nil,
+		WebhookURLs: []string{},
 	}
 	if !reflect.DeepEqual(ss, want) {
 		t.Errorf("query is '%v', want '%v'", ss, want)
@@ -129,6 +130,7 @@ func TestSavedSearchesUpdate(t *testing.T) {
 		NotifySlack: true,
 		UserID:      &userID,
 		OrgID:       nil,
+		WebhookURLs: []string{"https://example.com/hook"},
 	}
 
 	updatedSearch, err := SavedSearches.Update(ctx, updated)
@@ -221,6 +223,7 @@ func TestSavedSearchesGetByUserID(t *testing.T) {
 		NotifySlack: true,
 		UserID:      &userID,
 		OrgID:       nil,
+		WebhookURLs: []string{},
 	}}
 	if !reflect.DeepEqual(savedSearch, want) {
 		t.Errorf("query is '%v+', want '%v+'", savedSearch, want)
@@ -267,6 +270,7 @@ func TestSavedSearchesGetByID(t *testing.T) {
 		NotifySlack: true,
 		UserID:      &userID,
 		OrgID:       nil,
+		WebhookURLs: []string{},
 	}}
 
 	if !reflect.DeepEqual(savedSearch, want) {
@@ -366,6 +370,7 @@ func TestListSavedSearchesByUserID(t *testing.T) {
 		NotifySlack: true,
 		UserID:      &userID,
 		OrgID:       nil,
+		WebhookURLs: []string{},
 	}, {
 		ID:          2,
 		Query:       "test",
@@ -374,6 +379,7 @@ func TestListSavedSearchesByUserID(t *testing.T) {
 		NotifySlack: true,
 		UserID:      nil,
 		OrgID:       &org1.ID,
+		WebhookURLs: []string{},
 	}, {
 		ID:          3,
 		Query:       "test",
@@ -382,6 +388,7 @@ func TestListSavedSearchesByUserID(t *testing.T) {
 		NotifySlack: true,
 		UserID:      nil,
 		OrgID:       &org2.ID,
+		WebhookURLs: []string{},
 	}}
 
 	if !reflect.DeepEqual(savedSearches, want) {
@@ -17,6 +17,7 @@ var (
 	Users                     = &users{}
 	UserEmails                = &userEmails{}
 	EventLogs                 = &eventLogs{}
+	AuditLog                  = &auditLog{}
 
 	SurveyResponses = &surveyResponses{}
 
@@ -0,0 +1,131 @@
+package graphqlbackend
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+)
+
+// fieldScopes are the access token scopes required by GraphQL fields, keyed by "Type.field".
+// Fields of the Query and Mutation types that are not listed require the "user:all" scope, other
+// fields that are not listed don't require a scope of their own. Fields that map to "" don't
+// require a scope.
+//
+// Fields are checked wherever they occur in a query, so the fields listed here for types other than
+// Query and Mutation are those that expose data beyond the scope of the top-level field through
+// which they're reached, e.g. the settings and emails of the currentUser or the contents of the
+// files found by a search.
+var fieldScopes = map[string]string{
+	"Query.__schema":            "",
+	"Query.__type":              "",
+	"Query.currentUser":         "",
+	"Query.clientConfiguration": "",
+
+	"Query.search":                  authz.ScopeSearchRead,
+	"Query.searchFilterSuggestions": authz.ScopeSearchRead,
+	"Query.savedSearches":           authz.ScopeSearchRead,
+	"Query.repoGroups":              authz.ScopeSearchRead,
+
+	"Query.repository":         authz.ScopeRepoRead,
+	"Query.repositoryRedirect": authz.ScopeRepoRead,
+	"Query.repositories":       authz.ScopeRepoRead,
+	"GitBlob.content":          authz.ScopeRepoRead,
+	"GitBlob.richHTML":         authz.ScopeRepoRead,
+	"GitBlob.highlight":        authz.ScopeRepoRead,
+	"GitBlob.blame":            authz.ScopeRepoRead,
+	"File2.content":            authz.ScopeRepoRead,
+	"File2.richHTML":           authz.ScopeRepoRead,
+	"File2.highlight":          authz.ScopeRepoRead,
+	"GitCommit.tree":           authz.ScopeRepoRead,
+	"GitCommit.blob":           authz.ScopeRepoRead,
+	"GitCommit.file":           authz.ScopeRepoRead,
+	"GitCommit.ancestors":      authz.ScopeRepoRead,
+
+	"Query.campaigns":                             authz.ScopeCampaignsWrite,
+	"Mutation.createChangesets":                   authz.ScopeCampaignsWrite,
+	"Mutation.addChangesetsToCampaign":            authz.ScopeCampaignsWrite,
+	"Mutation.createCampaign":                     authz.ScopeCampaignsWrite,
+	"Mutation.createPatchSetFromPatches":          authz.ScopeCampaignsWrite,
+	"Mutation.createPatchSetFromSearchAndReplace": authz.ScopeCampaignsWrite,
+	"Mutation.updateCampaign":                     authz.ScopeCampaignsWrite,
+	"Mutation.retryCampaign":                      authz.ScopeCampaignsWrite,
+	"Mutation.deleteCampaign":                     authz.ScopeCampaignsWrite,
+	"Mutation.closeCampaign":                      authz.ScopeCampaignsWrite,
+	"Mutation.publishCampaign":                    authz.ScopeCampaignsWrite,
+	"Mutation.publishChangeset":                   authz.ScopeCampaignsWrite,
+	"Mutation.syncChangeset":                      authz.ScopeCampaignsWrite,
+
+	"Query.settingsSubject":                authz.ScopeSettingsWrite,
+	"Query.viewerSettings":                 authz.ScopeSettingsWrite,
+	"Query.viewerConfiguration":            authz.ScopeSettingsWrite,
+	"Mutation.settingsMutation":            authz.ScopeSettingsWrite,
+	"Mutation.configurationMutation":       authz.ScopeSettingsWrite,
+	"User.latestSettings":                  authz.ScopeSettingsWrite,
+	"User.settingsCascade":                 authz.ScopeSettingsWrite,
+	"User.configurationCascade":            authz.ScopeSettingsWrite,
+	"Org.latestSettings":                   authz.ScopeSettingsWrite,
+	"Org.settingsCascade":                  authz.ScopeSettingsWrite,
+	"Org.configurationCascade":             authz.ScopeSettingsWrite,
+	"Site.latestSettings":                  authz.ScopeSettingsWrite,
+	"Site.settingsCascade":                 authz.ScopeSettingsWrite,
+	"Site.configurationCascade":            authz.ScopeSettingsWrite,
+	"SettingsSubject.latestSettings":       authz.ScopeSettingsWrite,
+	"SettingsSubject.settingsCascade":      authz.ScopeSettingsWrite,
+	"SettingsSubject.configurationCascade": authz.ScopeSettingsWrite,
+
+	"User.email":            authz.ScopeUserAll,
+	"User.emails":           authz.ScopeUserAll,
+	"User.accessTokens":     authz.ScopeUserAll,
+	"User.externalAccounts": authz.ScopeUserAll,
+	"User.session":          authz.ScopeUserAll,
+	"User.eventLogs":        authz.ScopeUserAll,
+	"User.usageStatistics":  authz.ScopeUserAll,
+	"User.surveyResponses":  authz.ScopeUserAll,
+	"Site.configuration":    authz.ScopeUserAll,
+}
+
+// fieldScope returns the access token scope required by the field of the GraphQL type, or "" if
+// the field doesn't require a scope.
+func fieldScope(typeName, fieldName string) string {
+	if scope, ok := fieldScopes[typeName+"."+fieldName]; ok {
+		return scope
+	}
+	if typeName == "Query" || typeName == "Mutation" {
+		return authz.ScopeUserAll
+	}
+	return ""
+}
+
+// checkFieldScope returns an *authz.InsufficientScopeError if the current actor was authenticated
+// with an access token whose scopes don't permit the field of the GraphQL type.
+func checkFieldScope(ctx context.Context, typeName, fieldName string) error {
+	if actor.FromContext(ctx).Scopes == nil {
+		return nil
+	}
+	scope := fieldScope(typeName, fieldName)
+	if scope == "" {
+		return nil
+	}
+	return backend.CheckActorHasScope(ctx, typeName+"."+fieldName, scope)
+}
+
+// deniedFieldContext is the context of a GraphQL field that the access token of the actor doesn't
+// permit. It is done from the start, so the executor doesn't call the field's resolver and reports
+// err as the field's error instead.
+type deniedFieldContext struct {
+	context.Context
+	err error
+}
+
+var closedDone = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+func (c deniedFieldContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (c deniedFieldContext) Done() <-chan struct{}       { return closedDone }
+func (c deniedFieldContext) Err() error                  { return c.err }
@@ -0,0 +1,162 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+func TestCheckFieldScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		scopes    []string
+		typeName  string
+		fieldName string
+		wantErr   bool
+	}{
+		{
+			name:      "not authenticated with an access token",
+			typeName:  "Mutation",
+			fieldName: "deleteSavedSearch",
+		},
+		{
+			name:      "user:all",
+			scopes:    []string{authz.ScopeUserAll},
+			typeName:  "Mutation",
+			fieldName: "deleteSavedSearch",
+		},
+		{
+			name:      "search:read permits search",
+			scopes:    []string{authz.ScopeSearchRead},
+			typeName:  "Query",
+			fieldName: "search",
+		},
+		{
+			name:      "search:read does not permit deleting saved searches",
+			scopes:    []string{authz.ScopeSearchRead},
+			typeName:  "Mutation",
+			fieldName: "deleteSavedSearch",
+			wantErr:   true,
+		},
+		{
+			name:      "search:read does not permit repositories",
+			scopes:    []string{authz.ScopeSearchRead},
+			typeName:  "Query",
+			fieldName: "repository",
+			wantErr:   true,
+		},
+		{
+			name:      "search:read does not permit file contents",
+			scopes:    []string{authz.ScopeSearchRead},
+			typeName:  "GitBlob",
+			fieldName: "content",
+			wantErr:   true,
+		},
+		{
+			name:      "repo:read permits file contents",
+			scopes:    []string{authz.ScopeSearchRead, authz.ScopeRepoRead},
+			typeName:  "File2",
+			fieldName: "content",
+		},
+		{
+			name:      "nested fields without a scope",
+			scopes:    []string{authz.ScopeSearchRead},
+			typeName:  "User",
+			fieldName: "username",
+		},
+		{
+			name:      "settings:write does not permit emails",
+			scopes:    []string{authz.ScopeSettingsWrite},
+			typeName:  "User",
+			fieldName: "emails",
+			wantErr:   true,
+		},
+		{
+			name:      "campaigns:write permits creating campaigns",
+			scopes:    []string{authz.ScopeCampaignsWrite},
+			typeName:  "Mutation",
+			fieldName: "createCampaign",
+		},
+		{
+			name:      "settings:write does not permit creating campaigns",
+			scopes:    []string{authz.ScopeSettingsWrite},
+			typeName:  "Mutation",
+			fieldName: "createCampaign",
+			wantErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1, Scopes: test.scopes})
+			err := checkFieldScope(ctx, test.typeName, test.fieldName)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// 🚨 SECURITY: This tests that access tokens can't be used for nested fields their scopes don't
+// permit.
+func TestAccessTokenScopes_nestedFields(t *testing.T) {
+	resetMocks()
+	db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: 1, Username: "alice"}, nil
+	}
+	defer resetMocks()
+
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1, Scopes: []string{authz.ScopeSearchRead}})
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Context: ctx,
+			Schema:  mustParseGraphQLSchema(t),
+			Query: `
+				{
+					currentUser {
+						username
+						latestSettings {
+							contents
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"currentUser": {
+						"username": "alice",
+						"latestSettings": null
+					}
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `access token scopes ["search:read"] do not permit User.latestSettings (requires scope "settings:write")`,
+			}},
+		},
+		{
+			Context: ctx,
+			Schema:  mustParseGraphQLSchema(t),
+			Query: `
+				mutation {
+					deleteSavedSearch(id: "x") {
+						alwaysNil
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"deleteSavedSearch": null
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `access token scopes ["search:read"] do not permit Mutation.deleteSavedSearch (requires scope "user:all")`,
+			}},
+		},
+	})
+}
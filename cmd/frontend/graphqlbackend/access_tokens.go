@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"time"
 
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
@@ -17,9 +18,10 @@ import (
 )
 
 type createAccessTokenInput struct {
-	User   graphql.ID
-	Scopes []string
-	Note   string
+	User      graphql.ID
+	Scopes    []string
+	Note      string
+	ExpiresAt *DateTime
 }
 
 func (r *schemaResolver) CreateAccessToken(ctx context.Context, args *createAccessTokenInput) (*createAccessTokenResult, error) {
@@ -46,7 +48,7 @@ func (r *schemaResolver) CreateAccessToken(ctx context.Context, args *createAcce
 	}
 
 	// Validate scopes.
-	var hasUserAllScope bool
+	var hasUserAllScope, hasSudoScope, hasOtherScope bool
 	seenScope := map[string]struct{}{}
 	sort.Strings(args.Scopes)
 	for _, scope := range args.Scopes {
@@ -58,6 +60,9 @@ func (r *schemaResolver) CreateAccessToken(ctx context.Context, args *createAcce
 			if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
 				return nil, err
 			}
+			hasSudoScope = true
+		case authz.ScopeSearchRead, authz.ScopeRepoRead, authz.ScopeCampaignsWrite, authz.ScopeSettingsWrite:
+			hasOtherScope = true
 		default:
 			return nil, fmt.Errorf("unknown access token scope %q (valid scopes: %q)", scope, authz.AllScopes)
 		}
@@ -67,12 +72,55 @@ func (r *schemaResolver) CreateAccessToken(ctx context.Context, args *createAcce
 		}
 		seenScope[scope] = struct{}{}
 	}
-	if !hasUserAllScope {
-		return nil, fmt.Errorf("all access tokens must have scope %q", authz.ScopeUserAll)
+	if hasSudoScope && !hasUserAllScope {
+		return nil, fmt.Errorf("access tokens with scope %q must also have scope %q", authz.ScopeSiteAdminSudo, authz.ScopeUserAll)
+	}
+	if !hasUserAllScope && !hasOtherScope {
+		return nil, fmt.Errorf("access tokens must have scope %q or at least one of the narrower scopes (valid scopes: %q)", authz.ScopeUserAll, authz.AllScopes)
+	}
+
+	expiresAt, err := accessTokenExpiry(args.ExpiresAt, time.Now())
+	if err != nil {
+		return nil, err
 	}
 
-	id, token, err := db.AccessTokens.Create(ctx, userID, args.Scopes, args.Note, actor.FromContext(ctx).UID)
-	return &createAccessTokenResult{id: marshalAccessTokenID(id), token: token}, err
+	id, token, err := db.AccessTokens.Create(ctx, userID, args.Scopes, args.Note, actor.FromContext(ctx).UID, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	backend.LogAuditEvent(ctx, db.AuditAccessTokenCreate, string(marshalAccessTokenID(id)), map[string]interface{}{
+		"user":      args.User,
+		"scopes":    args.Scopes,
+		"note":      args.Note,
+		"expiresAt": expiresAt,
+	})
+	return &createAccessTokenResult{id: marshalAccessTokenID(id), token: token}, nil
+}
+
+// accessTokenExpiry returns the expiration time for a new access token, applying the site's default
+// and maximum access token lifetimes to the requested expiration time (if any).
+func accessTokenExpiry(requested *DateTime, now time.Time) (*time.Time, error) {
+	maxLifetime := conf.AccessTokensMaxLifetime()
+	if requested == nil {
+		lifetime := conf.AccessTokensDefaultLifetime()
+		if lifetime == 0 || (maxLifetime != 0 && lifetime > maxLifetime) {
+			lifetime = maxLifetime
+		}
+		if lifetime == 0 {
+			return nil, nil
+		}
+		expiresAt := now.Add(lifetime)
+		return &expiresAt, nil
+	}
+
+	expiresAt := requested.Time
+	if !expiresAt.After(now) {
+		return nil, errors.New("access token expiration date must be in the future")
+	}
+	if maxLifetime != 0 && expiresAt.Sub(now) > maxLifetime {
+		return nil, fmt.Errorf("access token expiration date may be at most %d days in the future", maxLifetime/(24*time.Hour))
+	}
+	return &expiresAt, nil
 }
 
 type createAccessTokenResult struct {
@@ -115,6 +163,7 @@ func (r *schemaResolver) DeleteAccessToken(ctx context.Context, args *deleteAcce
 		if err := db.AccessTokens.DeleteByID(ctx, token.ID, token.SubjectUserID); err != nil {
 			return nil, err
 		}
+		backend.LogAuditEvent(ctx, db.AuditAccessTokenDelete, string(*args.ByID), map[string]graphql.ID{"user": MarshalUserID(token.SubjectUserID)})
 
 	case args.ByToken != nil:
 		// 🚨 SECURITY: This is easier than the ByID case because anyone holding the access token's
@@ -122,6 +171,9 @@ func (r *schemaResolver) DeleteAccessToken(ctx context.Context, args *deleteAcce
 		if err := db.AccessTokens.DeleteByToken(ctx, *args.ByToken); err != nil {
 			return nil, err
 		}
+		// The actor may not be authenticated, and the token's ID isn't known, so record only that
+		// a token was revoked by its secret value.
+		backend.LogAuditEvent(ctx, db.AuditAccessTokenDelete, "", map[string]bool{"byToken": true})
 	}
 
 	return &EmptyResponse{}, nil
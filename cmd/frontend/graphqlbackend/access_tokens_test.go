@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/gqltesting"
@@ -12,12 +13,14 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 // 🚨 SECURITY: This tests that users can't create tokens for users they aren't allowed to do so for.
 func TestMutation_CreateAccessToken(t *testing.T) {
 	mockAccessTokensCreate := func(t *testing.T, wantCreatorUserID int32, wantScopes []string) {
-		db.Mocks.AccessTokens.Create = func(subjectUserID int32, scopes []string, note string, creatorUserID int32) (int64, string, error) {
+		db.Mocks.AccessTokens.Create = func(subjectUserID int32, scopes []string, note string, creatorUserID int32, expiresAt *time.Time) (int64, string, error) {
 			if want := int32(1); subjectUserID != want {
 				t.Errorf("got %v, want %v", subjectUserID, want)
 			}
@@ -32,6 +35,12 @@ func TestMutation_CreateAccessToken(t *testing.T) {
 			}
 			return 1, "t", nil
 		}
+		db.Mocks.AuditLog.Create = func(e *db.AuditLogEvent) error {
+			if want := db.AuditAccessTokenCreate; e.Action != want {
+				t.Errorf("got audit log action %q, want %q", e.Action, want)
+			}
+			return nil
+		}
 	}
 
 	const uid1GQLID = "VXNlcjox"
@@ -76,6 +85,41 @@ func TestMutation_CreateAccessToken(t *testing.T) {
 		}
 	})
 
+	t.Run("authenticated as user, using narrow scopes", func(t *testing.T) {
+		resetMocks()
+		mockAccessTokensCreate(t, 1, []string{authz.ScopeRepoRead, authz.ScopeSearchRead})
+
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+		if _, err := (&schemaResolver{}).CreateAccessToken(ctx, &createAccessTokenInput{
+			User:   uid1GQLID,
+			Scopes: []string{authz.ScopeSearchRead, authz.ScopeRepoRead},
+			Note:   "n",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("authenticated as site admin, using sudo scope without user:all", func(t *testing.T) {
+		resetMocks()
+		db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: true}, nil
+		}
+		defer func() { db.Mocks.Users.GetByCurrentAuthUser = nil }()
+
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+		result, err := (&schemaResolver{}).CreateAccessToken(ctx, &createAccessTokenInput{
+			User:   uid1GQLID,
+			Scopes: []string{authz.ScopeSiteAdminSudo, authz.ScopeSearchRead},
+			Note:   "n",
+		})
+		if err == nil {
+			t.Error("err == nil")
+		}
+		if result != nil {
+			t.Errorf("got result %v, want nil", result)
+		}
+	})
+
 	t.Run("authenticated as user, using site-admin-only scopes", func(t *testing.T) {
 		resetMocks()
 		db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
@@ -220,6 +264,12 @@ func TestMutation_DeleteAccessToken(t *testing.T) {
 			}
 			return &db.AccessToken{ID: 1, SubjectUserID: 2}, nil
 		}
+		db.Mocks.AuditLog.Create = func(e *db.AuditLogEvent) error {
+			if want := db.AuditAccessTokenDelete; e.Action != want {
+				t.Errorf("got audit log action %q, want %q", e.Action, want)
+			}
+			return nil
+		}
 	}
 
 	token1GQLID := graphql.ID("QWNjZXNzVG9rZW46MQ==")
@@ -321,3 +371,46 @@ func TestMutation_DeleteAccessToken(t *testing.T) {
 		}
 	})
 }
+
+func TestAccessTokenExpiry(t *testing.T) {
+	defer conf.Mock(nil)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	at := func(d time.Duration) *DateTime { return &DateTime{Time: now.Add(d)} }
+
+	tests := []struct {
+		name            string
+		defaultLifetime int
+		maxLifetime     int
+		requested       *DateTime
+		want            *time.Time
+		wantErr         bool
+	}{
+		{name: "no policy, no expiry"},
+		{name: "no policy, requested", requested: at(3 * day), want: &at(3 * day).Time},
+		{name: "default lifetime", defaultLifetime: 30, want: &at(30 * day).Time},
+		{name: "default lifetime, requested", defaultLifetime: 30, requested: at(3 * day), want: &at(3 * day).Time},
+		{name: "max lifetime applies when no default", maxLifetime: 10, want: &at(10 * day).Time},
+		{name: "default capped by max", defaultLifetime: 30, maxLifetime: 10, want: &at(10 * day).Time},
+		{name: "requested beyond max", maxLifetime: 10, requested: at(11 * day), wantErr: true},
+		{name: "requested in the past", requested: at(-day), wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+				AuthAccessTokens: &schema.AuthAccessTokens{
+					DefaultLifetimeDays: test.defaultLifetime,
+					MaxLifetimeDays:     test.maxLifetime,
+				},
+			}})
+			got, err := accessTokenExpiry(test.requested, now)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
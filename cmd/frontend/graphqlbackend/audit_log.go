@@ -0,0 +1,140 @@
+package graphqlbackend
+
+import (
+	"context"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+func (r *siteResolver) AuditLog(ctx context.Context, args *struct {
+	graphqlutil.ConnectionArgs
+	Actor   *graphql.ID
+	Action  *string
+	Subject *graphql.ID
+	Since   *DateTime
+	Until   *DateTime
+}) (*auditLogEventConnectionResolver, error) {
+	// 🚨 SECURITY: Only site admins can view the audit log.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var opt db.AuditLogListOptions
+	if args.Actor != nil {
+		userID, err := UnmarshalUserID(*args.Actor)
+		if err != nil {
+			return nil, err
+		}
+		opt.ActorUserID = userID
+	}
+	if args.Action != nil {
+		opt.Action = *args.Action
+	}
+	if args.Subject != nil {
+		opt.Subject = string(*args.Subject)
+	}
+	if args.Since != nil {
+		opt.Since = &args.Since.Time
+	}
+	if args.Until != nil {
+		opt.Until = &args.Until.Time
+	}
+	args.ConnectionArgs.Set(&opt.LimitOffset)
+	return &auditLogEventConnectionResolver{opt: opt}, nil
+}
+
+// auditLogEventConnectionResolver resolves a list of audit log events.
+//
+// 🚨 SECURITY: When instantiating an auditLogEventConnectionResolver value, the caller MUST check
+// that the actor is a site admin.
+type auditLogEventConnectionResolver struct {
+	opt db.AuditLogListOptions
+
+	// cache results because they are used by multiple fields
+	once   sync.Once
+	events []*db.AuditLogEvent
+	err    error
+}
+
+func (r *auditLogEventConnectionResolver) compute(ctx context.Context) ([]*db.AuditLogEvent, error) {
+	r.once.Do(func() {
+		opt2 := r.opt
+		if opt2.LimitOffset != nil {
+			tmp := *opt2.LimitOffset
+			opt2.LimitOffset = &tmp
+			opt2.Limit++ // so we can detect if there is a next page
+		}
+
+		r.events, r.err = db.AuditLog.List(ctx, opt2)
+	})
+	return r.events, r.err
+}
+
+func (r *auditLogEventConnectionResolver) Nodes(ctx context.Context) ([]*auditLogEventResolver, error) {
+	events, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.opt.LimitOffset != nil && len(events) > r.opt.LimitOffset.Limit {
+		events = events[:r.opt.LimitOffset.Limit]
+	}
+
+	l := make([]*auditLogEventResolver, 0, len(events))
+	for _, event := range events {
+		l = append(l, &auditLogEventResolver{event: event})
+	}
+	return l, nil
+}
+
+func (r *auditLogEventConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := db.AuditLog.Count(ctx, r.opt)
+	return int32(count), err
+}
+
+func (r *auditLogEventConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	events, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt.LimitOffset != nil && len(events) > r.opt.Limit), nil
+}
+
+func (r *auditLogEventConnectionResolver) ChainIsIntact(ctx context.Context) (bool, error) {
+	firstInvalidID, err := db.AuditLog.Verify(ctx)
+	return firstInvalidID == 0, err
+}
+
+type auditLogEventResolver struct {
+	event *db.AuditLogEvent
+}
+
+func (r *auditLogEventResolver) Actor(ctx context.Context) (*UserResolver, error) {
+	if r.event.ActorUserID == 0 {
+		return nil, nil
+	}
+	user, err := UserByIDInt32(ctx, r.event.ActorUserID)
+	if err != nil && errcode.IsNotFound(err) {
+		// Don't throw an error if a user has been deleted.
+		return nil, nil
+	}
+	return user, err
+}
+
+func (r *auditLogEventResolver) Action() string { return r.event.Action }
+
+func (r *auditLogEventResolver) Subject() *graphql.ID {
+	if r.event.Subject == "" {
+		return nil
+	}
+	id := graphql.ID(r.event.Subject)
+	return &id
+}
+
+func (r *auditLogEventResolver) Data() JSONValue { return JSONValue{r.event.Data} }
+
+func (r *auditLogEventResolver) CreatedAt() DateTime { return DateTime{Time: r.event.CreatedAt} }
@@ -0,0 +1,107 @@
+package graphqlbackend
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+)
+
+// 🚨 SECURITY: This tests that only site admins can view the audit log.
+func TestSite_AuditLog(t *testing.T) {
+	t.Run("non-site-admin", func(t *testing.T) {
+		resetMocks()
+		db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1}, nil
+		}
+		defer resetMocks()
+
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+		result, err := (&siteResolver{}).AuditLog(ctx, nil)
+		if err == nil {
+			t.Error("err == nil")
+		}
+		if result != nil {
+			t.Errorf("got result %v, want nil", result)
+		}
+	})
+
+	t.Run("site admin", func(t *testing.T) {
+		resetMocks()
+		db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: true}, nil
+		}
+		db.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+			return &types.User{ID: id, Username: "alice"}, nil
+		}
+		db.Mocks.AuditLog.List = func(opt db.AuditLogListOptions) ([]*db.AuditLogEvent, error) {
+			if want := int32(2); opt.ActorUserID != want {
+				t.Errorf("got ActorUserID %d, want %d", opt.ActorUserID, want)
+			}
+			if want := db.AuditUserSetSiteAdmin; opt.Action != want {
+				t.Errorf("got Action %q, want %q", opt.Action, want)
+			}
+			return []*db.AuditLogEvent{{
+				ID:          1,
+				ActorUserID: 2,
+				Action:      db.AuditUserSetSiteAdmin,
+				Subject:     "VXNlcjoz",
+				Data:        json.RawMessage(`{"siteAdmin":true}`),
+				CreatedAt:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			}}, nil
+		}
+		db.Mocks.AuditLog.Count = func(opt db.AuditLogListOptions) (int, error) { return 1, nil }
+		db.Mocks.AuditLog.Verify = func() (int64, error) { return 0, nil }
+		defer resetMocks()
+
+		gqltesting.RunTests(t, []*gqltesting.Test{
+			{
+				Context: actor.WithActor(context.Background(), &actor.Actor{UID: 1}),
+				Schema:  mustParseGraphQLSchema(t),
+				Query: `
+				{
+					site {
+						auditLog(first: 10, actor: "VXNlcjoy", action: "user.set_site_admin") {
+							nodes {
+								actor { username }
+								action
+								subject
+								data
+								createdAt
+							}
+							totalCount
+							pageInfo { hasNextPage }
+							chainIsIntact
+						}
+					}
+				}
+			`,
+				ExpectedResult: `
+				{
+					"site": {
+						"auditLog": {
+							"nodes": [
+								{
+									"actor": { "username": "alice" },
+									"action": "user.set_site_admin",
+									"subject": "VXNlcjoz",
+									"data": { "siteAdmin": true },
+									"createdAt": "2020-01-01T00:00:00Z"
+								}
+							],
+							"totalCount": 1,
+							"pageInfo": { "hasNextPage": false },
+							"chainIsIntact": true
+						}
+					}
+				}
+			`,
+			},
+		})
+	})
+}
@@ -28,6 +28,7 @@ type CreateCampaignArgs struct {
 		Branch      *string
 		PatchSet    *graphql.ID
 		Draft       *bool
+		AutoMerge   *bool
 	}
 }
 
@@ -38,6 +39,7 @@ type UpdateCampaignArgs struct {
 		Description *string
 		Branch      *string
 		PatchSet    *graphql.ID
+		AutoMerge   *bool
 	}
 }
 
@@ -45,6 +47,12 @@ type CreatePatchSetFromPatchesArgs struct {
 	Patches []PatchInput
 }
 
+type CreatePatchSetFromSearchAndReplaceArgs struct {
+	Query       string
+	PatternType string
+	Replacement string
+}
+
 type PatchInput struct {
 	Repository   graphql.ID
 	BaseRevision api.CommitID
@@ -110,6 +118,7 @@ type CampaignsResolver interface {
 	AddChangesetsToCampaign(ctx context.Context, args *AddChangesetsToCampaignArgs) (CampaignResolver, error)
 
 	CreatePatchSetFromPatches(ctx context.Context, args CreatePatchSetFromPatchesArgs) (PatchSetResolver, error)
+	CreatePatchSetFromSearchAndReplace(ctx context.Context, args CreatePatchSetFromSearchAndReplaceArgs) (PatchSetResolver, error)
 	PatchSetByID(ctx context.Context, id graphql.ID) (PatchSetResolver, error)
 
 	PatchByID(ctx context.Context, id graphql.ID) (PatchResolver, error)
@@ -179,6 +188,10 @@ func (defaultCampaignsResolver) CreatePatchSetFromPatches(ctx context.Context, a
 	return nil, campaignsOnlyInEnterprise
 }
 
+func (defaultCampaignsResolver) CreatePatchSetFromSearchAndReplace(ctx context.Context, args CreatePatchSetFromSearchAndReplaceArgs) (PatchSetResolver, error) {
+	return nil, campaignsOnlyInEnterprise
+}
+
 func (defaultCampaignsResolver) PatchSetByID(ctx context.Context, id graphql.ID) (PatchSetResolver, error) {
 	return nil, campaignsOnlyInEnterprise
 }
@@ -216,6 +229,7 @@ type CampaignResolver interface {
 	PatchSet(ctx context.Context) (PatchSetResolver, error)
 	Status(context.Context) (BackgroundProcessStatus, error)
 	ClosedAt() *DateTime
+	AutoMerge() bool
 	PublishedAt(ctx context.Context) (*DateTime, error)
 	Patches(ctx context.Context, args *graphqlutil.ConnectionArgs) PatchConnectionResolver
 }
@@ -256,6 +270,7 @@ type ExternalChangesetResolver interface {
 	Head(ctx context.Context) (*GitRefResolver, error)
 	Base(ctx context.Context) (*GitRefResolver, error)
 	Labels(ctx context.Context) ([]ChangesetLabelResolver, error)
+	RebaseError(ctx context.Context) (*string, error)
 }
 
 type PatchConnectionResolver interface {
@@ -311,6 +326,8 @@ type PatchSetResolver interface {
 	Patches(ctx context.Context, args *graphqlutil.ConnectionArgs) PatchConnectionResolver
 
 	PreviewURL() string
+
+	Status(ctx context.Context) (BackgroundProcessStatus, error)
 }
 
 type PreviewFileDiff interface {
@@ -47,6 +47,11 @@ func (r *schemaResolver) AddExternalService(ctx context.Context, args *struct {
 	if err := db.ExternalServices.Create(ctx, conf.Get, externalService); err != nil {
 		return nil, err
 	}
+	// The configuration contains secrets, so don't record it.
+	backend.LogAuditEvent(ctx, db.AuditExternalServiceCreate, string(marshalExternalServiceID(externalService.ID)), map[string]string{
+		"kind":        externalService.Kind,
+		"displayName": externalService.DisplayName,
+	})
 
 	res := &externalServiceResolver{externalService: externalService}
 	if err := syncExternalService(ctx, externalService); err != nil {
@@ -93,6 +98,10 @@ func (*schemaResolver) UpdateExternalService(ctx context.Context, args *struct {
 	if err != nil {
 		return nil, err
 	}
+	backend.LogAuditEvent(ctx, db.AuditExternalServiceUpdate, string(args.Input.ID), map[string]interface{}{
+		"displayName":   externalService.DisplayName,
+		"configUpdated": args.Input.Config != nil,
+	})
 
 	res := &externalServiceResolver{externalService: externalService}
 	if err = syncExternalService(ctx, externalService); err != nil {
@@ -149,6 +158,10 @@ func (*schemaResolver) DeleteExternalService(ctx context.Context, args *struct {
 	if err := db.ExternalServices.Delete(ctx, id); err != nil {
 		return nil, err
 	}
+	backend.LogAuditEvent(ctx, db.AuditExternalServiceDelete, string(args.ExternalService), map[string]string{
+		"kind":        externalService.Kind,
+		"displayName": externalService.DisplayName,
+	})
 	now := time.Now()
 	externalService.DeletedAt = &now
 
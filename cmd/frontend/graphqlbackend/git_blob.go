@@ -2,9 +2,11 @@ package graphqlbackend
 
 import (
 	"context"
+	"math"
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/lfs"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
@@ -32,3 +34,33 @@ func (r *GitTreeEntryResolver) Blame(ctx context.Context,
 
 	return hunksResolver, nil
 }
+
+func (r *GitTreeEntryResolver) LFS(ctx context.Context) (*lfsResolver, error) {
+	// Only small files can be LFS pointers, so don't read larger files.
+	if r.IsDirectory() || r.stat.Size() > lfs.MaxPointerSize {
+		return nil, nil
+	}
+	content, err := r.Content(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pointer, ok := lfs.ParsePointer([]byte(content))
+	if !ok {
+		return nil, nil
+	}
+	return &lfsResolver{pointer: pointer}, nil
+}
+
+type lfsResolver struct {
+	pointer *lfs.Pointer
+}
+
+func (r *lfsResolver) OID() string { return r.pointer.OID }
+
+// ByteSize is capped at the maximum GraphQL Int.
+func (r *lfsResolver) ByteSize() int32 {
+	if r.pointer.Size > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int32(r.pointer.Size)
+}
@@ -1,9 +1,18 @@
 package graphqlbackend
 
 import (
+	"context"
+	"os"
 	"testing"
 
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/highlight"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/util"
 )
 
 func TestIsBinary(t *testing.T) {
@@ -73,3 +82,70 @@ func TestIsBinary(t *testing.T) {
 		})
 	}
 }
+
+func TestGitBlob_LFS(t *testing.T) {
+	resetMocks()
+	db.Mocks.ExternalServices.List = func(opt db.ExternalServicesListOptions) ([]*types.ExternalService, error) {
+		return nil, nil
+	}
+	db.Mocks.Repos.MockGetByName(t, "github.com/gorilla/mux", 2)
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		return exampleCommitSHA1, nil
+	}
+	backend.Mocks.Repos.MockGetCommit_Return_NoCheck(t, &git.Commit{ID: exampleCommitSHA1})
+	defer resetMocks()
+
+	files := map[string]string{
+		"fixture.bin": "version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n",
+		"README":      "hello\n",
+	}
+	git.Mocks.Stat = func(commit api.CommitID, path string) (os.FileInfo, error) {
+		return &util.FileInfo{Name_: path, Size_: int64(len(files[path]))}, nil
+	}
+	git.Mocks.ReadFile = func(commit api.CommitID, name string) ([]byte, error) {
+		return []byte(files[name]), nil
+	}
+	defer git.ResetMocks()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: mustParseGraphQLSchema(t),
+			Query: `
+				{
+					repository(name: "github.com/gorilla/mux") {
+						commit(rev: "` + exampleCommitSHA1 + `") {
+							pointer: blob(path: "fixture.bin") {
+								lfs {
+									oid
+									byteSize
+								}
+							}
+							file: blob(path: "README") {
+								lfs {
+									oid
+								}
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+{
+  "repository": {
+    "commit": {
+      "pointer": {
+        "lfs": {
+          "oid": "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393",
+          "byteSize": 12345
+        }
+      },
+      "file": {
+        "lfs": null
+      }
+    }
+  }
+}
+			`,
+		},
+	})
+}
@@ -93,6 +93,12 @@ VARIABLES
 }
 
 func (prometheusTracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, args map[string]interface{}) (context.Context, trace.TraceFieldFinishFunc) {
+	// 🚨 SECURITY: Access tokens may only be used for the fields their scopes permit. This is
+	// checked here because it is the only hook that the executor calls for every field.
+	if err := checkFieldScope(ctx, typeName, fieldName); err != nil {
+		return deniedFieldContext{Context: ctx, err: err}, func(*gqlerrors.QueryError) {}
+	}
+
 	var finish trace.TraceFieldFinishFunc
 	if ot.ShouldTrace(ctx) {
 		ctx, finish = trace.OpenTracingTracer{}.TraceField(ctx, label, typeName, fieldName, trivial, args)
@@ -3,6 +3,8 @@ package graphqlbackend
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
 
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
@@ -59,6 +61,7 @@ func savedSearchByID(ctx context.Context, id graphql.ID) (*savedSearchResolver,
 			UserID:          ss.Config.UserID,
 			OrgID:           ss.Config.OrgID,
 			SlackWebhookURL: ss.Config.SlackWebhookURL,
+			WebhookURLs:     ss.Config.WebhookURLs,
 		},
 	}
 	return savedSearch, nil
@@ -97,6 +100,13 @@ func (r savedSearchResolver) OrgID() *graphql.ID {
 }
 func (r savedSearchResolver) SlackWebhookURL() *string { return r.s.SlackWebhookURL }
 
+func (r savedSearchResolver) WebhookURLs() []string {
+	if r.s.WebhookURLs == nil {
+		return []string{}
+	}
+	return r.s.WebhookURLs
+}
+
 func toSavedSearchResolver(entry types.SavedSearch) *savedSearchResolver {
 	return &savedSearchResolver{entry}
 }
@@ -142,12 +152,14 @@ func (r *schemaResolver) SendSavedSearchTestNotification(ctx context.Context, ar
 }
 
 func (r *schemaResolver) CreateSavedSearch(ctx context.Context, args *struct {
-	Description string
-	Query       string
-	NotifyOwner bool
-	NotifySlack bool
-	OrgID       *graphql.ID
-	UserID      *graphql.ID
+	Description   string
+	Query         string
+	NotifyOwner   bool
+	NotifySlack   bool
+	OrgID         *graphql.ID
+	UserID        *graphql.ID
+	WebhookURLs   *[]string
+	WebhookSecret *string
 }) (*savedSearchResolver, error) {
 	var userID, orgID *int32
 	// 🚨 SECURITY: Make sure the current user has permission to create a saved search for the specified user or org.
@@ -177,13 +189,26 @@ func (r *schemaResolver) CreateSavedSearch(ctx context.Context, args *struct {
 		return nil, errMissingPatternType
 	}
 
+	var webhookURLs []string
+	if args.WebhookURLs != nil {
+		webhookURLs = *args.WebhookURLs
+		if err := validateWebhookURLs(webhookURLs); err != nil {
+			return nil, err
+		}
+		if webhookURLs == nil {
+			webhookURLs = []string{}
+		}
+	}
+
 	ss, err := db.SavedSearches.Create(ctx, &types.SavedSearch{
-		Description: args.Description,
-		Query:       args.Query,
-		Notify:      args.NotifyOwner,
-		NotifySlack: args.NotifySlack,
-		UserID:      userID,
-		OrgID:       orgID,
+		Description:   args.Description,
+		Query:         args.Query,
+		Notify:        args.NotifyOwner,
+		NotifySlack:   args.NotifySlack,
+		UserID:        userID,
+		OrgID:         orgID,
+		WebhookURLs:   webhookURLs,
+		WebhookSecret: args.WebhookSecret,
 	})
 	if err != nil {
 		return nil, err
@@ -193,13 +218,15 @@ func (r *schemaResolver) CreateSavedSearch(ctx context.Context, args *struct {
 }
 
 func (r *schemaResolver) UpdateSavedSearch(ctx context.Context, args *struct {
-	ID          graphql.ID
-	Description string
-	Query       string
-	NotifyOwner bool
-	NotifySlack bool
-	OrgID       *graphql.ID
-	UserID      *graphql.ID
+	ID            graphql.ID
+	Description   string
+	Query         string
+	NotifyOwner   bool
+	NotifySlack   bool
+	OrgID         *graphql.ID
+	UserID        *graphql.ID
+	WebhookURLs   *[]string
+	WebhookSecret *string
 }) (*savedSearchResolver, error) {
 	var userID, orgID *int32
 	// 🚨 SECURITY: Make sure the current user has permission to update a saved search for the specified user or org.
@@ -234,14 +261,27 @@ func (r *schemaResolver) UpdateSavedSearch(ctx context.Context, args *struct {
 		return nil, errMissingPatternType
 	}
 
+	var webhookURLs []string
+	if args.WebhookURLs != nil {
+		webhookURLs = *args.WebhookURLs
+		if err := validateWebhookURLs(webhookURLs); err != nil {
+			return nil, err
+		}
+		if webhookURLs == nil {
+			webhookURLs = []string{}
+		}
+	}
+
 	ss, err := db.SavedSearches.Update(ctx, &types.SavedSearch{
-		ID:          id,
-		Description: args.Description,
-		Query:       args.Query,
-		Notify:      args.NotifyOwner,
-		NotifySlack: args.NotifySlack,
-		UserID:      userID,
-		OrgID:       orgID,
+		ID:            id,
+		Description:   args.Description,
+		Query:         args.Query,
+		Notify:        args.NotifyOwner,
+		NotifySlack:   args.NotifySlack,
+		UserID:        userID,
+		OrgID:         orgID,
+		WebhookURLs:   webhookURLs,
+		WebhookSecret: args.WebhookSecret,
 	})
 	if err != nil {
 		return nil, err
@@ -280,6 +320,18 @@ func (r *schemaResolver) DeleteSavedSearch(ctx context.Context, args *struct {
 	return &EmptyResponse{}, nil
 }
 
+// validateWebhookURLs returns an error if any of the given saved search webhook
+// URLs isn't an absolute HTTP(S) URL.
+func validateWebhookURLs(urls []string) error {
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook URL %q: must be an absolute http or https URL", raw)
+		}
+	}
+	return nil
+}
+
 var patternTypeRegexp = lazyregexp.New(`(?i)\bpatternType:(literal|regexp)\b`)
 
 func queryHasPatternType(query string) bool {
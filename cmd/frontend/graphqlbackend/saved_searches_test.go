@@ -61,12 +61,14 @@ func TestCreateSavedSearch(t *testing.T) {
 	}
 	userID := MarshalUserID(key)
 	savedSearches, err := (&schemaResolver{}).CreateSavedSearch(ctx, &struct {
-		Description string
-		Query       string
-		NotifyOwner bool
-		NotifySlack bool
-		OrgID       *graphql.ID
-		UserID      *graphql.ID
+		Description   string
+		Query         string
+		NotifyOwner   bool
+		NotifySlack   bool
+		OrgID         *graphql.ID
+		UserID        *graphql.ID
+		WebhookURLs   *[]string
+		WebhookSecret *string
 	}{Description: "test query", Query: "test type:diff patternType:regexp", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err != nil {
 		t.Fatal(err)
@@ -91,12 +93,14 @@ func TestCreateSavedSearch(t *testing.T) {
 
 	// Ensure create saved search errors when patternType is not provided in the query.
 	_, err = (&schemaResolver{}).CreateSavedSearch(ctx, &struct {
-		Description string
-		Query       string
-		NotifyOwner bool
-		NotifySlack bool
-		OrgID       *graphql.ID
-		UserID      *graphql.ID
+		Description   string
+		Query         string
+		NotifyOwner   bool
+		NotifySlack   bool
+		OrgID         *graphql.ID
+		UserID        *graphql.ID
+		WebhookURLs   *[]string
+		WebhookSecret *string
 	}{Description: "test query", Query: "test type:diff", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err == nil {
 		t.Error("Expected error for createSavedSearch when query does not provide a patternType: field.")
@@ -119,13 +123,15 @@ func TestUpdateSavedSearch(t *testing.T) {
 	}
 	userID := MarshalUserID(key)
 	savedSearches, err := (&schemaResolver{}).UpdateSavedSearch(ctx, &struct {
-		ID          graphql.ID
-		Description string
-		Query       string
-		NotifyOwner bool
-		NotifySlack bool
-		OrgID       *graphql.ID
-		UserID      *graphql.ID
+		ID            graphql.ID
+		Description   string
+		Query         string
+		NotifyOwner   bool
+		NotifySlack   bool
+		OrgID         *graphql.ID
+		UserID        *graphql.ID
+		WebhookURLs   *[]string
+		WebhookSecret *string
 	}{ID: marshalSavedSearchID(key), Description: "updated query description", Query: "test type:diff patternType:regexp", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err != nil {
 		t.Fatal(err)
@@ -151,19 +157,68 @@ func TestUpdateSavedSearch(t *testing.T) {
 
 	// Ensure update saved search errors when patternType is not provided in the query.
 	_, err = (&schemaResolver{}).UpdateSavedSearch(ctx, &struct {
-		ID          graphql.ID
-		Description string
-		Query       string
-		NotifyOwner bool
-		NotifySlack bool
-		OrgID       *graphql.ID
-		UserID      *graphql.ID
+		ID            graphql.ID
+		Description   string
+		Query         string
+		NotifyOwner   bool
+		NotifySlack   bool
+		OrgID         *graphql.ID
+		UserID        *graphql.ID
+		WebhookURLs   *[]string
+		WebhookSecret *string
 	}{ID: marshalSavedSearchID(key), Description: "updated query description", Query: "test type:diff", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err == nil {
 		t.Error("Expected error for updateSavedSearch when query does not provide a patternType: field.")
 	}
 }
 
+func TestCreateSavedSearch_webhooks(t *testing.T) {
+	ctx := context.Background()
+	defer resetMocks()
+
+	key := int32(1)
+	var created *types.SavedSearch
+	db.Mocks.SavedSearches.Create = func(ctx context.Context, newSavedSearch *types.SavedSearch) (*types.SavedSearch, error) {
+		created = newSavedSearch
+		return newSavedSearch, nil
+	}
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{SiteAdmin: true, ID: key}, nil
+	}
+	userID := MarshalUserID(key)
+
+	type args = struct {
+		Description   string
+		Query         string
+		NotifyOwner   bool
+		NotifySlack   bool
+		OrgID         *graphql.ID
+		UserID        *graphql.ID
+		WebhookURLs   *[]string
+		WebhookSecret *string
+	}
+
+	urls := []string{"https://example.com/hook"}
+	secret := "s3cr3t"
+	ss, err := (&schemaResolver{}).CreateSavedSearch(ctx, &args{Description: "d", Query: "test type:diff patternType:regexp", UserID: &userID, WebhookURLs: &urls, WebhookSecret: &secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(created.WebhookURLs, urls) || created.WebhookSecret == nil || *created.WebhookSecret != secret {
+		t.Errorf("unexpected saved search: %+v", created)
+	}
+	if have := ss.WebhookURLs(); !reflect.DeepEqual(have, urls) {
+		t.Errorf("got webhook URLs %v, want %v", have, urls)
+	}
+
+	for _, invalid := range []string{"example.com/hook", "ftp://example.com", "https://"} {
+		invalid := []string{invalid}
+		if _, err := (&schemaResolver{}).CreateSavedSearch(ctx, &args{Description: "d", Query: "test type:diff patternType:regexp", UserID: &userID, WebhookURLs: &invalid}); err == nil {
+			t.Errorf("expected error for webhook URL %q", invalid[0])
+		}
+	}
+}
+
 func TestDeleteSavedSearch(t *testing.T) {
 	ctx := context.Background()
 	defer resetMocks()
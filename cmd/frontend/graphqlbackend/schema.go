@@ -48,6 +48,22 @@ type Mutation {
         # created from this PatchSet.
         patches: [PatchInput!]!
     ): PatchSet!
+    # Create a patch set whose patches are generated on the server, by replacing the matches of a
+    # search query in the files that it matches, in every repository. The patches are generated in
+    # the background and PatchSet.status reports the progress.
+    #
+    # To create the campaign, call createCampaign with the returned PatchSet.id in the
+    # CreateCampaignInput.patchSet field once all patches are generated.
+    createPatchSetFromSearchAndReplace(
+        # The search query. Its pattern is replaced in the files that it matches.
+        query: String!
+        # The type of the pattern of the search query. Literal patterns are replaced like the
+        # equivalent regexp.
+        patternType: SearchPatternType!
+        # The replacement of each match. It can refer to capture groups with $1 or ${name} in regexp
+        # patterns, and to holes with :[name] in structural patterns.
+        replacement: String!
+    ): PatchSet!
     # Updates a campaign.
     # Note, updating is not allowed when:
     # The campaign has already been closed.
@@ -203,10 +219,19 @@ type Mutation {
     #
     # - "user:all": Full control of all resources accessible to the user account.
     # - "site-admin:sudo": Ability to perform any action as any other user. (Only site admins may create tokens
-    #   with this scope.)
+    #   with this scope, and only together with "user:all".)
+    # - "search:read": Ability to run searches.
+    # - "repo:read": Read access to repositories and their contents.
+    # - "campaigns:write": Ability to view, create, update and delete campaigns.
+    # - "settings:write": Ability to view and edit settings.
+    #
+    # Tokens must have the "user:all" scope or at least one of the narrower scopes.
+    #
+    # If expiresAt is null, the token expires after the site's default access token lifetime (if any). The
+    # expiration date may not be in the past or exceed the site's maximum access token lifetime (if any).
     #
     # Only the user or site admins may perform this mutation.
-    createAccessToken(user: ID!, scopes: [String!]!, note: String!): CreateAccessTokenResult!
+    createAccessToken(user: ID!, scopes: [String!]!, note: String!, expiresAt: DateTime): CreateAccessTokenResult!
     # Deletes and immediately revokes the specified access token, specified by either its ID or by the token
     # itself.
     #
@@ -381,6 +406,12 @@ type Mutation {
         notifySlack: Boolean!
         orgID: ID
         userID: ID
+        # URLs that a signed JSON payload is POSTed to when the saved search has new results.
+        # If omitted on updates, the existing webhook URLs are kept.
+        webhookURLs: [String!]
+        # The secret used to sign webhook payloads with HMAC-SHA256. If omitted on updates,
+        # the existing secret is kept. An empty string removes the secret.
+        webhookSecret: String
     ): SavedSearch!
     # Updates a saved search
     updateSavedSearch(
@@ -391,6 +422,12 @@ type Mutation {
         notifySlack: Boolean!
         orgID: ID
         userID: ID
+        # URLs that a signed JSON payload is POSTed to when the saved search has new results.
+        # If omitted on updates, the existing webhook URLs are kept.
+        webhookURLs: [String!]
+        # The secret used to sign webhook payloads with HMAC-SHA256. If omitted on updates,
+        # the existing secret is kept. An empty string removes the secret.
+        webhookSecret: String
     ): SavedSearch!
     # Deletes a saved search
     deleteSavedSearch(id: ID!): EmptyResponse
@@ -461,6 +498,10 @@ input CreateCampaignInput {
     # When a Campaign is created in draft mode, its patches are not
     # created on the codehost, but only when publishing the Campaign.
     draft: Boolean
+
+    # Whether or not to merge the changesets of the Campaign on the codehost
+    # once they are approved and their checks passed. Default is false.
+    autoMerge: Boolean
 }
 
 # Input arguments for updating a campaign.
@@ -483,6 +524,10 @@ input UpdateCampaignInput {
     # The Campaign's status will be updated accordingly while possibly
     # new ExternalChangesets are created/updated/closed on the codehosts.
     patchSet: ID
+
+    # Whether or not to merge the changesets of the Campaign on the codehost
+    # once they are approved and their checks passed (if non-null).
+    autoMerge: Boolean
 }
 
 # A set of Patches that will be turned into changesets by a campaign.
@@ -496,6 +541,10 @@ type PatchSet implements Node {
 
     # The URL where the PatchSet can be previewed and a campaign can be created from it.
     previewURL: String!
+
+    # The status of generating the patches on the server, for patch sets created with
+    # createPatchSetFromSearchAndReplace. It is always completed for other patch sets.
+    status: BackgroundProcessStatus!
 }
 
 # A paginated list of repository diffs committed to git.
@@ -604,6 +653,11 @@ type Campaign implements Node {
     # The date and time when the campaign was closed.
     closedAt: DateTime
 
+    # Whether the changesets of the campaign are merged on the codehost once
+    # they are approved and their checks passed. Failures to merge a changeset
+    # are recorded as events of the changeset.
+    autoMerge: Boolean!
+
     # The date and time when the Campaign changed from draft mode to published.
     # If the Campaign has not been published yet (is still in draft mode) this
     # is null.
@@ -776,6 +830,12 @@ type ExternalChangeset implements Node {
     # The state of the continuous integration checks on this changeset.
     # It can be null if no checks have been configured.
     checkState: ChangesetCheckState
+
+    # The error of the last attempt to rebase the branch of this changeset on
+    # its moved base branch, if the patch of the changeset no longer applies.
+    # Null if the changeset wasn't created by a campaign or the last rebase
+    # succeeded.
+    rebaseError: String
 }
 
 # A list of changesets.
@@ -1619,6 +1679,8 @@ type SavedSearch implements Node {
     orgID: ID
     # The Slack webhook URL associated with this saved search, if any.
     slackWebhookURL: String
+    # The URLs that a JSON payload is POSTed to when this saved search has new results.
+    webhookURLs: [String!]!
 }
 
 # A search query description.
@@ -1730,6 +1792,7 @@ enum ExternalServiceKind {
     AWSCODECOMMIT
     BITBUCKETCLOUD
     BITBUCKETSERVER
+    GERRIT
     GITHUB
     GITLAB
     GITOLITE
@@ -2766,6 +2829,9 @@ type GitBlob implements TreeEntry & File2 {
     content: String!
     # Whether or not it is binary.
     binary: Boolean!
+    # Git LFS metadata, if this blob is a Git LFS pointer file. The content of such a blob is the
+    # pointer file, not the contents of the LFS-tracked file.
+    lfs: LFS
     # The blob contents rendered as rich HTML, or an empty string if it is not a supported
     # rich file type.
     #
@@ -2812,6 +2878,14 @@ type GitBlob implements TreeEntry & File2 {
     lsif: LSIFQueryResolver
 }
 
+# A Git LFS pointer file, which refers to the contents of a file that are stored on a Git LFS server.
+type LFS {
+    # The SHA-256 hash (hex-encoded) of the contents of the LFS-tracked file.
+    oid: String!
+    # The size in bytes of the contents of the LFS-tracked file.
+    byteSize: Int!
+}
+
 # A wrapper object around LSIF query methods for a particular path-at-revision. When this node is
 # null, no LSIF data is available for containing git blob.
 type LSIFQueryResolver {
@@ -3074,6 +3148,8 @@ type AccessToken implements Node {
     createdAt: DateTime!
     # The date when the access token was last used to authenticate a request.
     lastUsedAt: DateTime
+    # The date after which the access token is no longer valid, or null if it never expires.
+    expiresAt: DateTime
 }
 
 # A list of access tokens.
@@ -3087,6 +3163,35 @@ type AccessTokenConnection {
     pageInfo: PageInfo!
 }
 
+# An administrative or security-relevant action recorded in the audit log.
+type AuditLogEvent {
+    # The user who performed the action, or null if the user no longer exists or the action was not performed by
+    # a user. For actions performed with a sudo access token, this is the access token's owner.
+    actor: User
+    # The action that was performed, such as "site_config.update" or "access_token.create".
+    action: String!
+    # The ID of the object affected by the action, if any.
+    subject: ID
+    # Details about the action. This never contains secrets.
+    data: JSONValue!
+    # The date when the action was performed.
+    createdAt: DateTime!
+}
+
+# A list of audit log events.
+type AuditLogEventConnection {
+    # A list of audit log events.
+    nodes: [AuditLogEvent!]!
+    # The total count of audit log events in the connection. This total count may be larger than the number of
+    # nodes in this object when the result is paginated.
+    totalCount: Int!
+    # Pagination information.
+    pageInfo: PageInfo!
+    # Whether the audit log's chain of hashes is intact. If false, events were modified or removed outside of
+    # Sourcegraph (for example, directly in the database).
+    chainIsIntact: Boolean!
+}
+
 # A list of authentication providers.
 type AuthProviderConnection {
     # A list of authentication providers.
@@ -3594,6 +3699,22 @@ type Site implements SettingsSubject {
         # Returns the first n access tokens from the list.
         first: Int
     ): AccessTokenConnection!
+    # The audit log of administrative and security-relevant actions on this site, most recent first. Only site
+    # admins may view the audit log.
+    auditLog(
+        # Returns the first n events from the list.
+        first: Int
+        # Include only events performed by this user.
+        actor: ID
+        # Include only events with this action (such as "site_config.update" or "access_token.create").
+        action: String
+        # Include only events affecting the object with this ID.
+        subject: ID
+        # Include only events that occurred at or after this time.
+        since: DateTime
+        # Include only events that occurred before this time.
+        until: DateTime
+    ): AuditLogEventConnection!
     # A list of all authentication providers. This information is visible to all viewers and does not contain any
     # secret information.
     authProviders: AuthProviderConnection!
@@ -4716,8 +4837,17 @@ type SyncError {
     message: String!
 }
 
+# FOR INTERNAL USE ONLY: A status message produced when the repositories of an
+# external service exceed its configured quota
+type ExternalServiceQuotaExceeded {
+    # The message of this status message
+    message: String!
+    # The external service whose quota was exceeded
+    externalService: ExternalService!
+}
+
 # FOR INTERNAL USE ONLY: A status message
-union StatusMessage = CloningProgress | ExternalServiceSyncError | SyncError
+union StatusMessage = CloningProgress | ExternalServiceSyncError | SyncError | ExternalServiceQuotaExceeded
 
 # An RFC 3339-encoded UTC date string, such as 1973-11-29T21:33:09Z. This value can be parsed into a
 # JavaScript Date using Date.parse. To produce this value from a JavaScript Date instance, use
@@ -240,6 +240,11 @@ type searchResolver struct {
 	repoRevs, missingRepoRevs []*search.RepositoryRevisions
 	repoOverLimit             bool
 	repoErr                   error
+	reposScope                string // the scope parameters of the and/or query the cached results belong to
+
+	// andOrPattern is the and/or expression of search patterns evaluated by
+	// the current leaf search of an and/or query, if any.
+	andOrPattern *andOrPattern
 
 	zoekt        *searchbackend.Zoekt
 	searcherURLs *endpoint.Map
@@ -0,0 +1,135 @@
+package graphqlbackend
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+)
+
+// SearchExportMatch is a single match produced by ExportSearch.
+type SearchExportMatch struct {
+	RepoID   api.RepoID
+	Repo     api.RepoName
+	Revision api.CommitID
+	Path     string
+
+	// InputRev is the revision of the repository that the query searched, as
+	// given in the query. It is empty for the default branch.
+	InputRev string
+
+	// Line is the 1-based line number of the match, or 0 if the file matched
+	// only by its path.
+	Line    int
+	Preview string
+}
+
+// ExportSearch runs the search query and calls fn for every match, in a stable
+// order. Unlike interactive searches, the result set is not capped: it pages
+// through all results using paginated search (see search_pagination.go), so
+// only text (file) matches are supported.
+//
+// Repository permissions are enforced with the actor in ctx, just like for
+// interactive searches.
+func ExportSearch(ctx context.Context, version string, patternType *string, query string, fn func(*SearchExportMatch) error) error {
+	first := int32(maxSearchResultsPerPaginatedRequest)
+	args := &SearchArgs{
+		Version:     version,
+		PatternType: patternType,
+		Query:       query,
+		First:       &first,
+	}
+
+	for {
+		impl, err := NewSearchImplementer(args)
+		if err != nil {
+			return &badRequestError{err}
+		}
+		sr, err := impl.Results(ctx)
+		if err != nil {
+			return err
+		}
+		if sr.cursor == nil {
+			// Paginated search only returns no cursor together with an alert
+			// explaining why the query could not be run.
+			return &badRequestError{searchAlertError(sr.alert)}
+		}
+
+		for _, result := range sr.SearchResults {
+			fm, ok := result.ToFileMatch()
+			if !ok {
+				continue
+			}
+			for _, m := range exportFileMatch(fm) {
+				if err := fn(m); err != nil {
+					return err
+				}
+			}
+		}
+
+		if sr.cursor.Finished {
+			return nil
+		}
+		after := marshalSearchCursor(sr.cursor)
+		args.After = &after
+	}
+}
+
+// SearchPatternInfo returns how searcher matches file contents with the search
+// query: with a regexp (which literal patterns are converted to) or with a
+// structural pattern, and whether matching is case-sensitive.
+func SearchPatternInfo(version string, patternType *string, q string) (*search.TextPatternInfo, error) {
+	searchType, err := detectSearchType(version, patternType, q)
+	if err != nil {
+		return nil, &badRequestError{err}
+	}
+	if searchType == query.SearchTypeLiteral {
+		q = query.ConvertToLiteral(q)
+	}
+	queryInfo, err := query.Process(q, searchType)
+	if err != nil {
+		return nil, &badRequestError{err}
+	}
+	return getPatternInfo(queryInfo, &getPatternInfoOptions{
+		performStructuralSearch: searchType == query.SearchTypeStructural,
+		performLiteralSearch:    searchType == query.SearchTypeLiteral,
+	})
+}
+
+// exportFileMatch returns the export matches of a single file match: one per
+// line match, or a single match without a line if the file matched only by its
+// path.
+func exportFileMatch(fm *FileMatchResolver) []*SearchExportMatch {
+	var inputRev string
+	if fm.InputRev != nil {
+		inputRev = *fm.InputRev
+	}
+	if len(fm.JLineMatches) == 0 {
+		return []*SearchExportMatch{{RepoID: fm.Repo.ID, Repo: fm.Repo.Name, Revision: fm.CommitID, Path: fm.JPath, InputRev: inputRev}}
+	}
+	matches := make([]*SearchExportMatch, 0, len(fm.JLineMatches))
+	for _, lm := range fm.JLineMatches {
+		matches = append(matches, &SearchExportMatch{
+			RepoID:   fm.Repo.ID,
+			Repo:     fm.Repo.Name,
+			Revision: fm.CommitID,
+			Path:     fm.JPath,
+			InputRev: inputRev,
+			Line:     int(lm.JLineNumber) + 1,
+			Preview:  lm.JPreview,
+		})
+	}
+	return matches
+}
+
+func searchAlertError(alert *searchAlert) error {
+	if alert == nil {
+		return errors.New("search returned no results cursor")
+	}
+	if alert.description == "" {
+		return errors.New(alert.title)
+	}
+	return errors.New(alert.title + ": " + alert.description)
+}
@@ -0,0 +1,67 @@
+package graphqlbackend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestExportFileMatch(t *testing.T) {
+	repo := &types.Repo{ID: 1, Name: "github.com/foo/bar"}
+
+	t.Run("line matches", func(t *testing.T) {
+		fm := &FileMatchResolver{
+			JPath:    "a.go",
+			Repo:     repo,
+			CommitID: "deadbeef",
+			JLineMatches: []*lineMatch{
+				{JPreview: "foo()", JLineNumber: 0},
+				{JPreview: "bar(foo)", JLineNumber: 9},
+			},
+		}
+		want := []*SearchExportMatch{
+			{RepoID: 1, Repo: repo.Name, Revision: "deadbeef", Path: "a.go", Line: 1, Preview: "foo()"},
+			{RepoID: 1, Repo: repo.Name, Revision: "deadbeef", Path: "a.go", Line: 10, Preview: "bar(foo)"},
+		}
+		if got := exportFileMatch(fm); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("path match", func(t *testing.T) {
+		inputRev := "v1"
+		fm := &FileMatchResolver{JPath: "foo.go", Repo: repo, CommitID: "deadbeef", InputRev: &inputRev}
+		want := []*SearchExportMatch{{RepoID: 1, Repo: repo.Name, Revision: "deadbeef", Path: "foo.go", InputRev: "v1"}}
+		if got := exportFileMatch(fm); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestSearchPatternInfo(t *testing.T) {
+	tests := []struct {
+		patternType string
+		query       string
+
+		wantPattern       string
+		wantStructural    bool
+		wantCaseSensitive bool
+	}{
+		{"literal", "repo:foo fmt.Println( file:\\.go$", `fmt\.Println\(`, false, false},
+		{"regexp", "repo:foo fmt\\.Print(ln)? case:yes", `fmt\.Print(ln)?`, false, true},
+		{"structural", "repo:foo fmt.Println(:[x]) lang:go", "fmt.Println(:[x])", true, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.patternType, func(t *testing.T) {
+			p, err := SearchPatternInfo("V2", &tc.patternType, tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Pattern != tc.wantPattern || p.IsStructuralPat != tc.wantStructural || p.IsCaseSensitive != tc.wantCaseSensitive {
+				t.Errorf("got pattern %q (structural: %v, case-sensitive: %v), want %q (structural: %v, case-sensitive: %v)",
+					p.Pattern, p.IsStructuralPat, p.IsCaseSensitive, tc.wantPattern, tc.wantStructural, tc.wantCaseSensitive)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/endpoint"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+)
+
+// andOrPattern is an and/or expression of search patterns within scope
+// parameters, such as "repo:foo (a or b) and not c".
+type andOrPattern struct {
+	scope   []query.Node
+	pattern query.Node
+}
+
+// patternExpr returns the search pattern expression of p. Each leaf pattern is
+// processed like the pattern of a query consisting of the scope parameters and
+// the leaf.
+func (p *andOrPattern) patternExpr(opts *getPatternInfoOptions) (*search.PatternExpr, error) {
+	expr, err := p.nodeToPatternExpr(p.pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := expr.Validate(); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+func (p *andOrPattern) nodeToPatternExpr(node query.Node, opts *getPatternInfoOptions) (*search.PatternExpr, error) {
+	leaf := func(node query.Node, negated bool) (*search.PatternExpr, error) {
+		q := make([]query.Node, 0, len(p.scope)+1)
+		q = append(q, p.scope...)
+		q = append(q, node)
+		info, err := getPatternInfo(query.AndOrQuery{Query: q}, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &search.PatternExpr{Kind: search.PatternLeaf, Pattern: info, Negated: negated}, nil
+	}
+
+	switch n := node.(type) {
+	case query.Parameter:
+		negated := n.Negated
+		n.Negated = false
+		return leaf(n, negated)
+	case query.Operator:
+		if n.Kind == query.Concat {
+			return leaf(n, false)
+		}
+		expr := &search.PatternExpr{Kind: search.PatternAnd}
+		if n.Kind == query.Or {
+			expr.Kind = search.PatternOr
+		}
+		for _, operand := range n.Operands {
+			o, err := p.nodeToPatternExpr(operand, opts)
+			if err != nil {
+				return nil, err
+			}
+			expr.Operands = append(expr.Operands, o)
+		}
+		return expr, nil
+	}
+	return nil, fmt.Errorf("unrecognized type %s in pattern expression", reflect.TypeOf(node).String())
+}
+
+// searchFilesInRepoWithPatternExpr is like searchFilesInRepo, but returns the
+// files whose contents match the and/or expression of patterns. Each pattern is
+// searched separately, and the results are combined per file. The
+// FileMatchLimit of info applies to the combined results only, so that files
+// beyond the limit of one pattern can't be missing from the combination.
+func searchFilesInRepoWithPatternExpr(ctx context.Context, searcherURLs *endpoint.Map, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, expr *search.PatternExpr, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
+	matches, limitHit, err = evaluatePatternExprInRepo(ctx, searcherURLs, repo, gitserverRepo, rev, expr, nil, patternFileMatchLimit(info.FileMatchLimit), fetchTimeout)
+	if err != nil {
+		return nil, false, err
+	}
+	if limit := int(info.FileMatchLimit); limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+		limitHit = true
+	}
+	return matches, limitHit, nil
+}
+
+// patternFileMatchLimitFactor is the multiple of the FileMatchLimit of a query
+// that each pattern of its and/or expression may match. Operands of an
+// and-expression usually match many more files than their intersection, but
+// searching for all matches of a common pattern in a large repository would be
+// too slow.
+const patternFileMatchLimitFactor = 10
+
+// patternFileMatchLimit returns the number of files that each pattern of an
+// and/or expression is searched for, given the FileMatchLimit of the query.
+func patternFileMatchLimit(limit int32) int32 {
+	if limit <= 0 || limit > math.MaxInt32/patternFileMatchLimitFactor {
+		return math.MaxInt32
+	}
+	return limit * patternFileMatchLimitFactor
+}
+
+// evaluatePatternExprInRepo returns the files that match expr. If within is
+// non-nil, only the files with these paths are searched. Each pattern is
+// searched for at most limit files.
+//
+// The operands of an and-expression after the first are only searched within
+// the files that matched the previous operands. This way, the results are
+// exact unless the first operand matches more than limit files, in which case
+// limitHit is true.
+func evaluatePatternExprInRepo(ctx context.Context, searcherURLs *endpoint.Map, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, expr *search.PatternExpr, within []string, limit int32, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
+	switch expr.Kind {
+	case search.PatternLeaf:
+		return searchPatternInRepo(ctx, searcherURLs, repo, gitserverRepo, rev, expr.Pattern, within, limit, fetchTimeout)
+
+	case search.PatternOr:
+		for _, o := range expr.Operands {
+			m, lh, err := evaluatePatternExprInRepo(ctx, searcherURLs, repo, gitserverRepo, rev, o, within, limit, fetchTimeout)
+			if err != nil {
+				return nil, false, err
+			}
+			matches = unionFileMatches(matches, m)
+			limitHit = limitHit || lh
+		}
+		return matches, limitHit, nil
+
+	case search.PatternAnd:
+		// Intersect the operands that are not negated first, so that we can
+		// stop early when no file matches all of them.
+		first := true
+		for _, o := range expr.Operands {
+			if o.Kind == search.PatternLeaf && o.Negated {
+				continue
+			}
+			m, lh, err := evaluatePatternExprInRepo(ctx, searcherURLs, repo, gitserverRepo, rev, o, within, limit, fetchTimeout)
+			if err != nil {
+				return nil, false, err
+			}
+			limitHit = limitHit || lh
+			if first {
+				matches, first = m, false
+			} else {
+				matches = intersectFileMatches(matches, m)
+			}
+			if len(matches) == 0 {
+				return nil, limitHit, nil
+			}
+			within = fileMatchPaths(matches)
+		}
+		for _, o := range expr.Operands {
+			if o.Kind != search.PatternLeaf || !o.Negated {
+				continue
+			}
+			m, lh, err := searchPatternInRepo(ctx, searcherURLs, repo, gitserverRepo, rev, o.Pattern, within, limit, fetchTimeout)
+			if err != nil {
+				return nil, false, err
+			}
+			limitHit = limitHit || lh
+			matches = subtractFileMatches(matches, m)
+			if len(matches) == 0 {
+				return nil, limitHit, nil
+			}
+			within = fileMatchPaths(matches)
+		}
+		return matches, limitHit, nil
+	}
+	return nil, false, fmt.Errorf("unknown pattern expression kind %d", expr.Kind)
+}
+
+// maxPathsPerSearch is the maximum number of paths that searchPatternInRepo
+// searches within at once. It bounds the length of the searcher request.
+const maxPathsPerSearch = 200
+
+// searchPatternInRepo returns at most limit files that match the leaf pattern
+// info, and whether there are more. If within is non-nil, only the files with
+// these paths are searched.
+func searchPatternInRepo(ctx context.Context, searcherURLs *endpoint.Map, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, within []string, limit int32, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
+	// The FileMatchLimit of the query applies to the combined results.
+	p := *info
+	p.FileMatchLimit = limit
+	if within == nil {
+		return searchFilesInRepo(ctx, searcherURLs, repo, gitserverRepo, rev, &p, fetchTimeout)
+	}
+
+	// Searching within no more paths than the limit at once, a search within
+	// paths is never truncated.
+	chunk := maxPathsPerSearch
+	if int(limit) < chunk {
+		chunk = int(limit)
+	}
+	for len(within) > 0 {
+		n := len(within)
+		if n > chunk {
+			n = chunk
+		}
+		p.IncludePatterns = append(append([]string{}, info.IncludePatterns...), pathsRegexp(within[:n]))
+		within = within[n:]
+
+		m, lh, err := searchFilesInRepo(ctx, searcherURLs, repo, gitserverRepo, rev, &p, fetchTimeout)
+		if err != nil {
+			return nil, false, err
+		}
+		matches = append(matches, m...)
+		limitHit = limitHit || lh
+	}
+	return matches, limitHit, nil
+}
+
+// pathsRegexp returns a regular expression that matches exactly the given
+// paths. It is case sensitive even if the path patterns of the search are not,
+// so that it doesn't match other files whose paths differ only in case.
+func pathsRegexp(paths []string) string {
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = regexp.QuoteMeta(p)
+	}
+	return "(?-i:^(?:" + strings.Join(quoted, "|") + ")$)"
+}
+
+func fileMatchPaths(matches []*FileMatchResolver) []string {
+	paths := make([]string, len(matches))
+	for i, fm := range matches {
+		paths[i] = fm.JPath
+	}
+	return paths
+}
+
+// unionFileMatches returns the file matches in left or right. The line matches
+// of files in both are merged.
+func unionFileMatches(left, right []*FileMatchResolver) []*FileMatchResolver {
+	byURI := make(map[string]*FileMatchResolver, len(left))
+	for _, fm := range left {
+		byURI[fm.uri] = fm
+	}
+	for _, fm := range right {
+		if existing := byURI[fm.uri]; existing != nil {
+			mergeFileMatch(existing, fm)
+			continue
+		}
+		byURI[fm.uri] = fm
+		left = append(left, fm)
+	}
+	return left
+}
+
+// intersectFileMatches returns the file matches of left that are also in
+// right, with their line matches merged.
+func intersectFileMatches(left, right []*FileMatchResolver) []*FileMatchResolver {
+	byURI := make(map[string]*FileMatchResolver, len(right))
+	for _, fm := range right {
+		byURI[fm.uri] = fm
+	}
+	var intersection []*FileMatchResolver
+	for _, fm := range left {
+		if other := byURI[fm.uri]; other != nil {
+			mergeFileMatch(fm, other)
+			intersection = append(intersection, fm)
+		}
+	}
+	return intersection
+}
+
+// subtractFileMatches returns the file matches of left that are not in right.
+func subtractFileMatches(left, right []*FileMatchResolver) []*FileMatchResolver {
+	exclude := make(map[string]struct{}, len(right))
+	for _, fm := range right {
+		exclude[fm.uri] = struct{}{}
+	}
+	var difference []*FileMatchResolver
+	for _, fm := range left {
+		if _, ok := exclude[fm.uri]; !ok {
+			difference = append(difference, fm)
+		}
+	}
+	return difference
+}
+
+// mergeFileMatch merges the line matches of src into dst, which must be
+// matches of the same file.
+func mergeFileMatch(dst, src *FileMatchResolver) {
+	dst.JLineMatches = mergeLineMatches(dst.JLineMatches, src.JLineMatches)
+	dst.JLimitHit = dst.JLimitHit || src.JLimitHit
+	dst.MatchCount = 0
+	for _, lm := range dst.JLineMatches {
+		dst.MatchCount += len(lm.JOffsetAndLengths)
+	}
+	if dst.symbols == nil {
+		dst.symbols = src.symbols
+	}
+}
+
+// mergeLineMatches merges line matches of the same line, and returns the line
+// matches ordered by line number.
+func mergeLineMatches(a, b []*lineMatch) []*lineMatch {
+	byLine := make(map[int32]*lineMatch, len(a)+len(b))
+	var merged []*lineMatch
+	for _, lm := range append(append([]*lineMatch{}, a...), b...) {
+		existing := byLine[lm.JLineNumber]
+		if existing == nil {
+			copy := *lm
+			copy.JOffsetAndLengths = append([][2]int32{}, lm.JOffsetAndLengths...)
+			byLine[lm.JLineNumber] = &copy
+			merged = append(merged, &copy)
+			continue
+		}
+		existing.JLimitHit = existing.JLimitHit || lm.JLimitHit
+	offsets:
+		for _, ol := range lm.JOffsetAndLengths {
+			for _, have := range existing.JOffsetAndLengths {
+				if have == ol {
+					continue offsets
+				}
+			}
+			existing.JOffsetAndLengths = append(existing.JOffsetAndLengths, ol)
+		}
+	}
+	for _, lm := range merged {
+		sort.Slice(lm.JOffsetAndLengths, func(i, j int) bool {
+			return lm.JOffsetAndLengths[i][0] < lm.JOffsetAndLengths[j][0]
+		})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].JLineNumber < merged[j].JLineNumber })
+	return merged
+}
@@ -0,0 +1,206 @@
+package graphqlbackend
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/pathmatch"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+)
+
+func TestSearchFilesInRepoWithPatternExpr(t *testing.T) {
+	// Each file contains the patterns that are its lines.
+	files := map[string][]string{
+		"a": {"foo", "bar"},
+		"b": {"foo"},
+		"c": {"bar", "baz"},
+		"d": {"foo", "baz"},
+	}
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// Like searcher, the mock returns at most FileMatchLimit files if it is
+	// positive, in the order of their paths.
+	var leafLimits []int32
+	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, fetchTimeout time.Duration) ([]*FileMatchResolver, bool, error) {
+		leafLimits = append(leafLimits, info.FileMatchLimit)
+		var matches []*FileMatchResolver
+	files:
+		for _, path := range paths {
+			for _, p := range info.IncludePatterns {
+				if !regexp.MustCompile(p).MatchString(path) {
+					continue files
+				}
+			}
+			for i, line := range files[path] {
+				if line != info.Pattern {
+					continue
+				}
+				if info.FileMatchLimit > 0 && len(matches) == int(info.FileMatchLimit) {
+					return matches, true, nil
+				}
+				matches = append(matches, &FileMatchResolver{
+					JPath:        path,
+					uri:          "git://repo#" + path,
+					JLineMatches: []*lineMatch{{JLineNumber: int32(i), JOffsetAndLengths: [][2]int32{{0, 3}}}},
+				})
+			}
+		}
+		return matches, false, nil
+	}
+	defer func() { mockSearchFilesInRepo = nil }()
+
+	leaf := func(pattern string, negated bool) *search.PatternExpr {
+		return &search.PatternExpr{Kind: search.PatternLeaf, Pattern: &search.TextPatternInfo{Pattern: pattern}, Negated: negated}
+	}
+	and := func(operands ...*search.PatternExpr) *search.PatternExpr {
+		return &search.PatternExpr{Kind: search.PatternAnd, Operands: operands}
+	}
+	or := func(operands ...*search.PatternExpr) *search.PatternExpr {
+		return &search.PatternExpr{Kind: search.PatternOr, Operands: operands}
+	}
+
+	tests := []struct {
+		name  string
+		expr  *search.PatternExpr
+		limit int32
+		want  []string
+		// wantLimitHit is true if the matches are truncated to limit, in
+		// which case they must be a subset of want.
+		wantLimitHit bool
+	}{
+		{name: "and", expr: and(leaf("foo", false), leaf("bar", false)), want: []string{"a"}},
+		{name: "or", expr: or(leaf("bar", false), leaf("baz", false)), want: []string{"a", "c", "d"}},
+		{name: "and not", expr: and(leaf("foo", false), leaf("bar", true)), want: []string{"b", "d"}},
+		{name: "nested", expr: and(or(leaf("bar", false), leaf("baz", false)), leaf("foo", true)), want: []string{"c"}},
+		{name: "no match", expr: and(leaf("bar", false), leaf("qux", false))},
+		{name: "limit", expr: or(leaf("foo", false), leaf("bar", false)), limit: 2, want: []string{"a", "b", "c", "d"}, wantLimitHit: true},
+		// The leaves match more files than the limit.
+		{name: "and over limit", expr: and(leaf("foo", false), leaf("baz", false)), limit: 1, want: []string{"d"}},
+		{name: "and not over limit", expr: and(leaf("foo", false), leaf("bar", true)), limit: 1, want: []string{"b", "d"}, wantLimitHit: true},
+		{name: "nested over limit", expr: and(or(leaf("foo", false), leaf("bar", false)), leaf("baz", true)), limit: 1, want: []string{"a", "b"}, wantLimitHit: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Like getPatternInfo, give the leaves the limit of the query.
+			var setLimit func(*search.PatternExpr)
+			setLimit = func(e *search.PatternExpr) {
+				if e.Pattern != nil {
+					e.Pattern.FileMatchLimit = test.limit
+				}
+				for _, o := range e.Operands {
+					setLimit(o)
+				}
+			}
+			setLimit(test.expr)
+			leafLimits = nil
+
+			info := &search.TextPatternInfo{FileMatchLimit: test.limit}
+			matches, limitHit, err := searchFilesInRepoWithPatternExpr(context.Background(), nil, &types.Repo{Name: "repo"}, gitserver.Repo{Name: "repo"}, "HEAD", info, test.expr, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if limitHit != test.wantLimitHit {
+				t.Errorf("got limitHit %v, want %v", limitHit, test.wantLimitHit)
+			}
+			for _, l := range leafLimits {
+				if want := patternFileMatchLimit(test.limit); l != want {
+					t.Errorf("pattern searched with FileMatchLimit %d, want %d", l, want)
+				}
+			}
+			var paths []string
+			for _, m := range matches {
+				paths = append(paths, m.JPath)
+			}
+			sort.Strings(paths)
+			if test.wantLimitHit {
+				if len(paths) != int(test.limit) {
+					t.Errorf("got %d matches, want %d", len(paths), test.limit)
+				}
+				for _, p := range paths {
+					if i := sort.SearchStrings(test.want, p); i == len(test.want) || test.want[i] != p {
+						t.Errorf("got match %q, want one of %v", p, test.want)
+					}
+				}
+				return
+			}
+			if !reflect.DeepEqual(paths, test.want) {
+				t.Errorf("got %v, want %v", paths, test.want)
+			}
+		})
+	}
+}
+
+func TestPatternFileMatchLimit(t *testing.T) {
+	for limit, want := range map[int32]int32{
+		0:                 math.MaxInt32,
+		30:                30 * patternFileMatchLimitFactor,
+		math.MaxInt32 / 2: math.MaxInt32,
+	} {
+		if got := patternFileMatchLimit(limit); got != want {
+			t.Errorf("patternFileMatchLimit(%d) = %d, want %d", limit, got, want)
+		}
+	}
+}
+
+func TestPathsRegexp(t *testing.T) {
+	// Searcher makes the path patterns case insensitive unless the query is
+	// case sensitive.
+	match, err := pathmatch.CompilePattern(pathsRegexp([]string{"a/b.go", "c+.go"}), pathmatch.CompileOptions{RegExp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		"a/b.go":   true,
+		"c+.go":    true,
+		"A/b.go":   false,
+		"a/b.go.x": false,
+		"x/a/b.go": false,
+		"cc.go":    false,
+	} {
+		if got := match.MatchPath(path); got != want {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestMergeFileMatch(t *testing.T) {
+	dst := &FileMatchResolver{
+		JLineMatches: []*lineMatch{
+			{JLineNumber: 3, JOffsetAndLengths: [][2]int32{{4, 2}}},
+			{JLineNumber: 1, JOffsetAndLengths: [][2]int32{{0, 3}}},
+		},
+		MatchCount: 2,
+	}
+	src := &FileMatchResolver{
+		JLineMatches: []*lineMatch{
+			{JLineNumber: 3, JOffsetAndLengths: [][2]int32{{0, 2}, {4, 2}}},
+			{JLineNumber: 2, JOffsetAndLengths: [][2]int32{{1, 1}}, JLimitHit: true},
+		},
+		JLimitHit: true,
+	}
+	mergeFileMatch(dst, src)
+
+	want := &FileMatchResolver{
+		JLineMatches: []*lineMatch{
+			{JLineNumber: 1, JOffsetAndLengths: [][2]int32{{0, 3}}},
+			{JLineNumber: 2, JOffsetAndLengths: [][2]int32{{1, 1}}, JLimitHit: true},
+			{JLineNumber: 3, JOffsetAndLengths: [][2]int32{{0, 2}, {4, 2}}},
+		},
+		JLimitHit:  true,
+		MatchCount: 4,
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("got %+v, want %+v", dst, want)
+	}
+}
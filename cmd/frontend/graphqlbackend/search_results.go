@@ -612,31 +612,45 @@ func (r *searchResolver) evaluateLeaf(ctx context.Context) (*SearchResultsResolv
 	return rr, err
 }
 
-// union returns the union of two sets of search results and merges common search data.
-func union(left, right *SearchResultsResolver) (*SearchResultsResolver, error) {
+// union returns the union of two sets of search results and merges common
+// search data. File matches of the same file are merged into one result.
+func union(left, right *SearchResultsResolver) *SearchResultsResolver {
 	if right == nil {
-		return left, nil
+		return left
 	}
 	if left == nil {
-		return right, nil
+		return right
 	}
-	if left.SearchResults != nil && right.SearchResults != nil {
-		left.SearchResults = append(left.SearchResults, right.SearchResults...)
-		// merge common search data.
-		left.searchResultsCommon.update(right.searchResultsCommon)
-		return left, nil
-	} else if right.SearchResults != nil {
-		return right, nil
+
+	fileMatches := make(map[string]*FileMatchResolver)
+	for _, r := range left.SearchResults {
+		if fileMatch, ok := r.ToFileMatch(); ok {
+			fileMatches[fileMatch.uri] = fileMatch
+		}
+	}
+	for _, r := range right.SearchResults {
+		if fileMatch, ok := r.ToFileMatch(); ok {
+			if existing := fileMatches[fileMatch.uri]; existing != nil {
+				mergeFileMatch(existing, fileMatch)
+				continue
+			}
+			fileMatches[fileMatch.uri] = fileMatch
+		}
+		left.SearchResults = append(left.SearchResults, r)
 	}
-	return left, nil
+	// merge common search data.
+	left.searchResultsCommon.update(right.searchResultsCommon)
+	// for union we want the newly computed union size.
+	left.searchResultsCommon.resultCount = int32(len(left.SearchResults))
+	return left
 }
 
 // intersect returns the intersection of two sets of search result content
 // matches, based on whether a single file path contains content matches in both
 // sets.
-func intersect(left, right *SearchResultsResolver) (*SearchResultsResolver, error) {
+func intersect(left, right *SearchResultsResolver) *SearchResultsResolver {
 	if left == nil || right == nil {
-		return nil, nil
+		return nil
 	}
 
 	rFileMatches := make(map[string]*FileMatchResolver)
@@ -659,7 +673,7 @@ func intersect(left, right *SearchResultsResolver) (*SearchResultsResolver, erro
 			continue
 		}
 
-		ltmpFileMatch.JLineMatches = append(ltmpFileMatch.JLineMatches, rtmpFileMatch.JLineMatches...)
+		mergeFileMatch(ltmpFileMatch, rtmpFileMatch)
 		merged = append(merged, ltmp)
 	}
 	left.SearchResults = merged
@@ -667,9 +681,33 @@ func intersect(left, right *SearchResultsResolver) (*SearchResultsResolver, erro
 	left.searchResultsCommon.update(right.searchResultsCommon)
 	// for intersect we want the newly computed intersection size.
 	left.searchResultsCommon.resultCount = int32(len(merged))
-	return left, nil
+	return left
+}
+
+// setAndOrQuery sets the and/or query evaluated by the next leaf search to the
+// scope parameters and patterns. The cached repositories are reset if the
+// scope differs from the one of the previous leaf search.
+func (r *searchResolver) setAndOrQuery(scope []query.Node, patterns ...query.Node) {
+	var key []string
+	for _, node := range scope {
+		key = append(key, node.String())
+	}
+	r.reposMu.Lock()
+	if k := strings.Join(key, " "); k != r.reposScope {
+		r.repoRevs, r.missingRepoRevs, r.repoOverLimit, r.repoErr = nil, nil, false, nil
+		r.reposScope = k
+	}
+	r.reposMu.Unlock()
+
+	q := make([]query.Node, 0, len(scope)+len(patterns))
+	q = append(q, scope...)
+	q = append(q, patterns...)
+	r.query = query.AndOrQuery{Query: q}
 }
 
+// evaluateOperator evaluates each operand of an and/or expression of search
+// patterns separately and combines their results. It is used for structural
+// search, which cannot evaluate such expressions in a single search.
 func (r *searchResolver) evaluateOperator(ctx context.Context, scopeParameters []query.Node, operator query.Operator) (*SearchResultsResolver, error) {
 	if len(operator.Operands) == 0 {
 		return nil, nil
@@ -680,7 +718,7 @@ func (r *searchResolver) evaluateOperator(ctx context.Context, scopeParameters [
 	if err != nil {
 		return nil, err
 	}
-	if result == nil {
+	if result == nil && operator.Kind == query.And {
 		return nil, nil
 	}
 	for _, term := range operator.Operands[1:] {
@@ -692,56 +730,194 @@ func (r *searchResolver) evaluateOperator(ctx context.Context, scopeParameters [
 			// Shortcircuit: intersecting with empty new results is empty.
 			return nil, nil
 		}
-		if new != nil {
-			switch operator.Kind {
-			case query.And:
-				result, err = intersect(result, new)
-			case query.Or:
-				result, err = union(result, new)
-			}
-			if err != nil {
-				return nil, err
-			}
+		switch operator.Kind {
+		case query.And:
+			result = intersect(result, new)
+		case query.Or:
+			result = union(result, new)
 		}
 	}
 	return result, nil
 }
 
-// evaluatePatternExpression evaluates a search pattern containing and/or expressions.
+// evaluatePatternExpression evaluates a search pattern containing and/or
+// expressions by evaluating each pattern separately.
 func (r *searchResolver) evaluatePatternExpression(ctx context.Context, scopeParameters []query.Node, node query.Node) (*SearchResultsResolver, error) {
 	switch term := node.(type) {
 	case query.Operator:
 		if term.Kind == query.And || term.Kind == query.Or {
 			return r.evaluateOperator(ctx, scopeParameters, term)
 		} else if term.Kind == query.Concat {
-			q := append(scopeParameters, term)
-			r.query = query.AndOrQuery{Query: q}
+			r.setAndOrQuery(scopeParameters, term)
 			return r.evaluateLeaf(ctx)
 		}
 	case query.Parameter:
-		q := append(scopeParameters, term)
-		r.query = query.AndOrQuery{Query: q}
+		if term.Negated {
+			return nil, &badRequestError{errors.New("negated patterns are not supported in structural search")}
+		}
+		r.setAndOrQuery(scopeParameters, term)
 		return r.evaluateLeaf(ctx)
 	}
 	// Unreachable.
 	return nil, fmt.Errorf("unrecognized type %s in evaluatePatternExpression", reflect.TypeOf(node).String())
 }
 
-// evaluate evaluates all expressions of a search query.
-func (r *searchResolver) evaluate(ctx context.Context, q []query.Node) (*SearchResultsResolver, error) {
-	scopeParameters, pattern, err := query.PartitionSearchPattern(q)
-	if err != nil {
-		return nil, err
+// evaluatePatterns evaluates the conjunction of the search patterns within the
+// scope parameters. An and/or expression of patterns is evaluated in a single
+// search, which evaluates the expression for each file.
+func (r *searchResolver) evaluatePatterns(ctx context.Context, scope []query.Node, patterns []query.Node) (*SearchResultsResolver, error) {
+	if len(patterns) == 0 {
+		r.setAndOrQuery(scope)
+		return r.evaluateLeaf(ctx)
+	}
+
+	pattern := patterns[0]
+	if len(patterns) > 1 {
+		pattern = query.Operator{Kind: query.And, Operands: patterns}
+	}
+
+	if p, ok := pattern.(query.Parameter); ok && !p.Negated {
+		r.setAndOrQuery(scope, pattern)
+		return r.evaluateLeaf(ctx)
 	}
-	if pattern == nil {
-		r.query = query.AndOrQuery{Query: scopeParameters}
+	if o, ok := pattern.(query.Operator); ok && o.Kind == query.Concat {
+		r.setAndOrQuery(scope, pattern)
 		return r.evaluateLeaf(ctx)
 	}
-	result, err := r.evaluatePatternExpression(ctx, scopeParameters, pattern)
+
+	if r.patternType == query.SearchTypeStructural {
+		return r.evaluatePatternExpression(ctx, scope, pattern)
+	}
+
+	// Search for files matching any of the patterns, and let the search
+	// backends evaluate the expression for each file.
+	var positive []query.Node
+	query.VisitNode(pattern, func(node query.Node) {
+		switch n := node.(type) {
+		case query.Parameter:
+			if !n.Negated {
+				positive = append(positive, n)
+			}
+		case query.Operator:
+			if n.Kind == query.Concat {
+				positive = append(positive, n)
+			}
+		}
+	})
+	r.setAndOrQuery(scope, positive...)
+	r.andOrPattern = &andOrPattern{scope: scope, pattern: pattern}
+	defer func() { r.andOrPattern = nil }()
+	return r.evaluateLeaf(ctx)
+}
+
+// evaluateAnd evaluates the conjunction of the operands within the scope
+// parameters. Parameters among the operands extend the scope of the other
+// operands. Groups containing parameters that are combined with or are
+// distributed over the other operands, so that each alternative is evaluated
+// within its own scope. For example, "foo (repo:a or repo:b)" is evaluated as
+// "(repo:a foo) or (repo:b foo)".
+func (r *searchResolver) evaluateAnd(ctx context.Context, scope []query.Node, operands []query.Node) (*SearchResultsResolver, error) {
+	scope = append([]query.Node{}, scope...)
+	var patterns, groups []query.Node
+	for _, node := range flattenAnd(operands) {
+		switch n := node.(type) {
+		case query.Parameter:
+			if n.Field == "" || n.Field == query.FieldContent {
+				patterns = append(patterns, n)
+			} else {
+				scope = append(scope, n)
+			}
+		case query.Operator:
+			if query.IsPatternExpression([]query.Node{n}) {
+				patterns = append(patterns, n)
+			} else {
+				groups = append(groups, n)
+			}
+		}
+	}
+
+	if len(groups) == 0 {
+		return r.evaluatePatterns(ctx, scope, patterns)
+	}
+
+	rest := append(append([]query.Node{}, patterns...), groups[1:]...)
+	var result *SearchResultsResolver
+	for _, alternative := range groups[0].(query.Operator).Operands {
+		new, err := r.evaluateAnd(ctx, scope, append(append([]query.Node{}, rest...), alternative))
+		if err != nil {
+			return nil, err
+		}
+		if new != nil && new.alert != nil {
+			return new, nil
+		}
+		result = union(result, new)
+	}
+	return result, nil
+}
+
+// flattenAnd returns the operands of and operators in nodes, recursively.
+// Concatenations that contain parameters other than search patterns are
+// treated like and operators.
+func flattenAnd(nodes []query.Node) []query.Node {
+	var flat []query.Node
+	for _, node := range nodes {
+		if o, ok := node.(query.Operator); ok && (o.Kind == query.And || o.Kind == query.Concat && !query.IsPatternExpression([]query.Node{o})) {
+			flat = append(flat, flattenAnd(o.Operands)...)
+			continue
+		}
+		flat = append(flat, node)
+	}
+	return flat
+}
+
+// maxAlternatives is the maximum number of alternatives that evaluateAnd may
+// evaluate for a query. Each alternative is a separate search, and their
+// number grows exponentially with the number of or-groups of parameters.
+const maxAlternatives = 64
+
+// countAlternatives returns the number of alternatives that evaluateAnd
+// evaluates for the conjunction of operands, or a number greater than
+// maxAlternatives if there are more.
+func countAlternatives(operands []query.Node) int {
+	count := 1
+	for _, node := range flattenAnd(operands) {
+		group, ok := node.(query.Operator)
+		if !ok || query.IsPatternExpression([]query.Node{group}) {
+			continue
+		}
+		sum := 0
+		for _, alternative := range group.Operands {
+			sum += countAlternatives([]query.Node{alternative})
+			if sum > maxAlternatives {
+				break
+			}
+		}
+		count *= sum
+		if count > maxAlternatives {
+			return maxAlternatives + 1
+		}
+	}
+	return count
+}
+
+// evaluate evaluates all expressions of a search query.
+func (r *searchResolver) evaluate(ctx context.Context, q []query.Node) (*SearchResultsResolver, error) {
+	if countAlternatives(q) > maxAlternatives {
+		return nil, &badRequestError{fmt.Errorf("the query combines too many alternatives of parameters with or: at most %d combinations are supported", maxAlternatives)}
+	}
+	result, err := r.evaluateAnd(ctx, nil, q)
 	if err != nil {
 		return nil, err
 	}
+	if result == nil {
+		return &SearchResultsResolver{}, nil
+	}
 	sortResults(result.SearchResults)
+	if limit := int(r.maxResults()); len(result.SearchResults) > limit {
+		result.SearchResults = result.SearchResults[:limit]
+		result.searchResultsCommon.resultCount = int32(limit)
+		result.searchResultsCommon.limitHit = true
+	}
 	return result, nil
 }
 
@@ -1259,6 +1435,15 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 	if err := args.PatternInfo.Validate(); err != nil {
 		return nil, &badRequestError{err}
 	}
+	if r.andOrPattern != nil {
+		// The expression is evaluated per file, so only file results are
+		// meaningful.
+		forceOnlyResultType = "file"
+		args.PatternExpr, err = r.andOrPattern.patternExpr(options)
+		if err != nil {
+			return nil, &badRequestError{err}
+		}
+	}
 
 	err = validateRepoHasFileUsage(r.query)
 	if err != nil {
@@ -1433,3 +1433,28 @@ func Test_SearchResultsResolver_ApproximateResultCount(t *testing.T) {
 		})
 	}
 }
+
+func TestCountAlternatives(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{query: "foo", want: 1},
+		{query: "foo or bar", want: 1},
+		{query: "foo (repo:a or repo:b)", want: 2},
+		{query: "foo (repo:a or repo:b) (file:c or file:d or file:e)", want: 6},
+		{query: "foo (repo:a or (repo:b (file:c or file:d)))", want: 3},
+		{query: strings.Repeat("(repo:a or repo:b) ", 7) + "foo", want: maxAlternatives + 1},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			q, err := query.ParseAndOr(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := countAlternatives(q.(*query.AndOrQuery).Query); got != test.want {
+				t.Errorf("got %d, want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -9,8 +9,10 @@ import (
 
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/sourcegraph/jsonx"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 )
 
@@ -161,7 +163,7 @@ func (r *settingsMutation) editSettings(ctx context.Context, keyPath jsonx.Path,
 func (r *settingsMutation) OverwriteSettings(ctx context.Context, args *struct {
 	Contents string
 }) (*updateSettingsPayload, error) {
-	_, err := settingsCreateIfUpToDate(ctx, r.subject, r.input.LastID, actor.FromContext(ctx).UID, args.Contents)
+	_, err := r.createIfUpToDate(ctx, args.Contents)
 	if err != nil {
 		return nil, err
 	}
@@ -185,13 +187,27 @@ func (r *settingsMutation) doUpdateSettings(ctx context.Context, computeEdits fu
 	}
 
 	// Write mutated settings.
-	updatedSettings, err := settingsCreateIfUpToDate(ctx, r.subject, r.input.LastID, actor.FromContext(ctx).UID, newSettings)
+	updatedSettings, err := r.createIfUpToDate(ctx, newSettings)
 	if err != nil {
 		return 0, err
 	}
 	return updatedSettings.ID, nil
 }
 
+// createIfUpToDate writes the new settings contents and records the change in the audit log.
+func (r *settingsMutation) createIfUpToDate(ctx context.Context, contents string) (*api.Settings, error) {
+	settings, err := settingsCreateIfUpToDate(ctx, r.subject, r.input.LastID, actor.FromContext(ctx).UID, contents)
+	if err != nil {
+		return nil, err
+	}
+	subjectID, err := r.subject.ID()
+	if err != nil {
+		return nil, err
+	}
+	backend.LogAuditEvent(ctx, db.AuditSettingsUpdate, string(subjectID), map[string]int32{"settingsID": settings.ID})
+	return settings, nil
+}
+
 func (r *settingsMutation) getCurrentSettings(ctx context.Context) (string, error) {
 	// Get the settings file whose contents to mutate.
 	settings, err := db.Settings.GetLatest(ctx, r.subject.toSubject())
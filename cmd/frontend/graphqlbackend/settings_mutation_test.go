@@ -29,6 +29,12 @@ func TestSettingsMutation_EditSettings(t *testing.T) {
 		}
 		return &api.Settings{ID: 2, Contents: contents}, nil
 	}
+	db.Mocks.AuditLog.Create = func(e *db.AuditLogEvent) error {
+		if want := db.AuditSettingsUpdate; e.Action != want {
+			t.Errorf("got audit log action %q, want %q", e.Action, want)
+		}
+		return nil
+	}
 
 	gqltesting.RunTests(t, []*gqltesting.Test{
 		{
@@ -73,6 +79,12 @@ func TestSettingsMutation_OverwriteSettings(t *testing.T) {
 		}
 		return &api.Settings{ID: 2, Contents: contents}, nil
 	}
+	db.Mocks.AuditLog.Create = func(e *db.AuditLogEvent) error {
+		if want := db.AuditSettingsUpdate; e.Action != want {
+			t.Errorf("got audit log action %q, want %q", e.Action, want)
+		}
+		return nil
+	}
 
 	gqltesting.RunTests(t, []*gqltesting.Test{
 		{
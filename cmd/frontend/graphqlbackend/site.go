@@ -179,6 +179,8 @@ func (r *schemaResolver) UpdateSiteConfiguration(ctx context.Context, args *stru
 	if err := globals.ConfigurationServerFrontendOnly.Write(ctx, prev); err != nil {
 		return false, err
 	}
+	// The site configuration contains secrets, so only record that it changed.
+	backend.LogAuditEvent(ctx, db.AuditSiteConfigUpdate, "", nil)
 	return globals.ConfigurationServerFrontendOnly.NeedServerRestart(), nil
 }
 
@@ -92,6 +92,10 @@ func (*schemaResolver) DeleteUser(ctx context.Context, args *struct {
 		return nil, err
 	}
 
+	backend.LogAuditEvent(ctx, db.AuditUserDelete, string(args.User), map[string]interface{}{
+		"username": user.Username,
+		"hard":     args.Hard != nil && *args.Hard,
+	})
 	return &EmptyResponse{}, nil
 }
 
@@ -111,6 +115,7 @@ func (*schemaResolver) DeleteOrganization(ctx context.Context, args *struct {
 	if err := db.Orgs.Delete(ctx, orgID); err != nil {
 		return nil, err
 	}
+	backend.LogAuditEvent(ctx, db.AuditOrgDelete, string(args.Organization), nil)
 	return &EmptyResponse{}, nil
 }
 
@@ -140,5 +145,6 @@ func (*schemaResolver) SetUserIsSiteAdmin(ctx context.Context, args *struct {
 	if err := db.Users.SetIsSiteAdmin(ctx, userID, args.SiteAdmin); err != nil {
 		return nil, err
 	}
+	backend.LogAuditEvent(ctx, db.AuditUserSetSiteAdmin, string(args.UserID), map[string]bool{"siteAdmin": args.SiteAdmin})
 	return &EmptyResponse{}, nil
 }
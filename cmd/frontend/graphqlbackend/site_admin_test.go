@@ -87,6 +87,15 @@ func TestDeleteUser(t *testing.T) {
 			},
 		}, nil
 	}
+	db.Mocks.AuditLog.Create = func(e *db.AuditLogEvent) error {
+		if want := db.AuditUserDelete; e.Action != want {
+			t.Errorf("got audit log action %q, want %q", e.Action, want)
+		}
+		if want := "VXNlcjo2"; e.Subject != want {
+			t.Errorf("got audit log subject %q, want %q", e.Subject, want)
+		}
+		return nil
+	}
 	db.Mocks.Authz.RevokeUserPermissions = func(_ context.Context, args *db.RevokeUserPermissionsArgs) error {
 		if args.UserID != 6 {
 			return fmt.Errorf("args.UserID: want 6 but got %v", args.UserID)
@@ -46,6 +46,10 @@ func (r *statusMessageResolver) ToSyncError() (*statusMessageResolver, bool) {
 	return r, r.message.SyncError != nil
 }
 
+func (r *statusMessageResolver) ToExternalServiceQuotaExceeded() (*statusMessageResolver, bool) {
+	return r, r.message.ExternalServiceQuotaExceeded != nil
+}
+
 func (r *statusMessageResolver) Message() (string, error) {
 	if r.message.Cloning != nil {
 		return r.message.Cloning.Message, nil
@@ -56,11 +60,23 @@ func (r *statusMessageResolver) Message() (string, error) {
 	if r.message.SyncError != nil {
 		return r.message.SyncError.Message, nil
 	}
+	if r.message.ExternalServiceQuotaExceeded != nil {
+		return r.message.ExternalServiceQuotaExceeded.Message, nil
+	}
 	return "", errors.New("status message is of unknown type")
 }
 
 func (r *statusMessageResolver) ExternalService(ctx context.Context) (*externalServiceResolver, error) {
-	id := r.message.ExternalServiceSyncError.ExternalServiceId
+	var id int64
+	switch {
+	case r.message.ExternalServiceSyncError != nil:
+		id = r.message.ExternalServiceSyncError.ExternalServiceId
+	case r.message.ExternalServiceQuotaExceeded != nil:
+		id = r.message.ExternalServiceQuotaExceeded.ExternalServiceId
+	default:
+		return nil, errors.New("status message has no external service")
+	}
+
 	externalService, err := db.ExternalServices.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
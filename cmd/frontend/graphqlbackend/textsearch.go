@@ -2,9 +2,7 @@ package graphqlbackend
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -24,18 +22,26 @@ import (
 
 	"github.com/inconshreveable/log15"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	searcherprotocol "github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/endpoint"
 	"github.com/sourcegraph/sourcegraph/internal/errcode"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/mutablelimiter"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	querytypes "github.com/sourcegraph/sourcegraph/internal/search/query/types"
+	"github.com/sourcegraph/sourcegraph/internal/search/searcher"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
+// maxRefGlobRevisionsPerRepo is the maximum number of distinct commits matched by ref globs that
+// are searched in a single repository. If more match, the repository is reported as partially
+// searched.
+const maxRefGlobRevisionsPerRepo = 50
+
 var (
 	// A global limiter on number of concurrent searcher searches.
 	textSearchLimiter = mutablelimiter.New(32)
@@ -294,51 +300,60 @@ func textSearch(ctx context.Context, searcherURLs *endpoint.Map, repo gitserver.
 }
 
 func textSearchURL(ctx context.Context, url string) ([]*FileMatchResolver, bool, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, false, err
-	}
-	req = req.WithContext(ctx)
-
-	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx), req,
-		nethttp.OperationName("Searcher Client"),
-		nethttp.ClientTrace(false))
-	defer ht.Finish()
-
-	// Do not lose the context returned by TraceRequest
-	ctx = req.Context()
-
-	resp, err := searchHTTPClient.Do(req)
-	if err != nil {
-		// If we failed due to cancellation or timeout (with no partial results in the response
-		// body), return just that.
-		if ctx.Err() != nil {
-			err = ctx.Err()
-		}
-		return nil, false, errors.Wrap(err, "searcher request failed")
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, false, err
+	var ht *nethttp.Tracer
+	defer func() {
+		if ht != nil {
+			ht.Finish()
 		}
-		return nil, false, errors.WithStack(&searcherError{StatusCode: resp.StatusCode, Message: string(body)})
-	}
+	}()
+	doer := httpcli.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		req, ht = nethttp.TraceRequest(ot.GetTracer(ctx), req,
+			nethttp.OperationName("Searcher Client"),
+			nethttp.ClientTrace(false))
+		return searchHTTPClient.Do(req)
+	})
 
-	r := struct {
-		Matches     []*FileMatchResolver
-		LimitHit    bool
-		DeadlineHit bool
-	}{}
-	err = json.NewDecoder(resp.Body).Decode(&r)
+	var matches []*FileMatchResolver
+	done, err := searcher.Stream(ctx, doer, url, func(fm *searcherprotocol.FileMatch) {
+		matches = append(matches, fileMatchFromSearcher(fm))
+	})
+	var serr *searcher.Error
+	if errors.As(err, &serr) {
+		// Keep reporting errors of searcher as before streaming, so that
+		// temporary errors are retried and bad requests shown to the user.
+		err = errors.WithStack(&searcherError{StatusCode: serr.StatusCode, Message: serr.Message})
+	}
 	if err != nil {
-		return nil, false, errors.Wrap(err, "searcher response invalid")
+		return nil, false, err
 	}
-	if r.DeadlineHit {
+	if done.DeadlineHit {
 		err = context.DeadlineExceeded
 	}
-	return r.Matches, r.LimitHit, err
+	return matches, done.LimitHit, err
+}
+
+// fileMatchFromSearcher converts a FileMatch streamed by searcher to a
+// FileMatchResolver.
+func fileMatchFromSearcher(fm *searcherprotocol.FileMatch) *FileMatchResolver {
+	lineMatches := make([]*lineMatch, 0, len(fm.LineMatches))
+	for _, lm := range fm.LineMatches {
+		offsetAndLengths := make([][2]int32, 0, len(lm.OffsetAndLengths))
+		for _, ol := range lm.OffsetAndLengths {
+			offsetAndLengths = append(offsetAndLengths, [2]int32{int32(ol[0]), int32(ol[1])})
+		}
+		lineMatches = append(lineMatches, &lineMatch{
+			JPreview:          lm.Preview,
+			JOffsetAndLengths: offsetAndLengths,
+			JLineNumber:       int32(lm.LineNumber),
+			JLimitHit:         lm.LimitHit,
+		})
+	}
+	return &FileMatchResolver{
+		JPath:        fm.Path,
+		JLineMatches: lineMatches,
+		JLimitHit:    fm.LimitHit,
+		MatchCount:   fm.MatchCount,
+	}
 }
 
 type searcherError struct {
@@ -453,6 +468,27 @@ func fileMatchURI(name api.RepoName, ref, path string) string {
 	return b.String()
 }
 
+// fileMatchesForRefs returns a copy of the matches, which were found by searching the commit that
+// all of the refs point to, for each ref. Each copy is labelled with its ref so that the results
+// show (and link to) the branch they came from.
+func fileMatchesForRefs(repo *types.Repo, matches []*FileMatchResolver, refs []string) []*FileMatchResolver {
+	labelled := make([]*FileMatchResolver, 0, len(matches)*len(refs))
+	for _, ref := range refs {
+		rev := search.ShortRefName(ref)
+		for _, fm := range matches {
+			fm2 := *fm
+			path := fm.JPath
+			if i := strings.Index(fm.uri, "#"); i >= 0 {
+				path = fm.uri[i+1:]
+			}
+			fm2.uri = fileMatchURI(repo.Name, rev, path)
+			fm2.InputRev = &rev
+			labelled = append(labelled, &fm2)
+		}
+	}
+	return labelled
+}
+
 var mockSearchFilesInRepos func(args *search.TextParameters) ([]*FileMatchResolver, *searchResultsCommon, error)
 
 // searchFilesInRepos searches a set of repos for a pattern.
@@ -614,16 +650,24 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 				continue
 			}
 
-			revSpecs, err := repoAllRevs.ExpandedRevSpecs(ctx)
+			revs, err := repoAllRevs.ExpandedRevisions(ctx)
 			if err != nil {
 				return err
 			}
 
-			if len(revSpecs) >= 2 && !conf.SearchMultipleRevisionsPerRepository() {
+			// Ref globs always search multiple revisions, because that is what they ask for.
+			hasRefGlob := repoAllRevs.HasRefGlob()
+			if len(revs) >= 2 && !hasRefGlob && !conf.SearchMultipleRevisionsPerRepository() {
 				return errMultipleRevsNotSupported
 			}
+			if hasRefGlob && len(revs) > maxRefGlobRevisionsPerRepo {
+				revs = revs[:maxRefGlobRevisionsPerRepo]
+				mu.Lock()
+				common.partial[repoAllRevs.Repo.Name] = struct{}{}
+				mu.Unlock()
+			}
 
-			for _, rev := range revSpecs {
+			for _, rev := range revs {
 				// Only reason acquire can fail is if ctx is cancelled. So we can stop
 				// looping through searcherRepos.
 				limitCtx, limitDone, acquireErr := textSearchLimiter.Acquire(ctx)
@@ -631,8 +675,13 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 					break outer
 				}
 
-				// Make a new repoRev for just the operation of searching this revspec.
-				repoRev := &search.RepositoryRevisions{Repo: repoAllRevs.Repo, Revs: []search.RevisionSpecifier{{RevSpec: rev}}}
+				// Make a new repoRev for just the operation of searching this revspec. Refs matched by
+				// ref globs are searched once per commit, and the matches are labelled with each ref.
+				revSpec, refs := rev.RevSpec, rev.Refs
+				if len(refs) > 0 {
+					revSpec = string(rev.Commit)
+				}
+				repoRev := &search.RepositoryRevisions{Repo: repoAllRevs.Repo, Revs: []search.RevisionSpecifier{{RevSpec: revSpec}}}
 
 				args := *args
 				if args.PatternInfo.IsStructuralPat && searcherReposFilteredFiles != nil {
@@ -650,11 +699,23 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 					defer wg.Done()
 					defer done()
 
-					matches, repoLimitHit, err := searchFilesInRepo(ctx, args.SearcherURLs, repoRev.Repo, repoRev.GitserverRepo(), repoRev.RevSpecs()[0], args.PatternInfo, fetchTimeout)
+					var (
+						matches      []*FileMatchResolver
+						repoLimitHit bool
+						err          error
+					)
+					if args.PatternExpr != nil {
+						matches, repoLimitHit, err = searchFilesInRepoWithPatternExpr(ctx, args.SearcherURLs, repoRev.Repo, repoRev.GitserverRepo(), repoRev.RevSpecs()[0], args.PatternInfo, args.PatternExpr, fetchTimeout)
+					} else {
+						matches, repoLimitHit, err = searchFilesInRepo(ctx, args.SearcherURLs, repoRev.Repo, repoRev.GitserverRepo(), repoRev.RevSpecs()[0], args.PatternInfo, fetchTimeout)
+					}
 					if err != nil {
 						tr.LogFields(otlog.String("repo", string(repoRev.Repo.Name)), otlog.Error(err), otlog.Bool("timeout", errcode.IsTimeout(err)), otlog.Bool("temporary", errcode.IsTemporary(err)))
 						log15.Warn("searchFilesInRepo failed", "error", err, "repo", repoRev.Repo.Name)
 					}
+					if len(refs) > 0 {
+						matches = fileMatchesForRefs(repoRev.Repo, matches, refs)
+					}
 					mu.Lock()
 					defer mu.Unlock()
 					if ctx.Err() == nil {
@@ -3,10 +3,13 @@ package graphqlbackend
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -174,6 +177,72 @@ func TestQueryToZoektQuery(t *testing.T) {
 	}
 }
 
+func TestPatternExprToZoektQuery(t *testing.T) {
+	leaf := func(pattern string, negated bool) *search.PatternExpr {
+		return &search.PatternExpr{
+			Kind:    search.PatternLeaf,
+			Negated: negated,
+			Pattern: &search.TextPatternInfo{
+				IsRegExp:              true,
+				Pattern:               pattern,
+				PatternMatchesContent: true,
+				PatternMatchesPath:    true,
+			},
+		}
+	}
+
+	cases := []struct {
+		Name  string
+		Expr  *search.PatternExpr
+		Query string
+	}{
+		{
+			Name:  "and",
+			Expr:  &search.PatternExpr{Kind: search.PatternAnd, Operands: []*search.PatternExpr{leaf("foo", false), leaf("bar", false)}},
+			Query: `foo bar case:no`,
+		},
+		{
+			Name:  "or",
+			Expr:  &search.PatternExpr{Kind: search.PatternOr, Operands: []*search.PatternExpr{leaf("foo", false), leaf("bar", false)}},
+			Query: `(foo case:no) or (bar case:no)`,
+		},
+		{
+			Name:  "and not",
+			Expr:  &search.PatternExpr{Kind: search.PatternAnd, Operands: []*search.PatternExpr{leaf("foo", false), leaf("bar", true)}},
+			Query: `foo -bar case:no`,
+		},
+		{
+			Name: "nested",
+			Expr: &search.PatternExpr{Kind: search.PatternAnd, Operands: []*search.PatternExpr{
+				{Kind: search.PatternOr, Operands: []*search.PatternExpr{leaf("foo", false), leaf("bar", false)}},
+				leaf("baz", true),
+			}},
+			Query: `((foo case:no) or (bar case:no)) -baz case:no`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			q, err := zoektquery.Parse(tt.Query)
+			if err != nil {
+				t.Fatalf("failed to parse %q: %v", tt.Query, err)
+			}
+			info := &search.TextPatternInfo{IncludePatterns: []string{`\.go$`}, PathPatternsAreRegExps: true}
+			got, err := patternExprToZoektQuery(info, tt.Expr, false)
+			if err != nil {
+				t.Fatal("patternExprToZoektQuery failed:", err)
+			}
+			file, err := fileRe(`\.go$`, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := zoektquery.Simplify(zoektquery.NewAnd(q, file))
+			if !queryEqual(got, want) {
+				t.Fatalf("mismatched queries\ngot  %s\nwant %s", got.String(), want.String())
+			}
+		})
+	}
+}
+
 func queryEqual(a, b zoektquery.Q) bool {
 	sortChildren := func(q zoektquery.Q) zoektquery.Q {
 		switch s := q.(type) {
@@ -284,6 +353,61 @@ func TestQueryToZoektFileOnlyQueries(t *testing.T) {
 	}
 }
 
+func TestTextSearchURL(t *testing.T) {
+	var stream string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("Stream") != "true" {
+			t.Errorf("expected a streaming request, got %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, stream)
+	}))
+	defer ts.Close()
+
+	stream = "event: match\n" +
+		`data: {"Path":"a.go","LineMatches":[{"Preview":"foo bar","LineNumber":2,"OffsetAndLengths":[[4,3]]}],"MatchCount":1}` + "\n\n" +
+		"event: done\n" +
+		`data: {"LimitHit":true}` + "\n\n"
+	matches, limitHit, err := textSearchURL(context.Background(), ts.URL+"?Repo=r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*FileMatchResolver{{
+		JPath: "a.go",
+		JLineMatches: []*lineMatch{{
+			JPreview:          "foo bar",
+			JOffsetAndLengths: [][2]int32{{4, 3}},
+			JLineNumber:       2,
+		}},
+		MatchCount: 1,
+	}}
+	if diff := cmp.Diff(want, matches, cmp.AllowUnexported(FileMatchResolver{})); diff != "" {
+		t.Errorf("matches mismatch (-want +got):\n%s", diff)
+	}
+	if !limitHit {
+		t.Error("expected limitHit")
+	}
+
+	stream = "event: done\n" + `data: {"DeadlineHit":true}` + "\n\n"
+	if _, _, err := textSearchURL(context.Background(), ts.URL); err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	stream = "event: done\n" + `data: {"Error":"boom"}` + "\n\n"
+	if _, _, err := textSearchURL(context.Background(), ts.URL); err == nil || err.Error() != "boom" {
+		t.Errorf("got error %v, want boom", err)
+	} else if errcode.IsTemporary(err) {
+		t.Errorf("got temporary error %v, want permanent error", err)
+	}
+
+	stream = "event: done\n" + `data: {"Error":"timed out fetching archive","ErrorCode":503}` + "\n\n"
+	if _, _, err := textSearchURL(context.Background(), ts.URL); err == nil || err.Error() != "timed out fetching archive" {
+		t.Errorf("got error %v, want timed out fetching archive", err)
+	} else if !errcode.IsTemporary(err) {
+		t.Errorf("got permanent error %v, want temporary error", err)
+	}
+}
+
 func TestSearchFilesInRepos(t *testing.T) {
 	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
 		repoName := repo.Name
@@ -434,6 +558,82 @@ func TestSearchFilesInRepos_multipleRevsPerRepo(t *testing.T) {
 	}
 }
 
+func TestSearchFilesInRepos_refGlob(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		searched []string
+	)
+	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
+		mu.Lock()
+		searched = append(searched, rev)
+		mu.Unlock()
+		return []*FileMatchResolver{
+			{
+				JPath: "main.go",
+				uri:   "git://" + string(repo.Name) + "?" + rev + "#" + "main.go",
+			},
+		}, false, nil
+	}
+	defer func() { mockSearchFilesInRepo = nil }()
+
+	zoekt := &searchbackend.Zoekt{Client: &fakeSearcher{repos: &zoekt.RepoList{}}}
+
+	q, err := query.ParseAndCheck("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := &search.TextParameters{
+		PatternInfo: &search.TextPatternInfo{
+			FileMatchLimit: defaultMaxSearchResults,
+			Pattern:        "foo",
+		},
+		// Searching the revisions matched by a ref glob does not require the
+		// searchMultipleRevisionsPerRepository experimental feature.
+		Repos:        makeRepositoryRevisions("foo@*refs/heads/release/*"),
+		Query:        q,
+		Zoekt:        zoekt,
+		SearcherURLs: endpoint.Static("test"),
+	}
+	args.Repos[0].ListRefs = func(context.Context, gitserver.Repo) ([]git.Ref, error) {
+		return []git.Ref{
+			{Name: "refs/heads/master", CommitID: "c1"},
+			{Name: "refs/heads/release/1.0", CommitID: "c2"},
+			{Name: "refs/heads/release/1.1", CommitID: "c3"},
+			{Name: "refs/heads/release/1.1-hotfix", CommitID: "c3"},
+		}, nil
+	}
+	results, _, err := searchFilesInRepos(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each commit is searched once.
+	sort.Strings(searched)
+	if want := []string{"c2", "c3"}; !reflect.DeepEqual(searched, want) {
+		t.Errorf("searched revisions %q, want %q", searched, want)
+	}
+
+	// The results are labelled with the branches they came from.
+	var resultURIs, resultRevs []string
+	for _, result := range results {
+		resultURIs = append(resultURIs, result.uri)
+		resultRevs = append(resultRevs, *result.InputRev)
+	}
+	sort.Strings(resultURIs)
+	sort.Strings(resultRevs)
+	wantResultURIs := []string{
+		"git://foo?release%2F1.0#main.go",
+		"git://foo?release%2F1.1#main.go",
+		"git://foo?release%2F1.1-hotfix#main.go",
+	}
+	if !reflect.DeepEqual(resultURIs, wantResultURIs) {
+		t.Errorf("got %v, want %v", resultURIs, wantResultURIs)
+	}
+	if want := []string{"release/1.0", "release/1.1", "release/1.1-hotfix"}; !reflect.DeepEqual(resultRevs, want) {
+		t.Errorf("got revs %q, want %q", resultRevs, want)
+	}
+}
+
 func TestRepoShouldBeSearched(t *testing.T) {
 	mockTextSearch = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
 		repoName := repo.Name
@@ -92,7 +92,12 @@ func zoektSearchHEAD(ctx context.Context, args *search.TextParameters, repos []*
 		repoMap[api.RepoName(strings.ToLower(string(repoRev.Repo.Name)))] = repoRev
 	}
 
-	queryExceptRepos, err := queryToZoektQuery(args.PatternInfo, isSymbol)
+	var queryExceptRepos zoektquery.Q
+	if args.PatternExpr != nil {
+		queryExceptRepos, err = patternExprToZoektQuery(args.PatternInfo, args.PatternExpr, isSymbol)
+	} else {
+		queryExceptRepos, err = queryToZoektQuery(args.PatternInfo, isSymbol)
+	}
 	if err != nil {
 		return nil, false, nil, err
 	}
@@ -378,8 +383,53 @@ func fileRe(pattern string, queryIsCaseSensitive bool) (zoektquery.Q, error) {
 }
 
 func queryToZoektQuery(query *search.TextPatternInfo, isSymbol bool) (zoektquery.Q, error) {
-	var and []zoektquery.Q
+	q, err := patternToZoektQuery(query, isSymbol)
+	if err != nil {
+		return nil, err
+	}
+	return withZoektFileFilters(q, query)
+}
 
+// patternExprToZoektQuery translates an and/or expression of patterns into a
+// zoekt query tree, restricted by the file filters of query.
+func patternExprToZoektQuery(query *search.TextPatternInfo, expr *search.PatternExpr, isSymbol bool) (zoektquery.Q, error) {
+	q, err := patternExprToZoektPatternQuery(expr, isSymbol)
+	if err != nil {
+		return nil, err
+	}
+	return withZoektFileFilters(q, query)
+}
+
+func patternExprToZoektPatternQuery(expr *search.PatternExpr, isSymbol bool) (zoektquery.Q, error) {
+	switch expr.Kind {
+	case search.PatternLeaf:
+		q, err := patternToZoektQuery(expr.Pattern, isSymbol)
+		if err != nil {
+			return nil, err
+		}
+		if expr.Negated {
+			return &zoektquery.Not{Child: q}, nil
+		}
+		return q, nil
+	case search.PatternAnd, search.PatternOr:
+		operands := make([]zoektquery.Q, 0, len(expr.Operands))
+		for _, o := range expr.Operands {
+			q, err := patternExprToZoektPatternQuery(o, isSymbol)
+			if err != nil {
+				return nil, err
+			}
+			operands = append(operands, q)
+		}
+		if expr.Kind == search.PatternAnd {
+			return zoektquery.NewAnd(operands...), nil
+		}
+		return zoektquery.NewOr(operands...), nil
+	}
+	return nil, fmt.Errorf("unknown pattern expression kind %d", expr.Kind)
+}
+
+// patternToZoektQuery returns the zoekt query for the pattern of query.
+func patternToZoektQuery(query *search.TextPatternInfo, isSymbol bool) (zoektquery.Q, error) {
 	var q zoektquery.Q
 	var err error
 	if query.IsRegExp {
@@ -404,7 +454,13 @@ func queryToZoektQuery(query *search.TextPatternInfo, isSymbol bool) (zoektquery
 		}
 	}
 
-	and = append(and, q)
+	return q, nil
+}
+
+// withZoektFileFilters restricts q to the files matched by the include and
+// exclude patterns of query.
+func withZoektFileFilters(q zoektquery.Q, query *search.TextPatternInfo) (zoektquery.Q, error) {
+	and := []zoektquery.Q{q}
 
 	// zoekt also uses regular expressions for file paths
 	// TODO PathPatternsAreCaseSensitive
@@ -11,3 +11,7 @@ type LSIFServerProxy struct {
 
 // Set by enterprise frontend
 var NewLSIFServerProxy func() (*LSIFServerProxy, error)
+
+// CampaignsExportHandler serves the CSV exports of campaigns. Set by
+// enterprise frontend.
+var CampaignsExportHandler http.Handler
@@ -0,0 +1,25 @@
+package bg
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+)
+
+// LogAuditLogHead periodically logs the ID and hash of the most recent audit log event. The chain
+// of hashes can't detect events that were removed from the end of the audit log, but comparing it
+// with the logged heads can.
+func LogAuditLogHead(ctx context.Context) {
+	for {
+		events, err := db.AuditLog.List(ctx, db.AuditLogListOptions{LimitOffset: &db.LimitOffset{Limit: 1}})
+		if err != nil {
+			log15.Error("reading the head of the audit log", "error", err)
+		} else if len(events) == 1 {
+			log15.Info("audit log head", "id", events[0].ID, "hash", hex.EncodeToString(events[0].Hash))
+		}
+		time.Sleep(time.Hour)
+	}
+}
@@ -0,0 +1,95 @@
+package bg
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/txemail"
+	"github.com/sourcegraph/sourcegraph/internal/txemail/txtypes"
+)
+
+// SendAccessTokenExpiryReminders periodically emails the owners of access tokens that expire soon,
+// so that they can replace the tokens before they stop working.
+func SendAccessTokenExpiryReminders(ctx context.Context) {
+	for {
+		if conf.CanSendEmail() {
+			if err := sendAccessTokenExpiryReminders(ctx, time.Now()); err != nil {
+				log15.Error("sending access token expiry reminders", "error", err)
+			}
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+func sendAccessTokenExpiryReminders(ctx context.Context, now time.Time) error {
+	// Claim the tokens before sending the reminders, so that frontend replicas running this
+	// concurrently never remind the owner of a token twice. A reminder that fails to send is not
+	// retried.
+	tokens, err := db.AccessTokens.ClaimExpiryReminders(ctx, now.Add(conf.AccessTokensExpiryReminder()))
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if err := sendAccessTokenExpiryReminder(ctx, token); err != nil {
+			log15.Warn("sending access token expiry reminder", "accessToken", token.ID, "user", token.SubjectUserID, "error", err)
+		}
+	}
+	return nil
+}
+
+func sendAccessTokenExpiryReminder(ctx context.Context, token *db.AccessToken) error {
+	user, err := db.Users.GetByID(ctx, token.SubjectUserID)
+	if err != nil {
+		return err
+	}
+	email, verified, err := db.UserEmails.GetPrimaryEmail(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return nil
+	}
+
+	tokensURL := globals.ExternalURL().ResolveReference(&url.URL{Path: "/users/" + user.Username + "/settings/tokens"})
+	return txemail.Send(ctx, txemail.Message{
+		To:       []string{email},
+		Template: accessTokenExpiryReminderEmailTemplates,
+		Data: struct {
+			Username  string
+			Note      string
+			ExpiresAt string
+			URL       string
+		}{
+			Username:  user.Username,
+			Note:      token.Note,
+			ExpiresAt: token.ExpiresAt.UTC().Format("January 2, 2006 15:04 MST"),
+			URL:       tokensURL.String(),
+		},
+	})
+}
+
+var accessTokenExpiryReminderEmailTemplates = txemail.MustValidate(txtypes.Templates{
+	Subject: `Your Sourcegraph access token "{{.Note}}" expires soon`,
+	Text: `
+The access token "{{.Note}}" for the user {{.Username}} on Sourcegraph expires on {{.ExpiresAt}}. After that, API clients using it will no longer be able to authenticate.
+
+To create a replacement access token, visit:
+
+  {{.URL}}
+`,
+	HTML: `
+<p>
+  The access token <strong>{{.Note}}</strong> for the user <strong>{{.Username}}</strong>
+  on Sourcegraph expires on {{.ExpiresAt}}. After that, API clients using it will no
+  longer be able to authenticate.
+</p>
+
+<p><strong><a href="{{.URL}}">Create a replacement access token</a></strong></p>
+`,
+})
@@ -0,0 +1,80 @@
+package bg
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/txemail"
+)
+
+func TestSendAccessTokenExpiryReminders(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	u, err := db.Users.Create(ctx, db.NewUser{Email: "u@example.com", Username: "u", EmailIsVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	soon, later := now.Add(24*time.Hour), now.Add(30*24*time.Hour)
+	if _, _, err := db.AccessTokens.Create(ctx, u.ID, []string{authz.ScopeUserAll}, "soon", u.ID, &soon); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := db.AccessTokens.Create(ctx, u.ID, []string{authz.ScopeUserAll}, "later", u.ID, &later); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := db.AccessTokens.Create(ctx, u.ID, []string{authz.ScopeUserAll}, "never", u.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu   sync.Mutex
+		sent []txemail.Message
+	)
+	txemail.MockSend = func(ctx context.Context, message txemail.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, message)
+		return nil
+	}
+	defer func() { txemail.MockSend = nil }()
+
+	// Run concurrently, like on multiple frontend replicas, to ensure that each token's owner is
+	// only reminded once.
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- sendAccessTokenExpiryReminders(ctx, now)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(sent) != 1 {
+		t.Fatalf("got %d emails, want 1", len(sent))
+	}
+	if want := []string{"u@example.com"}; !reflect.DeepEqual(sent[0].To, want) {
+		t.Errorf("got recipients %q, want %q", sent[0].To, want)
+	}
+	if note := reflect.ValueOf(sent[0].Data).FieldByName("Note").String(); note != "soon" {
+		t.Errorf("got reminder for token %q, want %q", note, "soon")
+	}
+}
@@ -113,7 +113,7 @@ func InitDB() error {
 }
 
 // Main is the main entrypoint for the frontend server program.
-func Main(githubWebhook, bitbucketServerWebhook http.Handler) error {
+func Main(githubWebhook, gitlabWebhook, bitbucketServerWebhook http.Handler) error {
 	log.SetFlags(0)
 	log.SetPrefix("")
 
@@ -194,6 +194,8 @@ func Main(githubWebhook, bitbucketServerWebhook http.Handler) error {
 	goroutine.Go(func() { bg.CheckRedisCacheEvictionPolicy() })
 	goroutine.Go(func() { bg.DeleteOldCacheDataInRedis() })
 	goroutine.Go(func() { bg.DeleteOldEventLogsInPostgres(context.Background()) })
+	goroutine.Go(func() { bg.SendAccessTokenExpiryReminders(context.Background()) })
+	goroutine.Go(func() { bg.LogAuditLogHead(context.Background()) })
 	goroutine.Go(mailreply.StartWorker)
 	go updatecheck.Start()
 
@@ -236,7 +238,7 @@ func Main(githubWebhook, bitbucketServerWebhook http.Handler) error {
 	}
 
 	// Create the external HTTP handler.
-	externalHandler, err := newExternalHTTPHandler(schema, githubWebhook, bitbucketServerWebhook, lsifServerProxy)
+	externalHandler, err := newExternalHTTPHandler(schema, githubWebhook, gitlabWebhook, bitbucketServerWebhook, lsifServerProxy)
 	if err != nil {
 		return err
 	}
@@ -1,12 +1,15 @@
 package httpapi
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/inconshreveable/log15"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
 	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/errcode"
@@ -61,19 +64,30 @@ func AccessTokenAuthMiddleware(next http.Handler) http.Handler {
 			//
 			// 🚨 SECURITY: It's important we check for the correct scopes to know what this token
 			// is allowed to do.
-			var requiredScope string
+			var (
+				subjectUserID int32
+				scopes        []string
+				err           error
+			)
 			if sudoUser == "" {
-				requiredScope = authz.ScopeUserAll
+				// The scopes of the token are checked by the handler of the request.
+				subjectUserID, scopes, err = db.AccessTokens.LookupScopes(r.Context(), token)
 			} else {
-				requiredScope = authz.ScopeSiteAdminSudo
+				subjectUserID, err = db.AccessTokens.Lookup(r.Context(), token, authz.ScopeSiteAdminSudo)
 			}
-			subjectUserID, err := db.AccessTokens.Lookup(r.Context(), token, requiredScope)
 			if err != nil {
 				log15.Error("Invalid access token.", "token", token, "err", err)
 				http.Error(w, "Invalid access token.", http.StatusUnauthorized)
 				return
 			}
 
+			// 🚨 SECURITY: Only the API checks the scopes of access tokens, so tokens with narrower
+			// scopes than "user:all" may not be used for other requests.
+			if sudoUser == "" && !authz.HasScope(scopes, authz.ScopeUserAll) && !strings.HasPrefix(r.URL.Path, "/.api/") {
+				http.Error(w, fmt.Sprintf("Access token scopes %q only permit API requests.", scopes), http.StatusForbidden)
+				return
+			}
+
 			// Determine the actor's user ID.
 			var actorUserID int32
 			if sudoUser == "" {
@@ -103,9 +117,14 @@ func AccessTokenAuthMiddleware(next http.Handler) http.Handler {
 				}
 				actorUserID = user.ID
 				log15.Debug("HTTP request used sudo token.", "requestURI", r.URL.RequestURI(), "tokenSubjectUserID", subjectUserID, "actorUserID", actorUserID, "actorUsername", user.Username)
+				backend.LogAuditEventForUser(r.Context(), subjectUserID, db.AuditAccessTokenSudo, string(graphqlbackend.MarshalUserID(user.ID)), map[string]string{
+					"username":   user.Username,
+					"method":     r.Method,
+					"requestURI": r.URL.RequestURI(),
+				})
 			}
 
-			r = r.WithContext(actor.WithActor(r.Context(), &actor.Actor{UID: actorUserID}))
+			r = r.WithContext(actor.WithActor(r.Context(), &actor.Actor{UID: actorUserID, Scopes: scopes}))
 		}
 
 		next.ServeHTTP(w, r)
@@ -69,9 +69,9 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/", nil)
 		req.Header.Set("Authorization", "token badbad")
 		var calledAccessTokensLookup bool
-		db.Mocks.AccessTokens.Lookup = func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error) {
+		db.Mocks.AccessTokens.LookupScopes = func(tokenHexEncoded string) (subjectUserID int32, scopes []string, err error) {
 			calledAccessTokensLookup = true
-			return 0, errors.New("x")
+			return 0, nil, errors.New("x")
 		}
 		defer func() { db.Mocks = db.MockStores{} }()
 		checkHTTPResponse(t, req, http.StatusUnauthorized, "Invalid access token.\n")
@@ -85,15 +85,12 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 			req, _ := http.NewRequest("GET", "/", nil)
 			req.Header.Set("Authorization", headerValue)
 			var calledAccessTokensLookup bool
-			db.Mocks.AccessTokens.Lookup = func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error) {
+			db.Mocks.AccessTokens.LookupScopes = func(tokenHexEncoded string) (subjectUserID int32, scopes []string, err error) {
 				calledAccessTokensLookup = true
 				if want := "abcdef"; tokenHexEncoded != want {
 					t.Errorf("got %q, want %q", tokenHexEncoded, want)
 				}
-				if want := authz.ScopeUserAll; requiredScope != want {
-					t.Errorf("got %q, want %q", requiredScope, want)
-				}
-				return 123, nil
+				return 123, []string{authz.ScopeUserAll}, nil
 			}
 			defer func() { db.Mocks = db.MockStores{} }()
 			checkHTTPResponse(t, req, http.StatusOK, "user 123")
@@ -109,15 +106,12 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 		req.Header.Set("Authorization", "token abcdef")
 		req = req.WithContext(actor.WithActor(context.Background(), &actor.Actor{UID: 456}))
 		var calledAccessTokensLookup bool
-		db.Mocks.AccessTokens.Lookup = func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error) {
+		db.Mocks.AccessTokens.LookupScopes = func(tokenHexEncoded string) (subjectUserID int32, scopes []string, err error) {
 			calledAccessTokensLookup = true
 			if want := "abcdef"; tokenHexEncoded != want {
 				t.Errorf("got %q, want %q", tokenHexEncoded, want)
 			}
-			if want := authz.ScopeUserAll; requiredScope != want {
-				t.Errorf("got %q, want %q", requiredScope, want)
-			}
-			return 123, nil
+			return 123, []string{authz.ScopeUserAll}, nil
 		}
 		defer func() { db.Mocks = db.MockStores{} }()
 		checkHTTPResponse(t, req, http.StatusOK, "user 123")
@@ -143,15 +137,12 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 			}
 			req = req.WithContext(actor.WithActor(context.Background(), &actor.Actor{UID: 456}))
 			var calledAccessTokensLookup bool
-			db.Mocks.AccessTokens.Lookup = func(tokenHexEncoded, requiredScope string) (subjectUserID int32, err error) {
+			db.Mocks.AccessTokens.LookupScopes = func(tokenHexEncoded string) (subjectUserID int32, scopes []string, err error) {
 				calledAccessTokensLookup = true
 				if want := "abcdef"; tokenHexEncoded != want {
 					t.Errorf("got %q, want %q", tokenHexEncoded, want)
 				}
-				if want := authz.ScopeUserAll; requiredScope != want {
-					t.Errorf("got %q, want %q", requiredScope, want)
-				}
-				return 123, nil
+				return 123, []string{authz.ScopeUserAll}, nil
 			}
 			defer func() { db.Mocks = db.MockStores{} }()
 			checkHTTPResponse(t, req, http.StatusOK, "user 123")
@@ -161,6 +152,22 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 		})
 	}
 
+	for path, want := range map[string]int{"/": http.StatusForbidden, "/.api/graphql": http.StatusOK} {
+		t.Run("valid non-sudo token with narrow scopes: "+path, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", path, nil)
+			req.Header.Set("Authorization", "token abcdef")
+			db.Mocks.AccessTokens.LookupScopes = func(tokenHexEncoded string) (subjectUserID int32, scopes []string, err error) {
+				return 123, []string{authz.ScopeSearchRead}, nil
+			}
+			defer func() { db.Mocks = db.MockStores{} }()
+			wantBody := "user 123"
+			if want == http.StatusForbidden {
+				wantBody = "Access token scopes [\"search:read\"] only permit API requests.\n"
+			}
+			checkHTTPResponse(t, req, want, wantBody)
+		})
+	}
+
 	t.Run("valid sudo token", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/", nil)
 		req.Header.Set("Authorization", `token-sudo token="abcdef",user="alice"`)
@@ -191,6 +198,11 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 			}
 			return &types.User{ID: 456, SiteAdmin: true}, nil
 		}
+		var auditEvent *db.AuditLogEvent
+		db.Mocks.AuditLog.Create = func(e *db.AuditLogEvent) error {
+			auditEvent = e
+			return nil
+		}
 		defer func() { db.Mocks = db.MockStores{} }()
 		checkHTTPResponse(t, req, http.StatusOK, "user 456")
 		if !calledAccessTokensLookup {
@@ -202,6 +214,9 @@ func TestAccessTokenAuthMiddleware(t *testing.T) {
 		if !calledUsersGetByUsername {
 			t.Error("!calledUsersGetByUsername")
 		}
+		if auditEvent == nil || auditEvent.Action != db.AuditAccessTokenSudo || auditEvent.ActorUserID != 123 {
+			t.Errorf("got audit log event %+v, want sudo event by user 123", auditEvent)
+		}
 	})
 
 	// Test that if a sudo token's subject user is not a site admin (which means they were demoted
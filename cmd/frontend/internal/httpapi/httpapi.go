@@ -30,7 +30,7 @@ import (
 //
 // 🚨 SECURITY: The caller MUST wrap the returned handler in middleware that checks authentication
 // and sets the actor in the request context.
-func NewHandler(m *mux.Router, schema *graphql.Schema, githubWebhook, bitbucketServerWebhook http.Handler, lsifServerProxy *httpapi.LSIFServerProxy) http.Handler {
+func NewHandler(m *mux.Router, schema *graphql.Schema, githubWebhook, gitlabWebhook, bitbucketServerWebhook http.Handler, lsifServerProxy *httpapi.LSIFServerProxy) http.Handler {
 	if m == nil {
 		m = apirouter.New(nil)
 	}
@@ -46,16 +46,26 @@ func NewHandler(m *mux.Router, schema *graphql.Schema, githubWebhook, bitbucketS
 	// Set handlers for the installed routes.
 	m.Get(apirouter.RepoShield).Handler(trace.TraceRoute(handler(serveRepoShield)))
 
+	m.Get(apirouter.SearchExport).Handler(trace.TraceRoute(handler(serveSearchExport)))
+
 	m.Get(apirouter.RepoRefresh).Handler(trace.TraceRoute(handler(serveRepoRefresh)))
 
 	if githubWebhook != nil {
 		m.Get(apirouter.GitHubWebhooks).Handler(trace.TraceRoute(githubWebhook))
 	}
 
+	if gitlabWebhook != nil {
+		m.Get(apirouter.GitLabWebhooks).Handler(trace.TraceRoute(gitlabWebhook))
+	}
+
 	if bitbucketServerWebhook != nil {
 		m.Get(apirouter.BitbucketServerWebhooks).Handler(trace.TraceRoute(bitbucketServerWebhook))
 	}
 
+	if httpapi.CampaignsExportHandler != nil {
+		m.Get(apirouter.CampaignsExport).Handler(trace.TraceRoute(httpapi.CampaignsExportHandler))
+	}
+
 	if envvar.SourcegraphDotComMode() {
 		m.Path("/updates").Methods("GET", "POST").Name("updatecheck").Handler(trace.TraceRoute(http.HandlerFunc(updatecheck.Handler)))
 	}
@@ -82,6 +92,8 @@ func NewHandler(m *mux.Router, schema *graphql.Schema, githubWebhook, bitbucketS
 		http.Error(w, "no route", http.StatusNotFound)
 	})
 
+	m.Use(routeScopeMiddleware)
+
 	return m
 }
 
@@ -0,0 +1,45 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	apirouter "github.com/sourcegraph/sourcegraph/cmd/frontend/internal/httpapi/router"
+)
+
+// routeScopes are the access token scopes required by API routes. Routes that are not listed
+// require the "user:all" scope. Routes that map to "" don't require a scope, either because they
+// don't expose anything specific to the user or because their handler checks scopes itself.
+var routeScopes = map[string]string{
+	apirouter.GraphQL:         "", // checked per field by serveGraphQL
+	apirouter.SrcCliVersion:   "",
+	apirouter.SrcCliDownload:  "",
+	apirouter.SearchExport:    authz.ScopeSearchRead,
+	apirouter.RepoShield:      authz.ScopeRepoRead,
+	apirouter.RepoRefresh:     authz.ScopeRepoRead,
+	apirouter.CampaignsExport: authz.ScopeCampaignsWrite,
+}
+
+// routeScopeMiddleware rejects requests whose access token scopes don't permit the matched route.
+func routeScopeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var name string
+		if route := mux.CurrentRoute(r); route != nil {
+			name = route.GetName()
+		}
+		scope, ok := routeScopes[name]
+		if !ok {
+			scope = authz.ScopeUserAll
+		}
+		if scope != "" {
+			// 🚨 SECURITY: Access tokens may only be used for the routes their scopes permit.
+			if err := backend.CheckActorHasScope(r.Context(), "API route "+name, scope); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,50 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	apirouter "github.com/sourcegraph/sourcegraph/cmd/frontend/internal/httpapi/router"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+)
+
+func TestRouteScopeMiddleware(t *testing.T) {
+	m := apirouter.New(mux.NewRouter().PathPrefix("/.api/").Subrouter())
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, name := range []string{apirouter.SearchExport, apirouter.CampaignsExport, apirouter.LSIFUpload, apirouter.GraphQL} {
+		m.Get(name).Handler(ok)
+	}
+	m.Use(routeScopeMiddleware)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		scopes []string
+		want   int
+	}{
+		{name: "session", method: "POST", path: "/.api/lsif/upload", want: http.StatusOK},
+		{name: "user:all", method: "POST", path: "/.api/lsif/upload", scopes: []string{authz.ScopeUserAll}, want: http.StatusOK},
+		{name: "unlisted route", method: "POST", path: "/.api/lsif/upload", scopes: []string{authz.ScopeSearchRead}, want: http.StatusForbidden},
+		{name: "listed route", method: "GET", path: "/.api/search/export", scopes: []string{authz.ScopeSearchRead}, want: http.StatusOK},
+		{name: "listed route, other scope", method: "GET", path: "/.api/search/export", scopes: []string{authz.ScopeRepoRead}, want: http.StatusForbidden},
+		{name: "campaigns export", method: "GET", path: "/.api/campaigns/Q2FtcGFpZ246MQ==/changesets.csv", scopes: []string{authz.ScopeCampaignsWrite}, want: http.StatusOK},
+		{name: "campaigns export, other scope", method: "GET", path: "/.api/campaigns/Q2FtcGFpZ246MQ==/changesets.csv", scopes: []string{authz.ScopeSearchRead}, want: http.StatusForbidden},
+		{name: "graphql", method: "POST", path: "/.api/graphql", scopes: []string{authz.ScopeRepoRead}, want: http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(test.method, test.path, nil)
+			req = req.WithContext(actor.WithActor(context.Background(), &actor.Actor{UID: 1, Scopes: test.scopes}))
+			rr := httptest.NewRecorder()
+			m.ServeHTTP(rr, req)
+			if rr.Code != test.want {
+				t.Errorf("got status %d, want %d (body %q)", rr.Code, test.want, rr.Body.String())
+			}
+		})
+	}
+}
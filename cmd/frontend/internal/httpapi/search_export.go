@@ -0,0 +1,190 @@
+package httpapi
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+// searchExportErrorTrailer is the HTTP trailer set when an export fails after
+// results have already been streamed, at which point the status code can no
+// longer be changed.
+const searchExportErrorTrailer = "X-Sourcegraph-Export-Error"
+
+// searchExportFlushInterval is the number of matches after which the response
+// is flushed to the client.
+const searchExportFlushInterval = 100
+
+// exportSearch is graphqlbackend.ExportSearch. It is replaced in tests.
+var exportSearch = graphqlbackend.ExportSearch
+
+// serveSearchExport streams all results of the search query in the "q" URL
+// query parameter as newline-delimited JSON (format=jsonl, the default) or CSV
+// (format=csv). The optional "patternType" parameter is interpreted as in the
+// GraphQL search API.
+func serveSearchExport(w http.ResponseWriter, r *http.Request) error {
+	params := r.URL.Query()
+
+	query := params.Get("q")
+	if query == "" {
+		return &errcode.HTTPErr{Status: http.StatusBadRequest, Err: errors.New("missing query parameter q")}
+	}
+
+	var patternType *string
+	if v := params.Get("patternType"); v != "" {
+		patternType = &v
+	}
+
+	format := params.Get("format")
+	if format == "" {
+		format = "jsonl"
+	}
+	newWriter, ok := searchExportWriters[format]
+	if !ok {
+		return &errcode.HTTPErr{Status: http.StatusBadRequest, Err: errors.Errorf("unsupported export format %q", format)}
+	}
+
+	var (
+		ew    searchExportWriter
+		count int
+	)
+	start := func() {
+		w.Header().Set("Content-Type", ew.contentType())
+		w.Header().Set("Content-Disposition", "attachment; filename=search-results."+format)
+		w.Header().Set("Trailer", searchExportErrorTrailer)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	err := exportSearch(r.Context(), "V2", patternType, query, func(m *graphqlbackend.SearchExportMatch) error {
+		if ew == nil {
+			ew = newWriter(w)
+			start()
+		}
+		if err := ew.write(m); err != nil {
+			return err
+		}
+		if count++; count%searchExportFlushInterval == 0 {
+			return flushSearchExport(w, ew)
+		}
+		return nil
+	})
+	if ew == nil {
+		// Nothing has been written yet, so we can still respond with an error
+		// status.
+		if err != nil {
+			return err
+		}
+		ew = newWriter(w)
+		start()
+	}
+	if flushErr := flushSearchExport(w, ew); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		log15.Error("search export failed", "query", query, "matches", count, "error", err)
+		w.Header().Set(searchExportErrorTrailer, err.Error())
+	}
+	return nil
+}
+
+func flushSearchExport(w http.ResponseWriter, ew searchExportWriter) error {
+	if err := ew.flush(); err != nil {
+		return err
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// searchExportWriter writes search export matches in a specific format.
+type searchExportWriter interface {
+	contentType() string
+	write(*graphqlbackend.SearchExportMatch) error
+	flush() error
+}
+
+var searchExportWriters = map[string]func(io.Writer) searchExportWriter{
+	"jsonl": newJSONLinesSearchExportWriter,
+	"csv":   newCSVSearchExportWriter,
+}
+
+// searchExportRecord is the JSON shape of a single exported match.
+type searchExportRecord struct {
+	Repo     string `json:"repo"`
+	Revision string `json:"revision"`
+	Path     string `json:"path"`
+	Line     int    `json:"line,omitempty"`
+	Preview  string `json:"preview,omitempty"`
+}
+
+type jsonLinesSearchExportWriter struct {
+	enc *json.Encoder
+}
+
+func newJSONLinesSearchExportWriter(w io.Writer) searchExportWriter {
+	return &jsonLinesSearchExportWriter{enc: json.NewEncoder(w)}
+}
+
+func (*jsonLinesSearchExportWriter) contentType() string {
+	return "application/x-ndjson; charset=utf-8"
+}
+
+func (ew *jsonLinesSearchExportWriter) write(m *graphqlbackend.SearchExportMatch) error {
+	return ew.enc.Encode(&searchExportRecord{
+		Repo:     string(m.Repo),
+		Revision: string(m.Revision),
+		Path:     m.Path,
+		Line:     m.Line,
+		Preview:  m.Preview,
+	})
+}
+
+func (*jsonLinesSearchExportWriter) flush() error { return nil }
+
+type csvSearchExportWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVSearchExportWriter(w io.Writer) searchExportWriter {
+	return &csvSearchExportWriter{w: csv.NewWriter(w)}
+}
+
+func (*csvSearchExportWriter) contentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (ew *csvSearchExportWriter) writeHeader() error {
+	if ew.wroteHeader {
+		return nil
+	}
+	ew.wroteHeader = true
+	return ew.w.Write([]string{"repo", "revision", "path", "line", "preview"})
+}
+
+func (ew *csvSearchExportWriter) write(m *graphqlbackend.SearchExportMatch) error {
+	if err := ew.writeHeader(); err != nil {
+		return err
+	}
+	var line string
+	if m.Line > 0 {
+		line = strconv.Itoa(m.Line)
+	}
+	return ew.w.Write([]string{string(m.Repo), string(m.Revision), m.Path, line, m.Preview})
+}
+
+func (ew *csvSearchExportWriter) flush() error {
+	if err := ew.writeHeader(); err != nil {
+		return err
+	}
+	ew.w.Flush()
+	return ew.w.Error()
+}
@@ -0,0 +1,94 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+)
+
+func TestSearchExport(t *testing.T) {
+	matches := []*graphqlbackend.SearchExportMatch{
+		{Repo: "github.com/foo/bar", Revision: "deadbeef", Path: "a.go", Line: 3, Preview: `	oldAPI("x, y")`},
+		{Repo: "github.com/foo/bar", Revision: "deadbeef", Path: "b.go"},
+	}
+
+	var failAfter error
+	exportSearch = func(ctx context.Context, version string, patternType *string, query string, fn func(*graphqlbackend.SearchExportMatch) error) error {
+		if query != "oldAPI" {
+			t.Errorf("got query %q, want %q", query, "oldAPI")
+		}
+		for _, m := range matches {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return failAfter
+	}
+	defer func() { exportSearch = graphqlbackend.ExportSearch }()
+
+	c := newTest()
+
+	t.Run("jsonl", func(t *testing.T) {
+		resp, err := c.GetOK("/search/export?q=oldAPI")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		want := `{"repo":"github.com/foo/bar","revision":"deadbeef","path":"a.go","line":3,"preview":"\toldAPI(\"x, y\")"}
+{"repo":"github.com/foo/bar","revision":"deadbeef","path":"b.go"}
+`
+		if string(body) != want {
+			t.Errorf("got body\n%s\nwant\n%s", body, want)
+		}
+		if got, want := resp.Header.Get("Content-Type"), "application/x-ndjson; charset=utf-8"; got != want {
+			t.Errorf("got Content-Type %q, want %q", got, want)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		resp, err := c.GetOK("/search/export?q=oldAPI&format=csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		want := `repo,revision,path,line,preview
+github.com/foo/bar,deadbeef,a.go,3,"	oldAPI(""x, y"")"
+github.com/foo/bar,deadbeef,b.go,,
+`
+		if string(body) != want {
+			t.Errorf("got body\n%s\nwant\n%s", body, want)
+		}
+	})
+
+	t.Run("error after results", func(t *testing.T) {
+		failAfter = errors.New("boom")
+		defer func() { failAfter = nil }()
+
+		resp, err := c.GetOK("/search/export?q=oldAPI&format=csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := resp.Trailer.Get(searchExportErrorTrailer), "boom"; got != want {
+			t.Errorf("got trailer %q, want %q", got, want)
+		}
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		for _, url := range []string{
+			"/search/export",
+			"/search/export?q=oldAPI&format=xml",
+		} {
+			resp, err := c.Get(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("%s: got status %d, want %d", url, resp.StatusCode, http.StatusBadRequest)
+			}
+		}
+	})
+}
@@ -4,10 +4,12 @@ package types
 type SavedSearch struct {
 	ID              int32 // the globally unique DB ID
 	Description     string
-	Query           string  // the literal search query to be ran
-	Notify          bool    // whether or not to notify the owner(s) of this saved search via email
-	NotifySlack     bool    // whether or not to notify the owner(s) of this saved search via Slack
-	UserID          *int32  // if non-nil, the owner is this user. UserID/OrgID are mutually exclusive.
-	OrgID           *int32  // if non-nil, the owner is this organization. UserID/OrgID are mutually exclusive.
-	SlackWebhookURL *string // if non-nil && NotifySlack == true, indicates that this Slack webhook URL should be used instead of the owners default Slack webhook.
+	Query           string   // the literal search query to be ran
+	Notify          bool     // whether or not to notify the owner(s) of this saved search via email
+	NotifySlack     bool     // whether or not to notify the owner(s) of this saved search via Slack
+	UserID          *int32   // if non-nil, the owner is this user. UserID/OrgID are mutually exclusive.
+	OrgID           *int32   // if non-nil, the owner is this organization. UserID/OrgID are mutually exclusive.
+	SlackWebhookURL *string  // if non-nil && NotifySlack == true, indicates that this Slack webhook URL should be used instead of the owners default Slack webhook.
+	WebhookURLs     []string // URLs that a JSON payload is POSTed to when there are new results. On updates, nil leaves them unchanged.
+	WebhookSecret   *string  // if non-nil, the secret used to sign webhook payloads. On updates, nil leaves it unchanged.
 }
@@ -28,6 +28,8 @@ var (
 	runRepoCleanup, _ = strconv.ParseBool(env.Get("SRC_RUN_REPO_CLEANUP", "", "Periodically remove inactive repositories."))
 	wantPctFree       = env.Get("SRC_REPOS_DESIRED_PERCENT_FREE", "10", "Target percentage of free space on disk.")
 	janitorInterval   = env.Get("SRC_REPOS_JANITOR_INTERVAL", "1m", "Interval between cleanup runs")
+	backupDir         = env.Get("SRC_REPOS_BACKUP_DIR", "", "Dir that repos are backed up to and restored from as Git bundles. Backups are disabled if empty.")
+	signingPassphrase = env.Get("SRC_GIT_COMMIT_SIGNING_PASSPHRASE", "", "Passphrase of the private key in the gitCommitSigning site configuration.")
 )
 
 func main() {
@@ -46,10 +48,17 @@ func main() {
 	if err != nil {
 		log.Fatalf("parsing $SRC_REPOS_DESIRED_PERCENT_FREE: %v", err)
 	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		log15.Warn("failed to get hostname, repositories will not be replicated", "error", err)
+	}
 	gitserver := server.Server{
 		ReposDir:                reposDir,
 		DeleteStaleRepositories: runRepoCleanup,
 		DesiredPercentFree:      wantPctFree2,
+		Hostname:                hostname,
+		BackupDir:               backupDir,
+		CommitSigningPassphrase: signingPassphrase,
 	}
 	gitserver.RegisterMetrics()
 
@@ -0,0 +1,470 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+// Backups are written to a directory below Server.BackupDir. Each repository
+// is stored as a Git bundle of all of its refs ($name.bundle) and a JSON file
+// with backupMetadata ($name.json), where $name is the repository name.
+
+const (
+	bundleExt   = ".bundle"
+	metadataExt = ".json"
+)
+
+// backupMetadata is stored next to the bundle of a repository.
+type backupMetadata struct {
+	Repo        api.RepoName
+	URL         string // the remote URL, without credentials
+	LastFetched time.Time
+	LastChanged time.Time
+	BackedUpAt  time.Time
+}
+
+// skipError is returned for repositories that were deliberately not backed up
+// or restored.
+type skipError struct{ reason string }
+
+func (e *skipError) Error() string { return e.reason }
+
+// backupPath returns the directory for the backup named dir, which must be
+// inside s.BackupDir.
+func (s *Server) backupPath(dir string) (string, error) {
+	if s.BackupDir == "" {
+		return "", errors.New("backups are disabled (set SRC_REPOS_BACKUP_DIR)")
+	}
+	if dir == "" {
+		return "", errors.New("empty backup dir")
+	}
+	// Cleaning the path as an absolute path removes any ".." elements that
+	// would escape s.BackupDir.
+	return filepath.Join(s.BackupDir, filepath.Clean("/"+filepath.FromSlash(dir))), nil
+}
+
+// checkRepoName returns an error if repo can't safely be used as a path
+// below a directory, because it has an empty, "." or ".." path element.
+func checkRepoName(repo api.RepoName) error {
+	for _, elem := range strings.Split(string(repo), "/") {
+		switch elem {
+		case "", ".", "..":
+			return errors.Errorf("invalid repository name %q", repo)
+		}
+	}
+	return nil
+}
+
+// joinWithin joins root and the slash-separated path rel, and returns an
+// error if the result is not below root.
+func joinWithin(root, rel string) (string, error) {
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if !strings.HasPrefix(path, filepath.Clean(root)+string(filepath.Separator)) {
+		return "", errors.Errorf("path %q is outside of %q", rel, root)
+	}
+	return path, nil
+}
+
+func bundlePaths(backupPath string, repo api.RepoName) (bundle, metadata string, err error) {
+	if err := checkRepoName(repo); err != nil {
+		return "", "", err
+	}
+	base, err := joinWithin(backupPath, string(repo))
+	if err != nil {
+		return "", "", err
+	}
+	return base + bundleExt, base + metadataExt, nil
+}
+
+// checkedDir is like s.dir, but returns an error if the directory of repo is
+// not below s.ReposDir.
+func (s *Server) checkedDir(repo api.RepoName) (GitDir, error) {
+	if err := checkRepoName(repo); err != nil {
+		return "", err
+	}
+	dir := s.dir(repo)
+	if _, err := joinWithin(s.ReposDir, string(protocol.NormalizeRepo(repo))); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// errBackupRunning is returned when a backup or restore is requested while
+// another one is running.
+var errBackupRunning = errors.New("a backup or restore is already running")
+
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
+	var req protocol.BackupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	backupPath, err := s.backupPath(req.Dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list := func() ([]api.RepoName, error) {
+		if len(req.Repos) > 0 {
+			return req.Repos, nil
+		}
+		dirs, err := s.findGitDirs()
+		if err != nil {
+			return nil, err
+		}
+		var repos []api.RepoName
+		for _, dir := range dirs {
+			// Replicas are backed up by the repository's primary gitserver.
+			if !isReplica(dir) {
+				repos = append(repos, s.name(dir))
+			}
+		}
+		return repos, nil
+	}
+	s.startBackupOperation(w, "backup", req.Dir, list, func(ctx context.Context, repo api.RepoName) error {
+		return s.backupRepo(ctx, repo, backupPath)
+	})
+}
+
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	var req protocol.RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	backupPath, err := s.backupPath(req.Dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list := func() ([]api.RepoName, error) {
+		if len(req.Repos) > 0 {
+			return req.Repos, nil
+		}
+		return listBundles(backupPath)
+	}
+	s.startBackupOperation(w, "restore", req.Dir, list, func(ctx context.Context, repo api.RepoName) error {
+		return s.restoreRepo(ctx, repo, backupPath)
+	})
+}
+
+func (s *Server) handleBackupStatus(w http.ResponseWriter, r *http.Request) {
+	s.backupMu.Lock()
+	var status protocol.BackupStatus
+	if s.backupStatus != nil {
+		status = copyBackupStatus(s.backupStatus)
+	}
+	s.backupMu.Unlock()
+
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// startBackupOperation runs fn for each repository returned by list in the
+// background, and responds with its initial status. Only one backup or
+// restore runs at a time; its progress is returned by /backup-status.
+func (s *Server) startBackupOperation(w http.ResponseWriter, op, dir string, list func() ([]api.RepoName, error), fn func(context.Context, api.RepoName) error) {
+	s.backupMu.Lock()
+	if s.backupStatus != nil && s.backupStatus.Running {
+		s.backupMu.Unlock()
+		http.Error(w, errBackupRunning.Error(), http.StatusConflict)
+		return
+	}
+	s.backupStatus = &protocol.BackupStatus{
+		Operation:      op,
+		Dir:            dir,
+		Running:        true,
+		StartedAt:      time.Now().UTC(),
+		BackupResponse: protocol.BackupResponse{Repos: []api.RepoName{}},
+	}
+	status := copyBackupStatus(s.backupStatus)
+	s.backupMu.Unlock()
+
+	ctx, cancel := s.serverContext()
+	go func() {
+		defer cancel()
+
+		repos, err := list()
+		if err == nil {
+			s.backupMu.Lock()
+			s.backupStatus.Total = len(repos)
+			s.backupMu.Unlock()
+
+			s.forEachRepo(ctx, repos, fn)
+		}
+
+		s.backupMu.Lock()
+		defer s.backupMu.Unlock()
+		st := s.backupStatus
+		st.Running = false
+		st.FinishedAt = time.Now().UTC()
+		if err != nil {
+			st.Error = err.Error()
+		}
+		log15.Info("finished "+op, "dir", dir, "repos", len(st.Repos), "skipped", len(st.Skipped), "errors", len(st.Errors), "error", err)
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// copyBackupStatus returns a copy of st that can be used without holding
+// s.backupMu.
+func copyBackupStatus(st *protocol.BackupStatus) protocol.BackupStatus {
+	c := *st
+	c.Repos = append([]api.RepoName{}, st.Repos...)
+	if st.Skipped != nil {
+		c.Skipped = make(map[api.RepoName]string, len(st.Skipped))
+		for k, v := range st.Skipped {
+			c.Skipped[k] = v
+		}
+	}
+	if st.Errors != nil {
+		c.Errors = make(map[api.RepoName]string, len(st.Errors))
+		for k, v := range st.Errors {
+			c.Errors[k] = v
+		}
+	}
+	return c
+}
+
+// forEachRepo calls fn for each repo in turn and records the results in
+// s.backupStatus.
+func (s *Server) forEachRepo(ctx context.Context, repos []api.RepoName, fn func(context.Context, api.RepoName) error) {
+	for _, repo := range repos {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := checkRepoName(repo)
+		if err == nil {
+			repo = protocol.NormalizeRepo(repo)
+			repoCtx, cancel := context.WithTimeout(ctx, longGitCommandTimeout)
+			err = fn(repoCtx, repo)
+			cancel()
+		}
+
+		s.backupMu.Lock()
+		resp := &s.backupStatus.BackupResponse
+		switch e := err.(type) {
+		case nil:
+			resp.Repos = append(resp.Repos, repo)
+		case *skipError:
+			if resp.Skipped == nil {
+				resp.Skipped = map[api.RepoName]string{}
+			}
+			resp.Skipped[repo] = e.reason
+		default:
+			log15.Warn("failed to back up or restore repository", "repo", repo, "error", err)
+			if resp.Errors == nil {
+				resp.Errors = map[api.RepoName]string{}
+			}
+			resp.Errors[repo] = err.Error()
+		}
+		s.backupMu.Unlock()
+	}
+}
+
+// backupRepo writes a bundle of repo and its metadata to backupPath.
+func (s *Server) backupRepo(ctx context.Context, repo api.RepoName, backupPath string) error {
+	dir, err := s.checkedDir(repo)
+	if err != nil {
+		return err
+	}
+	bundlePath, metadataPath, err := bundlePaths(backupPath, repo)
+	if err != nil {
+		return err
+	}
+	if !repoCloned(dir) {
+		return &skipError{"not cloned"}
+	}
+	if repoCloneOptions(dir) != (protocol.CloneOptions{}) {
+		// A bundle needs the full history and all blobs. The repository is
+		// cloned from the code host with the same options instead.
+		return &skipError{"partial or shallow clone"}
+	}
+
+	md := backupMetadata{Repo: repo, BackedUpAt: time.Now().UTC()}
+	if remoteURL, err := repoRemoteURL(ctx, dir); err == nil {
+		md.URL = withoutCredentials(remoteURL)
+	}
+	if lastFetched, err := repoLastFetched(dir); err == nil {
+		md.LastFetched = lastFetched
+	}
+	if lastChanged, err := repoLastChanged(dir); err == nil {
+		md.LastChanged = lastChanged
+	}
+
+	if err := os.MkdirAll(filepath.Dir(bundlePath), os.ModePerm); err != nil {
+		return err
+	}
+
+	// Write to temporary files first, so that an interrupted backup doesn't
+	// leave behind a truncated bundle in place of a previous one.
+	cmd := exec.CommandContext(ctx, "git", "bundle", "create", bundlePath+".tmp", "--all")
+	cmd.Dir = string(dir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(bundlePath + ".tmp")
+		if strings.Contains(string(output), "Refusing to create empty bundle") {
+			return &skipError{"empty repository"}
+		}
+		return errors.Wrapf(err, "failed to create bundle. Output: %s", string(output))
+	}
+	if err := os.Rename(bundlePath+".tmp", bundlePath); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(metadataPath+".tmp", b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(metadataPath+".tmp", metadataPath)
+}
+
+// restoreRepo clones repo from its bundle in backupPath. The repository must
+// be assigned to this gitserver and not be cloned yet.
+func (s *Server) restoreRepo(ctx context.Context, repo api.RepoName, backupPath string) error {
+	dir, err := s.checkedDir(repo)
+	if err != nil {
+		return err
+	}
+	bundlePath, metadataPath, err := bundlePaths(backupPath, repo)
+	if err != nil {
+		return err
+	}
+	b, err := ioutil.ReadFile(metadataPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read backup metadata")
+	}
+	var md backupMetadata
+	if err := json.Unmarshal(b, &md); err != nil {
+		return errors.Wrap(err, "failed to parse backup metadata")
+	}
+	if protocol.NormalizeRepo(md.Repo) != repo {
+		return errors.Errorf("bundle is for repository %s", md.Repo)
+	}
+
+	// Only restore repositories that this gitserver would hold, otherwise
+	// they would be unreachable (and moved or removed by the janitor).
+	replica := false
+	if addrs := s.gitserverClient().Addrs(ctx); len(addrs) > 1 {
+		repoAddrs, index, ok := s.shardIndex(ctx, repo)
+		if !ok {
+			return errors.New("cannot check the gitserver the repository is assigned to: this gitserver's address is unknown")
+		}
+		if index < 0 {
+			return &skipError{fmt.Sprintf("assigned to %s", repoAddrs[0])}
+		}
+		replica = index > 0
+	}
+
+	if repoCloned(dir) {
+		return &skipError{"already cloned"}
+	}
+	lock, ok := s.locker.TryAcquire(dir, "restoring from backup")
+	if !ok {
+		return &skipError{"clone in progress"}
+	}
+	defer lock.Release()
+
+	ctx, cancel, err := s.acquireCloneLimiter(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	tmpDir, err := s.tempDir("restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	tmpPath := filepath.Join(tmpDir, ".git")
+	tmp := GitDir(tmpPath)
+	if err := cloneBundle(ctx, bundlePath, tmp, md.URL); err != nil {
+		return err
+	}
+	if replica {
+		if err := gitConfigSet(tmp, replicaConfigKey, "true"); err != nil {
+			return err
+		}
+	}
+
+	// Keep the times the repository was last fetched and changed, so that it
+	// is scheduled for an update as if it had not been restored.
+	if !md.LastChanged.IsZero() {
+		if err := os.Chtimes(tmp.Path("sg_refhash"), md.LastChanged, md.LastChanged); err != nil {
+			return errors.Wrap(err, "failed to set last changed time")
+		}
+	}
+	if !md.LastFetched.IsZero() {
+		if err := os.Chtimes(tmp.Path("HEAD"), md.LastFetched, md.LastFetched); err != nil {
+			return errors.Wrap(err, "failed to set last fetched time")
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(string(dir)), os.ModePerm); err != nil {
+		return err
+	}
+	if err := renameAndSync(tmpPath, string(dir)); err != nil {
+		return err
+	}
+	log15.Info("restored repository", "repo", repo)
+	return nil
+}
+
+// listBundles returns the names of the repositories with a bundle in
+// backupPath.
+func listBundles(backupPath string) ([]api.RepoName, error) {
+	var repos []api.RepoName
+	err := filepath.Walk(backupPath, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || !strings.HasSuffix(path, bundleExt) {
+			return nil
+		}
+		rel, err := filepath.Rel(backupPath, strings.TrimSuffix(path, bundleExt))
+		if err != nil {
+			return err
+		}
+		repos = append(repos, api.RepoName(filepath.ToSlash(rel)))
+		return nil
+	})
+	return repos, err
+}
+
+// withoutCredentials removes the user information, which may contain
+// credentials, from a remote URL. Repositories restored without credentials
+// get them from the next repository update.
+func withoutCredentials(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.User == nil {
+		return rawurl
+	}
+	u.User = nil
+	return u.String()
+}
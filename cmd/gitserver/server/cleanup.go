@@ -129,7 +129,7 @@ func (s *Server) cleanupRepos() {
 			return false, errors.Wrap(err, "failed to get remote URL")
 		}
 
-		if _, err := s.cloneRepo(ctx, repo, remoteURL, &cloneOptions{Block: true, Overwrite: true}); err != nil {
+		if _, err := s.cloneRepo(ctx, repo, remoteURL, &cloneOptions{Block: true, Overwrite: true, Replica: isReplica(dir), CloneOptions: repoCloneOptions(dir)}); err != nil {
 			return true, err
 		}
 		reposRecloned.Inc()
@@ -169,6 +169,30 @@ func (s *Server) cleanupRepos() {
 		return false, multi
 	}
 
+	computeRepoSize := func(dir GitDir) (done bool, err error) {
+		_, err = s.repoSize(dir)
+		return false, err
+	}
+
+	// primaries collects the repositories this gitserver is the primary for
+	// by replica address, but only when it is time to check that their
+	// replicas exist.
+	var primaries map[string][]api.RepoName
+	if time.Since(s.lastReplicaReconcile) > replicaReconcileInterval {
+		primaries = map[string][]api.RepoName{}
+		s.lastReplicaReconcile = time.Now()
+	}
+	reconcileReplica := func(dir GitDir) (done bool, err error) {
+		return s.reconcileReplica(dir, primaries)
+	}
+
+	// transfers collects the repositories that are assigned to another
+	// gitserver by the address of that gitserver.
+	transfers := map[api.RepoName]string{}
+	maybeTransfer := func(dir GitDir) (done bool, err error) {
+		return s.maybeTransfer(dir, transfers)
+	}
+
 	type cleanupFn struct {
 		Name string
 		Do   func(GitDir) (bool, error)
@@ -176,6 +200,12 @@ func (s *Server) cleanupRepos() {
 	cleanups := []cleanupFn{
 		// Do some sanity checks on the repository.
 		{"maybe remove corrupt", maybeRemoveCorrupt},
+		// Remove replicas this gitserver no longer needs to hold, and record
+		// the repositories whose replicas it is responsible for.
+		{"reconcile replica", reconcileReplica},
+		// Repositories that are assigned to another gitserver (because the
+		// list of gitservers changed) are moved there after the walk.
+		{"maybe transfer", maybeTransfer},
 		// If git is interrupted it can leave lock files lying around. It does
 		// not clean these up, and instead fails commands.
 		{"remove stale locks", removeStaleLocks},
@@ -187,6 +217,9 @@ func (s *Server) cleanupRepos() {
 		// these problems. git gc is slow and resource intensive. It is
 		// cheaper and faster to just reclone the repository.
 		{"maybe reclone", maybeReclone},
+		// Compute the sizes of the repositories that changed since the last
+		// run, so that requests for them don't have to.
+		{"compute repo size", computeRepoSize},
 	}
 
 	err := bestEffortWalk(s.ReposDir, func(dir string, fi os.FileInfo) error {
@@ -220,6 +253,9 @@ func (s *Server) cleanupRepos() {
 		log15.Error("cleanup: error iterating over repositories", "error", err)
 	}
 
+	s.ensureReplicas(primaries)
+	s.transferRepos(transfers)
+
 	if s.DiskSizer == nil {
 		s.DiskSizer = &StatDiskSizer{}
 	}
@@ -378,7 +414,7 @@ func (s *Server) freeUpSpace(howManyBytesToFree int64) error {
 		if spaceFreed >= howManyBytesToFree {
 			return nil
 		}
-		delta, err := dirSize(string(d))
+		delta, err := s.repoSize(d)
 		if err != nil {
 			return errors.Wrapf(err, "computing size of directory %s", d)
 		}
@@ -474,6 +510,7 @@ func (s *Server) removeRepoDirectory(gitDir GitDir) error {
 	if err := renameAndSync(dir, filepath.Join(tmp, "repo")); err != nil {
 		return err
 	}
+	s.invalidateRepoSize(gitDir)
 
 	// Everything after this point is just cleanup, so any error that occurs
 	// should not be returned, just logged.
@@ -2,20 +2,27 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"testing/quick"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 )
 
 const (
@@ -231,6 +238,130 @@ func TestCleanupOldLocks(t *testing.T) {
 	)
 }
 
+func TestCleanupReplicas(t *testing.T) {
+	root, cleanup := tmpDir(t)
+	defer cleanup()
+
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	client := &gitserver.Client{
+		Addrs: func(context.Context) []string {
+			return []string{"gitserver-0:3178", "gitserver-1:3178", "gitserver-2:3178"}
+		},
+		ReplicationFactor: func() int { return 2 },
+		HTTPClient: httpcli.DoerFunc(func(r *http.Request) (*http.Response, error) {
+			mu.Lock()
+			requested = append(requested, r.URL.Host+r.URL.Path)
+			mu.Unlock()
+			body := `{}`
+			if r.URL.Path == "/repos" {
+				body = `{"Results": {}}`
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+		}),
+	}
+
+	// Find repositories for which gitserver-0 is the primary, a replica and
+	// neither.
+	var primaries, replicas, others []api.RepoName
+	for i := 0; i < 100; i++ {
+		repo := api.RepoName(fmt.Sprintf("github.com/foo/repo%d", i))
+		switch addrs := client.AddrsForRepo(context.Background(), repo); {
+		case addrs[0] == "gitserver-0:3178":
+			primaries = append(primaries, repo)
+		case addrs[1] == "gitserver-0:3178":
+			replicas = append(replicas, repo)
+		default:
+			others = append(others, repo)
+		}
+	}
+	if len(primaries) < 2 || len(replicas) < 1 || len(others) < 2 {
+		t.Fatal("not enough repositories for each shard")
+	}
+	promoted, primary, replica, stale, wrongShard := primaries[0], primaries[1], replicas[0], others[0], others[1]
+
+	s := &Server{ReposDir: root, Hostname: "gitserver-0", peerClient: client}
+	s.Handler() // Handler as a side-effect sets up Server
+
+	for _, test := range []struct {
+		repo   api.RepoName
+		marked bool
+	}{
+		{repo: promoted, marked: true},
+		{repo: primary},
+		{repo: replica},
+		{repo: stale, marked: true},
+		{repo: wrongShard},
+	} {
+		dir := s.dir(test.repo)
+		if err := exec.Command("git", "--bare", "init", string(dir)).Run(); err != nil {
+			t.Fatal(err)
+		}
+		if err := gitConfigSet(dir, "remote.origin.url", "https://"+string(test.repo)); err != nil {
+			t.Fatal(err)
+		}
+		if test.marked {
+			if err := gitConfigSet(dir, replicaConfigKey, "true"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	s.cleanupRepos()
+
+	if isReplica(s.dir(promoted)) {
+		t.Error("expected replica to be promoted to primary")
+	}
+	if isReplica(s.dir(primary)) {
+		t.Error("expected primary to not be marked as replica")
+	}
+	if !isReplica(s.dir(replica)) {
+		t.Error("expected repo to be marked as replica")
+	}
+	if _, err := os.Stat(string(s.dir(stale))); !os.IsNotExist(err) {
+		t.Error("expected stale replica to be removed")
+	}
+	if _, err := os.Stat(string(s.dir(wrongShard))); err != nil {
+		t.Error("expected repo that is not a replica to not be removed")
+	}
+
+	// The primaries' replicas don't exist, so they are requested to be cloned.
+	for i := 0; i < 100 && atomic.LoadInt32(&s.ensuringReplicas) == 1; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var updates int
+	for _, r := range requested {
+		if strings.HasSuffix(r, "/repo-update") {
+			updates++
+		}
+	}
+	if want := 2; updates != want {
+		t.Errorf("got %d replica updates, want %d (requests %v)", updates, want, requested)
+	}
+}
+
+func TestHostnameMatch(t *testing.T) {
+	tests := []struct {
+		hostname, addr string
+		want           bool
+	}{
+		{"gitserver-0", "gitserver-0", true},
+		{"gitserver-0", "gitserver-0:3178", true},
+		{"gitserver-0", "gitserver-0.gitserver:3178", true},
+		{"gitserver-0", "gitserver-01:3178", false},
+		{"gitserver-0", "gitserver-1:3178", false},
+	}
+	for _, test := range tests {
+		if got := hostnameMatch(test.hostname, test.addr); got != test.want {
+			t.Errorf("hostnameMatch(%q, %q) = %v, want %v", test.hostname, test.addr, got, test.want)
+		}
+	}
+}
+
 func TestSetupAndClearTmp(t *testing.T) {
 	root, cleanup := tmpDir(t)
 	defer cleanup()
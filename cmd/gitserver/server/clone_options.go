@@ -0,0 +1,133 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+// The options a repository was cloned with are recorded in its git config,
+// so that fetches keep shallow clones shallow, and so that reclones, replicas
+// and transfers use the same options.
+const (
+	partialCloneConfigKey = "sourcegraph.partialClone"
+	cloneDepthConfigKey   = "sourcegraph.cloneDepth"
+)
+
+// fetchArgs returns the arguments to git fetch that keep a clone with opts
+// shallow. Fetches of partial clones don't need a filter: only the blobs of
+// new commits are fetched.
+func fetchArgs(opts protocol.CloneOptions) []string {
+	if opts.Depth > 0 {
+		return []string{"--depth=" + strconv.Itoa(opts.Depth)}
+	}
+	return nil
+}
+
+// cloneArgs returns the arguments to git clone for a clone with opts.
+func cloneArgs(opts protocol.CloneOptions) []string {
+	var args []string
+	if opts.Partial {
+		args = append(args, "--filter=blob:none")
+	}
+	if opts.Depth > 0 {
+		// --depth implies --single-branch, but we want all branches.
+		args = append(args, "--depth="+strconv.Itoa(opts.Depth), "--no-single-branch")
+	}
+	return args
+}
+
+// setCloneOptions records the options the repository in dir was cloned with.
+func setCloneOptions(dir GitDir, opts protocol.CloneOptions) error {
+	if opts.Partial {
+		if err := gitConfigSet(dir, partialCloneConfigKey, "true"); err != nil {
+			return err
+		}
+	}
+	if opts.Depth > 0 {
+		if err := gitConfigSet(dir, cloneDepthConfigKey, strconv.Itoa(opts.Depth)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateCloneOptions records opts as the options of the repository in dir,
+// if they changed. Fetches use a changed depth right away, so a shallow clone
+// whose depth was removed is deepened to the full history by its next fetch.
+// Other changes apply once the repository is recloned.
+func updateCloneOptions(dir GitDir, opts protocol.CloneOptions) error {
+	current := repoCloneOptions(dir)
+	if current == opts {
+		return nil
+	}
+	if current.Partial && !opts.Partial {
+		if err := gitConfigUnset(dir, partialCloneConfigKey); err != nil {
+			return err
+		}
+	}
+	if current.Depth > 0 && opts.Depth == 0 {
+		if err := gitConfigUnset(dir, cloneDepthConfigKey); err != nil {
+			return err
+		}
+	}
+	return setCloneOptions(dir, opts)
+}
+
+// repoCloneOptions returns the options the repository in dir was cloned
+// with. It is the zero value for a full clone.
+func repoCloneOptions(dir GitDir) protocol.CloneOptions {
+	var opts protocol.CloneOptions
+	if v, _ := gitConfigGet(dir, partialCloneConfigKey); strings.TrimSpace(v) == "true" {
+		opts.Partial = true
+	}
+	if v, _ := gitConfigGet(dir, cloneDepthConfigKey); v != "" {
+		opts.Depth, _ = strconv.Atoi(strings.TrimSpace(v))
+	}
+	return opts
+}
+
+// isShallow reports whether the repository in dir is a shallow clone.
+func isShallow(dir GitDir) bool {
+	_, err := os.Stat(dir.Path("shallow"))
+	return err == nil
+}
+
+// shallowCloneError returns an error if the git command args needs the full
+// history of the repository in dir, and the repository is a shallow clone.
+// Otherwise the command would silently give wrong results: blame attributes
+// lines to the oldest commit of the clone, and commit and diff searches (which
+// are the only users of git log --source, -S and -G) miss older commits.
+func shallowCloneError(dir GitDir, args []string) error {
+	if len(args) == 0 || !isShallow(dir) {
+		return nil
+	}
+
+	var feature string
+	switch args[0] {
+	case "blame":
+		feature = "blame"
+	case "log":
+		for _, arg := range args[1:] {
+			if arg == "--" {
+				break
+			}
+			if arg == "--source" || strings.HasPrefix(arg, "-S") || strings.HasPrefix(arg, "-G") {
+				feature = "commit and diff search"
+				break
+			}
+		}
+	}
+	if feature == "" {
+		return nil
+	}
+
+	clone := "a shallow clone"
+	if opts := repoCloneOptions(dir); opts.Depth > 0 {
+		clone = fmt.Sprintf("a shallow clone of depth %d", opts.Depth)
+	}
+	return fmt.Errorf("%s needs the full history of the repository, but it is %s (remove the depth from the cloneOptions of its external service to fetch the full history)", feature, clone)
+}
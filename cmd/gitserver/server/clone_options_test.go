@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+func TestCloneOptions(t *testing.T) {
+	remote, cleanup1 := tmpDir(t)
+	defer cleanup1()
+	git := func(dir string, arg ...string) string {
+		t.Helper()
+		c := exec.Command("git", arg...)
+		c.Dir = dir
+		c.Env = append(os.Environ(), "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@a.com", "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@a.com")
+		b, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s failed: %s: %s", strings.Join(arg, " "), err, b)
+		}
+		return strings.TrimSpace(string(b))
+	}
+	commit := func(msg string) {
+		t.Helper()
+		git(remote, "commit", "--allow-empty", "-m", msg)
+	}
+	git(remote, "init", ".")
+	git(remote, "config", "uploadpack.allowFilter", "true")
+	if err := ioutil.WriteFile(filepath.Join(remote, "README"), []byte("hello\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	git(remote, "add", "README")
+	commit("a")
+	commit("b")
+	commit("c")
+	// Local paths are cloned without the Git protocol, which ignores depth
+	// and filters.
+	remoteURL := "file://" + remote
+
+	reposDir, cleanup2 := tmpDir(t)
+	defer cleanup2()
+	s := &Server{ReposDir: reposDir}
+	_ = s.Handler()
+
+	t.Run("partial", func(t *testing.T) {
+		repo := api.RepoName("example.com/partial")
+		opts := protocol.CloneOptions{Partial: true}
+		if _, err := s.cloneRepo(context.Background(), repo, remoteURL, &cloneOptions{Block: true, CloneOptions: opts}); err != nil {
+			t.Fatal(err)
+		}
+		dir := s.dir(repo)
+		if got := repoCloneOptions(dir); got != opts {
+			t.Errorf("got clone options %+v, want %+v", got, opts)
+		}
+		if got := git(string(dir), "config", "remote.origin.partialclonefilter"); got != "blob:none" {
+			t.Errorf("got partial clone filter %q, want blob:none", got)
+		}
+		// Blobs are fetched on demand.
+		if got := git(string(dir), "cat-file", "-p", "HEAD:README"); got != "hello" {
+			t.Errorf("got README %q, want hello", got)
+		}
+	})
+
+	t.Run("shallow", func(t *testing.T) {
+		repo := api.RepoName("example.com/shallow")
+		opts := protocol.CloneOptions{Depth: 1}
+		if _, err := s.cloneRepo(context.Background(), repo, remoteURL, &cloneOptions{Block: true, CloneOptions: opts}); err != nil {
+			t.Fatal(err)
+		}
+		dir := s.dir(repo)
+		if got := repoCloneOptions(dir); got != opts {
+			t.Errorf("got clone options %+v, want %+v", got, opts)
+		}
+		if got := git(string(dir), "rev-list", "--count", "HEAD"); got != "1" {
+			t.Errorf("got %s commits, want 1", got)
+		}
+
+		for args, wantErr := range map[string]bool{
+			"blame HEAD -- README":                 true,
+			"log --source --no-patch HEAD":         true,
+			"log -Ghello HEAD":                     true,
+			"log --format=%H -n1 HEAD -- --source": false,
+			"rev-parse HEAD":                       false,
+		} {
+			if err := shallowCloneError(dir, strings.Fields(args)); (err != nil) != wantErr {
+				t.Errorf("shallowCloneError(%q) = %v, want error %v", args, err, wantErr)
+			}
+		}
+
+		// Fetches keep the clone shallow.
+		commit("d")
+		if err := s.doRepoUpdate2(repo, remoteURL); err != nil {
+			t.Fatal(err)
+		}
+		if got := git(string(dir), "rev-list", "--count", "HEAD"); got != "1" {
+			t.Errorf("got %s commits after fetch, want 1", got)
+		}
+
+		// Updates from senders that don't know the clone options, such as
+		// RequestRepoUpdate, keep the clone shallow.
+		srv := httptest.NewServer(s.Handler())
+		defer srv.Close()
+		client := &gitserver.Client{
+			HTTPClient: http.DefaultClient,
+			Addrs:      func(context.Context) []string { return []string{strings.TrimPrefix(srv.URL, "http://")} },
+		}
+		commit("e")
+		resp, err := client.RequestRepoUpdate(context.Background(), gitserver.Repo{Name: repo, URL: remoteURL}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error != "" {
+			t.Fatal(resp.Error)
+		}
+		if got := repoCloneOptions(dir); got != opts {
+			t.Errorf("got clone options %+v after RequestRepoUpdate, want %+v", got, opts)
+		}
+		if !isShallow(dir) {
+			t.Error("expected clone to stay shallow after RequestRepoUpdate")
+		}
+		if got := git(string(dir), "rev-list", "--count", "HEAD"); got != "1" {
+			t.Errorf("got %s commits after RequestRepoUpdate, want 1", got)
+		}
+
+		// Removing the depth deepens the clone to the full history.
+		if err := updateCloneOptions(dir, protocol.CloneOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.doRepoUpdate2(repo, remoteURL); err != nil {
+			t.Fatal(err)
+		}
+		if isShallow(dir) {
+			t.Error("expected clone not to be shallow")
+		}
+		if got := git(string(dir), "rev-list", "--count", "HEAD"); got != "5" {
+			t.Errorf("got %s commits after removing depth, want 5", got)
+		}
+		if err := shallowCloneError(dir, []string{"blame", "HEAD", "--", "README"}); err != nil {
+			t.Error(err)
+		}
+	})
+}
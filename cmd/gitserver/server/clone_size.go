@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+// cloneSizeLimitsFile is the name of the file under ReposDir in which the
+// maximum clone sizes of repositories are recorded.
+const cloneSizeLimitsFile = ".clone-size-limits.json"
+
+// cloneSizeCheckInterval is how often the size of a clone in progress is
+// checked against its maximum size.
+var cloneSizeCheckInterval = 5 * time.Second
+
+// cloneTooLargeError is returned when a clone exceeds its maximum size.
+type cloneTooLargeError struct {
+	maxSize int64
+}
+
+func (e *cloneTooLargeError) Error() string {
+	return fmt.Sprintf("clone aborted: the repository exceeds the maximum clone size of %d bytes", e.maxSize)
+}
+
+// watchCloneSize periodically checks the size of the clone in dir. The
+// returned context is canceled as soon as the clone exceeds maxSize bytes.
+//
+// The returned stop function must be called when the clone has finished. It
+// checks the final size of the clone and returns a *cloneTooLargeError if it
+// exceeded maxSize. It is safe to call stop multiple times.
+//
+// If maxSize is not positive, the size of the clone is not checked.
+func watchCloneSize(ctx context.Context, dir string, maxSize int64) (context.Context, func() error) {
+	if maxSize <= 0 {
+		return ctx, func() error { return nil }
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	var (
+		mu       sync.Mutex
+		exceeded bool
+	)
+	check := func() bool {
+		size, err := dirSize(dir)
+		if err != nil || size <= maxSize {
+			return false
+		}
+		mu.Lock()
+		exceeded = true
+		mu.Unlock()
+		cancel()
+		return true
+	}
+
+	go func() {
+		defer close(exited)
+		t := time.NewTicker(cloneSizeCheckInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if check() {
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() error {
+		once.Do(func() {
+			close(done)
+			<-exited
+			check()
+			cancel()
+		})
+
+		mu.Lock()
+		defer mu.Unlock()
+		if exceeded {
+			return &cloneTooLargeError{maxSize: maxSize}
+		}
+		return nil
+	}
+}
+
+// cloneSizeLimit returns the maximum clone size in bytes recorded for repo,
+// or 0 if there is none. It applies to all clones of repo that don't
+// specify a maximum size, e.g. on-demand clones and reclones.
+func (s *Server) cloneSizeLimit(repo api.RepoName) int64 {
+	s.cloneSizeLimitsMu.Lock()
+	defer s.cloneSizeLimitsMu.Unlock()
+	if err := s.loadCloneSizeLimitsLocked(); err != nil {
+		log15.Warn("failed to load clone size limits", "error", err)
+	}
+	return s.cloneSizeLimits[protocol.NormalizeRepo(repo)]
+}
+
+// recordCloneSizeLimit records maxSize as the maximum clone size of repo,
+// and logs a warning if it fails. A maxSize of 0 removes the limit.
+func (s *Server) recordCloneSizeLimit(repo api.RepoName, maxSize int64) {
+	if err := s.setCloneSizeLimit(repo, maxSize); err != nil {
+		log15.Warn("failed to record clone size limit", "repo", repo, "error", err)
+	}
+}
+
+// setCloneSizeLimit records maxSize as the maximum clone size of repo. A
+// maxSize of 0 removes the limit. The limits are persisted in
+// cloneSizeLimitsFile, so they survive restarts of gitserver.
+func (s *Server) setCloneSizeLimit(repo api.RepoName, maxSize int64) error {
+	repo = protocol.NormalizeRepo(repo)
+
+	s.cloneSizeLimitsMu.Lock()
+	defer s.cloneSizeLimitsMu.Unlock()
+	if err := s.loadCloneSizeLimitsLocked(); err != nil {
+		return err
+	}
+	if s.cloneSizeLimits[repo] == maxSize {
+		return nil
+	}
+	if maxSize == 0 {
+		delete(s.cloneSizeLimits, repo)
+	} else {
+		s.cloneSizeLimits[repo] = maxSize
+	}
+
+	data, err := json.Marshal(s.cloneSizeLimits)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(s.ReposDir, cloneSizeLimitsFile+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "writing clone size limits")
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return errors.Wrap(err, "writing clone size limits")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "writing clone size limits")
+	}
+	return errors.Wrap(os.Rename(f.Name(), filepath.Join(s.ReposDir, cloneSizeLimitsFile)), "writing clone size limits")
+}
+
+// loadCloneSizeLimitsLocked reads cloneSizeLimitsFile, unless it was read
+// already. The caller must hold s.cloneSizeLimitsMu.
+func (s *Server) loadCloneSizeLimitsLocked() error {
+	if s.cloneSizeLimits != nil {
+		return nil
+	}
+	s.cloneSizeLimits = make(map[api.RepoName]int64)
+	data, err := ioutil.ReadFile(filepath.Join(s.ReposDir, cloneSizeLimitsFile))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "reading clone size limits")
+	}
+	return errors.Wrap(json.Unmarshal(data, &s.cloneSizeLimits), "reading clone size limits")
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchCloneSize(t *testing.T) {
+	defer func(d time.Duration) { cloneSizeCheckInterval = d }(cloneSizeCheckInterval)
+	cloneSizeCheckInterval = time.Millisecond
+
+	dir, cleanup := tmpDir(t)
+	defer cleanup()
+
+	t.Run("within limit", func(t *testing.T) {
+		ctx, stop := watchCloneSize(context.Background(), dir, 1024)
+		if err := ioutil.WriteFile(filepath.Join(dir, "small"), make([]byte, 10), 0600); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if err := ctx.Err(); err != nil {
+			t.Fatalf("unexpected context error: %v", err)
+		}
+		if err := stop(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("exceeded", func(t *testing.T) {
+		ctx, stop := watchCloneSize(context.Background(), dir, 1024)
+		if err := ioutil.WriteFile(filepath.Join(dir, "large"), make([]byte, 2048), 0600); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected context to be canceled")
+		}
+		for i := 0; i < 2; i++ {
+			if _, ok := stop().(*cloneTooLargeError); !ok {
+				t.Fatal("expected cloneTooLargeError")
+			}
+		}
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		ctx, stop := watchCloneSize(context.Background(), dir, 0)
+		if ctx != context.Background() {
+			t.Fatal("expected unchanged context")
+		}
+		if err := stop(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCloneSizeLimits(t *testing.T) {
+	root, cleanup := tmpDir(t)
+	defer cleanup()
+
+	s := &Server{ReposDir: root}
+	if got := s.cloneSizeLimit("github.com/foo/bar"); got != 0 {
+		t.Fatalf("got limit %d, want 0", got)
+	}
+	if err := s.setCloneSizeLimit("github.com/foo/bar", 1024); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.setCloneSizeLimit("github.com/foo/baz", 2048); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.setCloneSizeLimit("github.com/foo/baz", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// The limits survive restarts.
+	s = &Server{ReposDir: root}
+	if got := s.cloneSizeLimit("GitHub.com/foo/bar.git"); got != 1024 {
+		t.Fatalf("got limit %d, want 1024", got)
+	}
+	if got := s.cloneSizeLimit("github.com/foo/baz"); got != 0 {
+		t.Fatalf("got limit %d, want 0", got)
+	}
+}
+
+func TestRepoSize(t *testing.T) {
+	root, cleanup := tmpDir(t)
+	defer cleanup()
+	dir := GitDir(filepath.Join(root, ".git"))
+	mkFiles(t, string(dir), "HEAD")
+	if err := ioutil.WriteFile(dir.Path("HEAD"), make([]byte, 10), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{ReposDir: root}
+	if size, err := s.repoSize(dir); err != nil || size != 10 {
+		t.Fatalf("got size %d (error %v), want 10", size, err)
+	}
+
+	// The size is cached until it is invalidated.
+	if err := ioutil.WriteFile(dir.Path("packed-refs"), make([]byte, 20), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if size, _ := s.repoSize(dir); size != 10 {
+		t.Fatalf("got size %d, want cached size 10", size)
+	}
+	s.invalidateRepoSize(dir)
+	if size, _ := s.repoSize(dir); size != 30 {
+		t.Fatalf("got size %d, want 30", size)
+	}
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/ssh"
+)
+
+// If the gitCommitSigning site configuration is set, the commits created by
+// createCommitFromPatch are signed with its private key, so that code hosts
+// that require signed commits on protected branches accept them.
+//
+// The private key is stored in the site configuration encrypted with a
+// passphrase, which only gitserver knows (see Server.CommitSigningPassphrase).
+// Commits are signed in-process, like git does with gpg or ssh-keygen: the
+// armored signature of the raw commit object is added to it as its gpgsig
+// header. This way gitserver needs neither a GPG keyring nor an SSH agent.
+
+// errUnencryptedSigningKey is returned for private keys that are not protected
+// by a passphrase, which we refuse to use so that they are never stored in the
+// site configuration in plain text.
+var errUnencryptedSigningKey = errors.New("the commit signing key must be encrypted with a passphrase")
+
+// A commitSigner signs the raw contents of commit objects.
+type commitSigner interface {
+	// sign returns the ASCII-armored signature of payload.
+	sign(payload []byte) (string, error)
+}
+
+// getCommitSigner returns the gitCommitSigning site configuration and its
+// commitSigner, or nil if commits are not signed. The decrypted key is cached
+// until the configuration changes.
+func (s *Server) getCommitSigner() (*schema.GitCommitSigning, commitSigner, error) {
+	c := conf.Get().GitCommitSigning
+	if c == nil {
+		return nil, nil, nil
+	}
+
+	s.commitSignerMu.Lock()
+	defer s.commitSignerMu.Unlock()
+
+	if s.commitSigner != nil && s.commitSignerConfig == *c {
+		return c, s.commitSigner, nil
+	}
+
+	signer, err := newCommitSigner(c, s.CommitSigningPassphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.commitSignerConfig, s.commitSigner = *c, signer
+	return c, signer, nil
+}
+
+func newCommitSigner(c *schema.GitCommitSigning, passphrase string) (commitSigner, error) {
+	if passphrase == "" {
+		return nil, errors.New("SRC_GIT_COMMIT_SIGNING_PASSPHRASE is not set")
+	}
+
+	switch c.Format {
+	case "openpgp":
+		return newOpenPGPSigner(c.PrivateKey, passphrase)
+	case "ssh":
+		return newSSHSigner(c.PrivateKey, passphrase)
+	default:
+		return nil, errors.Errorf("unknown commit signing key format %q", c.Format)
+	}
+}
+
+type openPGPSigner struct {
+	entity *openpgp.Entity
+}
+
+func newOpenPGPSigner(key, passphrase string) (*openPGPSigner, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading OpenPGP private key")
+	}
+	if len(entities) != 1 {
+		return nil, errors.Errorf("expected exactly one OpenPGP key, got %d", len(entities))
+	}
+
+	e := entities[0]
+	if e.PrivateKey == nil {
+		return nil, errors.New("OpenPGP key has no private key")
+	}
+	if !e.PrivateKey.Encrypted {
+		return nil, errUnencryptedSigningKey
+	}
+	if err := e.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+		return nil, errors.Wrap(err, "decrypting OpenPGP private key")
+	}
+	// Signing subkeys take precedence over the primary key.
+	for _, sk := range e.Subkeys {
+		if sk.PrivateKey != nil && sk.PrivateKey.Encrypted {
+			if err := sk.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+				return nil, errors.Wrap(err, "decrypting OpenPGP private subkey")
+			}
+		}
+	}
+
+	return &openPGPSigner{entity: e}, nil
+}
+
+func (s *openPGPSigner) sign(payload []byte) (string, error) {
+	var buf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&buf, s.entity, bytes.NewReader(payload), nil); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// sshSigner creates signatures in the format of `ssh-keygen -Y sign`, which
+// git uses for commits if gpg.format is "ssh". See
+// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig.
+type sshSigner struct {
+	signer ssh.Signer
+}
+
+func newSSHSigner(key, passphrase string) (*sshSigner, error) {
+	_, err := ssh.ParseRawPrivateKey([]byte(key))
+	if err == nil {
+		return nil, errUnencryptedSigningKey
+	}
+	if _, ok := err.(*ssh.PassphraseMissingError); !ok {
+		return nil, errors.Wrap(err, "reading SSH private key")
+	}
+
+	signer, err := ssh.ParsePrivateKeyWithPassphrase([]byte(key), []byte(passphrase))
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting SSH private key")
+	}
+	return &sshSigner{signer: signer}, nil
+}
+
+const (
+	sshSigMagic     = "SSHSIG"
+	sshSigNamespace = "git"
+	sshSigHash      = "sha512"
+)
+
+func (s *sshSigner) sign(payload []byte) (string, error) {
+	h := sha512.Sum512(payload)
+	signed := append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{sshSigNamespace, "", sshSigHash, h[:]})...)
+
+	var (
+		sig *ssh.Signature
+		err error
+	)
+	// ssh-keygen rejects RSA signatures with SHA-1, the default of Sign.
+	if as, ok := s.signer.(ssh.AlgorithmSigner); ok && s.signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = as.SignWithAlgorithm(nil, signed, ssh.SigAlgoRSASHA2512)
+	} else {
+		sig, err = s.signer.Sign(nil, signed)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	blob := append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}{1, s.signer.PublicKey().Marshal(), sshSigNamespace, "", sshSigHash, ssh.Marshal(sig)})...)
+
+	var b strings.Builder
+	b.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	enc := base64.StdEncoding.EncodeToString(blob)
+	for len(enc) > 70 {
+		b.WriteString(enc[:70] + "\n")
+		enc = enc[70:]
+	}
+	b.WriteString(enc + "\n")
+	b.WriteString("-----END SSH SIGNATURE-----\n")
+	return b.String(), nil
+}
+
+// signCommit writes a copy of the HEAD commit of the repository in dir that
+// is signed by signer to the repository's objects and returns its ID. env
+// holds the environment of the git commands, such as GIT_DIR. HEAD is not
+// updated.
+func signCommit(ctx context.Context, signer commitSigner, dir string, env []string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "commit", "HEAD")
+	cmd.Dir, cmd.Env = dir, env
+	commit, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrap(err, "reading commit")
+	}
+
+	sig, err := signer.sign(commit)
+	if err != nil {
+		return "", errors.Wrap(err, "signing commit")
+	}
+
+	signed, err := addCommitSignature(commit, sig)
+	if err != nil {
+		return "", err
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "hash-object", "-t", "commit", "-w", "--stdin")
+	cmd.Dir, cmd.Env = dir, env
+	cmd.Stdin = bytes.NewReader(signed)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrap(err, "writing signed commit")
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// addCommitSignature adds sig as the gpgsig header to the raw commit object.
+// Header values that span multiple lines have their continuation lines
+// indented by a single space.
+func addCommitSignature(commit []byte, sig string) ([]byte, error) {
+	i := bytes.Index(commit, []byte("\n\n"))
+	if i < 0 {
+		return nil, errors.New("malformed commit object: no end of headers")
+	}
+
+	var b bytes.Buffer
+	b.Write(commit[:i+1])
+	b.WriteString("gpgsig ")
+	b.WriteString(strings.Replace(strings.TrimSuffix(sig, "\n"), "\n", "\n ", -1))
+	b.WriteString("\n")
+	b.Write(commit[i+1:])
+	return b.Bytes(), nil
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+const testSigningPassphrase = "hunter2"
+
+func TestCreateCommitFromPatch_signing(t *testing.T) {
+	remote, cleanup1 := tmpDir(t)
+	defer cleanup1()
+
+	run := func(t *testing.T, dir string, env []string, name string, arg ...string) string {
+		t.Helper()
+		c := exec.Command(name, arg...)
+		c.Dir = dir
+		c.Env = append([]string{
+			"GIT_COMMITTER_NAME=a",
+			"GIT_COMMITTER_EMAIL=a@a.com",
+			"GIT_AUTHOR_NAME=a",
+			"GIT_AUTHOR_EMAIL=a@a.com",
+			"PATH=" + os.Getenv("PATH"),
+		}, env...)
+		b, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s %s failed: %s\n%s", name, strings.Join(arg, " "), err, b)
+		}
+		return string(b)
+	}
+
+	run(t, remote, nil, "git", "init", ".")
+	run(t, remote, nil, "sh", "-c", "echo hello world > hello.txt")
+	run(t, remote, nil, "git", "add", "hello.txt")
+	run(t, remote, nil, "git", "commit", "-m", "hello")
+	base := strings.TrimSpace(run(t, remote, nil, "git", "rev-parse", "HEAD"))
+
+	reposDir, cleanup2 := tmpDir(t)
+	defer cleanup2()
+	s := &Server{ReposDir: reposDir, CommitSigningPassphrase: testSigningPassphrase}
+	s.Handler()
+	repo := api.RepoName("example.com/foo/bar")
+	if _, err := s.cloneRepo(context.Background(), repo, remote, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Dir(string(s.dir(repo)))
+
+	keysDir, cleanup3 := tmpDir(t)
+	defer cleanup3()
+
+	createCommit := func(t *testing.T, signing *schema.GitCommitSigning) string {
+		t.Helper()
+		conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+			ExperimentalFeatures: &schema.ExperimentalFeatures{},
+			GitCommitSigning:     signing,
+		}})
+		defer conf.Mock(nil)
+
+		status, resp := s.createCommitFromPatch(context.Background(), protocol.CreateCommitFromPatchRequest{
+			Repo:       repo,
+			BaseCommit: api.CommitID(base),
+			TargetRef:  "refs/heads/signed",
+			Patch:      "--- hello.txt\n+++ hello.txt\n@@ -1 +1 @@\n-hello world\n+hello signed world\n",
+			CommitInfo: protocol.PatchCommitInfo{
+				Message:     "Sign me",
+				AuthorName:  "Sourcegraph Bot",
+				AuthorEmail: "campaigns@sourcegraph.com",
+				Date:        time.Now(),
+			},
+			GitApplyArgs: []string{"-p0"},
+		})
+		if status != http.StatusOK {
+			t.Fatalf("creating commit failed: %+v", resp.Error)
+		}
+		return resp.Rev
+	}
+
+	t.Run("unsigned", func(t *testing.T) {
+		rev := createCommit(t, nil)
+		if out := run(t, dir, nil, "git", "cat-file", "commit", rev); strings.Contains(out, "gpgsig") {
+			t.Fatalf("commit is signed although signing is disabled:\n%s", out)
+		}
+	})
+
+	t.Run("openpgp", func(t *testing.T) {
+		if _, err := exec.LookPath("gpg"); err != nil {
+			t.Skip("gpg not found")
+		}
+
+		gnupgHome := filepath.Join(keysDir, "gnupg")
+		if err := os.Mkdir(gnupgHome, 0700); err != nil {
+			t.Fatal(err)
+		}
+		env := []string{"GNUPGHOME=" + gnupgHome}
+		gpg := func(arg ...string) string {
+			t.Helper()
+			return run(t, gnupgHome, env, "gpg", append([]string{"--batch", "--pinentry-mode", "loopback", "--passphrase", testSigningPassphrase}, arg...)...)
+		}
+		gpg("--quick-generate-key", "Sourcegraph Bot <bot@example.com>", "rsa2048", "sign", "never")
+
+		rev := createCommit(t, &schema.GitCommitSigning{
+			Format:         "openpgp",
+			PrivateKey:     gpg("--armor", "--export-secret-keys", "bot@example.com"),
+			CommitterName:  "Sourcegraph Bot",
+			CommitterEmail: "bot@example.com",
+		})
+
+		out := run(t, dir, env, "git", "verify-commit", "--raw", rev)
+		if !strings.Contains(out, "GOODSIG") {
+			t.Fatalf("no good signature:\n%s", out)
+		}
+		if out := run(t, dir, nil, "git", "log", "-1", "--format=%cn <%ce>", rev); strings.TrimSpace(out) != "Sourcegraph Bot <bot@example.com>" {
+			t.Errorf("wrong committer: %s", out)
+		}
+	})
+
+	for _, keyType := range []string{"ed25519", "rsa"} {
+		t.Run("ssh "+keyType, func(t *testing.T) {
+			if _, err := exec.LookPath("ssh-keygen"); err != nil {
+				t.Skip("ssh-keygen not found")
+			}
+
+			keyFile := filepath.Join(keysDir, "id_"+keyType)
+			run(t, keysDir, nil, "ssh-keygen", "-q", "-t", keyType, "-N", testSigningPassphrase, "-C", "bot@example.com", "-f", keyFile)
+
+			key, err := ioutil.ReadFile(keyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub, err := ioutil.ReadFile(keyFile + ".pub")
+			if err != nil {
+				t.Fatal(err)
+			}
+			allowedSigners := filepath.Join(keysDir, "allowed_signers_"+keyType)
+			writeFile(t, allowedSigners, []byte("bot@example.com "+string(pub)))
+
+			rev := createCommit(t, &schema.GitCommitSigning{
+				Format:         "ssh",
+				PrivateKey:     string(key),
+				CommitterEmail: "bot@example.com",
+			})
+
+			out := run(t, dir, nil, "git", "-c", "gpg.ssh.allowedSignersFile="+allowedSigners, "verify-commit", rev)
+			if !strings.Contains(out, `Good "git" signature for bot@example.com`) {
+				t.Fatalf("no good signature:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestNewCommitSigner(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not found")
+	}
+
+	dir, cleanup := tmpDir(t)
+	defer cleanup()
+
+	genKey := func(name, passphrase string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", passphrase, "-f", path).CombinedOutput(); err != nil {
+			t.Fatalf("ssh-keygen failed: %s\n%s", err, out)
+		}
+		key, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(key)
+	}
+	encrypted := genKey("encrypted", testSigningPassphrase)
+	unencrypted := genKey("unencrypted", "")
+
+	for _, tc := range []struct {
+		name       string
+		config     schema.GitCommitSigning
+		passphrase string
+		wantErr    string
+	}{
+		{"valid", schema.GitCommitSigning{Format: "ssh", PrivateKey: encrypted}, testSigningPassphrase, ""},
+		{"unencrypted key", schema.GitCommitSigning{Format: "ssh", PrivateKey: unencrypted}, testSigningPassphrase, errUnencryptedSigningKey.Error()},
+		{"wrong passphrase", schema.GitCommitSigning{Format: "ssh", PrivateKey: encrypted}, "wrong", "decrypting SSH private key"},
+		{"no passphrase", schema.GitCommitSigning{Format: "ssh", PrivateKey: encrypted}, "", "SRC_GIT_COMMIT_SIGNING_PASSPHRASE is not set"},
+		{"unknown format", schema.GitCommitSigning{Format: "x509", PrivateKey: encrypted}, testSigningPassphrase, `unknown commit signing key format "x509"`},
+		{"invalid key", schema.GitCommitSigning{Format: "openpgp", PrivateKey: encrypted}, testSigningPassphrase, "reading OpenPGP private key"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newCommitSigner(&tc.config, tc.passphrase)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("wrong error. have=%v, want=%q", err, tc.wantErr)
+			}
+		})
+	}
+}
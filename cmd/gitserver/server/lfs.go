@@ -0,0 +1,536 @@
+package server
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/lfs"
+)
+
+// Repositories that use Git LFS contain pointer files instead of the contents
+// of LFS-tracked files. If the gitLFS site configuration is enabled, the LFS
+// objects of the pointer files in a repository's default branch are fetched
+// after the repository is cloned or updated, and stored in the standard
+// location ($GIT_DIR/lfs/objects). Tar archives (which searcher and symbols
+// use) contain the contents of the LFS objects in place of the pointer files.
+
+const (
+	defaultLFSMaxFileSizeMB = 10
+	defaultLFSMaxRepoSizeMB = 500
+
+	// lfsBatchSize is the number of objects requested from the LFS batch API
+	// at once.
+	lfsBatchSize = 100
+)
+
+var lfsObjectsFetched = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "src_gitserver_lfs_objects_fetched",
+	Help: "number of Git LFS objects fetched from LFS servers",
+})
+
+func init() {
+	prometheus.MustRegister(lfsObjectsFetched)
+}
+
+// lfsDoer is the HTTP client used to fetch LFS objects. It respects the
+// tls.external site configuration.
+var lfsDoer = func() httpcli.Doer {
+	doer, err := httpcli.NewFactory(httpcli.NewMiddleware(httpcli.ContextErrorMiddleware), httpcli.ExternalTransportOpt).Doer()
+	if err != nil {
+		log15.Error("failed to create HTTP client for Git LFS, using the default client", "error", err)
+		return http.DefaultClient
+	}
+	return doer
+}()
+
+// lfsLimits returns whether fetching LFS objects is enabled, and the maximum
+// sizes in bytes of an object and of the objects of a repository.
+func lfsLimits() (enabled bool, maxFileSize, maxRepoSize int64) {
+	c := conf.Get().GitLFS
+	if c == nil || !c.Enabled {
+		return false, 0, 0
+	}
+	maxFileSizeMB, maxRepoSizeMB := c.MaxFileSizeMB, c.MaxRepoSizeMB
+	if maxFileSizeMB <= 0 {
+		maxFileSizeMB = defaultLFSMaxFileSizeMB
+	}
+	if maxRepoSizeMB <= 0 {
+		maxRepoSizeMB = defaultLFSMaxRepoSizeMB
+	}
+	return true, int64(maxFileSizeMB) << 20, int64(maxRepoSizeMB) << 20
+}
+
+func lfsObjectsDir(dir GitDir) string {
+	return dir.Path("lfs", "objects")
+}
+
+func lfsObjectPath(dir GitDir, oid string) string {
+	return filepath.Join(lfsObjectsDir(dir), oid[0:2], oid[2:4], oid)
+}
+
+// hasLFSObject returns true if the object of p is stored in dir.
+func hasLFSObject(dir GitDir, p *lfs.Pointer) bool {
+	fi, err := os.Stat(lfsObjectPath(dir, p.OID))
+	return err == nil && fi.Mode().IsRegular() && fi.Size() == p.Size
+}
+
+// fetchLFSObjects fetches the LFS objects of the pointer files in the default
+// branch of the repository in dir from the LFS server of remoteURL, within the
+// configured size limits. Objects that are no longer needed are removed. If
+// fetching LFS objects is disabled, all objects are removed.
+func fetchLFSObjects(ctx context.Context, dir GitDir, remoteURL string) error {
+	enabled, maxFileSize, maxRepoSize := lfsLimits()
+	if !enabled {
+		if _, err := os.Stat(lfsObjectsDir(dir)); err == nil {
+			return os.RemoveAll(lfsObjectsDir(dir))
+		}
+		return nil
+	}
+
+	pointers, err := listLFSPointers(ctx, dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to list LFS pointers")
+	}
+
+	// Prefer small objects, so that as many files as possible have their
+	// contents when the repository size limit is reached.
+	sort.Slice(pointers, func(i, j int) bool {
+		if pointers[i].Size != pointers[j].Size {
+			return pointers[i].Size < pointers[j].Size
+		}
+		return pointers[i].OID < pointers[j].OID
+	})
+	var (
+		total   int64
+		wanted  = map[string]bool{}
+		missing []*lfs.Pointer
+	)
+	for _, p := range pointers {
+		if p.Size > maxFileSize || total+p.Size > maxRepoSize {
+			continue
+		}
+		total += p.Size
+		wanted[p.OID] = true
+		if !hasLFSObject(dir, p) {
+			missing = append(missing, p)
+		}
+	}
+
+	if err := pruneLFSObjects(dir, wanted); err != nil {
+		return errors.Wrap(err, "failed to remove unused LFS objects")
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	batchURL, err := lfsBatchURL(remoteURL)
+	if err != nil {
+		return err
+	}
+	for len(missing) > 0 {
+		batch := missing
+		if len(batch) > lfsBatchSize {
+			batch = batch[:lfsBatchSize]
+		}
+		missing = missing[len(batch):]
+		if err := downloadLFSObjects(ctx, dir, batchURL, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listLFSPointers returns the LFS pointers among the files in the HEAD commit
+// of the repository in dir. Pointers to the same object are only returned
+// once.
+func listLFSPointers(ctx context.Context, dir GitDir) ([]*lfs.Pointer, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "HEAD^{tree}")
+	cmd.Dir = string(dir)
+	if err := cmd.Run(); err != nil {
+		// Empty repository.
+		return nil, nil
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "ls-tree", "-r", "-l", "-z", "HEAD")
+	cmd.Dir = string(dir)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "git ls-tree")
+	}
+
+	// Only small blobs can be pointers. Each entry is
+	// "<mode> SP <type> SP <object> SP <size> TAB <path>".
+	var candidates bytes.Buffer
+	seen := map[string]bool{}
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		i := bytes.IndexByte(entry, '\t')
+		if i < 0 {
+			continue
+		}
+		fields := strings.Fields(string(entry[:i]))
+		if len(fields) != 4 || fields[1] != "blob" || seen[fields[2]] {
+			continue
+		}
+		if size, err := strconv.ParseInt(fields[3], 10, 64); err != nil || size > lfs.MaxPointerSize {
+			continue
+		}
+		seen[fields[2]] = true
+		candidates.WriteString(fields[2] + "\n")
+	}
+	if candidates.Len() == 0 {
+		return nil, nil
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	cmd.Dir = string(dir)
+	cmd.Stdin = &candidates
+	out, err = cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "git cat-file")
+	}
+
+	// The output for each blob is "<object> SP <type> SP <size> LF <contents> LF".
+	var pointers []*lfs.Pointer
+	seenOIDs := map[string]bool{}
+	r := bufio.NewReader(bytes.NewReader(out))
+	for {
+		header, err := r.ReadString('\n')
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil, errors.Errorf("unexpected git cat-file output: %q", header)
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, errors.Errorf("unexpected git cat-file output: %q", header)
+		}
+		contents := make([]byte, size+1)
+		if _, err := io.ReadFull(r, contents); err != nil {
+			return nil, err
+		}
+		if p, ok := lfs.ParsePointer(contents[:size]); ok && !seenOIDs[p.OID] {
+			seenOIDs[p.OID] = true
+			pointers = append(pointers, p)
+		}
+	}
+	return pointers, nil
+}
+
+// pruneLFSObjects removes the LFS objects in dir that are not in wanted.
+func pruneLFSObjects(dir GitDir, wanted map[string]bool) error {
+	err := filepath.Walk(lfsObjectsDir(dir), func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || wanted[fi.Name()] {
+			return nil
+		}
+		return os.Remove(path)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// lfsBatchURL returns the URL of the LFS batch API for the repository at
+// remoteURL, as described in
+// https://github.com/git-lfs/git-lfs/blob/master/docs/api/server-discovery.md.
+// Only HTTP(S) remotes are supported.
+func lfsBatchURL(remoteURL string) (*url.URL, error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid remote URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("Git LFS is only supported for HTTP(S) remote URLs, not %q", u.Scheme)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	if !strings.HasSuffix(u.Path, ".git") {
+		u.Path += ".git"
+	}
+	u.Path += "/info/lfs/objects/batch"
+	u.RawPath = ""
+	return u, nil
+}
+
+type lfsBatchObject struct {
+	OID     string `json:"oid"`
+	Size    int64  `json:"size"`
+	Actions *struct {
+		Download *struct {
+			Href   string            `json:"href"`
+			Header map[string]string `json:"header"`
+		} `json:"download"`
+	} `json:"actions,omitempty"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// downloadLFSObjects requests download URLs for the objects of pointers from
+// the LFS batch API at batchURL, and downloads them into dir.
+func downloadLFSObjects(ctx context.Context, dir GitDir, batchURL *url.URL, pointers []*lfs.Pointer) error {
+	objects := make([]lfsBatchObject, len(pointers))
+	for i, p := range pointers {
+		objects[i] = lfsBatchObject{OID: p.OID, Size: p.Size}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"operation": "download",
+		"transfers": []string{"basic"},
+		"objects":   objects,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The credentials in the remote URL are sent with basic authentication.
+	req, err := http.NewRequest("POST", batchURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.git-lfs+json")
+	req.Header.Set("Content-Type", "application/vnd.git-lfs+json")
+	resp, err := lfsDoer.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "LFS batch request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("LFS batch request failed with status %d: %s", resp.StatusCode, b)
+	}
+	var batch struct {
+		Objects []lfsBatchObject `json:"objects"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		return errors.Wrap(err, "invalid LFS batch response")
+	}
+
+	for _, o := range batch.Objects {
+		if o.Error != nil {
+			log15.Warn("LFS object not available", "repo", dir, "oid", o.OID, "code", o.Error.Code, "error", o.Error.Message)
+			continue
+		}
+		if o.Actions == nil || o.Actions.Download == nil {
+			// The server may omit the download action for objects it knows
+			// we already have.
+			continue
+		}
+		p := &lfs.Pointer{OID: o.OID, Size: o.Size}
+		if err := downloadLFSObject(ctx, dir, p, o.Actions.Download.Href, o.Actions.Download.Header); err != nil {
+			return errors.Wrapf(err, "failed to download LFS object %s", o.OID)
+		}
+		lfsObjectsFetched.Inc()
+	}
+	return nil
+}
+
+// downloadLFSObject downloads the object of p from href and stores it in dir
+// if its size and hash match p.
+func downloadLFSObject(ctx context.Context, dir GitDir, p *lfs.Pointer, href string, header map[string]string) error {
+	if len(p.OID) != 64 || strings.ContainsAny(p.OID, "/\\.") {
+		return errors.New("invalid oid")
+	}
+
+	req, err := http.NewRequest("GET", href, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := lfsDoer.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	tmpDir := dir.Path("lfs", "tmp")
+	if err := os.MkdirAll(tmpDir, os.ModePerm); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(tmpDir, p.OID)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(resp.Body, p.Size+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n != p.Size {
+		return errors.Errorf("got %d bytes, want %d", n, p.Size)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != p.OID {
+		return errors.Errorf("got object with hash %s", got)
+	}
+
+	path := lfsObjectPath(dir, p.OID)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// lfsArchiveWriter is an http.ResponseWriter for tar archives that replaces
+// LFS pointer files with the contents of the LFS objects stored in dir. Once
+// the response status is written, the response body is rewritten in a
+// separate goroutine. Close must be called to wait for it to finish.
+type lfsArchiveWriter struct {
+	http.ResponseWriter
+	dir GitDir
+
+	pw   *io.PipeWriter
+	done chan struct{}
+}
+
+func (w *lfsArchiveWriter) WriteHeader(code int) {
+	w.ResponseWriter.WriteHeader(code)
+	if code != http.StatusOK || w.pw != nil {
+		return
+	}
+
+	pr, pw := io.Pipe()
+	w.pw, w.done = pw, make(chan struct{})
+	go func() {
+		defer close(w.done)
+		out := io.Writer(w.ResponseWriter)
+		if fw := newFlushingResponseWriter(w.ResponseWriter); fw != nil {
+			defer fw.Close()
+			out = fw
+		}
+		if err := substituteLFSObjects(out, pr, w.dir); err != nil {
+			log15.Warn("failed to substitute LFS objects in archive", "repo", w.dir, "error", err)
+			pr.CloseWithError(err)
+			return
+		}
+		// Discard the padding after the end of the archive.
+		_, _ = io.Copy(ioutil.Discard, pr)
+	}()
+}
+
+func (w *lfsArchiveWriter) Write(p []byte) (int, error) {
+	if w.pw == nil {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.pw.Write(p)
+}
+
+// Header waits for the rewritten response body to be written, because the
+// header is only accessed again (to set trailers) once the archive is done.
+func (w *lfsArchiveWriter) Header() http.Header {
+	w.Close()
+	return w.ResponseWriter.Header()
+}
+
+// Flush is a no-op: the rewritten response body is flushed separately.
+func (w *lfsArchiveWriter) Flush() {}
+
+func (w *lfsArchiveWriter) Close() error {
+	if w.pw != nil {
+		w.pw.Close()
+		<-w.done
+	}
+	return nil
+}
+
+// substituteLFSObjects copies the tar archive read from r to w, replacing LFS
+// pointer files with the contents of their LFS objects stored in dir. Pointer
+// files whose objects aren't stored are copied unchanged.
+func substituteLFSObjects(w io.Writer, r io.Reader, dir GitDir) error {
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > lfs.MaxPointerSize {
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if _, err := io.Copy(tw, tr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if p, ok := lfs.ParsePointer(b); ok && hasLFSObject(dir, p) {
+			if err := copyLFSObject(tw, hdr, lfsObjectPath(dir, p.OID), p.Size); err == nil {
+				continue
+			} else if !os.IsNotExist(err) {
+				return err
+			}
+			// The object was removed after it was checked for, so fall
+			// back to the pointer file.
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(b); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func copyLFSObject(tw *tar.Writer, hdr *tar.Header, path string, size int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hdr.Size = size
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	n, err := io.Copy(tw, f)
+	if err == nil && n != size {
+		err = fmt.Errorf("LFS object %s changed while it was read", path)
+	}
+	return err
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestLFS(t *testing.T) {
+	const contents = "the contents of an LFS-tracked file\n"
+	sum := sha256.Sum256([]byte(contents))
+	oid := hex.EncodeToString(sum[:])
+	pointer := fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, len(contents))
+
+	remote, cleanup1 := tmpDir(t)
+	defer cleanup1()
+	git := func(arg ...string) {
+		t.Helper()
+		c := exec.Command("git", arg...)
+		c.Dir = remote
+		c.Env = append(os.Environ(), "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@a.com", "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@a.com")
+		if b, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %s: %s", strings.Join(arg, " "), err, b)
+		}
+	}
+	git("init", ".")
+	for name, data := range map[string]string{
+		"fixture.bin":    pointer,
+		"copy.bin":       pointer,
+		"README":         "hello\n",
+		".gitattributes": "*.bin filter=lfs diff=lfs merge=lfs -text\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(remote, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("add", ".")
+	git("commit", "-m", "add files")
+
+	// A minimal LFS server.
+	var batchRequests, downloads int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/foo/bar.git/info/lfs/objects/batch", func(w http.ResponseWriter, r *http.Request) {
+		batchRequests++
+		if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Operation string
+			Objects   []struct {
+				OID  string
+				Size int64
+			}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Operation != "download" || len(req.Objects) != 1 || req.Objects[0].OID != oid {
+			http.Error(w, fmt.Sprintf("unexpected request %+v", req), http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"objects": []interface{}{map[string]interface{}{
+				"oid":  oid,
+				"size": len(contents),
+				"actions": map[string]interface{}{
+					"download": map[string]interface{}{
+						"href":   "http://" + r.Host + "/objects/" + oid,
+						"header": map[string]string{"Authorization": "token"},
+					},
+				},
+			}},
+		})
+	})
+	mux.HandleFunc("/objects/"+oid, func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		if r.Header.Get("Authorization") != "token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.WriteString(w, contents)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	remoteURL := strings.Replace(srv.URL, "http://", "http://alice:secret@", 1) + "/foo/bar"
+
+	reposDir, cleanup2 := tmpDir(t)
+	defer cleanup2()
+	s := &Server{ReposDir: reposDir}
+	h := s.Handler()
+	repo := api.RepoName("example.com/foo/bar")
+	if _, err := s.cloneRepo(context.Background(), repo, remote, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+	dir := s.dir(repo)
+
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{},
+		GitLFS:               &schema.GitLFS{Enabled: true},
+	}})
+	defer conf.Mock(nil)
+
+	if err := fetchLFSObjects(context.Background(), dir, remoteURL); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(lfsObjectPath(dir, oid)); err != nil || string(b) != contents {
+		t.Fatalf("got LFS object %q (error %v), want %q", b, err, contents)
+	}
+
+	// Objects that are already stored aren't fetched again.
+	if err := fetchLFSObjects(context.Background(), dir, remoteURL); err != nil {
+		t.Fatal(err)
+	}
+	if batchRequests != 1 || downloads != 1 {
+		t.Errorf("got %d batch requests and %d downloads, want 1 each", batchRequests, downloads)
+	}
+
+	t.Run("archive", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/archive?repo="+string(repo)+"&treeish=HEAD&format=tar", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d: %s", rec.Code, rec.Body.String())
+		}
+		if e := rec.Result().Trailer.Get("X-Exec-Error"); e != "" {
+			t.Fatalf("archive failed: %s", e)
+		}
+
+		files := map[string]string{}
+		tr := tar.NewReader(rec.Body)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ioutil.ReadAll(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hdr.Typeflag == tar.TypeReg {
+				files[hdr.Name] = string(b)
+			}
+		}
+		for name, want := range map[string]string{
+			"fixture.bin": contents,
+			"copy.bin":    contents,
+			"README":      "hello\n",
+		} {
+			if files[name] != want {
+				t.Errorf("got %s %q, want %q", name, files[name], want)
+			}
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		conf.Mock(&conf.Unified{})
+		if err := fetchLFSObjects(context.Background(), dir, remoteURL); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(lfsObjectsDir(dir)); !os.IsNotExist(err) {
+			t.Error("expected LFS objects to be removed")
+		}
+	})
+}
+
+func TestLFSBatchURL(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/foo/bar":           "https://github.com/foo/bar.git/info/lfs/objects/batch",
+		"https://github.com/foo/bar.git":       "https://github.com/foo/bar.git/info/lfs/objects/batch",
+		"https://u:p@example.com/foo/bar.git/": "https://u:p@example.com/foo/bar.git/info/lfs/objects/batch",
+		"git@github.com:foo/bar.git":           "",
+		"ssh://git@github.com/foo/bar.git":     "",
+	}
+	for remoteURL, want := range tests {
+		u, err := lfsBatchURL(remoteURL)
+		var got string
+		if err == nil {
+			got = u.String()
+		}
+		if got != want {
+			t.Errorf("lfsBatchURL(%q) = %q (error %v), want %q", remoteURL, got, err, want)
+		}
+	}
+}
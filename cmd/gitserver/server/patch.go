@@ -66,6 +66,13 @@ func (s *Server) createCommitFromPatch(ctx context.Context, req protocol.CreateC
 		return http.StatusInternalServerError, resp
 	}
 
+	signing, signer, err := s.getCommitSigner()
+	if err != nil {
+		log15.Error("Failed to load commit signing key", "err", err)
+		resp.SetError(repo, "", "", errors.Wrap(err, "gitserver: loading commit signing key"))
+		return http.StatusInternalServerError, resp
+	}
+
 	redactor := newURLRedactor(remoteURL)
 	defer func() {
 		if resp.Error != nil {
@@ -182,6 +189,14 @@ func (s *Server) createCommitFromPatch(ctx context.Context, req protocol.CreateC
 	if committerEmail == "" {
 		committerEmail = authorEmail
 	}
+	// Code hosts verify the signature of a commit against the keys of its
+	// committer.
+	if signing != nil && signing.CommitterName != "" {
+		committerName = signing.CommitterName
+	}
+	if signing != nil && signing.CommitterEmail != "" {
+		committerEmail = signing.CommitterEmail
+	}
 
 	cmd = exec.CommandContext(ctx, "git", "commit", "-m", message)
 	cmd.Dir = tmpRepoDir
@@ -213,6 +228,15 @@ func (s *Server) createCommitFromPatch(ctx context.Context, req protocol.CreateC
 	}
 	cmtHash := strings.TrimSpace(string(out))
 
+	if signer != nil {
+		cmtHash, err = signCommit(ctx, signer, tmpRepoDir, []string{tmpGitPathEnv, altObjectsEnv})
+		if err != nil {
+			log15.Error("Failed to sign commit.", "ref", ref, "err", err)
+			resp.SetError(repo, "", "", errors.Wrap(err, "gitserver: signing commit"))
+			return http.StatusInternalServerError, resp
+		}
+	}
+
 	// Move objects from tmpObjectsDir to repoObjectsDir.
 	err = filepath.Walk(tmpObjectsDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -8,6 +8,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 )
 
 // HACK(keegancsmith) workaround to experiment with cloning less in a large
@@ -25,7 +26,7 @@ func useRefspecOverrides() bool {
 //
 // To not clone everything we instead init a bare repo and only add the
 // refspecs we care about. Then we finally do a fetch.
-func refspecOverridesCloneCmd(ctx context.Context, url, tmpPath string) (*exec.Cmd, error) {
+func refspecOverridesCloneCmd(ctx context.Context, url, tmpPath string, opts protocol.CloneOptions) (*exec.Cmd, error) {
 	if err := os.MkdirAll(tmpPath, os.ModePerm); err != nil {
 		return nil, errors.Wrapf(err, "clone failed to create tmp dir")
 	}
@@ -44,13 +45,18 @@ func refspecOverridesCloneCmd(ctx context.Context, url, tmpPath string) (*exec.C
 			return nil, errors.Wrapf(err, "clone setup failed")
 		}
 	}
-	cmd := exec.CommandContext(ctx, "git", "fetch", "--progress")
+	args := append([]string{"fetch", "--progress"}, fetchArgs(opts)...)
+	if opts.Partial {
+		// Fetching from origin with a filter makes it a promisor remote.
+		args = append(args, "--filter=blob:none")
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = tmpPath
 	return cmd, nil
 }
 
 // HACK(keegancsmith) workaround to experiment with cloning less in a large
 // monorepo. https://github.com/sourcegraph/customer/issues/19
-func refspecOverridesFetchCmd(ctx context.Context, url string) *exec.Cmd {
-	return exec.CommandContext(ctx, "git", append([]string{"fetch", "--prune", url}, refspecOverrides...)...)
+func refspecOverridesFetchCmd(ctx context.Context, args []string, url string) *exec.Cmd {
+	return exec.CommandContext(ctx, "git", append(append(args, url), refspecOverrides...)...)
 }
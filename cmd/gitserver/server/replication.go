@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+)
+
+// replicaConfigKey is the git config key that marks a clone as a replica of a
+// repository whose primary copy is on another gitserver.
+const replicaConfigKey = "sourcegraph.replica"
+
+// replicaReconcileInterval is how often the janitor checks that the replicas
+// of the repositories this gitserver is the primary for exist.
+const replicaReconcileInterval = time.Hour
+
+var (
+	replicaUpdates = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "src_gitserver_replica_updates",
+		Help: "number of requests to update a replica on another gitserver",
+	})
+	replicaUpdateErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "src_gitserver_replica_update_errors",
+		Help: "number of failed requests to update a replica on another gitserver",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(replicaUpdates)
+	prometheus.MustRegister(replicaUpdateErrors)
+}
+
+func (s *Server) gitserverClient() *gitserver.Client {
+	if s.peerClient != nil {
+		return s.peerClient
+	}
+	return gitserver.DefaultClient
+}
+
+// ownAddr returns the address of this gitserver in the list of gitservers,
+// or "" if it is unknown.
+func (s *Server) ownAddr(ctx context.Context) string {
+	if s.Hostname == "" {
+		return ""
+	}
+	for _, addr := range s.gitserverClient().Addrs(ctx) {
+		if hostnameMatch(s.Hostname, addr) {
+			return addr
+		}
+	}
+	return ""
+}
+
+// shardIndex returns the addresses of the gitservers holding a copy of repo
+// (the primary first) and the index of this gitserver among them, or -1 if it
+// isn't one of them. ok is false if this gitserver doesn't know its own
+// address, in which case repositories are neither replicated nor moved
+// between gitservers.
+func (s *Server) shardIndex(ctx context.Context, repo api.RepoName) (addrs []string, index int, ok bool) {
+	if s.ownAddr(ctx) == "" {
+		return nil, -1, false
+	}
+
+	addrs = s.gitserverClient().AddrsForRepo(ctx, repo)
+	for i, addr := range addrs {
+		if hostnameMatch(s.Hostname, addr) {
+			return addrs, i, true
+		}
+	}
+	return addrs, -1, true
+}
+
+// hostnameMatch returns true if addr (such as "gitserver-0.gitserver:3178")
+// is the address of the host with the given hostname (such as
+// "gitserver-0").
+func hostnameMatch(hostname, addr string) bool {
+	if !strings.HasPrefix(addr, hostname) {
+		return false
+	}
+	if len(addr) == len(hostname) {
+		return true
+	}
+	c := addr[len(hostname)]
+	return c == '.' || c == ':'
+}
+
+func isReplica(dir GitDir) bool {
+	v, _ := gitConfigGet(dir, replicaConfigKey)
+	return strings.TrimSpace(v) == "true"
+}
+
+// replicate requests that the replicas of repo are cloned or updated, if this
+// gitserver is the repo's primary. The requests are sent in the background.
+func (s *Server) replicate(repo api.RepoName, url string) {
+	if s.skipCloneForTests {
+		return
+	}
+
+	ctx, cancel := s.serverContext()
+	addrs, index, ok := s.shardIndex(ctx, repo)
+	cancel()
+	if !ok || index != 0 || len(addrs) < 2 {
+		return
+	}
+
+	for _, addr := range addrs[1:] {
+		go func(addr string) {
+			ctx, cancel := s.serverContext()
+			defer cancel()
+			ctx, cancel2 := context.WithTimeout(ctx, longGitCommandTimeout)
+			defer cancel2()
+			s.updateReplica(ctx, addr, repo, url)
+		}(addr)
+	}
+}
+
+func (s *Server) updateReplica(ctx context.Context, addr string, repo api.RepoName, url string) {
+	replicaUpdates.Inc()
+	if err := s.gitserverClient().RequestReplicaUpdate(ctx, addr, gitserver.Repo{Name: repo, URL: url}, s.cloneSizeLimit(repo), repoCloneOptions(s.dir(repo))); err != nil {
+		replicaUpdateErrors.Inc()
+		log15.Warn("failed to update replica", "repo", repo, "replica", addr, "error", err)
+	}
+}
+
+// reconcileReplica is a cleanup function. It removes replicas that this
+// gitserver no longer needs to hold (for example, because the replication
+// factor was reduced), and marks the repositories whose primary or replica
+// this gitserver has become. The repositories this gitserver is the primary
+// for are added to primaries (keyed by replica address), so that missing
+// replicas can be created by ensureReplicas.
+func (s *Server) reconcileReplica(dir GitDir, primaries map[string][]api.RepoName) (done bool, err error) {
+	ctx, cancel := s.serverContext()
+	defer cancel()
+
+	repo := s.name(dir)
+	addrs, index, ok := s.shardIndex(ctx, repo)
+	if !ok {
+		return false, nil
+	}
+
+	switch replica := isReplica(dir); {
+	case index < 0 && replica:
+		log15.Info("removing replica", "repo", repo)
+		if err := s.removeRepoDirectory(dir); err != nil {
+			return true, err
+		}
+		reposRemoved.Inc()
+		return true, nil
+
+	case index == 0 && replica:
+		log15.Info("promoting replica to primary", "repo", repo)
+		if err := gitConfigUnset(dir, replicaConfigKey); err != nil {
+			return false, err
+		}
+
+	case index > 0 && !replica:
+		return false, gitConfigSet(dir, replicaConfigKey, "true")
+	}
+
+	if index == 0 && primaries != nil {
+		for _, addr := range addrs[1:] {
+			primaries[addr] = append(primaries[addr], repo)
+		}
+	}
+	return false, nil
+}
+
+// ensureReplicas requests that replicas are cloned for the repositories in
+// primaries that are missing on the given replica gitservers. It runs in the
+// background, and is a no-op if a previous run is still in progress.
+func (s *Server) ensureReplicas(primaries map[string][]api.RepoName) {
+	if len(primaries) == 0 || !atomic.CompareAndSwapInt32(&s.ensuringReplicas, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&s.ensuringReplicas, 0)
+
+		ctx, cancel := s.serverContext()
+		defer cancel()
+
+		client := s.gitserverClient()
+		for addr, repos := range primaries {
+			for len(repos) > 0 {
+				batch := repos
+				if len(batch) > 1000 {
+					batch = batch[:1000]
+				}
+				repos = repos[len(batch):]
+
+				res, err := client.ReplicaRepoInfo(ctx, addr, batch...)
+				if err != nil {
+					log15.Warn("failed to list replicas", "replica", addr, "error", err)
+					break
+				}
+				for _, repo := range batch {
+					if info, ok := res.Results[repo]; ok && (info.Cloned || info.CloneInProgress) {
+						continue
+					}
+					url, err := repoRemoteURL(ctx, s.dir(repo))
+					if err != nil {
+						log15.Warn("failed to get remote URL for replica", "repo", repo, "error", err)
+						continue
+					}
+					log15.Info("creating missing replica", "repo", repo, "replica", addr)
+					updateCtx, cancelUpdate := context.WithTimeout(ctx, longGitCommandTimeout)
+					s.updateReplica(updateCtx, addr, repo, url)
+					cancelUpdate()
+				}
+				if ctx.Err() != nil {
+					return
+				}
+			}
+		}
+	}()
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 )
 
-func (s *Server) repoInfo(ctx context.Context, repo api.RepoName) (*protocol.RepoInfo, error) {
+func (s *Server) repoInfo(ctx context.Context, repo api.RepoName, size bool) (*protocol.RepoInfo, error) {
 	dir := s.dir(repo)
 	resp := protocol.RepoInfo{
 		Cloned: repoCloned(dir),
@@ -29,6 +29,7 @@ func (s *Server) repoInfo(ctx context.Context, repo api.RepoName) (*protocol.Rep
 			resp.CloneProgress = "This will never finish cloning"
 		}
 	}
+	resp.TransferProgress = s.transferProgress(repo)
 	if resp.Cloned {
 		if mtime, err := repoLastFetched(dir); err != nil {
 			log15.Warn("error computing last-fetched date", "repo", repo, "err", err)
@@ -47,6 +48,14 @@ func (s *Server) repoInfo(ctx context.Context, repo api.RepoName) (*protocol.Rep
 		} else {
 			resp.LastChanged = &lastChanged
 		}
+
+		if size {
+			if repoSize, err := s.repoSize(dir); err != nil {
+				log15.Warn("error computing repo size", "repo", repo, "err", err)
+			} else {
+				resp.Size = repoSize
+			}
+		}
 	}
 	return &resp, nil
 }
@@ -62,7 +71,7 @@ func (s *Server) handleRepoInfo(w http.ResponseWriter, r *http.Request) {
 		Results: make(map[api.RepoName]*protocol.RepoInfo, len(req.Repos)),
 	}
 	for _, repoName := range req.Repos {
-		result, err := s.repoInfo(r.Context(), repoName)
+		result, err := s.repoInfo(r.Context(), repoName, req.Size)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -92,5 +101,42 @@ func (s *Server) handleRepoDelete(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) deleteRepo(repo api.RepoName) error {
-	return s.removeRepoDirectory(s.dir(repo))
+	if err := s.removeRepoDirectory(s.dir(repo)); err != nil {
+		return err
+	}
+	return s.setCloneSizeLimit(repo, 0)
+}
+
+// repoSize returns the size in bytes of the repository in dir. Sizes are
+// cached until the repository is cloned, fetched or removed again, and the
+// janitor computes the sizes missing from the cache, so that requests for
+// the sizes of many repositories don't walk their clones.
+func (s *Server) repoSize(dir GitDir) (int64, error) {
+	s.repoSizesMu.Lock()
+	size, ok := s.repoSizes[dir]
+	s.repoSizesMu.Unlock()
+	if ok {
+		return size, nil
+	}
+
+	size, err := dirSize(string(dir))
+	if err != nil {
+		return 0, err
+	}
+
+	s.repoSizesMu.Lock()
+	if s.repoSizes == nil {
+		s.repoSizes = make(map[GitDir]int64)
+	}
+	s.repoSizes[dir] = size
+	s.repoSizesMu.Unlock()
+	return size, nil
+}
+
+// invalidateRepoSize removes the cached size of the repository in dir. It
+// must be called whenever the repository changes.
+func (s *Server) invalidateRepoSize(dir GitDir) {
+	s.repoSizesMu.Lock()
+	delete(s.repoSizes, dir)
+	s.repoSizesMu.Unlock()
 }
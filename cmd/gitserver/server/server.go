@@ -33,6 +33,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/honey"
 	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
@@ -40,6 +41,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/repotrackutil"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 // tempDirName is the name used for the temporary directory under ReposDir.
@@ -116,6 +118,46 @@ type Server struct {
 	// DiskSizer tells how much disk is free and how large the disk is.
 	DiskSizer DiskSizer
 
+	// Hostname is the hostname of this gitserver. It is used to find this
+	// gitserver's address in the list of gitservers, which is needed to
+	// replicate repositories (see the gitReplicationFactor site
+	// configuration) and to move them to another gitserver when the list of
+	// gitservers changes. If empty, repositories are neither replicated nor
+	// moved.
+	Hostname string
+
+	// BackupDir is the directory that repositories are backed up to and
+	// restored from (see handleBackup and handleRestore). If empty, backups
+	// are disabled.
+	BackupDir string
+
+	// CommitSigningPassphrase is the passphrase of the private key in the
+	// gitCommitSigning site configuration, which the commits created by
+	// createCommitFromPatch are signed with.
+	CommitSigningPassphrase string
+
+	// peerClient is set by tests to control the gitservers that
+	// repositories are replicated and moved to. If nil,
+	// gitserver.DefaultClient is used.
+	peerClient *gitserver.Client
+
+	// ensuringReplicas is 1 while missing replicas are being created.
+	ensuringReplicas int32
+
+	// transferringRepos is 1 while repositories are being moved to other
+	// gitservers.
+	transferringRepos int32
+
+	transfersMu sync.Mutex
+	transfers   map[api.RepoName]string // repo -> progress of its transfer to or from another gitserver
+
+	backupMu     sync.Mutex
+	backupStatus *protocol.BackupStatus // the running or last backup or restore
+
+	// lastReplicaReconcile is when the janitor last checked for missing
+	// replicas.
+	lastReplicaReconcile time.Time
+
 	// skipCloneForTests is set by tests to avoid clones.
 	skipCloneForTests bool
 
@@ -138,6 +180,16 @@ type Server struct {
 
 	repoUpdateLocksMu sync.Mutex // protects the map below and also updates to locks.once
 	repoUpdateLocks   map[api.RepoName]*locks
+
+	commitSignerMu     sync.Mutex // protects the fields below
+	commitSignerConfig schema.GitCommitSigning
+	commitSigner       commitSigner // decrypted from commitSignerConfig
+
+	repoSizesMu sync.Mutex
+	repoSizes   map[GitDir]int64 // size in bytes of the clones, see repoSize
+
+	cloneSizeLimitsMu sync.Mutex
+	cloneSizeLimits   map[api.RepoName]int64 // loaded from cloneSizeLimitsFile, see cloneSizeLimit
 }
 
 type locks struct {
@@ -232,6 +284,11 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/repos", s.handleRepoInfo)
 	mux.HandleFunc("/delete", s.handleRepoDelete)
 	mux.HandleFunc("/repo-update", s.handleRepoUpdate)
+	mux.HandleFunc("/repo-transfer", s.handleRepoTransfer)
+	mux.HandleFunc("/bundle", s.handleBundle)
+	mux.HandleFunc("/backup", s.handleBackup)
+	mux.HandleFunc("/restore", s.handleRestore)
+	mux.HandleFunc("/backup-status", s.handleBackupStatus)
 	mux.HandleFunc("/getGitolitePhabricatorMetadata", s.handleGetGitolitePhabricatorMetadata)
 	mux.HandleFunc("/create-commit-from-patch", s.handleCreateCommitFromPatch)
 	mux.HandleFunc("/ping", func(w http.ResponseWriter, _ *http.Request) {
@@ -403,7 +460,12 @@ func (s *Server) handleRepoUpdate(w http.ResponseWriter, r *http.Request) {
 		// optimistically, we assume that our cloning attempt might
 		// succeed.
 		resp.CloneInProgress = true
-		_, err := s.cloneRepo(ctx, req.Repo, req.URL, &cloneOptions{Block: true})
+		opts := &cloneOptions{Block: true, MaxSize: req.MaxCloneSize, Replica: req.Replica}
+		if req.CloneOptions != nil {
+			opts.CloneOptions = *req.CloneOptions
+			s.recordCloneSizeLimit(req.Repo, req.MaxCloneSize)
+		}
+		_, err := s.cloneRepo(ctx, req.Repo, req.URL, opts)
 		if err != nil {
 			log15.Warn("error cloning repo", "repo", req.Repo, "err", err)
 			resp.Error = err.Error()
@@ -412,6 +474,15 @@ func (s *Server) handleRepoUpdate(w http.ResponseWriter, r *http.Request) {
 		resp.Cloned = true
 		var statusErr, updateErr error
 
+		// Only senders that know the configured clone options may change
+		// them. Others, such as manual updates, keep the existing options.
+		if req.CloneOptions != nil {
+			if err := updateCloneOptions(dir, *req.CloneOptions); err != nil {
+				log15.Warn("failed to update clone options", "repo", req.Repo, "error", err)
+			}
+			s.recordCloneSizeLimit(req.Repo, req.MaxCloneSize)
+		}
+
 		if debounce(req.Repo, req.Since) {
 			updateErr = s.doRepoUpdate(ctx, req.Repo, req.URL)
 		}
@@ -496,6 +567,17 @@ func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
 	req.Args = append(req.Args, treeish, "--")
 	req.Args = append(req.Args, paths...)
 
+	// Searcher and symbols request tar archives, which contain the contents
+	// of Git LFS objects (if fetched) in place of LFS pointer files.
+	if enabled, _, _ := lfsLimits(); enabled && format == "tar" {
+		dir := s.dir(req.Repo)
+		if _, err := os.Stat(lfsObjectsDir(dir)); err == nil {
+			lw := &lfsArchiveWriter{ResponseWriter: w, dir: dir}
+			defer lw.Close()
+			w = lw
+		}
+	}
+
 	s.exec(w, r, req)
 }
 
@@ -654,6 +736,17 @@ func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.Exec
 	w.Header().Add("Trailer", "X-Exec-Stderr")
 	w.WriteHeader(http.StatusOK)
 
+	// Report a clear error instead of wrong results for commands that need
+	// the history a shallow clone doesn't have.
+	if err := shallowCloneError(dir, req.Args); err != nil {
+		status = "shallow-clone"
+		execErr = err
+		w.Header().Set("X-Exec-Error", err.Error())
+		w.Header().Set("X-Exec-Exit-Status", "128")
+		w.Header().Set("X-Exec-Stderr", "")
+		return
+	}
+
 	// Special-case `git rev-parse HEAD` requests. These are invoked by search queries for every repo in scope.
 	// For searches over large repo sets (> 1k), this leads to too many child process execs, which can lead
 	// to a persistent failure mode where every exec takes > 10s, which is disastrous for gitserver performance.
@@ -724,6 +817,18 @@ type cloneOptions struct {
 
 	// Overwrite will overwrite the existing clone.
 	Overwrite bool
+
+	// MaxSize, if non-zero, is the maximum size in bytes of the clone. The
+	// clone is aborted if it exceeds it. If zero, the limit recorded for the
+	// repository applies (see cloneSizeLimit).
+	MaxSize int64
+
+	// Replica marks the clone as a replica of a repository whose primary copy
+	// is on another gitserver. Replicas are not replicated further.
+	Replica bool
+
+	// CloneOptions make the clone a partial or shallow clone.
+	protocol.CloneOptions
 }
 
 // cloneRepo issues a git clone command for the given repo. It is
@@ -807,14 +912,27 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 		tmpPath = filepath.Join(tmpPath, ".git")
 		tmp := GitDir(tmpPath)
 
+		maxSize := s.cloneSizeLimit(repo)
+		if opts != nil && opts.MaxSize != 0 {
+			maxSize = opts.MaxSize
+		}
+		cloneCtx, stopWatchingSize := watchCloneSize(ctx, tmpPath, maxSize)
+		defer stopWatchingSize()
+
+		var remoteOpts protocol.CloneOptions
+		if opts != nil {
+			remoteOpts = opts.CloneOptions
+		}
+
 		var cmd *exec.Cmd
 		if useRefspecOverrides() {
-			cmd, err = refspecOverridesCloneCmd(ctx, url, tmpPath)
+			cmd, err = refspecOverridesCloneCmd(cloneCtx, url, tmpPath, remoteOpts)
 			if err != nil {
 				return err
 			}
 		} else {
-			cmd = exec.CommandContext(ctx, "git", "clone", "--mirror", "--progress", url, tmpPath)
+			args := append([]string{"clone", "--mirror", "--progress"}, cloneArgs(remoteOpts)...)
+			cmd = exec.CommandContext(cloneCtx, "git", append(args, url, tmpPath)...)
 		}
 		// see issue #7322: skip LFS content in repositories with Git LFS configured
 		cmd.Env = append(cmd.Env, "GIT_LFS_SKIP_SMUDGE=1")
@@ -824,7 +942,11 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 		defer pw.Close()
 		go readCloneProgress(redactor, lock, pr)
 
-		if output, err := runWithRemoteOpts(ctx, cmd, pw); err != nil {
+		output, err := runWithRemoteOpts(cloneCtx, cmd, pw)
+		if sizeErr := stopWatchingSize(); sizeErr != nil {
+			return sizeErr
+		}
+		if err != nil {
 			return errors.Wrapf(err, "clone failed. Output: %s", string(output))
 		}
 
@@ -840,6 +962,17 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 			return err
 		}
 
+		if err := setCloneOptions(tmp, remoteOpts); err != nil {
+			return err
+		}
+
+		replica := opts != nil && opts.Replica
+		if replica {
+			if err := gitConfigSet(tmp, replicaConfigKey, "true"); err != nil {
+				return err
+			}
+		}
+
 		if overwrite {
 			// remove the current repo by putting it into our temporary directory
 			err := renameAndSync(dstPath, filepath.Join(filepath.Dir(tmpPath), "old"))
@@ -854,10 +987,19 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 		if err := renameAndSync(tmpPath, dstPath); err != nil {
 			return err
 		}
+		s.invalidateRepoSize(dir)
 
 		log15.Info("repo cloned", "repo", repo)
 		repoClonedCounter.Inc()
 
+		if err := fetchLFSObjects(ctx, GitDir(dstPath), url); err != nil {
+			log15.Warn("failed to fetch Git LFS objects", "repo", repo, "error", err)
+		}
+
+		if !replica {
+			s.replicate(repo, url)
+		}
+
 		return nil
 	}
 
@@ -1307,10 +1449,19 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 	if customCmd := customFetchCmd(ctx, url); customCmd != nil {
 		cmd = customCmd
 		configRemoteOpts = false
-	} else if useRefspecOverrides() {
-		cmd = refspecOverridesFetchCmd(ctx, url)
 	} else {
-		cmd = exec.CommandContext(ctx, "git", "fetch", "--prune", url, "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*", "+refs/pull/*:refs/pull/*", "+refs/sourcegraph/*:refs/sourcegraph/*")
+		// Keep shallow clones shallow, or fetch the full history if the
+		// depth of a shallow clone was removed.
+		cloneOpts := repoCloneOptions(dir)
+		args := append([]string{"fetch", "--prune"}, fetchArgs(cloneOpts)...)
+		if cloneOpts.Depth == 0 && isShallow(dir) {
+			args = append(args, "--unshallow")
+		}
+		if useRefspecOverrides() {
+			cmd = refspecOverridesFetchCmd(ctx, args, url)
+		} else {
+			cmd = exec.CommandContext(ctx, "git", append(args, url, "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*", "+refs/pull/*:refs/pull/*", "+refs/sourcegraph/*:refs/sourcegraph/*")...)
+		}
 	}
 	cmd.Dir = string(dir)
 
@@ -1319,6 +1470,7 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 	// either way they can't still be in use. we don't care exactly
 	// when the cleanup happens, just that it does.
 	defer s.cleanTmpFiles(dir)
+	defer s.invalidateRepoSize(dir)
 
 	if output, err := runWith(ctx, cmd, configRemoteOpts, nil); err != nil {
 		log15.Error("Failed to update", "repo", repo, "error", err, "output", string(output))
@@ -1376,6 +1528,14 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 		log15.Error("Failed to set HEAD", "repo", repo, "error", err, "output", string(output))
 		return errors.Wrap(err, "Failed to set HEAD")
 	}
+
+	if err := fetchLFSObjects(ctx, dir, url); err != nil {
+		log15.Warn("failed to fetch Git LFS objects", "repo", repo, "error", err)
+	}
+
+	if !isReplica(dir) {
+		s.replicate(repo, url)
+	}
 	return nil
 }
 
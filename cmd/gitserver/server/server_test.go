@@ -105,9 +105,11 @@ func TestRequest(t *testing.T) {
 	s := &Server{ReposDir: "/testroot", skipCloneForTests: true}
 	h := s.Handler()
 
+	origRepoCloned := repoCloned
 	repoCloned = func(dir GitDir) bool {
 		return dir == s.dir("github.com/gorilla/mux") || dir == s.dir("my-mux")
 	}
+	defer func() { repoCloned = origRepoCloned }()
 
 	testRepoExists = func(ctx context.Context, url string) error {
 		if url == "https://github.com/nicksnyder/go-i18n.git" {
@@ -411,6 +413,35 @@ func TestCloneRepo(t *testing.T) {
 	if wantCommit != gotCommit {
 		t.Fatal("failed to clone:", gotCommit)
 	}
+
+	// Test a clone exceeding its maximum size is aborted.
+	_, err = s.cloneRepo(context.Background(), "example.com/foo/toolarge", remote, &cloneOptions{Block: true, MaxSize: 1})
+	if _, ok := errors.Cause(err).(*cloneTooLargeError); !ok {
+		t.Fatalf("expected clone to fail with cloneTooLargeError, got %v", err)
+	}
+	if _, err := os.Stat(string(s.dir("example.com/foo/toolarge"))); !os.IsNotExist(err) {
+		t.Fatalf("expected too large clone to be removed: %v", err)
+	}
+
+	// Test clones without a maximum size, e.g. on-demand clones, use the
+	// limit recorded for the repository.
+	if err := s.setCloneSizeLimit("example.com/foo/toolarge", 1); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.cloneRepo(context.Background(), "example.com/foo/toolarge", remote, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		_, cloning := s.locker.Status(s.dir("example.com/foo/toolarge"))
+		if !cloning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := os.Stat(string(s.dir("example.com/foo/toolarge"))); !os.IsNotExist(err) {
+		t.Fatalf("expected too large on-demand clone to be removed: %v", err)
+	}
 }
 
 func TestRemoveBadRefs(t *testing.T) {
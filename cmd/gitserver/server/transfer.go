@@ -0,0 +1,348 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+// When the list of gitservers changes, some repositories are assigned to a
+// different gitserver. Instead of recloning them from the code host, the
+// janitor of the gitserver that holds a repository asks the gitserver it is
+// now assigned to to copy it (by fetching a Git bundle of the repository), and
+// then removes its own copy.
+
+var (
+	reposTransferred = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "src_gitserver_repos_transferred",
+		Help: "number of repositories moved to the gitserver they are assigned to",
+	})
+	repoTransferErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "src_gitserver_repo_transfer_errors",
+		Help: "number of failed attempts to move a repository to the gitserver it is assigned to",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(reposTransferred)
+	prometheus.MustRegister(repoTransferErrors)
+}
+
+// setTransferProgress records the progress of a transfer of repo, which is
+// reported in the repo's info. An empty progress clears it.
+func (s *Server) setTransferProgress(repo api.RepoName, progress string) {
+	s.transfersMu.Lock()
+	defer s.transfersMu.Unlock()
+	if progress == "" {
+		delete(s.transfers, repo)
+		return
+	}
+	if s.transfers == nil {
+		s.transfers = make(map[api.RepoName]string)
+	}
+	s.transfers[repo] = progress
+}
+
+func (s *Server) transferProgress(repo api.RepoName) string {
+	s.transfersMu.Lock()
+	defer s.transfersMu.Unlock()
+	return s.transfers[repo]
+}
+
+// maybeTransfer is a cleanup function. If the repository in dir is assigned
+// to another gitserver (and isn't a replica, which reconcileReplica takes
+// care of), it is added to transfers (keyed by the address of the gitserver
+// it is assigned to), so that it can be moved by transferRepos.
+func (s *Server) maybeTransfer(dir GitDir, transfers map[api.RepoName]string) (done bool, err error) {
+	ctx, cancel := s.serverContext()
+	defer cancel()
+
+	repo := s.name(dir)
+	addrs, index, ok := s.shardIndex(ctx, repo)
+	if !ok || index >= 0 || isReplica(dir) {
+		return false, nil
+	}
+	transfers[repo] = addrs[0]
+	return true, nil
+}
+
+// transferRepos moves the repositories in transfers to the gitservers they
+// are assigned to. It runs in the background, and is a no-op if a previous run
+// is still in progress.
+func (s *Server) transferRepos(transfers map[api.RepoName]string) {
+	if len(transfers) == 0 || !atomic.CompareAndSwapInt32(&s.transferringRepos, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&s.transferringRepos, 0)
+
+		ctx, cancel := s.serverContext()
+		defer cancel()
+
+		sourceAddr := s.ownAddr(ctx)
+		if sourceAddr == "" {
+			return
+		}
+		for repo, addr := range transfers {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := s.transferRepo(ctx, repo, sourceAddr, addr); err != nil {
+				repoTransferErrors.Inc()
+				log15.Warn("failed to transfer repository", "repo", repo, "to", addr, "error", err)
+			}
+		}
+	}()
+}
+
+// transferRepo asks the gitserver at addr to copy repo from this gitserver
+// (at sourceAddr), and removes this gitserver's copy once it has a copy.
+func (s *Server) transferRepo(ctx context.Context, repo api.RepoName, sourceAddr, addr string) error {
+	ctx, cancel := context.WithTimeout(ctx, longGitCommandTimeout)
+	defer cancel()
+
+	dir := s.dir(repo)
+	url, err := repoRemoteURL(ctx, dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to get remote URL")
+	}
+
+	empty, err := isEmptyRepo(ctx, dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to list refs")
+	}
+
+	s.setTransferProgress(repo, fmt.Sprintf("transferring to %s", addr))
+	defer s.setTransferProgress(repo, "")
+
+	log15.Info("transferring repository", "repo", repo, "to", addr)
+	res, err := s.gitserverClient().RequestRepoTransfer(ctx, addr, &protocol.RepoTransferRequest{
+		Repo:         repo,
+		URL:          url,
+		SourceAddr:   sourceAddr,
+		MaxCloneSize: s.cloneSizeLimit(repo),
+		CloneOptions: repoCloneOptions(dir),
+		Empty:        empty,
+	})
+	if err != nil {
+		return err
+	}
+	if res.Error != "" {
+		return errors.New(res.Error)
+	}
+	if !res.Cloned {
+		return errors.New("repository was not cloned")
+	}
+
+	if err := s.removeRepoDirectory(dir); err != nil {
+		return errors.Wrap(err, "failed to remove transferred repository")
+	}
+	log15.Info("transferred repository", "repo", repo, "to", addr)
+	reposTransferred.Inc()
+	return nil
+}
+
+// handleRepoTransfer copies a repository that was assigned to this gitserver
+// from the gitserver that holds it. It is synchronous, so that the other
+// gitserver only removes its copy once the transfer succeeded.
+func (s *Server) handleRepoTransfer(w http.ResponseWriter, r *http.Request) {
+	var req protocol.RepoTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Repo = protocol.NormalizeRepo(req.Repo)
+
+	// Like repo updates, we don't want to cancel the transfer partway through
+	// if the request terminates.
+	ctx, cancel1 := s.serverContext()
+	defer cancel1()
+	ctx, cancel2 := context.WithTimeout(ctx, longGitCommandTimeout)
+	defer cancel2()
+
+	s.recordCloneSizeLimit(req.Repo, req.MaxCloneSize)
+
+	var resp protocol.RepoTransferResponse
+	var err error
+	switch {
+	case repoCloned(s.dir(req.Repo)):
+	case req.CloneOptions != (protocol.CloneOptions{}) || req.Empty:
+		// Bundles need the full history and all blobs, so partial and
+		// shallow clones are cloned from the code host instead. So are empty
+		// repositories, which can't be bundled.
+		_, err = s.cloneRepo(ctx, req.Repo, req.URL, &cloneOptions{Block: true, CloneOptions: req.CloneOptions})
+	default:
+		err = s.receiveRepo(ctx, req.Repo, req.URL, req.SourceAddr)
+	}
+	if err != nil {
+		log15.Warn("failed to receive repository", "repo", req.Repo, "from", req.SourceAddr, "error", err)
+		resp.Error = err.Error()
+	}
+	resp.Cloned = repoCloned(s.dir(req.Repo))
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// receiveRepo clones repo from a bundle of the copy on the gitserver at
+// sourceAddr. It is a no-op if the repository is already cloned.
+func (s *Server) receiveRepo(ctx context.Context, repo api.RepoName, url, sourceAddr string) error {
+	dir := s.dir(repo)
+	if repoCloned(dir) {
+		return nil
+	}
+
+	progress := fmt.Sprintf("transferring from %s", sourceAddr)
+	lock, ok := s.locker.TryAcquire(dir, progress)
+	if !ok {
+		return errors.New("another clone is already in progress")
+	}
+	defer lock.Release()
+	s.setTransferProgress(repo, progress)
+	defer s.setTransferProgress(repo, "")
+
+	ctx, cancel, err := s.acquireCloneLimiter(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	tmpDir, err := s.tempDir("transfer-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	bundle, err := s.gitserverClient().Bundle(ctx, sourceAddr, repo)
+	if err != nil {
+		return errors.Wrap(err, "failed to request bundle")
+	}
+	bundlePath := filepath.Join(tmpDir, "repo.bundle")
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		bundle.Close()
+		return err
+	}
+	_, err = io.Copy(f, bundle)
+	bundle.Close()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to download bundle")
+	}
+
+	lock.SetStatus(progress + ": cloning bundle")
+	s.setTransferProgress(repo, progress+": cloning bundle")
+	tmpPath := filepath.Join(tmpDir, ".git")
+	if err := cloneBundle(ctx, bundlePath, GitDir(tmpPath), url); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(string(dir)), os.ModePerm); err != nil {
+		return err
+	}
+	if err := renameAndSync(tmpPath, string(dir)); err != nil {
+		return err
+	}
+	log15.Info("received repository", "repo", repo, "from", sourceAddr)
+
+	if url != "" {
+		s.replicate(repo, url)
+	}
+	return nil
+}
+
+// cloneBundle clones the Git bundle at bundlePath into the new repository
+// tmp, and sets the repository's remote URL to url. If url is empty, the
+// repository has no remote until it is next updated.
+func cloneBundle(ctx context.Context, bundlePath string, tmp GitDir, url string) error {
+	cmd := exec.CommandContext(ctx, "git", "clone", "--mirror", bundlePath, string(tmp))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "failed to clone bundle. Output: %s", string(output))
+	}
+	if url != "" {
+		cmd = exec.CommandContext(ctx, "git", "remote", "set-url", "origin", "--", url)
+	} else {
+		cmd = exec.CommandContext(ctx, "git", "remote", "remove", "origin")
+	}
+	cmd.Dir = string(tmp)
+	if _, err := runCommand(ctx, cmd); err != nil {
+		return errors.Wrap(err, "failed to set remote URL")
+	}
+
+	removeBadRefs(ctx, tmp)
+	if err := setLastChanged(tmp); err != nil {
+		return errors.Wrap(err, "failed to update last changed time")
+	}
+	return setGitAttributes(tmp)
+}
+
+// isEmptyRepo reports whether the repository in dir has no refs.
+func isEmptyRepo(ctx context.Context, dir GitDir) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "for-each-ref", "--count=1")
+	cmd.Dir = string(dir)
+	out, err := cmd.Output()
+	if err != nil {
+		return false, err
+	}
+	return len(bytes.TrimSpace(out)) == 0, nil
+}
+
+// handleBundle writes a Git bundle of all refs of a repository. It is used by
+// other gitservers to copy the repository.
+func (s *Server) handleBundle(w http.ResponseWriter, r *http.Request) {
+	repo := protocol.NormalizeRepo(api.RepoName(r.URL.Query().Get("repo")))
+	if repo == "" {
+		http.Error(w, "empty repo", http.StatusBadRequest)
+		return
+	}
+	dir := s.dir(repo)
+	if !repoCloned(dir) {
+		http.Error(w, "repository not cloned", http.StatusNotFound)
+		return
+	}
+
+	// Buffer the bundle in a temporary file, so that a failure to create it
+	// is reported as an error status instead of a truncated bundle.
+	tmpDir, err := s.tempDir("bundle-")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+	bundlePath := filepath.Join(tmpDir, "repo.bundle")
+
+	cmd := exec.CommandContext(r.Context(), "git", "bundle", "create", bundlePath, "--all")
+	cmd.Dir = string(dir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		log15.Warn("failed to create bundle", "repo", repo, "error", err, "output", string(output))
+		http.Error(w, fmt.Sprintf("failed to create bundle: %s", output), http.StatusInternalServerError)
+		return
+	}
+
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = io.Copy(w, f)
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+func TestTransferRepos(t *testing.T) {
+	remote, cleanup1 := tmpDir(t)
+	defer cleanup1()
+	git := func(dir string, arg ...string) string {
+		t.Helper()
+		c := exec.Command("git", arg...)
+		c.Dir = dir
+		c.Env = append(os.Environ(), "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@a.com", "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@a.com")
+		b, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s failed: %s: %s", strings.Join(arg, " "), err, b)
+		}
+		return strings.TrimSpace(string(b))
+	}
+	git(remote, "init", ".")
+	git(remote, "commit", "--allow-empty", "-m", "hello")
+	git(remote, "branch", "other")
+	wantCommit := git(remote, "rev-parse", "HEAD")
+
+	// Empty repositories can't be bundled, so they are recloned.
+	emptyRemote, cleanup4 := tmpDir(t)
+	defer cleanup4()
+	git(emptyRemote, "init", ".")
+
+	// The gitservers call each other through the client, which is routed to
+	// their handlers.
+	handlers := map[string]http.Handler{}
+	client := &gitserver.Client{
+		Addrs: func(context.Context) []string {
+			return []string{"gitserver-a:3178", "gitserver-b:3178"}
+		},
+		HTTPClient: httpcli.DoerFunc(func(r *http.Request) (*http.Response, error) {
+			h, ok := handlers[r.URL.Host]
+			if !ok {
+				return nil, fmt.Errorf("unexpected host %s", r.URL.Host)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+			resp := rec.Result()
+			resp.Request = r
+			return resp, nil
+		}),
+	}
+	newServer := func(hostname string) (*Server, func()) {
+		reposDir, cleanup := tmpDir(t)
+		s := &Server{ReposDir: reposDir, Hostname: hostname, peerClient: client}
+		handlers[hostname+":3178"] = s.Handler()
+		return s, cleanup
+	}
+	a, cleanup2 := newServer("gitserver-a")
+	defer cleanup2()
+	b, cleanup3 := newServer("gitserver-b")
+	defer cleanup3()
+
+	// Find two repositories that are assigned to gitserver-b, and clone them
+	// on gitserver-a (as if they had been assigned to gitserver-a before).
+	var repos []api.RepoName
+	for i := 0; i < 100 && len(repos) < 2; i++ {
+		if name := api.RepoName(fmt.Sprintf("example.com/foo/repo%d", i)); client.AddrForRepo(context.Background(), name) == "gitserver-b:3178" {
+			repos = append(repos, name)
+		}
+	}
+	repo, emptyRepo := repos[0], repos[1]
+	if _, err := a.cloneRepo(context.Background(), repo, remote, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.cloneRepo(context.Background(), emptyRepo, emptyRemote, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	a.cleanupRepos()
+	for i := 0; i < 1000 && atomic.LoadInt32(&a.transferringRepos) == 1; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := os.Stat(string(a.dir(repo))); !os.IsNotExist(err) {
+		t.Error("expected repository to be removed from the gitserver it was assigned to before")
+	}
+	dir := b.dir(repo)
+	if !repoCloned(dir) {
+		t.Fatal("expected repository to be transferred")
+	}
+	if got := git(string(dir), "rev-parse", "HEAD"); got != wantCommit {
+		t.Errorf("got HEAD %s, want %s", got, wantCommit)
+	}
+	if got := git(string(dir), "rev-parse", "other"); got != wantCommit {
+		t.Errorf("got branch other %s, want %s", got, wantCommit)
+	}
+	if got, err := repoRemoteURL(context.Background(), dir); err != nil {
+		t.Fatal(err)
+	} else if got != remote {
+		t.Errorf("got remote URL %q, want %q", got, remote)
+	}
+	if b.transferProgress(repo) != "" || a.transferProgress(repo) != "" {
+		t.Error("expected transfer progress to be cleared")
+	}
+
+	if _, err := os.Stat(string(a.dir(emptyRepo))); !os.IsNotExist(err) {
+		t.Error("expected empty repository to be removed from the gitserver it was assigned to before")
+	}
+	if !repoCloned(b.dir(emptyRepo)) {
+		t.Fatal("expected empty repository to be transferred")
+	}
+	if got, err := repoRemoteURL(context.Background(), b.dir(emptyRepo)); err != nil {
+		t.Fatal(err)
+	} else if got != emptyRemote {
+		t.Errorf("got remote URL %q, want %q", got, emptyRemote)
+	}
+}
@@ -19,11 +19,12 @@ import (
 // It returns maps whose keys represent the old value and value represent the
 // new value, i.e. a map of the saved query in the oldList and what its new
 // value is in the newList for each respective category. For deleted, the new
-// value will be an empty struct.
-func diffSavedQueryConfigs(oldList, newList map[api.SavedQueryIDSpec]api.ConfigSavedQuery) (deleted, updated, created map[api.SavedQuerySpecAndConfig]api.SavedQuerySpecAndConfig) {
-	deleted = map[api.SavedQuerySpecAndConfig]api.SavedQuerySpecAndConfig{}
-	updated = map[api.SavedQuerySpecAndConfig]api.SavedQuerySpecAndConfig{}
-	created = map[api.SavedQuerySpecAndConfig]api.SavedQuerySpecAndConfig{}
+// value will be an empty struct. The keys are pointers because
+// api.SavedQuerySpecAndConfig is not comparable.
+func diffSavedQueryConfigs(oldList, newList map[api.SavedQueryIDSpec]api.ConfigSavedQuery) (deleted, updated, created map[*api.SavedQuerySpecAndConfig]api.SavedQuerySpecAndConfig) {
+	deleted = map[*api.SavedQuerySpecAndConfig]api.SavedQuerySpecAndConfig{}
+	updated = map[*api.SavedQuerySpecAndConfig]api.SavedQuerySpecAndConfig{}
+	created = map[*api.SavedQuerySpecAndConfig]api.SavedQuerySpecAndConfig{}
 
 	// Because the api.SavedqueryIDSpec contains pointers, we should use its
 	// unique string key.
@@ -42,20 +43,21 @@ func diffSavedQueryConfigs(oldList, newList map[api.SavedQueryIDSpec]api.ConfigS
 	// Detect deleted entries
 	for k, oldVal := range oldByKey {
 		if _, ok := newByKey[k]; !ok {
-			deleted[oldVal] = api.SavedQuerySpecAndConfig{}
+			oldVal := oldVal
+			deleted[&oldVal] = api.SavedQuerySpecAndConfig{}
 		}
 	}
 
 	for k, newVal := range newByKey {
 		// Detect created entries
 		if oldVal, ok := oldByKey[k]; !ok {
-			created[oldVal] = newVal
+			created[&oldVal] = newVal
 			continue
 		}
 		// Detect updated entries
 		oldVal := oldByKey[k]
 		if ok := reflect.DeepEqual(newVal, oldVal); !ok {
-			updated[oldVal] = newVal
+			updated[&oldVal] = newVal
 		}
 	}
 	return deleted, updated, created
@@ -67,7 +69,7 @@ func sendNotificationsForCreatedOrUpdatedOrDeleted(oldList, newList map[api.Save
 		oldVal := oldVal
 		newVal := newVal
 		go func() {
-			if err := notifySavedQueryWasCreatedOrUpdated(oldVal, newVal); err != nil {
+			if err := notifySavedQueryWasCreatedOrUpdated(*oldVal, newVal); err != nil {
 				log15.Error("Failed to handle deleted saved search.", "query", oldVal.Config.Query, "error", err)
 			}
 		}()
@@ -76,7 +78,7 @@ func sendNotificationsForCreatedOrUpdatedOrDeleted(oldList, newList map[api.Save
 		oldVal := oldVal
 		newVal := newVal
 		go func() {
-			if err := notifySavedQueryWasCreatedOrUpdated(oldVal, newVal); err != nil {
+			if err := notifySavedQueryWasCreatedOrUpdated(*oldVal, newVal); err != nil {
 				log15.Error("Failed to handle created saved search.", "query", oldVal.Config.Query, "error", err)
 			}
 		}()
@@ -85,7 +87,7 @@ func sendNotificationsForCreatedOrUpdatedOrDeleted(oldList, newList map[api.Save
 		oldVal := oldVal
 		newVal := newVal
 		go func() {
-			if err := notifySavedQueryWasCreatedOrUpdated(oldVal, newVal); err != nil {
+			if err := notifySavedQueryWasCreatedOrUpdated(*oldVal, newVal); err != nil {
 				log15.Error("Failed to handle updated saved search.", "query", oldVal.Config.Query, "error", err)
 			}
 		}()
@@ -164,5 +166,10 @@ func serveTestNotification(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := webhookNotifyTest(r.Context(), args.SavedSearch.Config); err != nil {
+		writeError(w, fmt.Errorf("error sending webhook notifications: %s", err))
+		return
+	}
+
 	log15.Info("saved query test notification sent", "spec", args.SavedSearch.Spec, "key", args.SavedSearch.Spec.Key)
 }
@@ -53,6 +53,8 @@ func main() {
 
 	http.HandleFunc(queryrunnerapi.PathTestNotification, serveTestNotification)
 
+	startWebhookWorkers(ctx)
+
 	go func() {
 		err := executor.run(ctx)
 		if err != nil {
@@ -138,7 +140,7 @@ func (e *executorT) run(ctx context.Context) error {
 // runQuery runs the given query if an appropriate amount of time has elapsed
 // since it last ran.
 func (e *executorT) runQuery(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery) error {
-	if !query.Notify && !query.NotifySlack {
+	if !query.Notify && !query.NotifySlack && len(query.WebhookURLs) == 0 {
 		// No need to run this query because there will be nobody to notify.
 		return nil
 	}
@@ -297,9 +299,10 @@ func notify(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSave
 		recipients: recipients,
 	}
 
-	// Send Slack and email notifications.
+	// Send Slack, email and webhook notifications.
 	n.slackNotify(ctx)
 	n.emailNotify(ctx)
+	n.webhookNotify()
 	return nil
 }
 
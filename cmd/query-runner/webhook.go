@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+const utmSourceWebhook = "saved-search-webhook"
+
+// Webhook events, as sent in the X-Sourcegraph-Event header and the event field
+// of the payload.
+const (
+	webhookEventNewResults = "saved_search.new_results"
+	webhookEventTest       = "saved_search.test"
+)
+
+const (
+	webhookEventHeader     = "X-Sourcegraph-Event"
+	webhookSignatureHeader = "X-Sourcegraph-Signature"
+)
+
+// webhookPayload is the JSON payload POSTed to the webhook URLs of a saved
+// search.
+type webhookPayload struct {
+	Event                  string `json:"event"`
+	Description            string `json:"description"`
+	Query                  string `json:"query"`
+	ResultCount            int    `json:"resultCount"`
+	ApproximateResultCount string `json:"approximateResultCount,omitempty"`
+	ResultsURL             string `json:"resultsURL"`
+}
+
+// webhookNotify queues the delivery of the new results to the webhook URLs of
+// the saved query. It doesn't block: deliveries are dropped if the queue is
+// full.
+func (n *notifier) webhookNotify() {
+	if len(n.query.WebhookURLs) == 0 {
+		return
+	}
+
+	payload := &webhookPayload{
+		Event:                  webhookEventNewResults,
+		Description:            n.query.Description,
+		Query:                  n.query.Query,
+		ResultCount:            len(n.results.Data.Search.Results.Results),
+		ApproximateResultCount: n.results.Data.Search.Results.ApproximateResultCount,
+		ResultsURL:             searchURL(n.newQuery, utmSourceWebhook),
+	}
+	for _, url := range n.query.WebhookURLs {
+		d := &webhookDelivery{
+			url:         url,
+			secret:      n.query.WebhookSecret,
+			description: n.query.Description,
+			payload:     payload,
+		}
+		select {
+		case webhookQueue <- d:
+		default:
+			log15.Error("Dropping saved search webhook, too many deliveries are pending.", "url", url, "description", n.query.Description)
+			logEvent(0, "SavedSearchWebhookNotificationFailed", "results")
+		}
+	}
+}
+
+// webhookDelivery is a webhook payload waiting to be delivered.
+type webhookDelivery struct {
+	url         string
+	secret      *string
+	description string // of the saved query, for logging
+	payload     *webhookPayload
+}
+
+// webhookWorkers is the number of webhooks delivered concurrently.
+const webhookWorkers = 4
+
+// webhookQueue holds the webhook deliveries waiting for a worker. Saved
+// queries are run one after another, so webhooks are delivered in the
+// background: a slow or unreachable webhook URL would otherwise delay all
+// saved queries while its delivery is retried.
+var webhookQueue = make(chan *webhookDelivery, 1000)
+
+// startWebhookWorkers starts the goroutines delivering the webhooks in
+// webhookQueue. They stop when ctx is done.
+func startWebhookWorkers(ctx context.Context) {
+	for i := 0; i < webhookWorkers; i++ {
+		go runWebhookWorker(ctx)
+	}
+}
+
+// runWebhookWorker delivers the webhooks in webhookQueue until ctx is done.
+func runWebhookWorker(ctx context.Context) {
+	for {
+		select {
+		case d := <-webhookQueue:
+			deliverWebhook(ctx, d)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func deliverWebhook(ctx context.Context, d *webhookDelivery) {
+	if err := webhookNotify(ctx, d.url, d.secret, d.payload); err != nil {
+		log15.Error("Failed to deliver saved search webhook.", "url", d.url, "description", d.description, "error", err)
+		logEvent(0, "SavedSearchWebhookNotificationFailed", "results")
+		return
+	}
+	logEvent(0, "SavedSearchWebhookNotificationSent", "results")
+}
+
+func webhookNotifyTest(ctx context.Context, query api.ConfigSavedQuery) error {
+	payload := &webhookPayload{
+		Event:       webhookEventTest,
+		Description: query.Description,
+		Query:       query.Query,
+		ResultsURL:  searchURL(query.Query, utmSourceWebhook),
+	}
+	for _, url := range query.WebhookURLs {
+		if err := webhookNotify(ctx, url, query.WebhookSecret, payload); err != nil {
+			logEvent(0, "SavedSearchWebhookNotificationFailed", "test")
+			return errors.Wrapf(err, "webhook %s", url)
+		}
+		logEvent(0, "SavedSearchWebhookNotificationSent", "test")
+	}
+	return nil
+}
+
+// webhookMaxAttempts is the number of times delivery of a webhook payload is
+// attempted before giving up.
+const webhookMaxAttempts = 5
+
+// webhookBackoff returns how long to wait before the given (1-based) retry.
+var webhookBackoff = func(retry int) time.Duration {
+	return time.Second << uint(retry-1)
+}
+
+// webhookDoer sends webhook requests. It is replaced in tests.
+//
+// 🚨 SECURITY: Webhook URLs are set by users, so webhooks must not be able to
+// reach the services of the cluster. The dialer refuses non-public addresses
+// after DNS resolution, which covers redirects and DNS names that resolve to
+// internal addresses as well. Proxies from the environment are not used,
+// since requests through them would not be checked.
+var webhookDoer httpcli.Doer = &http.Client{
+	Timeout: 30 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 30 * time.Second,
+			Control: webhookDialControl,
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+}
+
+// webhookDeniedError is returned when a webhook URL resolves to an address
+// that webhooks may not be sent to.
+type webhookDeniedError struct {
+	addr string
+}
+
+func (e *webhookDeniedError) Error() string {
+	return fmt.Sprintf("webhooks may not be sent to the non-public address %s", e.addr)
+}
+
+// webhookDeniedNets are the networks webhooks may not be sent to: private,
+// shared, loopback, link-local (including cloud metadata endpoints),
+// unspecified and multicast addresses.
+var webhookDeniedNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"224.0.0.0/4",
+		"::/128",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+		"ff00::/8",
+	} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// webhookDialControl refuses connections to the addresses in
+// webhookDeniedNets. It is called with the resolved address of every
+// connection.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return &webhookDeniedError{addr: host}
+	}
+	for _, n := range webhookDeniedNets {
+		if n.Contains(ip) {
+			return &webhookDeniedError{addr: host}
+		}
+	}
+	return nil
+}
+
+// webhookNotify POSTs the payload to the given webhook URL. If secret is set,
+// the payload is signed with it (see signWebhookPayload). Delivery is retried
+// with exponential backoff on network errors and on 429 and 5xx responses.
+func webhookNotify(ctx context.Context, url string, secret *string, payload *webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		retry, err := postWebhook(ctx, url, secret, payload.Event, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == webhookMaxAttempts {
+			return errors.Wrapf(err, "giving up after %d attempt(s)", attempt)
+		}
+
+		log15.Warn("Retrying saved search webhook delivery.", "url", url, "attempt", attempt, "error", err)
+		select {
+		case <-time.After(webhookBackoff(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// postWebhook makes a single delivery attempt and reports whether a failed
+// attempt may be retried.
+func postWebhook(ctx context.Context, url string, secret *string, event string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Sourcegraph-Saved-Search-Webhook")
+	req.Header.Set(webhookEventHeader, event)
+	if secret != nil && *secret != "" {
+		req.Header.Set(webhookSignatureHeader, signWebhookPayload(*secret, body))
+	}
+
+	resp, err := webhookDoer.Do(req)
+	if err != nil {
+		var denied *webhookDeniedError
+		if errors.As(err, &denied) {
+			return false, denied
+		}
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+}
+
+// signWebhookPayload returns the value of the signature header for the given
+// payload: "sha256=" followed by the hex-encoded HMAC-SHA256 of the payload
+// keyed with the secret.
+func signWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
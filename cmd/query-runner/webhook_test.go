@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+func TestWebhookNotify(t *testing.T) {
+	defer func(orig func(int) time.Duration) { webhookBackoff = orig }(webhookBackoff)
+	webhookBackoff = func(int) time.Duration { return 0 }
+
+	// The test servers listen on loopback addresses, which webhookDoer
+	// refuses (see TestWebhookNotify_denied).
+	defer func(orig httpcli.Doer) { webhookDoer = orig }(webhookDoer)
+	webhookDoer = http.DefaultClient
+
+	payload := &webhookPayload{
+		Event:       webhookEventNewResults,
+		Description: "test query",
+		Query:       "test type:diff",
+		ResultCount: 3,
+		ResultsURL:  "https://sourcegraph.example.com/search?q=test",
+	}
+
+	t.Run("signed", func(t *testing.T) {
+		secret := "s3cr3t"
+		var got *http.Request
+		var body []byte
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r
+			body, _ = ioutil.ReadAll(r.Body)
+		}))
+		defer ts.Close()
+
+		if err := webhookNotify(context.Background(), ts.URL, &secret, payload); err != nil {
+			t.Fatal(err)
+		}
+		if have, want := got.Header.Get(webhookEventHeader), webhookEventNewResults; have != want {
+			t.Errorf("event header: have %q, want %q", have, want)
+		}
+		if have, want := got.Header.Get(webhookSignatureHeader), signWebhookPayload(secret, body); have != want {
+			t.Errorf("signature header: have %q, want %q", have, want)
+		}
+		var p webhookPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Fatal(err)
+		}
+		if p != *payload {
+			t.Errorf("payload: have %+v, want %+v", p, *payload)
+		}
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if sig := r.Header.Get(webhookSignatureHeader); sig != "" {
+				t.Errorf("unexpected signature header %q", sig)
+			}
+		}))
+		defer ts.Close()
+
+		if err := webhookNotify(context.Background(), ts.URL, nil, payload); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for _, tc := range []struct {
+		name     string
+		statuses []int
+		attempts int
+		wantErr  bool
+	}{
+		{name: "retry on 5xx", statuses: []int{500, 503, 200}, attempts: 3},
+		{name: "retry on 429", statuses: []int{429, 200}, attempts: 2},
+		{name: "no retry on 4xx", statuses: []int{400}, attempts: 1, wantErr: true},
+		{name: "give up", statuses: []int{500, 500, 500, 500, 500}, attempts: webhookMaxAttempts, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statuses[attempts])
+				attempts++
+			}))
+			defer ts.Close()
+
+			err := webhookNotify(context.Background(), ts.URL, nil, payload)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("have error %v, want error %v", err, tc.wantErr)
+			}
+			if attempts != tc.attempts {
+				t.Errorf("have %d attempts, want %d", attempts, tc.attempts)
+			}
+		})
+	}
+}
+
+// 🚨 SECURITY: This tests that webhooks can't be sent to the services of the cluster.
+func TestNotifierWebhookNotify(t *testing.T) {
+	defer func(orig func(int) time.Duration) { webhookBackoff = orig }(webhookBackoff)
+	webhookBackoff = func(int) time.Duration { return 0 }
+	defer func(orig httpcli.Doer) { webhookDoer = orig }(webhookDoer)
+	webhookDoer = http.DefaultClient
+	defer func(orig chan *webhookDelivery) { webhookQueue = orig }(webhookQueue)
+	webhookQueue = make(chan *webhookDelivery, 1)
+
+	delivered := make(chan string, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p webhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Error(err)
+		}
+		delivered <- r.URL.Path
+	}))
+	defer ts.Close()
+
+	n := &notifier{
+		query: api.ConfigSavedQuery{
+			Description: "test query",
+			Query:       "test",
+			WebhookURLs: []string{ts.URL + "/a", ts.URL + "/b"},
+		},
+		newQuery: "test",
+		results:  &gqlSearchResponse{},
+	}
+
+	// No worker is running yet, so the second delivery doesn't fit in the
+	// queue and is dropped instead of blocking.
+	n.webhookNotify()
+	if have, want := len(webhookQueue), 1; have != want {
+		t.Fatalf("queued deliveries: have %d, want %d", have, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		runWebhookWorker(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case path := <-delivered:
+		if path != "/a" {
+			t.Errorf("delivered to %q, want %q", path, "/a")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	select {
+	case path := <-delivered:
+		t.Errorf("unexpected delivery to %q", path)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWebhookNotify_denied(t *testing.T) {
+	defer func(orig func(int) time.Duration) { webhookBackoff = orig }(webhookBackoff)
+	webhookBackoff = func(int) time.Duration { return 0 }
+
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+	}))
+	defer ts.Close()
+
+	// Redirects to internal services are refused as well.
+	redirect := httptest.NewServer(http.RedirectHandler(ts.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	for _, url := range []string{ts.URL, redirect.URL, "http://localhost:" + strings.TrimPrefix(ts.URL, "http://127.0.0.1:")} {
+		err := webhookNotify(context.Background(), url, nil, &webhookPayload{Event: webhookEventTest})
+		var denied *webhookDeniedError
+		if !errors.As(err, &denied) {
+			t.Errorf("%s: have error %v, want webhookDeniedError", url, err)
+		}
+	}
+	if attempts != 0 {
+		t.Errorf("have %d requests to the internal service, want 0", attempts)
+	}
+}
+
+func TestWebhookDialControl(t *testing.T) {
+	for _, tc := range []struct {
+		address string
+		denied  bool
+	}{
+		{address: "93.184.216.34:443"},
+		{address: "[2606:2800:220:1:248:1893:25c8:1946]:443"},
+		{address: "127.0.0.1:80", denied: true},
+		{address: "10.1.2.3:3178", denied: true},
+		{address: "172.20.0.1:80", denied: true},
+		{address: "192.168.1.1:80", denied: true},
+		{address: "169.254.169.254:80", denied: true},
+		{address: "0.0.0.0:80", denied: true},
+		{address: "[::1]:80", denied: true},
+		{address: "[::ffff:127.0.0.1]:80", denied: true},
+		{address: "[fd00::1]:80", denied: true},
+		{address: "[fe80::1]:80", denied: true},
+	} {
+		err := webhookDialControl("tcp", tc.address, nil)
+		if (err != nil) != tc.denied {
+			t.Errorf("%s: have error %v, want denied %v", tc.address, err, tc.denied)
+		}
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// Expected value computed with:
+	// printf '{"event":"saved_search.test"}' | openssl dgst -sha256 -hmac secret
+	have := signWebhookPayload("secret", []byte(`{"event":"saved_search.test"}`))
+	want := "sha256=792071ed90edd15602ec28e0b0c01a530e59667b6c9fb973a0909c488a8f968f"
+	if have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
@@ -143,6 +143,27 @@ func (s BitbucketServerSource) CloseChangeset(ctx context.Context, c *Changeset)
 	return nil
 }
 
+// MergeChangeset merges the given *Changeset on the code host and updates the
+// Metadata column in the *campaigns.Changeset to the newly merged pull request.
+func (s BitbucketServerSource) MergeChangeset(ctx context.Context, c *Changeset) error {
+	pr, ok := c.Changeset.Metadata.(*bitbucketserver.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a Bitbucket Server pull request")
+	}
+
+	err := s.client.MergePullRequest(ctx, pr)
+	if err != nil {
+		return err
+	}
+
+	if err := s.loadPullRequestData(ctx, pr); err != nil {
+		return errors.Wrap(err, "loading pull request data")
+	}
+	c.Changeset.Metadata = pr
+
+	return nil
+}
+
 // LoadChangesets loads the latest state of the given Changesets from the codehost.
 func (s BitbucketServerSource) LoadChangesets(ctx context.Context, cs ...*Changeset) error {
 	var notFound []*Changeset
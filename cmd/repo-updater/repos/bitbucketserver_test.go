@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/inconshreveable/log15"
 	"github.com/sourcegraph/sourcegraph/internal/campaigns"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
@@ -422,6 +425,104 @@ func TestBitbucketServerSource_CloseChangeset(t *testing.T) {
 	}
 }
 
+func TestBitbucketServerSource_MergeChangeset(t *testing.T) {
+	// A stub Bitbucket Server API serving the pull request 60 of
+	// SOUR/automation-testing at version 2. Merging it at any other version
+	// fails like merging a pull request that changed in the meantime.
+	const (
+		prPath    = "/rest/api/1.0/projects/SOUR/repos/automation-testing/pull-requests/60"
+		staleBody = `{"errors":[{"message":"You are attempting to modify a pull request based on out-of-date information.","currentVersion":2,"expectedVersion":1}]}`
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == prPath+"/merge":
+			if r.URL.Query().Get("version") != "2" {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, staleBody)
+				return
+			}
+			fmt.Fprint(w, `{"id":60,"version":3,"state":"MERGED","toRef":{"id":"refs/heads/master","repository":{"slug":"automation-testing","project":{"key":"SOUR"}}}}`)
+		case r.Method == "GET" && r.URL.Path == prPath+"/activities":
+			fmt.Fprint(w, `{"values":[{"id":1,"action":"MERGED"}],"isLastPage":true}`)
+		case r.Method == "GET" && r.URL.Path == prPath+"/commits":
+			fmt.Fprint(w, `{"values":[{"id":"head-sha","committerTimestamp":1586250000000}],"isLastPage":true}`)
+		case r.Method == "GET" && r.URL.Path == "/rest/build-status/1.0/commits/head-sha":
+			fmt.Fprint(w, `{"values":[{"state":"SUCCESSFUL","key":"ci"}],"isLastPage":true}`)
+		default:
+			http.Error(w, "Not found: "+r.URL.Path, http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	newPR := func(version int) *bitbucketserver.PullRequest {
+		pr := &bitbucketserver.PullRequest{ID: 60, Version: version, State: "OPEN"}
+		pr.ToRef.Repository.Slug = "automation-testing"
+		pr.ToRef.Repository.Project.Key = "SOUR"
+		return pr
+	}
+
+	merged := newPR(3)
+	merged.State = "MERGED"
+	merged.ToRef.ID = "refs/heads/master"
+	merged.Activities = []*bitbucketserver.Activity{{ID: 1, Action: bitbucketserver.MergedActivityAction}}
+	merged.Commits = []*bitbucketserver.Commit{{ID: "head-sha", CommitterTimestamp: 1586250000000}}
+	merged.CommitStatus = []*bitbucketserver.CommitStatus{{Commit: "head-sha", Status: bitbucketserver.BuildStatus{State: "SUCCESSFUL", Key: "ci"}}}
+
+	testCases := []struct {
+		name string
+		pr   *bitbucketserver.PullRequest
+		want *bitbucketserver.PullRequest
+		err  string
+	}{
+		{
+			name: "success",
+			pr:   newPR(2),
+			want: merged,
+		},
+		{
+			name: "stale version",
+			pr:   newPR(1),
+			err:  fmt.Sprintf("Bitbucket API HTTP error: code=409 url=%q body=%q", srv.URL+prPath+"/merge?version=1", staleBody),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &ExternalService{
+				Kind: "BITBUCKETSERVER",
+				Config: marshalJSON(t, &schema.BitbucketServerConnection{
+					Url:   srv.URL,
+					Token: "secret",
+				}),
+			}
+
+			bbsSrc, err := NewBitbucketServerSource(svc, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.err == "" {
+				tc.err = "<nil>"
+			}
+
+			cs := &Changeset{Changeset: &campaigns.Changeset{Metadata: tc.pr}}
+			err = bbsSrc.MergeChangeset(context.Background(), cs)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, cs.Changeset.Metadata); diff != "" {
+				t.Errorf("pull request mismatch (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestBitbucketServerSource_UpdateChangeset(t *testing.T) {
 	instanceURL := os.Getenv("BITBUCKET_SERVER_URL")
 	if instanceURL == "" {
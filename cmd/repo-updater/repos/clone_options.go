@@ -0,0 +1,51 @@
+package repos
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+// cloneOptionsRule is an entry of the cloneOptions of an external service.
+type cloneOptionsRule struct {
+	urn     string
+	pattern *regexp.Regexp
+	opts    gitserverprotocol.CloneOptions
+}
+
+// setCloneOptions sets the CloneOptions of each of the repos to the first
+// entry of the cloneOptions of the given external services whose pattern
+// matches the repo's name. Only the external services the repo belongs to are
+// considered.
+func setCloneOptions(svcs []*ExternalService, rs ...*Repo) error {
+	var rules []cloneOptionsRule
+	for _, svc := range svcs {
+		cos, err := svc.CloneOptions()
+		if err != nil {
+			return errors.Wrapf(err, "clone options of external service %d", svc.ID)
+		}
+		for _, co := range cos {
+			pattern, err := regexp.Compile(co.Pattern)
+			if err != nil {
+				return errors.Wrapf(err, "clone options of external service %d", svc.ID)
+			}
+			rules = append(rules, cloneOptionsRule{
+				urn:     svc.URN(),
+				pattern: pattern,
+				opts:    gitserverprotocol.CloneOptions{Partial: co.Partial, Depth: co.Depth},
+			})
+		}
+	}
+
+	for _, r := range rs {
+		r.CloneOptions = gitserverprotocol.CloneOptions{}
+		for _, rule := range rules {
+			if _, ok := r.Sources[rule.urn]; ok && rule.pattern.MatchString(r.Name) {
+				r.CloneOptions = rule.opts
+				break
+			}
+		}
+	}
+	return nil
+}
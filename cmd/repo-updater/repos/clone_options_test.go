@@ -0,0 +1,78 @@
+package repos_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+func TestSyncer_Sync_CloneOptions(t *testing.T) {
+	repo := func(name string) *repos.Repo {
+		return &repos.Repo{
+			Name: name,
+			ExternalRepo: api.ExternalRepoSpec{
+				ID:          name,
+				ServiceType: github.ServiceType,
+				ServiceID:   "https://github.com/",
+			},
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		config string
+		want   map[string]gitserverprotocol.CloneOptions
+	}{
+		{
+			name: "no clone options",
+			want: map[string]gitserverprotocol.CloneOptions{},
+		},
+		{
+			name:   "first match applies",
+			config: `{"cloneOptions": [{"pattern": "^mono", "partial": true}, {"pattern": "repo$", "depth": 10}]}`,
+			want: map[string]gitserverprotocol.CloneOptions{
+				"monorepo":  {Partial: true},
+				"otherrepo": {Depth: 10},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			svc := &repos.ExternalService{ID: 1, Kind: "github", Config: tc.config}
+			store := new(repos.FakeStore)
+			if err := store.UpsertExternalServices(ctx, svc); err != nil {
+				t.Fatal(err)
+			}
+
+			syncer := &repos.Syncer{
+				Store:            store,
+				Sourcer:          repos.NewFakeSourcer(nil, repos.NewFakeSource(svc, nil, repo("monorepo"), repo("otherrepo"), repo("small"))),
+				DisableStreaming: true,
+				Synced:           make(chan repos.Diff, 1),
+				Now:              time.Now,
+			}
+			if err := syncer.Sync(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			have := map[string]gitserverprotocol.CloneOptions{}
+			diff := <-syncer.Synced
+			for _, r := range diff.Repos() {
+				if r.CloneOptions != (gitserverprotocol.CloneOptions{}) {
+					have[r.Name] = r.CloneOptions
+				}
+			}
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Errorf("unexpected clone options (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A GerritSource yields repositories from a single Gerrit connection configured
+// in Sourcegraph via the external services configuration.
+type GerritSource struct {
+	svc     *ExternalService
+	config  *schema.GerritConnection
+	baseURL *url.URL
+	exclude excludeFunc
+	client  *gerrit.Client
+}
+
+// NewGerritSource returns a new GerritSource from the given external service.
+func NewGerritSource(svc *ExternalService, cf *httpcli.Factory) (*GerritSource, error) {
+	var c schema.GerritConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, fmt.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newGerritSource(svc, &c, cf)
+}
+
+func newGerritSource(svc *ExternalService, c *schema.GerritConnection, cf *httpcli.Factory) (*GerritSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.NewExternalHTTPClientFactory()
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	var eb excludeBuilder
+	for _, r := range c.Exclude {
+		eb.Exact(r.Name)
+		eb.Pattern(r.Pattern)
+	}
+	exclude, err := eb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	client := gerrit.NewClient(baseURL, cli)
+	client.Username = c.Username
+	client.Password = c.Password
+
+	return &GerritSource{
+		svc:     svc,
+		config:  c,
+		baseURL: baseURL,
+		exclude: exclude,
+		client:  client,
+	}, nil
+}
+
+// ListRepos returns all Gerrit projects accessible to all connections configured
+// in Sourcegraph via the external services configuration.
+func (s GerritSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	if len(s.config.Projects) > 0 {
+		s.listConfiguredProjects(ctx, results)
+		return
+	}
+	s.listAllProjects(ctx, results)
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s GerritSource) ExternalServices() ExternalServices {
+	return ExternalServices{s.svc}
+}
+
+func (s GerritSource) makeRepo(p *gerrit.Project) *Repo {
+	id := p.ID
+	if id == "" {
+		id = url.PathEscape(p.Name)
+	}
+
+	urn := s.svc.URN()
+	return &Repo{
+		Name: string(reposource.GerritRepoName(
+			s.config.RepositoryPathPattern,
+			s.baseURL.Hostname(),
+			p.Name,
+		)),
+		URI: string(reposource.GerritRepoName(
+			"",
+			s.baseURL.Hostname(),
+			p.Name,
+		)),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          id,
+			ServiceType: gerrit.ServiceType,
+			ServiceID:   s.baseURL.String(),
+		},
+		Description: p.Description,
+		Archived:    p.State == gerrit.ProjectStateReadOnly,
+		Sources: map[string]*SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: s.authenticatedRemoteURL(p),
+			},
+		},
+		Metadata: p,
+	}
+}
+
+// authenticatedRemoteURL returns the project's Git remote URL with the configured
+// Gerrit credentials inserted in the URL userinfo.
+func (s *GerritSource) authenticatedRemoteURL(p *gerrit.Project) string {
+	if s.config.GitURLType == "ssh" {
+		port := s.config.SshPort
+		if port == 0 {
+			port = 29418
+		}
+		u := url.URL{
+			Scheme: "ssh",
+			Host:   s.baseURL.Hostname() + ":" + strconv.Itoa(port),
+			Path:   "/" + p.Name,
+		}
+		if s.config.Username != "" {
+			u.User = url.User(s.config.Username)
+		}
+		return u.String()
+	}
+
+	u := *s.baseURL
+	if s.config.Username == "" && s.config.Password == "" {
+		u.Path += p.Name
+		return u.String()
+	}
+
+	// Authenticated Git over HTTP requests must be made to the "/a/" prefixed URL.
+	u.Path += "a/" + p.Name
+	u.User = url.UserPassword(s.config.Username, s.config.Password)
+	return u.String()
+}
+
+func (s *GerritSource) excludes(p *gerrit.Project) bool {
+	return p.State == gerrit.ProjectStateHidden || s.exclude(p.Name)
+}
+
+func (s *GerritSource) listConfiguredProjects(ctx context.Context, results chan SourceResult) {
+	for _, name := range s.config.Projects {
+		p, err := s.client.GetProject(ctx, name)
+		if err != nil {
+			results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gerrit.project: name=%q", name)}
+			continue
+		}
+
+		// The project API doesn't always include the name.
+		if p.Name == "" {
+			p.Name = name
+		}
+
+		if !s.excludes(p) {
+			results <- SourceResult{Source: s, Repo: s.makeRepo(p)}
+		}
+	}
+}
+
+func (s *GerritSource) listAllProjects(ctx context.Context, results chan SourceResult) {
+	args := gerrit.ListProjectsArgs{Limit: 100}
+	for {
+		projects, more, err := s.client.ListProjects(ctx, args)
+		if err != nil {
+			results <- SourceResult{Source: s, Err: errors.Wrapf(err, "gerrit.projects: start=%d", args.Start)}
+			return
+		}
+
+		for _, p := range projects {
+			if !s.excludes(p) {
+				results <- SourceResult{Source: s, Repo: s.makeRepo(p)}
+			}
+		}
+
+		if !more || len(projects) == 0 {
+			return
+		}
+		args.Start += len(projects)
+	}
+}
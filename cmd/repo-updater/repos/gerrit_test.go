@@ -0,0 +1,166 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+)
+
+// newGerritServer returns a stub Gerrit server serving the projects API for the
+// given projects, one project per page.
+func newGerritServer(projects map[string]string) *httptest.Server {
+	names := make([]string, 0, len(projects))
+	for name := range projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.EscapedPath(), "/a")
+
+		if path == "/projects/" {
+			start, _ := strconv.Atoi(r.URL.Query().Get("S"))
+			fmt.Fprint(w, ")]}'\n{")
+			if start < len(names) {
+				name := names[start]
+				fmt.Fprintf(w, "%q: {\"id\": %q, \"state\": %q, \"_more_projects\": %t}", name, strings.ReplaceAll(name, "/", "%2F"), projects[name], start+1 < len(names))
+			}
+			fmt.Fprint(w, "}")
+			return
+		}
+
+		for _, name := range names {
+			if path == "/projects/"+strings.ReplaceAll(name, "/", "%2F") {
+				fmt.Fprintf(w, ")]}'\n{\"id\": %q, \"name\": %q, \"state\": %q}", strings.ReplaceAll(name, "/", "%2F"), name, projects[name])
+				return
+			}
+		}
+		http.Error(w, "Not found: "+path, http.StatusNotFound)
+	}))
+}
+
+func TestGerritSource_ListRepos(t *testing.T) {
+	srv := newGerritServer(map[string]string{
+		"All-Projects":     gerrit.ProjectStateActive,
+		"device/common":    gerrit.ProjectStateReadOnly,
+		"platform/build":   gerrit.ProjectStateActive,
+		"platform/secrets": gerrit.ProjectStateHidden,
+	})
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	host := u.Hostname()
+
+	for _, tc := range []struct {
+		name   string
+		config string
+		repos  map[string]string // name -> clone URL
+		err    string
+	}{
+		{
+			name:   "all projects",
+			config: `{"url": %q}`,
+			repos: map[string]string{
+				host + "/All-Projects":   srv.URL + "/All-Projects",
+				host + "/device/common":  srv.URL + "/device/common",
+				host + "/platform/build": srv.URL + "/platform/build",
+			},
+		},
+		{
+			name:   "excluded",
+			config: `{"url": %q, "exclude": [{"name": "All-Projects"}, {"pattern": "^device/"}]}`,
+			repos: map[string]string{
+				host + "/platform/build": srv.URL + "/platform/build",
+			},
+		},
+		{
+			name:   "configured projects",
+			config: `{"url": %q, "username": "alice", "password": "secret", "repositoryPathPattern": "gerrit/{name}", "projects": ["platform/build", "missing"]}`,
+			repos: map[string]string{
+				"gerrit/platform/build": "http://alice:secret@" + u.Host + "/a/platform/build",
+			},
+			err: `gerrit.project: name="missing"`,
+		},
+		{
+			name:   "ssh",
+			config: `{"url": %q, "username": "alice", "gitURLType": "ssh", "projects": ["platform/build"]}`,
+			repos: map[string]string{
+				host + "/platform/build": "ssh://alice@127.0.0.1:29418/platform/build",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &ExternalService{
+				ID:     1,
+				Kind:   "GERRIT",
+				Config: fmt.Sprintf(tc.config, srv.URL),
+			}
+
+			src, err := NewGerritSource(svc, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rs, err := listAll(context.Background(), src)
+			if have := fmt.Sprint(err); !strings.Contains(have, tc.err) || (tc.err == "" && err != nil) {
+				t.Fatalf("unexpected error: have %q, want %q", have, tc.err)
+			}
+
+			have := make(map[string]string, len(rs))
+			for _, r := range rs {
+				have[r.Name] = r.Sources[svc.URN()].CloneURL
+			}
+			if diff := cmp.Diff(tc.repos, have); diff != "" {
+				t.Errorf("repos mismatch (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGerritSource_makeRepo(t *testing.T) {
+	svc := &ExternalService{ID: 1, Kind: "GERRIT", Config: `{"url": "https://gerrit.example.com"}`}
+	src, err := NewGerritSource(svc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &gerrit.Project{
+		ID:          "device%2Fcommon",
+		Name:        "device/common",
+		Description: "Common device configuration",
+		State:       gerrit.ProjectStateReadOnly,
+	}
+
+	want := &Repo{
+		Name:        "gerrit.example.com/device/common",
+		URI:         "gerrit.example.com/device/common",
+		Description: "Common device configuration",
+		Archived:    true,
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          "device%2Fcommon",
+			ServiceType: gerrit.ServiceType,
+			ServiceID:   "https://gerrit.example.com/",
+		},
+		Sources: map[string]*SourceInfo{
+			"extsvc:gerrit:1": {
+				ID:       "extsvc:gerrit:1",
+				CloneURL: "https://gerrit.example.com/device/common",
+			},
+		},
+		Metadata: p,
+	}
+
+	if diff := cmp.Diff(want, src.makeRepo(p)); diff != "" {
+		t.Errorf("repo mismatch (-want +have):\n%s", diff)
+	}
+}
@@ -206,6 +206,24 @@ func (s GithubSource) CloseChangeset(ctx context.Context, c *Changeset) error {
 	return nil
 }
 
+// MergeChangeset merges the given *Changeset on the code host and updates the
+// Metadata column in the *campaigns.Changeset to the newly merged pull request.
+func (s GithubSource) MergeChangeset(ctx context.Context, c *Changeset) error {
+	pr, ok := c.Changeset.Metadata.(*github.PullRequest)
+	if !ok {
+		return errors.New("Changeset is not a GitHub pull request")
+	}
+
+	err := s.client.MergePullRequest(ctx, pr)
+	if err != nil {
+		return err
+	}
+
+	c.Changeset.Metadata = pr
+
+	return nil
+}
+
 // LoadChangesets loads the latest state of the given Changesets from the codehost.
 func (s GithubSource) LoadChangesets(ctx context.Context, cs ...*Changeset) error {
 	prs := make([]*github.PullRequest, len(cs))
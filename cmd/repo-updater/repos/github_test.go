@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -270,6 +271,95 @@ func TestGithubSource_UpdateChangeset(t *testing.T) {
 	}
 }
 
+func TestGithubSource_MergeChangeset(t *testing.T) {
+	// A stub GitHub Enterprise GraphQL API that merges the pull request
+	// "PR_mergeable" and refuses to merge any other.
+	type mergeInput struct {
+		ID              string `json:"pullRequestId"`
+		ExpectedHeadOid string `json:"expectedHeadOid"`
+	}
+	var got mergeInput
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/graphql" {
+			http.Error(w, "Not found: "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		var req struct {
+			Variables struct {
+				Input mergeInput `json:"input"`
+			} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		got = req.Variables.Input
+		if got.ID != "PR_mergeable" {
+			fmt.Fprint(w, `{"data":{"mergePullRequest":null},"errors":[{"message":"Pull Request is not mergeable"}]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"mergePullRequest":{"pullRequest":{"id":%q,"state":"MERGED","number":45,"headRefOid":%q,"baseRefName":"master"}}}}`, got.ID, got.ExpectedHeadOid)
+	}))
+	defer srv.Close()
+
+	testCases := []struct {
+		name string
+		pr   *github.PullRequest
+		want *github.PullRequest
+		err  string
+	}{
+		{
+			name: "success",
+			pr:   &github.PullRequest{ID: "PR_mergeable", HeadRefOid: "head-sha", State: "OPEN"},
+			want: &github.PullRequest{ID: "PR_mergeable", HeadRefOid: "head-sha", State: "MERGED", Number: 45, BaseRefName: "master"},
+		},
+		{
+			name: "not mergeable",
+			pr:   &github.PullRequest{ID: "PR_conflicting", HeadRefOid: "head-sha", State: "OPEN"},
+			err:  "error in GraphQL response: Pull Request is not mergeable",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &ExternalService{
+				Kind: "GITHUB",
+				Config: marshalJSON(t, &schema.GitHubConnection{
+					Url:   srv.URL,
+					Token: "secret",
+				}),
+			}
+
+			githubSrc, err := NewGithubSource(svc, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.err == "" {
+				tc.err = "<nil>"
+			}
+
+			cs := &Changeset{Changeset: &campaigns.Changeset{Metadata: tc.pr}}
+			err = githubSrc.MergeChangeset(context.Background(), cs)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+			if want := (mergeInput{ID: tc.pr.ID, ExpectedHeadOid: tc.pr.HeadRefOid}); got != want {
+				t.Errorf("merge input:\nhave: %+v\nwant: %+v", got, want)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, cs.Changeset.Metadata); diff != "" {
+				t.Errorf("pull request mismatch (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestGithubSource_LoadChangesets(t *testing.T) {
 	testCases := []struct {
 		name string
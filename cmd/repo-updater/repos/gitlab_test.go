@@ -7,11 +7,14 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/campaigns"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/rcache"
 	"github.com/sourcegraph/sourcegraph/internal/testutil"
@@ -212,3 +215,219 @@ func TestGitLabSource_makeRepo(t *testing.T) {
 		})
 	}
 }
+
+func TestGitLabSource_ChangesetSource(t *testing.T) {
+	ctx := context.Background()
+	project := &gitlab.Project{ProjectCommon: gitlab.ProjectCommon{ID: 1}}
+
+	newSource := func(t *testing.T) *GitLabSource {
+		t.Helper()
+
+		src, err := NewGitLabSource(&ExternalService{
+			Kind:   "GITLAB",
+			Config: marshalJSON(t, &schema.GitLabConnection{Url: "https://gitlab.com"}),
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return src
+	}
+
+	newChangeset := func(mr *gitlab.MergeRequest) *Changeset {
+		c := &Changeset{
+			Title:     "title",
+			Body:      "body",
+			HeadRef:   "refs/heads/head",
+			BaseRef:   "refs/heads/base",
+			Changeset: &campaigns.Changeset{},
+			Repo:      &Repo{Metadata: project},
+		}
+		if mr != nil {
+			c.Changeset.Metadata = mr
+			c.Changeset.ExternalID = strconv.Itoa(mr.IID)
+		}
+		return c
+	}
+
+	mockLoadData := func() {
+		gitlab.MockLoadMergeRequestNotes = func(c *gitlab.Client, ctx context.Context, p *gitlab.Project, mr *gitlab.MergeRequest) error {
+			mr.Notes = []*gitlab.Note{{ID: 1, Body: "approved this merge request", System: true}}
+			return nil
+		}
+		gitlab.MockLoadMergeRequestPipelines = func(c *gitlab.Client, ctx context.Context, p *gitlab.Project, mr *gitlab.MergeRequest) error {
+			mr.Pipelines = []*gitlab.Pipeline{{ID: 2, Status: gitlab.PipelineStatusSuccess}}
+			return nil
+		}
+	}
+
+	resetMocks := func() {
+		gitlab.MockCreateMergeRequest = nil
+		gitlab.MockGetMergeRequest = nil
+		gitlab.MockGetOpenMergeRequestByRefs = nil
+		gitlab.MockUpdateMergeRequest = nil
+		gitlab.MockAcceptMergeRequest = nil
+		gitlab.MockLoadMergeRequestNotes = nil
+		gitlab.MockLoadMergeRequestPipelines = nil
+	}
+
+	t.Run("CreateChangeset", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			createErr error
+			exists    bool
+		}{
+			"new merge request":      {},
+			"existing merge request": {createErr: gitlab.ErrMergeRequestAlreadyExists, exists: true},
+		} {
+			t.Run(name, func(t *testing.T) {
+				defer resetMocks()
+				mockLoadData()
+
+				mr := &gitlab.MergeRequest{IID: 42, SourceBranch: "head", TargetBranch: "base"}
+				gitlab.MockCreateMergeRequest = func(c *gitlab.Client, ctx context.Context, p *gitlab.Project, opts gitlab.CreateMergeRequestOpts) (*gitlab.MergeRequest, error) {
+					want := gitlab.CreateMergeRequestOpts{
+						SourceBranch: "head",
+						TargetBranch: "base",
+						Title:        "title",
+						Description:  "body",
+					}
+					if diff := cmp.Diff(want, opts); diff != "" {
+						t.Errorf("unexpected options:\n%s", diff)
+					}
+					if tc.createErr != nil {
+						return nil, tc.createErr
+					}
+					return mr, nil
+				}
+				gitlab.MockGetOpenMergeRequestByRefs = func(c *gitlab.Client, ctx context.Context, p *gitlab.Project, source, target string) (*gitlab.MergeRequest, error) {
+					if source != "head" || target != "base" {
+						t.Errorf("unexpected refs: %q -> %q", source, target)
+					}
+					return mr, nil
+				}
+
+				c := newChangeset(nil)
+				exists, err := newSource(t).CreateChangeset(ctx, c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if exists != tc.exists {
+					t.Errorf("exists: have %v, want %v", exists, tc.exists)
+				}
+				if have, want := c.Changeset.ExternalID, "42"; have != want {
+					t.Errorf("ExternalID: have %q, want %q", have, want)
+				}
+				if have, want := c.Changeset.ExternalServiceType, gitlab.ServiceType; have != want {
+					t.Errorf("ExternalServiceType: have %q, want %q", have, want)
+				}
+				if have := c.Changeset.Metadata.(*gitlab.MergeRequest); len(have.Notes) != 1 || len(have.Pipelines) != 1 {
+					t.Errorf("merge request data not loaded: %+v", have)
+				}
+			})
+		}
+	})
+
+	t.Run("CloseChangeset", func(t *testing.T) {
+		defer resetMocks()
+		mockLoadData()
+
+		gitlab.MockUpdateMergeRequest = func(c *gitlab.Client, ctx context.Context, p *gitlab.Project, mr *gitlab.MergeRequest, opts gitlab.UpdateMergeRequestOpts) (*gitlab.MergeRequest, error) {
+			if opts.StateEvent != gitlab.UpdateMergeRequestStateEventClose {
+				t.Errorf("unexpected state event: %q", opts.StateEvent)
+			}
+			return &gitlab.MergeRequest{IID: mr.IID, State: gitlab.MergeRequestStateClosed}, nil
+		}
+
+		c := newChangeset(&gitlab.MergeRequest{IID: 42, State: gitlab.MergeRequestStateOpened})
+		if err := newSource(t).CloseChangeset(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		if have := c.Changeset.Metadata.(*gitlab.MergeRequest).State; have != gitlab.MergeRequestStateClosed {
+			t.Errorf("state: have %q, want %q", have, gitlab.MergeRequestStateClosed)
+		}
+	})
+
+	t.Run("MergeChangeset", func(t *testing.T) {
+		defer resetMocks()
+		mockLoadData()
+
+		gitlab.MockAcceptMergeRequest = func(c *gitlab.Client, ctx context.Context, p *gitlab.Project, mr *gitlab.MergeRequest) (*gitlab.MergeRequest, error) {
+			if mr.SHA != "deadbeef" {
+				t.Errorf("unexpected SHA: %q", mr.SHA)
+			}
+			if mr.IID == 409 {
+				return nil, errors.New("merge request has conflicts")
+			}
+			return &gitlab.MergeRequest{IID: mr.IID, SHA: mr.SHA, State: gitlab.MergeRequestStateMerged}, nil
+		}
+
+		c := newChangeset(&gitlab.MergeRequest{IID: 42, SHA: "deadbeef", State: gitlab.MergeRequestStateOpened})
+		if err := newSource(t).MergeChangeset(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		if have := c.Changeset.Metadata.(*gitlab.MergeRequest).State; have != gitlab.MergeRequestStateMerged {
+			t.Errorf("state: have %q, want %q", have, gitlab.MergeRequestStateMerged)
+		}
+
+		c = newChangeset(&gitlab.MergeRequest{IID: 409, SHA: "deadbeef", State: gitlab.MergeRequestStateOpened})
+		if err := newSource(t).MergeChangeset(ctx, c); err == nil {
+			t.Error("want error for conflicting merge request, have nil")
+		}
+		if have := c.Changeset.Metadata.(*gitlab.MergeRequest).State; have != gitlab.MergeRequestStateOpened {
+			t.Errorf("state: have %q, want %q", have, gitlab.MergeRequestStateOpened)
+		}
+	})
+
+	t.Run("UpdateChangeset", func(t *testing.T) {
+		defer resetMocks()
+		mockLoadData()
+
+		gitlab.MockUpdateMergeRequest = func(c *gitlab.Client, ctx context.Context, p *gitlab.Project, mr *gitlab.MergeRequest, opts gitlab.UpdateMergeRequestOpts) (*gitlab.MergeRequest, error) {
+			want := gitlab.UpdateMergeRequestOpts{
+				Title:        "title",
+				Description:  "body",
+				TargetBranch: "base",
+			}
+			if diff := cmp.Diff(want, opts); diff != "" {
+				t.Errorf("unexpected options:\n%s", diff)
+			}
+			return &gitlab.MergeRequest{IID: mr.IID, Title: opts.Title}, nil
+		}
+
+		c := newChangeset(&gitlab.MergeRequest{IID: 42})
+		if err := newSource(t).UpdateChangeset(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		if have := c.Changeset.Metadata.(*gitlab.MergeRequest).Title; have != "title" {
+			t.Errorf("title: have %q, want %q", have, "title")
+		}
+	})
+
+	t.Run("LoadChangesets", func(t *testing.T) {
+		defer resetMocks()
+		mockLoadData()
+
+		gitlab.MockGetMergeRequest = func(c *gitlab.Client, ctx context.Context, p *gitlab.Project, iid int) (*gitlab.MergeRequest, error) {
+			if iid == 404 {
+				return nil, errors.Wrap(gitlab.ErrNotFound, "not found")
+			}
+			return &gitlab.MergeRequest{IID: iid, Title: "loaded"}, nil
+		}
+
+		found := newChangeset(&gitlab.MergeRequest{IID: 42})
+		missing := newChangeset(&gitlab.MergeRequest{IID: 404})
+
+		err := newSource(t).LoadChangesets(ctx, found, missing)
+		notFound, ok := err.(ChangesetsNotFoundError)
+		if !ok {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(notFound.Changesets) != 1 || notFound.Changesets[0] != missing {
+			t.Errorf("unexpected not found changesets: %+v", notFound.Changesets)
+		}
+
+		mr := found.Changeset.Metadata.(*gitlab.MergeRequest)
+		if mr.Title != "loaded" || len(mr.Notes) != 1 || len(mr.Pipelines) != 1 {
+			t.Errorf("merge request not loaded: %+v", mr)
+		}
+	})
+}
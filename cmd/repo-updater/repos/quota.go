@@ -0,0 +1,177 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A QuotaViolation describes how the repositories of an external service
+// exceeded its configured quota in the last Sync.
+type QuotaViolation struct {
+	ExternalServiceID int64
+	Message           string
+}
+
+// quotaService is an external service that has a quota configured.
+type quotaService struct {
+	svc   *ExternalService
+	quota *schema.ExternalServiceQuota
+}
+
+// quotaServices returns the external services that have a quota configured.
+func quotaServices(svcs []*ExternalService) ([]quotaService, error) {
+	var qs []quotaService
+	for _, svc := range svcs {
+		q, err := svc.Quota()
+		if err != nil {
+			return nil, errors.Wrapf(err, "quota of external service %d", svc.ID)
+		}
+		if q != nil && (q.MaxRepos > 0 || q.MaxTotalCloneSizeMB > 0) {
+			qs = append(qs, quotaService{svc: svc, quota: q})
+		}
+	}
+	return qs, nil
+}
+
+// enforceQuotas modifies the diff so that the repositories of each external
+// service fit into its quota.
+//
+// Repositories beyond maxRepos lose the external service as a source.
+// Repositories that were already stored are kept in favour of newly added
+// ones, so that a growing code host doesn't evict existing repositories.
+//
+// Repositories that have not been cloned yet lose the external service as a
+// source once the total size of its cloned repositories reaches
+// maxTotalCloneSizeMB. Otherwise their CloneSizeLimit is set to the remaining
+// budget.
+//
+// Repositories that end up with no sources are removed from the diff if they
+// were added, or deleted if they were stored. The violations found are
+// returned.
+func (s *Syncer) enforceQuotas(ctx context.Context, qs []quotaService, diff *Diff) ([]QuotaViolation, error) {
+	var violations []QuotaViolation
+	pruned := map[*Repo]bool{}
+	for _, q := range qs {
+		urn := q.svc.URN()
+
+		stored := reposWithSource(append(append(Repos{}, diff.Modified...), diff.Unmodified...), urn)
+		sort.Sort(stored)
+		added := reposWithSource(diff.Added, urn)
+		sort.Slice(added, func(i, j int) bool { return added[i].Name < added[j].Name })
+		rs := append(stored, added...)
+
+		if max := q.quota.MaxRepos; max > 0 && len(rs) > max {
+			for _, r := range rs[max:] {
+				delete(r.Sources, urn)
+				pruned[r] = true
+			}
+			violations = append(violations, QuotaViolation{
+				ExternalServiceID: q.svc.ID,
+				Message:           fmt.Sprintf("%d repositories were not synced because the quota of %d repositories was exceeded", len(rs)-max, max),
+			})
+			rs = rs[:max]
+		}
+
+		if q.quota.MaxTotalCloneSizeMB <= 0 || s.CloneSizes == nil || len(rs) == 0 {
+			continue
+		}
+
+		names := make([]api.RepoName, 0, len(rs))
+		for _, r := range rs {
+			names = append(names, api.RepoName(r.Name))
+		}
+		sizes, err := s.CloneSizes(ctx, names...)
+		if err != nil {
+			return nil, errors.Wrap(err, "clone sizes")
+		}
+
+		max := int64(q.quota.MaxTotalCloneSizeMB) * 1024 * 1024
+		var used int64
+		for _, r := range rs {
+			used += sizes[api.RepoName(r.Name)]
+		}
+
+		var skipped int
+		for _, r := range rs {
+			if _, cloned := sizes[api.RepoName(r.Name)]; cloned {
+				continue
+			}
+			if used >= max {
+				delete(r.Sources, urn)
+				pruned[r] = true
+				skipped++
+				continue
+			}
+			// The limit is best-effort: repositories cloning concurrently
+			// may together exceed the remaining budget. They are accounted
+			// for in the next Sync.
+			if remaining := max - used; r.CloneSizeLimit == 0 || remaining < r.CloneSizeLimit {
+				r.CloneSizeLimit = remaining
+			}
+		}
+
+		if skipped > 0 {
+			violations = append(violations, QuotaViolation{
+				ExternalServiceID: q.svc.ID,
+				Message:           fmt.Sprintf("%d repositories were not cloned because the clone size quota of %d MB was reached", skipped, q.quota.MaxTotalCloneSizeMB),
+			})
+		}
+	}
+
+	if len(pruned) > 0 {
+		removeSourceless(diff, pruned)
+	}
+
+	return violations, nil
+}
+
+// reposWithSource returns the repos that have the given source.
+func reposWithSource(rs Repos, urn string) Repos {
+	var filtered Repos
+	for _, r := range rs {
+		if _, ok := r.Sources[urn]; ok && !r.IsDeleted() {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// removeSourceless removes added repos without sources from the diff and
+// moves stored repos without sources to the deleted repos. Unmodified repos
+// that were pruned of some of their sources are moved to the modified repos.
+func removeSourceless(diff *Diff, pruned map[*Repo]bool) {
+	var added Repos
+	for _, r := range diff.Added {
+		if len(r.Sources) > 0 {
+			added = append(added, r)
+		}
+	}
+	diff.Added = added
+
+	var modified Repos
+	for _, r := range diff.Modified {
+		if len(r.Sources) > 0 {
+			modified = append(modified, r)
+		} else {
+			diff.Deleted = append(diff.Deleted, r)
+		}
+	}
+
+	var unmodified Repos
+	for _, r := range diff.Unmodified {
+		switch {
+		case len(r.Sources) == 0 && !r.IsDeleted():
+			diff.Deleted = append(diff.Deleted, r)
+		case pruned[r]:
+			modified = append(modified, r)
+		default:
+			unmodified = append(unmodified, r)
+		}
+	}
+	diff.Modified, diff.Unmodified = modified, unmodified
+}
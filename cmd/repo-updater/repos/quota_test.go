@@ -0,0 +1,159 @@
+package repos_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+)
+
+func TestSyncer_Sync_Quotas(t *testing.T) {
+	const mb = 1024 * 1024
+
+	repo := func(name string) *repos.Repo {
+		return &repos.Repo{
+			Name: name,
+			ExternalRepo: api.ExternalRepoSpec{
+				ID:          name,
+				ServiceType: github.ServiceType,
+				ServiceID:   "https://github.com/",
+			},
+		}
+	}
+
+	type result struct {
+		Stored     []string
+		Limits     map[string]int64
+		Violations []repos.QuotaViolation
+	}
+
+	for _, tc := range []struct {
+		name   string
+		config string
+		stored []string
+		source []string
+		sizes  map[api.RepoName]int64
+		want   result
+	}{
+		{
+			name:   "no quota",
+			source: []string{"a", "b", "c"},
+			want:   result{Stored: []string{"a", "b", "c"}},
+		},
+		{
+			name:   "max repos",
+			config: `{"quota": {"maxRepos": 2}}`,
+			source: []string{"c", "b", "a"},
+			want: result{
+				Stored: []string{"a", "b"},
+				Violations: []repos.QuotaViolation{{
+					ExternalServiceID: 1,
+					Message:           "1 repositories were not synced because the quota of 2 repositories was exceeded",
+				}},
+			},
+		},
+		{
+			name:   "max repos keeps stored repos",
+			config: `{"quota": {"maxRepos": 1}}`,
+			stored: []string{"z"},
+			source: []string{"a", "z"},
+			want: result{
+				Stored: []string{"z"},
+				Violations: []repos.QuotaViolation{{
+					ExternalServiceID: 1,
+					Message:           "1 repositories were not synced because the quota of 1 repositories was exceeded",
+				}},
+			},
+		},
+		{
+			name:   "max total clone size reached",
+			config: `{"quota": {"maxTotalCloneSizeMB": 1}}`,
+			source: []string{"a", "b"},
+			sizes:  map[api.RepoName]int64{"a": 2 * mb},
+			want: result{
+				Stored: []string{"a"},
+				Violations: []repos.QuotaViolation{{
+					ExternalServiceID: 1,
+					Message:           "1 repositories were not cloned because the clone size quota of 1 MB was reached",
+				}},
+			},
+		},
+		{
+			name:   "max total clone size remaining",
+			config: `{"quota": {"maxTotalCloneSizeMB": 3}}`,
+			source: []string{"a", "b"},
+			sizes:  map[api.RepoName]int64{"a": 1 * mb},
+			want: result{
+				Stored: []string{"a", "b"},
+				Limits: map[string]int64{"b": 2 * mb},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			svc := &repos.ExternalService{ID: 1, Kind: "github", Config: tc.config}
+			store := new(repos.FakeStore)
+			if err := store.UpsertExternalServices(ctx, svc); err != nil {
+				t.Fatal(err)
+			}
+
+			var stored []*repos.Repo
+			for _, name := range tc.stored {
+				stored = append(stored, repo(name).With(repos.Opt.RepoSources(svc.URN())))
+			}
+			if err := store.UpsertRepos(ctx, stored...); err != nil {
+				t.Fatal(err)
+			}
+
+			var sourced []*repos.Repo
+			for _, name := range tc.source {
+				sourced = append(sourced, repo(name))
+			}
+
+			syncer := &repos.Syncer{
+				Store:            store,
+				Sourcer:          repos.NewFakeSourcer(nil, repos.NewFakeSource(svc, nil, sourced...)),
+				DisableStreaming: true,
+				Synced:           make(chan repos.Diff, 1),
+				Now:              time.Now,
+				CloneSizes: func(ctx context.Context, names ...api.RepoName) (map[api.RepoName]int64, error) {
+					return tc.sizes, nil
+				},
+			}
+			if err := syncer.Sync(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			have := result{Violations: syncer.QuotaViolations()}
+			diff := <-syncer.Synced
+			for _, r := range append(append(diff.Added, diff.Modified...), diff.Unmodified...) {
+				if r.CloneSizeLimit != 0 {
+					if have.Limits == nil {
+						have.Limits = map[string]int64{}
+					}
+					have.Limits[r.Name] = r.CloneSizeLimit
+				}
+			}
+
+			rs, err := store.ListRepos(ctx, repos.StoreListReposArgs{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range rs {
+				have.Stored = append(have.Stored, r.Name)
+			}
+			sort.Strings(have.Stored)
+
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Errorf("unexpected result (-want +have):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
+)
+
+// A ScheduleStore persists the update schedule of repos so that the learned
+// update intervals survive restarts of repo-updater.
+type ScheduleStore interface {
+	ListRepoUpdateSchedules(context.Context) ([]*RepoUpdateSchedule, error)
+	UpsertRepoUpdateSchedules(context.Context, ...*RepoUpdateSchedule) error
+	DeleteRepoUpdateSchedules(context.Context, ...api.RepoID) error
+}
+
+// RepoUpdateSchedule is the persisted update schedule of a single repo.
+type RepoUpdateSchedule struct {
+	RepoID      api.RepoID
+	Interval    time.Duration
+	Due         time.Time
+	LastChanged time.Time
+}
+
+// ListRepoUpdateSchedules lists the update schedules of all repos.
+func (s DBStore) ListRepoUpdateSchedules(ctx context.Context) (schedules []*RepoUpdateSchedule, _ error) {
+	return schedules, s.paginate(ctx, 0, 0, listRepoUpdateSchedulesQuery,
+		func(sc scanner) (last, count int64, err error) {
+			var (
+				rs      RepoUpdateSchedule
+				seconds int64
+			)
+			if err = sc.Scan(&rs.RepoID, &seconds, &rs.Due, &dbutil.NullTime{Time: &rs.LastChanged}); err != nil {
+				return 0, 0, err
+			}
+			rs.Interval = time.Duration(seconds) * time.Second
+			schedules = append(schedules, &rs)
+			return int64(rs.RepoID), 1, nil
+		},
+	)
+}
+
+const listRepoUpdateSchedulesQueryFmtstr = `
+-- source: cmd/repo-updater/repos/schedule_store.go:DBStore.ListRepoUpdateSchedules
+SELECT
+  repo_id,
+  interval_seconds,
+  due_at,
+  last_changed_at
+FROM repo_update_schedule
+WHERE repo_id > %s
+ORDER BY repo_id ASC LIMIT %s
+`
+
+func listRepoUpdateSchedulesQuery(cursor, limit int64) *sqlf.Query {
+	return sqlf.Sprintf(listRepoUpdateSchedulesQueryFmtstr, cursor, limit)
+}
+
+// UpsertRepoUpdateSchedules updates or inserts the given repo update schedules.
+// Schedules of repos that don't exist are ignored.
+func (s DBStore) UpsertRepoUpdateSchedules(ctx context.Context, schedules ...*RepoUpdateSchedule) error {
+	if len(schedules) == 0 {
+		return nil
+	}
+
+	type record struct {
+		RepoID          api.RepoID `json:"repo_id"`
+		IntervalSeconds int64      `json:"interval_seconds"`
+		DueAt           time.Time  `json:"due_at"`
+		LastChangedAt   *time.Time `json:"last_changed_at,omitempty"`
+	}
+
+	records := make([]record, 0, len(schedules))
+	for _, rs := range schedules {
+		records = append(records, record{
+			RepoID:          rs.RepoID,
+			IntervalSeconds: int64(rs.Interval / time.Second),
+			DueAt:           rs.Due.UTC(),
+			LastChangedAt:   nullTimeColumn(rs.LastChanged.UTC()),
+		})
+	}
+
+	batch, err := json.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, "UpsertRepoUpdateSchedules: marshalling failed")
+	}
+
+	q := sqlf.Sprintf(upsertRepoUpdateSchedulesQueryFmtstr, string(batch))
+	return s.exec(ctx, q)
+}
+
+const upsertRepoUpdateSchedulesQueryFmtstr = `
+-- source: cmd/repo-updater/repos/schedule_store.go:DBStore.UpsertRepoUpdateSchedules
+INSERT INTO repo_update_schedule (
+  repo_id,
+  interval_seconds,
+  due_at,
+  last_changed_at,
+  updated_at
+)
+SELECT
+  batch.repo_id,
+  batch.interval_seconds,
+  batch.due_at,
+  batch.last_changed_at,
+  now()
+FROM json_to_recordset(%s) AS batch (
+  repo_id          integer,
+  interval_seconds integer,
+  due_at           timestamptz,
+  last_changed_at  timestamptz
+)
+JOIN repo ON repo.id = batch.repo_id
+ON CONFLICT (repo_id) DO UPDATE
+SET
+  interval_seconds = excluded.interval_seconds,
+  due_at           = excluded.due_at,
+  last_changed_at  = COALESCE(excluded.last_changed_at, repo_update_schedule.last_changed_at),
+  updated_at       = excluded.updated_at
+`
+
+// DeleteRepoUpdateSchedules deletes the update schedules of the given repos.
+func (s DBStore) DeleteRepoUpdateSchedules(ctx context.Context, ids ...api.RepoID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	qs := make([]*sqlf.Query, 0, len(ids))
+	for _, id := range ids {
+		qs = append(qs, sqlf.Sprintf("%s", id))
+	}
+
+	q := sqlf.Sprintf(deleteRepoUpdateSchedulesQueryFmtstr, sqlf.Join(qs, ","))
+	return s.exec(ctx, q)
+}
+
+const deleteRepoUpdateSchedulesQueryFmtstr = `
+-- source: cmd/repo-updater/repos/schedule_store.go:DBStore.DeleteRepoUpdateSchedules
+DELETE FROM repo_update_schedule WHERE repo_id IN (%s)
+`
+
+func (s DBStore) exec(ctx context.Context, q *sqlf.Query) error {
+	rows, err := s.db.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
@@ -3,6 +3,8 @@ package repos
 import (
 	"container/heap"
 	"context"
+	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
@@ -77,6 +79,9 @@ const (
 
 	// maxDelay is the maximum amount of time between scheduled updates for a single repository.
 	maxDelay = 8 * time.Hour
+
+	// persistInterval is how often changes to the schedule are written to the ScheduleStore.
+	persistInterval = 30 * time.Second
 )
 
 // updateScheduler schedules repo update (or clone) requests to gitserver.
@@ -91,6 +96,10 @@ const (
 //
 // When it is time for a repo to update, the scheduler inserts the repo into a queue.
 //
+// The schedule can be persisted in a ScheduleStore so that the learned update intervals
+// survive restarts. Repos that become overdue while repo-updater isn't running are spread
+// over their update interval instead of being fetched all at once.
+//
 // A worker continuously dequeues repos and sends updates to gitserver, but its concurrency
 // is limited by the gitMaxConcurrentClones site configuration.
 type updateScheduler struct {
@@ -107,6 +116,15 @@ type configuredRepo2 struct {
 	URL  string
 	ID   api.RepoID
 	Name api.RepoName
+
+	// MaxCloneSize is the maximum size in bytes of the initial clone of the
+	// repo. Zero means no limit.
+	MaxCloneSize int64
+
+	// CloneOptions are the options for the clone of the repo, as configured
+	// by its external services. Nil if they are unknown, in which case the
+	// options of an existing clone are kept.
+	CloneOptions *gitserverprotocol.CloneOptions
 }
 
 // notifyChanBuffer controls the buffer size of notification channels.
@@ -122,12 +140,59 @@ func NewUpdateScheduler() *updateScheduler {
 			notifyEnqueue: make(chan struct{}, notifyChanBuffer),
 		},
 		schedule: &schedule{
-			index:  make(map[api.RepoID]*scheduledRepoUpdate),
-			wakeup: make(chan struct{}, notifyChanBuffer),
+			index:   make(map[api.RepoID]*scheduledRepoUpdate),
+			wakeup:  make(chan struct{}, notifyChanBuffer),
+			dirty:   make(map[api.RepoID]struct{}),
+			removed: make(map[api.RepoID]struct{}),
 		},
 	}
 }
 
+// LoadSchedule loads the persisted update schedule from the given store.
+// Repos that are added to the scheduler afterwards resume their persisted
+// schedule instead of starting over with the minimum update interval.
+func (s *updateScheduler) LoadSchedule(ctx context.Context, store ScheduleStore) error {
+	schedules, err := store.ListRepoUpdateSchedules(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.schedule.mu.Lock()
+	defer s.schedule.mu.Unlock()
+
+	s.schedule.persisted = make(map[api.RepoID]*RepoUpdateSchedule, len(schedules))
+	for _, rs := range schedules {
+		s.schedule.persisted[rs.RepoID] = rs
+	}
+
+	return nil
+}
+
+// RunSchedulePersister periodically writes the changes to the update schedule to the given store.
+func RunSchedulePersister(ctx context.Context, scheduler *updateScheduler, store ScheduleStore) {
+	ticker := time.NewTicker(persistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			// Flush the pending changes before we exit.
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := scheduler.schedule.persist(ctx, store); err != nil {
+				log15.Error("failed to persist update schedule", "err", err)
+			}
+			return
+		}
+
+		if err := scheduler.schedule.persist(ctx, store); err != nil {
+			schedError.Inc()
+			log15.Error("failed to persist update schedule", "err", err)
+		}
+	}
+}
+
 // runScheduleLoop starts the loop that schedules updates by enqueuing them into the updateQueue.
 func (s *updateScheduler) runScheduleLoop(ctx context.Context) {
 	for {
@@ -158,6 +223,7 @@ func (s *updateScheduler) runSchedule() {
 		s.updateQueue.enqueue(repoUpdate.Repo, priorityLow)
 		repoUpdate.Due = timeNow().Add(repoUpdate.Interval)
 		heap.Fix(s.schedule, 0)
+		s.schedule.dirty[repoUpdate.Repo.ID] = struct{}{}
 	}
 }
 
@@ -199,7 +265,7 @@ func (s *updateScheduler) runUpdateLoop(ctx context.Context) {
 					// This is the heuristic that is described in the updateScheduler documentation.
 					// Update that documentation if you update this logic.
 					interval := resp.LastFetched.Sub(*resp.LastChanged) / 2
-					s.schedule.updateInterval(repo, interval)
+					s.schedule.updateInterval(repo, interval, *resp.LastChanged)
 				}
 			}(ctx, repo, cancel)
 		}
@@ -208,7 +274,7 @@ func (s *updateScheduler) runUpdateLoop(ctx context.Context) {
 
 // requestRepoUpdate sends a request to gitserver to request an update.
 var requestRepoUpdate = func(ctx context.Context, repo configuredRepo2, since time.Duration) (*gitserverprotocol.RepoUpdateResponse, error) {
-	return gitserver.DefaultClient.RequestRepoUpdate(ctx, gitserver.Repo{Name: repo.Name, URL: repo.URL}, since)
+	return gitserver.DefaultClient.RequestRepoUpdateWithOptions(ctx, gitserver.Repo{Name: repo.Name, URL: repo.URL}, since, repo.MaxCloneSize, repo.CloneOptions)
 }
 
 // configuredLimiter returns a mutable limiter that is
@@ -282,9 +348,14 @@ func (s *updateScheduler) remove(r *Repo) {
 }
 
 func configuredRepo2FromRepo(r *Repo) configuredRepo2 {
+	// The repos of sync diffs have the clone options of their external
+	// services.
+	cloneOptions := r.CloneOptions
 	repo := configuredRepo2{
-		ID:   r.ID,
-		Name: api.RepoName(r.Name),
+		ID:           r.ID,
+		Name:         api.RepoName(r.Name),
+		MaxCloneSize: r.CloneSizeLimit,
+		CloneOptions: &cloneOptions,
 	}
 
 	if urls := r.CloneURLs(); len(urls) > 0 {
@@ -302,6 +373,11 @@ func (s *updateScheduler) UpdateOnce(id api.RepoID, name api.RepoName, url strin
 		Name: name,
 		URL:  url,
 	}
+	// Clone the repo with the same options as a scheduled update would.
+	if known, ok := s.schedule.get(id); ok {
+		repo.MaxCloneSize = known.MaxCloneSize
+		repo.CloneOptions = known.CloneOptions
+	}
 	schedManualFetch.Inc()
 	s.updateQueue.enqueue(repo, priorityHigh)
 }
@@ -369,6 +445,10 @@ func (s *updateScheduler) ScheduleInfo(id api.RepoID) *protocol.RepoUpdateSchedu
 			IntervalSeconds: int(update.Interval / time.Second),
 			Due:             update.Due,
 		}
+		if !update.LastChanged.IsZero() {
+			lastChanged := update.LastChanged
+			result.Schedule.LastChanged = &lastChanged
+		}
 	}
 	s.schedule.mu.Unlock()
 
@@ -571,14 +651,36 @@ type schedule struct {
 	// timer sends a value on the wakeup channel when it is time
 	timer  *time.Timer
 	wakeup chan struct{}
+
+	// persisted holds the schedules loaded from the ScheduleStore of repos
+	// that haven't been added to the schedule yet.
+	persisted map[api.RepoID]*RepoUpdateSchedule
+
+	// dirty and removed track the repos whose schedule changed since the
+	// schedule was last persisted.
+	dirty   map[api.RepoID]struct{}
+	removed map[api.RepoID]struct{}
 }
 
 // scheduledRepoUpdate is the update schedule for a single repo.
 type scheduledRepoUpdate struct {
-	Repo     configuredRepo2 // the repo to update
-	Interval time.Duration   // how regularly the repo is updated
-	Due      time.Time       // the next time that the repo will be enqueued for a update
-	Index    int             `json:"-"` // the index in the heap
+	Repo        configuredRepo2 // the repo to update
+	Interval    time.Duration   // how regularly the repo is updated
+	Due         time.Time       // the next time that the repo will be enqueued for a update
+	LastChanged time.Time       // the last time the repo changed, as reported by gitserver
+	Index       int             `json:"-"` // the index in the heap
+}
+
+// get returns the scheduled repo with the given ID.
+func (s *schedule) get(id api.RepoID) (configuredRepo2, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update := s.index[id]
+	if update == nil {
+		return configuredRepo2{}, false
+	}
+	return update.Repo, true
 }
 
 // upsert inserts or updates a repo in the schedule.
@@ -595,35 +697,62 @@ func (s *schedule) upsert(repo configuredRepo2) (updated bool) {
 		return true
 	}
 
-	heap.Push(s, &scheduledRepoUpdate{
+	update := &scheduledRepoUpdate{
 		Repo:     repo,
 		Interval: minDelay,
 		Due:      timeNow().Add(minDelay),
-	})
+	}
+
+	if rs := s.persisted[repo.ID]; rs != nil {
+		delete(s.persisted, repo.ID)
+
+		update.Interval = clampInterval(rs.Interval)
+		update.Due = rs.Due
+		update.LastChanged = rs.LastChanged
+
+		// Spread repos that became due while we weren't running over their
+		// interval to avoid a thundering herd of fetches on startup.
+		if now := timeNow(); update.Due.Before(now) {
+			update.Due = now.Add(jitter(update.Interval))
+		}
+	}
+
+	heap.Push(s, update)
+	s.dirty[repo.ID] = struct{}{}
+	delete(s.removed, repo.ID)
 
 	s.rescheduleTimer()
 
 	return false
 }
 
-// updateInterval updates the update interval of a repo in the schedule.
-// It does nothing if the repo is not in the schedule.
-func (s *schedule) updateInterval(repo configuredRepo2, interval time.Duration) {
+// clampInterval returns the given interval clamped to [minDelay, maxDelay].
+func clampInterval(interval time.Duration) time.Duration {
+	switch {
+	case interval > maxDelay:
+		return maxDelay
+	case interval < minDelay:
+		return minDelay
+	default:
+		return interval
+	}
+}
+
+// updateInterval updates the update interval and the last change time of a
+// repo in the schedule. It does nothing if the repo is not in the schedule.
+func (s *schedule) updateInterval(repo configuredRepo2, interval time.Duration, lastChanged time.Time) {
 	if repo.ID == 0 {
 		panic("repo.id is zero")
 	}
 
 	s.mu.Lock()
 	if update := s.index[repo.ID]; update != nil {
-		switch {
-		case interval > maxDelay:
-			update.Interval = maxDelay
-		case interval < minDelay:
-			update.Interval = minDelay
-		default:
-			update.Interval = interval
-		}
+		update.Interval = clampInterval(interval)
 		update.Due = timeNow().Add(update.Interval)
+		if !lastChanged.IsZero() {
+			update.LastChanged = lastChanged
+		}
+		s.dirty[repo.ID] = struct{}{}
 		log15.Debug("updated repo", "repo", repo.Name, "due", update.Due.Sub(timeNow()))
 		heap.Fix(s, update.Index)
 		s.rescheduleTimer()
@@ -650,9 +779,61 @@ func (s *schedule) remove(repo configuredRepo2) (removed bool) {
 		s.rescheduleTimer()
 	}
 
+	delete(s.dirty, repo.ID)
+	s.removed[repo.ID] = struct{}{}
+
 	return true
 }
 
+// persist writes the schedule changes since the last call to the given store.
+// Changes that failed to be written are retried on the next call.
+func (s *schedule) persist(ctx context.Context, store ScheduleStore) error {
+	s.mu.Lock()
+	upserts := make([]*RepoUpdateSchedule, 0, len(s.dirty))
+	for id := range s.dirty {
+		if update := s.index[id]; update != nil {
+			upserts = append(upserts, &RepoUpdateSchedule{
+				RepoID:      id,
+				Interval:    update.Interval,
+				Due:         update.Due,
+				LastChanged: update.LastChanged,
+			})
+		}
+	}
+	deletes := make([]api.RepoID, 0, len(s.removed))
+	for id := range s.removed {
+		deletes = append(deletes, id)
+	}
+	s.dirty = make(map[api.RepoID]struct{})
+	s.removed = make(map[api.RepoID]struct{})
+	s.mu.Unlock()
+
+	sort.Slice(upserts, func(i, j int) bool { return upserts[i].RepoID < upserts[j].RepoID })
+	sort.Slice(deletes, func(i, j int) bool { return deletes[i] < deletes[j] })
+
+	err := store.DeleteRepoUpdateSchedules(ctx, deletes...)
+	if err == nil {
+		err = store.UpsertRepoUpdateSchedules(ctx, upserts...)
+	}
+
+	if err != nil {
+		s.mu.Lock()
+		for _, rs := range upserts {
+			if _, ok := s.removed[rs.RepoID]; !ok {
+				s.dirty[rs.RepoID] = struct{}{}
+			}
+		}
+		for _, id := range deletes {
+			if _, ok := s.index[id]; !ok {
+				s.removed[id] = struct{}{}
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	return err
+}
+
 // rescheduleTimer schedules the scheduler to wakeup
 // at the time that the next repo is due for an update.
 // The caller must hold the lock on s.mu.
@@ -673,6 +854,20 @@ func (s *schedule) reset() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// Keep the learned schedule around so that repos resume it once they are
+	// added back to the schedule.
+	if s.persisted == nil && len(s.heap) > 0 {
+		s.persisted = make(map[api.RepoID]*RepoUpdateSchedule, len(s.heap))
+	}
+	for _, update := range s.heap {
+		s.persisted[update.Repo.ID] = &RepoUpdateSchedule{
+			RepoID:      update.Repo.ID,
+			Interval:    update.Interval,
+			Due:         update.Due,
+			LastChanged: update.LastChanged,
+		}
+	}
+
 	s.heap = s.heap[:0]
 	s.index = map[api.RepoID]*scheduledRepoUpdate{}
 	s.wakeup = make(chan struct{}, notifyChanBuffer)
@@ -725,6 +920,11 @@ var notify = func(ch chan struct{}) {
 	}
 }
 
+// jitter returns a random duration in [0, d).
+var jitter = func(d time.Duration) time.Duration {
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
 // Mockable time functions for testing.
 var (
 	timeNow       = time.Now
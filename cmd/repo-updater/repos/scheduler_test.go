@@ -9,6 +9,8 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/mutablelimiter"
 )
@@ -556,8 +558,10 @@ func verifyQueue(t *testing.T, s *updateScheduler, expected []*repoUpdate) {
 }
 
 func Test_updateScheduler_UpdateFromDiff(t *testing.T) {
-	a := configuredRepo2{ID: 1, Name: "a", URL: "a.com"}
-	b := configuredRepo2{ID: 2, Name: "b", URL: "b.com"}
+	// The repos of sync diffs have the clone options of their external
+	// services.
+	a := configuredRepo2{ID: 1, Name: "a", URL: "a.com", CloneOptions: &gitserverprotocol.CloneOptions{}}
+	b := configuredRepo2{ID: 2, Name: "b", URL: "b.com", CloneOptions: &gitserverprotocol.CloneOptions{}}
 
 	tests := []struct {
 		name            string
@@ -976,7 +980,7 @@ func TestSchedule_updateInterval(t *testing.T) {
 
 			for _, call := range test.updateCalls {
 				mockTime(call.time)
-				s.schedule.updateInterval(call.repo, call.interval)
+				s.schedule.updateInterval(call.repo, call.interval, time.Time{})
 			}
 
 			verifySchedule(t, s, test.finalSchedule)
@@ -1319,7 +1323,7 @@ func TestUpdateScheduler_runUpdateLoop(t *testing.T) {
 				},
 			},
 			finalSchedule: []*scheduledRepoUpdate{
-				{Repo: a, Interval: time.Minute, Due: defaultTime.Add(time.Minute)},
+				{Repo: a, Interval: time.Minute, Due: defaultTime.Add(time.Minute), LastChanged: defaultTime},
 			},
 			timeAfterFuncDelays: []time.Duration{time.Minute},
 			expectedNotifications: func(s *updateScheduler) []chan struct{} {
@@ -1465,3 +1469,146 @@ func Test_updateQueue_Less(t *testing.T) {
 		})
 	}
 }
+
+type fakeScheduleStore struct {
+	schedules map[api.RepoID]*RepoUpdateSchedule
+	err       error
+	upserts   int
+	deletes   int
+}
+
+func (s *fakeScheduleStore) ListRepoUpdateSchedules(ctx context.Context) ([]*RepoUpdateSchedule, error) {
+	var schedules []*RepoUpdateSchedule
+	for _, rs := range s.schedules {
+		schedules = append(schedules, rs)
+	}
+	return schedules, s.err
+}
+
+func (s *fakeScheduleStore) UpsertRepoUpdateSchedules(ctx context.Context, schedules ...*RepoUpdateSchedule) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, rs := range schedules {
+		s.schedules[rs.RepoID] = rs
+	}
+	s.upserts += len(schedules)
+	return nil
+}
+
+func (s *fakeScheduleStore) DeleteRepoUpdateSchedules(ctx context.Context, ids ...api.RepoID) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, id := range ids {
+		delete(s.schedules, id)
+	}
+	s.deletes += len(ids)
+	return nil
+}
+
+func TestUpdateScheduler_LoadSchedule(t *testing.T) {
+	a := configuredRepo2{ID: 1, Name: "a", URL: "a.com"}
+	b := configuredRepo2{ID: 2, Name: "b", URL: "b.com"}
+	c := configuredRepo2{ID: 3, Name: "c", URL: "c.com"}
+	d := configuredRepo2{ID: 4, Name: "d", URL: "d.com"}
+
+	_, stop := startRecording()
+	defer stop()
+
+	jitter = func(d time.Duration) time.Duration { return d / 2 }
+	defer func() { jitter = nil }()
+
+	store := &fakeScheduleStore{schedules: map[api.RepoID]*RepoUpdateSchedule{
+		a.ID: {RepoID: a.ID, Interval: time.Hour, Due: defaultTime.Add(10 * time.Minute), LastChanged: defaultTime.Add(-time.Hour)},
+		b.ID: {RepoID: b.ID, Interval: 30 * time.Minute, Due: defaultTime.Add(-time.Hour)},
+		c.ID: {RepoID: c.ID, Interval: 24 * time.Hour, Due: defaultTime.Add(12 * time.Hour)},
+	}}
+
+	s := NewUpdateScheduler()
+	if err := s.LoadSchedule(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, repo := range []configuredRepo2{a, b, c, d} {
+		s.schedule.upsert(repo)
+	}
+
+	if have, want := s.ScheduleInfo(a.ID).Schedule.LastChanged, defaultTime.Add(-time.Hour); have == nil || !have.Equal(want) {
+		t.Errorf("unexpected last changed time: have %v, want %v", have, want)
+	}
+
+	verifySchedule(t, s, []*scheduledRepoUpdate{
+		{Repo: d, Interval: minDelay, Due: defaultTime.Add(minDelay)},
+		{Repo: a, Interval: time.Hour, Due: defaultTime.Add(10 * time.Minute), LastChanged: defaultTime.Add(-time.Hour)},
+		{Repo: b, Interval: 30 * time.Minute, Due: defaultTime.Add(15 * time.Minute)},
+		{Repo: c, Interval: maxDelay, Due: defaultTime.Add(12 * time.Hour)},
+	})
+}
+
+func TestSchedule_persist(t *testing.T) {
+	a := configuredRepo2{ID: 1, Name: "a", URL: "a.com"}
+	b := configuredRepo2{ID: 2, Name: "b", URL: "b.com"}
+
+	_, stop := startRecording()
+	defer stop()
+
+	ctx := context.Background()
+	store := &fakeScheduleStore{schedules: map[api.RepoID]*RepoUpdateSchedule{
+		b.ID: {RepoID: b.ID, Interval: time.Hour, Due: defaultTime},
+	}}
+
+	s := NewUpdateScheduler()
+	if err := s.LoadSchedule(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+
+	s.schedule.upsert(a)
+	s.schedule.upsert(b)
+	s.schedule.updateInterval(a, 2*time.Hour, defaultTime.Add(-time.Hour))
+	s.schedule.remove(b)
+
+	store.err = errors.New("boom")
+	if err := s.schedule.persist(ctx, store); err == nil {
+		t.Fatal("expected error")
+	}
+
+	store.err = nil
+	if err := s.schedule.persist(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[api.RepoID]*RepoUpdateSchedule{
+		a.ID: {RepoID: a.ID, Interval: 2 * time.Hour, Due: defaultTime.Add(2 * time.Hour), LastChanged: defaultTime.Add(-time.Hour)},
+	}
+	if diff := cmp.Diff(want, store.schedules); diff != "" {
+		t.Fatalf("unexpected persisted schedules:\n%s", diff)
+	}
+
+	// Nothing changed since the last call, so nothing should be written.
+	if err := s.schedule.persist(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	if store.upserts != 1 || store.deletes != 1 {
+		t.Fatalf("unexpected writes: %d upserts, %d deletes", store.upserts, store.deletes)
+	}
+}
+
+func TestSchedule_resetKeepsSchedule(t *testing.T) {
+	a := configuredRepo2{ID: 1, Name: "a", URL: "a.com"}
+
+	_, stop := startRecording()
+	defer stop()
+
+	s := NewUpdateScheduler()
+	setupInitialSchedule(s, []*scheduledRepoUpdate{
+		{Repo: a, Interval: time.Hour, Due: defaultTime.Add(time.Hour)},
+	})
+
+	s.schedule.reset()
+	s.schedule.upsert(a)
+
+	verifySchedule(t, s, []*scheduledRepoUpdate{
+		{Repo: a, Interval: time.Hour, Due: defaultTime.Add(time.Hour)},
+	})
+}
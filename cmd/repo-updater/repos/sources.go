@@ -61,6 +61,8 @@ func NewSource(svc *ExternalService, cf *httpcli.Factory) (Source, error) {
 		return NewBitbucketServerSource(svc, cf)
 	case "bitbucketcloud":
 		return NewBitbucketCloudSource(svc, cf)
+	case "gerrit":
+		return NewGerritSource(svc, cf)
 	case "gitolite":
 		return NewGitoliteSource(svc, cf)
 	case "phabricator":
@@ -103,6 +105,10 @@ type ChangesetSource interface {
 	CloseChangeset(context.Context, *Changeset) error
 	// UpdateChangeset can update Changesets.
 	UpdateChangeset(context.Context, *Changeset) error
+	// MergeChangeset will merge the Changeset on the source. It returns an
+	// error if the codehost refuses to merge it, e.g. because it has
+	// conflicts or its head changed since it was last loaded.
+	MergeChangeset(context.Context, *Changeset) error
 }
 
 // ChangesetsNotFoundError is returned by LoadChangesets if any of the passed
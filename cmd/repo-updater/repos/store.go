@@ -15,6 +15,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketcloud"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
@@ -851,6 +852,8 @@ func scanRepo(r *Repo, s scanner) error {
 		r.Metadata = new(bitbucketcloud.Repo)
 	case "awscodecommit":
 		r.Metadata = new(awscodecommit.Repository)
+	case "gerrit":
+		r.Metadata = new(gerrit.Project)
 	case "gitolite":
 		r.Metadata = new(gitolite.Repo)
 	default:
@@ -1095,6 +1095,83 @@ func testStoreListReposPagination(store repos.Store) func(*testing.T) {
 	}
 }
 
+func testDBStoreRepoUpdateSchedules(store *repos.DBStore) func(*testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	return func(t *testing.T) {
+		ctx := context.Background()
+
+		txstore, err := store.Transact(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer txstore.Done(&errRollback)
+
+		rs := mkRepos(2, &repos.Repo{
+			Name:      "github.com/foo/bar",
+			CreatedAt: now,
+			ExternalRepo: api.ExternalRepoSpec{
+				ID:          "AAAAA==",
+				ServiceType: "github",
+				ServiceID:   "http://github.com",
+			},
+			Sources: map[string]*repos.SourceInfo{
+				"extsvc:1": {
+					ID:       "extsvc:1",
+					CloneURL: "git@github.com:foo/bar.git",
+				},
+			},
+		})
+		if err := txstore.UpsertRepos(ctx, rs...); err != nil {
+			t.Fatalf("UpsertRepos error: %s", err)
+		}
+
+		ss := txstore.(repos.ScheduleStore)
+		want := []*repos.RepoUpdateSchedule{
+			{RepoID: rs[0].ID, Interval: time.Hour, Due: now.Add(time.Hour), LastChanged: now.Add(-time.Hour)},
+			{RepoID: rs[1].ID, Interval: time.Minute, Due: now.Add(time.Minute)},
+		}
+
+		// Schedules of unknown repos are ignored.
+		unknown := &repos.RepoUpdateSchedule{RepoID: rs[1].ID + 100, Interval: time.Minute, Due: now}
+		if err := ss.UpsertRepoUpdateSchedules(ctx, append(want, unknown)...); err != nil {
+			t.Fatalf("UpsertRepoUpdateSchedules error: %s", err)
+		}
+
+		listSchedules := func() []*repos.RepoUpdateSchedule {
+			have, err := ss.ListRepoUpdateSchedules(ctx)
+			if err != nil {
+				t.Fatalf("ListRepoUpdateSchedules error: %s", err)
+			}
+			for _, h := range have {
+				h.Due = h.Due.UTC()
+				h.LastChanged = h.LastChanged.UTC()
+			}
+			return have
+		}
+
+		if diff := cmp.Diff(want, listSchedules()); diff != "" {
+			t.Fatalf("unexpected schedules:\n%s", diff)
+		}
+
+		// A zero last change time doesn't overwrite the stored one.
+		want[0].Interval = 2 * time.Hour
+		updated := *want[0]
+		updated.LastChanged = time.Time{}
+		if err := ss.UpsertRepoUpdateSchedules(ctx, &updated); err != nil {
+			t.Fatalf("UpsertRepoUpdateSchedules error: %s", err)
+		}
+
+		if err := ss.DeleteRepoUpdateSchedules(ctx, rs[1].ID); err != nil {
+			t.Fatalf("DeleteRepoUpdateSchedules error: %s", err)
+		}
+
+		if diff := cmp.Diff(want[:1], listSchedules()); diff != "" {
+			t.Fatalf("unexpected schedules:\n%s", diff)
+		}
+	}
+}
+
 func testDBStoreTransact(store *repos.DBStore) func(*testing.T) {
 	return func(t *testing.T) {
 		ctx := context.Background()
@@ -41,12 +41,22 @@ type Syncer struct {
 	// Now is time.Now. Can be set by tests to get deterministic output.
 	Now func() time.Time
 
+	// CloneSizes returns the size in bytes of the given repositories on
+	// gitserver. Repositories that are not cloned are omitted. It is used to
+	// enforce the clone size quotas of external services, which are ignored
+	// if CloneSizes is nil.
+	CloneSizes func(ctx context.Context, repos ...api.RepoName) (map[api.RepoName]int64, error)
+
 	// lastSyncErr contains the last error returned by the Sourcer in each
 	// Sync. It's reset with each Sync and if the sync produced no error, it's
 	// set to nil.
 	lastSyncErr   error
 	lastSyncErrMu sync.Mutex
 
+	// quotaViolations contains the quota violations found in the last Sync.
+	quotaViolations   []QuotaViolation
+	quotaViolationsMu sync.Mutex
+
 	syncSignal signal
 }
 
@@ -109,18 +119,28 @@ func (s *Syncer) Sync(ctx context.Context) (err error) {
 		return errors.New("Syncer is not enabled")
 	}
 
+	var svcs []*ExternalService
+	if svcs, err = s.Store.ListExternalServices(ctx, StoreListExternalServicesArgs{}); err != nil {
+		return errors.Wrap(err, "syncer.sync.store.list-external-services")
+	}
+
+	var qs []quotaService
+	if qs, err = quotaServices(svcs); err != nil {
+		return errors.Wrap(err, "syncer.sync.quotas")
+	}
+
 	var streamingInserter func(*Repo)
 	if s.DisableStreaming {
 		streamingInserter = func(*Repo) {} //noop
 	} else {
-		streamingInserter, err = s.makeNewRepoInserter(ctx)
+		streamingInserter, err = s.makeNewRepoInserter(ctx, qs)
 		if err != nil {
 			return errors.Wrap(err, "syncer.sync.streaming")
 		}
 	}
 
 	var sourced Repos
-	if sourced, err = s.sourced(ctx, streamingInserter); err != nil {
+	if sourced, err = s.sourced(ctx, svcs, streamingInserter); err != nil {
 		return errors.Wrap(err, "syncer.sync.sourced")
 	}
 
@@ -140,6 +160,17 @@ func (s *Syncer) Sync(ctx context.Context) (err error) {
 	}
 
 	diff = NewDiff(sourced, stored)
+
+	var violations []QuotaViolation
+	if violations, err = s.enforceQuotas(ctx, qs, &diff); err != nil {
+		return errors.Wrap(err, "syncer.sync.enforce-quotas")
+	}
+	s.setQuotaViolations(violations)
+
+	if err = setCloneOptions(svcs, diff.Repos()...); err != nil {
+		return errors.Wrap(err, "syncer.sync.clone-options")
+	}
+
 	upserts := s.upserts(diff)
 
 	if err = store.UpsertRepos(ctx, upserts...); err != nil {
@@ -212,6 +243,15 @@ func (s *Syncer) syncSubset(ctx context.Context, insertOnly bool, sourcedSubset
 	}
 
 	diff = NewDiff(sourcedSubset, storedSubset)
+
+	var svcs []*ExternalService
+	if svcs, err = store.ListExternalServices(ctx, StoreListExternalServicesArgs{}); err != nil {
+		return Diff{}, errors.Wrap(err, "syncer.syncsubset.store.list-external-services")
+	}
+	if err = setCloneOptions(svcs, diff.Repos()...); err != nil {
+		return Diff{}, errors.Wrap(err, "syncer.syncsubset.clone-options")
+	}
+
 	upserts := s.upserts(diff)
 
 	if err = store.UpsertRepos(ctx, upserts...); err != nil {
@@ -351,12 +391,7 @@ func merge(o, n *Repo) {
 	o.Update(n)
 }
 
-func (s *Syncer) sourced(ctx context.Context, observe ...func(*Repo)) ([]*Repo, error) {
-	svcs, err := s.Store.ListExternalServices(ctx, StoreListExternalServicesArgs{})
-	if err != nil {
-		return nil, err
-	}
-
+func (s *Syncer) sourced(ctx context.Context, svcs []*ExternalService, observe ...func(*Repo)) ([]*Repo, error) {
 	srcs, err := s.Sourcer(svcs...)
 	if err != nil {
 		return nil, err
@@ -368,7 +403,7 @@ func (s *Syncer) sourced(ctx context.Context, observe ...func(*Repo)) ([]*Repo,
 	return listAll(ctx, srcs, observe...)
 }
 
-func (s *Syncer) makeNewRepoInserter(ctx context.Context) (func(*Repo), error) {
+func (s *Syncer) makeNewRepoInserter(ctx context.Context, qs []quotaService) (func(*Repo), error) {
 	// syncSubset requires querying the store for related repositories, and
 	// will do nothing if `insertOnly` is set and there are any related repositories. Most
 	// repositories will already have related repos, so to avoid that cost we
@@ -379,12 +414,25 @@ func (s *Syncer) makeNewRepoInserter(ctx context.Context) (func(*Repo), error) {
 		return nil, err
 	}
 
+	// Repos of external services with quotas are only inserted by the
+	// final syncer, which enforces the quotas.
+	quotaURNs := make(map[string]bool, len(qs))
+	for _, q := range qs {
+		quotaURNs[q.svc.URN()] = true
+	}
+
 	return func(r *Repo) {
 		// We know this won't be an insert.
 		if _, ok := ids[r.ExternalRepo]; ok {
 			return
 		}
 
+		for urn := range r.Sources {
+			if quotaURNs[urn] {
+				return
+			}
+		}
+
 		err := s.insertIfNew(ctx, r)
 		if err != nil && s.Logger != nil {
 			// Best-effort, final syncer will handle this repo if this failed.
@@ -425,6 +473,21 @@ func (s *Syncer) LastSyncError() error {
 	return s.lastSyncErr
 }
 
+func (s *Syncer) setQuotaViolations(violations []QuotaViolation) {
+	s.quotaViolationsMu.Lock()
+	s.quotaViolations = violations
+	s.quotaViolationsMu.Unlock()
+}
+
+// QuotaViolations returns the external service quota violations found in the
+// last successful Sync run.
+func (s *Syncer) QuotaViolations() []QuotaViolation {
+	s.quotaViolationsMu.Lock()
+	defer s.quotaViolationsMu.Unlock()
+
+	return s.quotaViolations
+}
+
 func (s *Syncer) observe(ctx context.Context, family, title string) (context.Context, func(*Diff, *error)) {
 	began := s.Now()
 	tr, ctx := trace.New(ctx, family, title)
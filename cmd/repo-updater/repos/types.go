@@ -19,9 +19,11 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/campaigns"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/jsonc"
 	"github.com/sourcegraph/sourcegraph/schema"
 	"github.com/xeipuuv/gojsonschema"
@@ -96,6 +98,8 @@ func (e ExternalService) Configuration() (cfg interface{}, _ error) {
 		cfg = &schema.AWSCodeCommitConnection{}
 	case "bitbucketserver":
 		cfg = &schema.BitbucketServerConnection{}
+	case "gerrit":
+		cfg = &schema.GerritConnection{}
 	case "github":
 		cfg = &schema.GitHubConnection{}
 	case "gitlab":
@@ -112,6 +116,29 @@ func (e ExternalService) Configuration() (cfg interface{}, _ error) {
 	return cfg, jsonc.Unmarshal(e.Config, cfg)
 }
 
+// Quota returns the quota configured for the external service, or nil if it
+// has none.
+func (e ExternalService) Quota() (*schema.ExternalServiceQuota, error) {
+	var cfg struct {
+		Quota *schema.ExternalServiceQuota `json:"quota"`
+	}
+	if err := jsonc.Unmarshal(e.Config, &cfg); err != nil {
+		return nil, err
+	}
+	return cfg.Quota, nil
+}
+
+// CloneOptions returns the clone options configured for the external service.
+func (e ExternalService) CloneOptions() ([]*schema.ExternalServiceCloneOptions, error) {
+	var cfg struct {
+		CloneOptions []*schema.ExternalServiceCloneOptions `json:"cloneOptions"`
+	}
+	if err := jsonc.Unmarshal(e.Config, &cfg); err != nil {
+		return nil, err
+	}
+	return cfg.CloneOptions, nil
+}
+
 // Exclude changes the configuration of an external service to exclude the given
 // repos from being synced.
 func (e *ExternalService) Exclude(rs ...*Repo) error {
@@ -124,6 +151,8 @@ func (e *ExternalService) Exclude(rs ...*Repo) error {
 		return e.excludeBitbucketServerRepos(rs...)
 	case "awscodecommit":
 		return e.excludeAWSCodeCommitRepos(rs...)
+	case "gerrit":
+		return e.excludeGerritRepos(rs...)
 	case "gitolite":
 		return e.excludeGitoliteRepos(rs...)
 	case "other":
@@ -300,6 +329,34 @@ func (e *ExternalService) excludeBitbucketServerRepos(rs ...*Repo) error {
 	})
 }
 
+// excludeGerritRepos changes the configuration of a Gerrit external service to exclude the
+// given repos from being synced.
+func (e *ExternalService) excludeGerritRepos(rs ...*Repo) error {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	return e.config("gerrit", func(v interface{}) (string, interface{}, error) {
+		c := v.(*schema.GerritConnection)
+		set := make(map[string]bool, len(c.Exclude))
+		for _, ex := range c.Exclude {
+			if ex.Name != "" {
+				set[ex.Name] = true
+			}
+		}
+
+		for _, r := range rs {
+			p, ok := r.Metadata.(*gerrit.Project)
+			if ok && p.Name != "" && !set[p.Name] {
+				c.Exclude = append(c.Exclude, &schema.ExcludedGerritProject{Name: p.Name})
+				set[p.Name] = true
+			}
+		}
+
+		return "exclude", c.Exclude, nil
+	})
+}
+
 // excludeGitoliteRepos changes the configuration of a Gitolite external service to exclude the
 // given repos from being synced.
 func (e *ExternalService) excludeGitoliteRepos(rs ...*Repo) error {
@@ -466,6 +523,8 @@ func (e ExternalService) schema() string {
 		return schema.AWSCodeCommitSchemaJSON
 	case "bitbucketserver":
 		return schema.BitbucketServerSchemaJSON
+	case "gerrit":
+		return schema.GerritSchemaJSON
 	case "github":
 		return schema.GitHubSchemaJSON
 	case "gitlab":
@@ -568,6 +627,14 @@ type Repo struct {
 	Sources map[string]*SourceInfo
 	// Metadata contains the raw source code host JSON metadata.
 	Metadata interface{}
+	// CloneSizeLimit is the maximum size in bytes of the initial clone of the
+	// repository, as allowed by the quotas of its external services. Zero
+	// means no limit. It is computed by the Syncer and is not persisted.
+	CloneSizeLimit int64 `json:"-"`
+	// CloneOptions are the options for the initial clone of the repository,
+	// as configured by its external services. It is computed by the Syncer
+	// and is not persisted.
+	CloneOptions gitserverprotocol.CloneOptions `json:"-"`
 }
 
 // A SourceInfo represents a source a Repo belongs to (such as an external service).
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
@@ -537,6 +539,15 @@ func (s *Server) handleStatusMessages(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	for _, v := range s.Syncer.QuotaViolations() {
+		resp.Messages = append(resp.Messages, protocol.StatusMessage{
+			ExternalServiceQuotaExceeded: &protocol.ExternalServiceQuotaExceeded{
+				Message:           v.Message,
+				ExternalServiceId: v.ExternalServiceID,
+			},
+		})
+	}
+
 	log15.Debug("TRACE handleStatusMessages", "messages", resp.Messages)
 
 	respond(w, http.StatusOK, resp)
@@ -659,6 +670,34 @@ func newRepoInfo(r *repos.Repo) (*protocol.RepoInfo, error) {
 			Blob:   pathAppend(root, "/browse/{path}?at={rev}"),
 			Commit: pathAppend(root, "/commits/{commit}"),
 		}
+	case "gerrit":
+		proj := r.Metadata.(*gerrit.Project)
+		base, err := url.Parse(r.ExternalRepo.ServiceID)
+		if err != nil {
+			break
+		}
+
+		// Gerrit can only browse repository contents through Gitiles. Without it, we can only
+		// link to the project and to the changes of a commit.
+		if gitiles := proj.GitilesURL(); gitiles != "" {
+			u, err := base.Parse(gitiles)
+			if err != nil {
+				break
+			}
+			root := u.String()
+			info.Links = &protocol.RepoLinks{
+				Root:   root,
+				Tree:   pathAppend(root, "/+/{rev}/{path}"),
+				Blob:   pathAppend(root, "/+/{rev}/{path}"),
+				Commit: pathAppend(root, "/+/{commit}"),
+			}
+			break
+		}
+
+		info.Links = &protocol.RepoLinks{
+			Root:   pathAppend(base.String(), "/admin/repos/"+url.PathEscape(proj.Name)),
+			Commit: pathAppend(base.String(), "/q/{commit}"),
+		}
 	case "awscodecommit":
 		repo := r.Metadata.(*awscodecommit.Repository)
 		if repo.ARN == "" {
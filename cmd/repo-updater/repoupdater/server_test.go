@@ -23,6 +23,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
@@ -552,6 +553,8 @@ func TestServer_StatusMessages(t *testing.T) {
 		name            string
 		stored          repos.Repos
 		gitserverCloned []string
+		sourced         repos.Repos
+		extSvcConfig    string
 		sourcerErr      error
 		listRepoErr     error
 		res             *protocol.StatusMessagesResponse
@@ -658,6 +661,26 @@ func TestServer_StatusMessages(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:         "external service quota exceeded",
+			sourced:      []*repos.Repo{{Name: "foo"}, {Name: "bar"}},
+			extSvcConfig: `{"quota": {"maxRepos": 1}}`,
+			res: &protocol.StatusMessagesResponse{
+				Messages: []protocol.StatusMessage{
+					{
+						Cloning: &protocol.CloningProgress{
+							Message: "1 repositories enqueued for cloning...",
+						},
+					},
+					{
+						ExternalServiceQuotaExceeded: &protocol.ExternalServiceQuotaExceeded{
+							Message:           "1 repositories were not synced because the quota of 1 repositories was exceeded",
+							ExternalServiceId: githubService.ID,
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -681,7 +704,10 @@ func TestServer_StatusMessages(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			err = store.UpsertExternalServices(ctx, githubService)
+			svc := githubService.With(func(e *repos.ExternalService) {
+				e.Config = tc.extSvcConfig
+			})
+			err = store.UpsertExternalServices(ctx, svc)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -692,9 +718,18 @@ func TestServer_StatusMessages(t *testing.T) {
 				Now:   clock.Now,
 			}
 
-			if tc.sourcerErr != nil || tc.listRepoErr != nil {
+			sourced := tc.sourced.Clone()
+			for i, r := range sourced {
+				r.ExternalRepo = api.ExternalRepoSpec{
+					ID:          strconv.Itoa(i),
+					ServiceType: github.ServiceType,
+					ServiceID:   "https://github.com/",
+				}
+			}
+
+			if tc.sourcerErr != nil || tc.listRepoErr != nil || len(sourced) > 0 {
 				store.ListReposError = tc.listRepoErr
-				sourcer := repos.NewFakeSourcer(tc.sourcerErr, repos.NewFakeSource(githubService, nil))
+				sourcer := repos.NewFakeSourcer(tc.sourcerErr, repos.NewFakeSource(svc, nil, sourced...))
 				// Run Sync so that possibly `LastSyncErrors` is set
 				syncer.Sourcer = sourcer
 				_ = syncer.Sync(ctx)
@@ -844,6 +879,31 @@ func TestRepoLookup(t *testing.T) {
 		},
 	}
 
+	gerritRepository := &repos.Repo{
+		Name:      "gerrit.example.com/platform/build",
+		URI:       "gerrit.example.com/platform/build",
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          "platform%2Fbuild",
+			ServiceType: gerrit.ServiceType,
+			ServiceID:   "https://gerrit.example.com/",
+		},
+		Sources: map[string]*repos.SourceInfo{
+			"extsvc:789": {
+				ID:       "extsvc:789",
+				CloneURL: "https://gerrit.example.com/platform/build",
+			},
+		},
+		Metadata: &gerrit.Project{
+			ID:   "platform%2Fbuild",
+			Name: "platform/build",
+			WebLinks: []gerrit.WebLink{
+				{Name: "browse", URL: "/plugins/gitiles/platform/build"},
+			},
+		},
+	}
+
 	testCases := []struct {
 		name               string
 		args               protocol.RepoLookupArgs
@@ -908,6 +968,24 @@ func TestRepoLookup(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "found - Gerrit",
+			args: protocol.RepoLookupArgs{
+				Repo: api.RepoName("gerrit.example.com/platform/build"),
+			},
+			stored: []*repos.Repo{gerritRepository},
+			result: &protocol.RepoLookupResult{Repo: &protocol.RepoInfo{
+				ExternalRepo: gerritRepository.ExternalRepo,
+				Name:         "gerrit.example.com/platform/build",
+				VCS:          protocol.VCSInfo{URL: "https://gerrit.example.com/platform/build"},
+				Links: &protocol.RepoLinks{
+					Root:   "https://gerrit.example.com/plugins/gitiles/platform/build",
+					Tree:   "https://gerrit.example.com/plugins/gitiles/platform/build/+/{rev}/{path}",
+					Blob:   "https://gerrit.example.com/plugins/gitiles/platform/build/+/{rev}/{path}",
+					Commit: "https://gerrit.example.com/plugins/gitiles/platform/build/+/{commit}",
+				},
+			}},
+		},
 		{
 			name: "found - GitHub.com on Sourcegraph.com",
 			args: protocol.RepoLookupArgs{
@@ -106,6 +106,11 @@ func Main(enterpriseInit EnterpriseInit) {
 	}
 
 	scheduler := repos.NewUpdateScheduler()
+	scheduleStore := repos.NewDBStore(db, sql.TxOptions{})
+	if err := scheduler.LoadSchedule(ctx, scheduleStore); err != nil {
+		// Not fatal: repos start over with the minimum update interval.
+		log15.Error("failed to load persisted update schedule", "err", err)
+	}
 	server := &repoupdater.Server{
 		Store:           store,
 		Scheduler:       scheduler,
@@ -179,6 +184,7 @@ func Main(enterpriseInit EnterpriseInit) {
 		DisableStreaming: !streamingSyncer,
 		Logger:           log15.Root(),
 		Now:              clock,
+		CloneSizes:       gitserver.DefaultClient.RepoSizes,
 	}
 
 	if envvar.SourcegraphDotComMode() {
@@ -200,6 +206,7 @@ func Main(enterpriseInit EnterpriseInit) {
 
 	// Git fetches scheduler
 	go repos.RunScheduler(ctx, scheduler)
+	go repos.RunSchedulePersister(ctx, scheduler, scheduleStore)
 	log15.Debug("started scheduler")
 
 	host := ""
@@ -4,6 +4,7 @@ package protocol
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
@@ -42,6 +43,12 @@ type Request struct {
 	// The deadline for the search request.
 	// It is parsed with time.Time.UnmarshalText.
 	Deadline string
+
+	// Stream if true will respond with a stream of server-sent events
+	// (text/event-stream) instead of a single Response. Each FileMatch is
+	// sent as a StreamEventMatch event as soon as it is found, followed by a
+	// single StreamEventDone event.
+	Stream bool
 }
 
 // GitserverRepo returns the repository information necessary to perform gitserver requests.
@@ -166,6 +173,57 @@ type Response struct {
 	DeadlineHit bool
 }
 
+// Event names used in streaming search responses. See Request.Stream.
+const (
+	// StreamEventMatch is sent for every FileMatch found. Its data is a JSON
+	// encoded FileMatch.
+	StreamEventMatch = "match"
+
+	// StreamEventDone is the last event of a stream. Its data is a JSON
+	// encoded StreamDone.
+	StreamEventDone = "done"
+)
+
+// StreamDone is the trailer of a streaming search response.
+type StreamDone struct {
+	// LimitHit is true if the stream may not include all FileMatches because a match limit was hit.
+	LimitHit bool
+
+	// DeadlineHit is true if the stream may not include all FileMatches because a deadline was hit.
+	DeadlineHit bool
+
+	// Stats describes the work done to produce the stream.
+	Stats SearchStats
+
+	// Error is non-empty if the search failed after the stream was
+	// started. Errors that happen before the first event is sent are
+	// reported with a non-200 HTTP status code instead.
+	Error string
+
+	// ErrorCode is the HTTP status code searcher would have responded with
+	// if Error had happened before the stream was started. It tells bad
+	// requests and temporary errors apart.
+	ErrorCode int
+}
+
+// SearchStats describes the work done by a search request.
+type SearchStats struct {
+	// MatchCount is the number of FileMatches sent.
+	MatchCount int
+
+	// FilesSearched is the number of files which were searched. It is not
+	// reported for structural searches.
+	FilesSearched int
+
+	// FilesSkipped is the number of files skipped because they did not
+	// match the include/exclude patterns. It is not reported for structural
+	// searches.
+	FilesSkipped int
+
+	// Duration is how long the search took, including fetching the archive.
+	Duration time.Duration
+}
+
 // FileMatch is the struct used by vscode to receive search results
 type FileMatch struct {
 	Path        string
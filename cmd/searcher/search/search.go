@@ -99,17 +99,17 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	matches, limitHit, deadlineHit, err := s.search(ctx, &p)
+	if p.Stream {
+		s.serveStream(ctx, w, &p)
+		return
+	}
+
+	var matches []protocol.FileMatch
+	_, limitHit, deadlineHit, err := s.search(ctx, &p, func(m protocol.FileMatch) {
+		matches = append(matches, m)
+	})
 	if err != nil {
-		code := http.StatusInternalServerError
-		if isBadRequest(err) || ctx.Err() == context.Canceled {
-			code = http.StatusBadRequest
-		} else if isTemporary(err) {
-			code = http.StatusServiceUnavailable
-		} else {
-			log.Printf("internal error serving %#+v: %s", p, err)
-		}
-		http.Error(w, err.Error(), code)
+		writeSearchError(ctx, w, &p, err)
 		return
 	}
 	if matches == nil {
@@ -130,7 +130,61 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(&resp)
 }
 
-func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []protocol.FileMatch, limitHit, deadlineHit bool, err error) {
+// serveStream responds to p with a stream of server-sent events. Matches are
+// written as soon as they are found, followed by a protocol.StreamDone
+// trailer.
+func (s *Service) serveStream(ctx context.Context, w http.ResponseWriter, p *protocol.Request) {
+	ew := newEventWriter(w)
+	stats, limitHit, deadlineHit, err := s.search(ctx, p, func(m protocol.FileMatch) {
+		ew.Event(protocol.StreamEventMatch, &m)
+	})
+	if err != nil && !ew.Started() {
+		// Nothing has been sent yet, so we can still respond with an
+		// appropriate status code.
+		writeSearchError(ctx, w, p, err)
+		return
+	}
+
+	done := protocol.StreamDone{
+		LimitHit:    limitHit,
+		DeadlineHit: deadlineHit,
+		Stats:       stats,
+	}
+	if err != nil {
+		done.Error = err.Error()
+		done.ErrorCode = searchErrorCode(ctx, err)
+		if done.ErrorCode == http.StatusInternalServerError {
+			log.Printf("internal error serving %#+v: %s", p, err)
+		}
+	}
+	ew.Event(protocol.StreamEventDone, &done)
+}
+
+// writeSearchError responds to a failed search with err and a status code
+// describing it.
+func writeSearchError(ctx context.Context, w http.ResponseWriter, p *protocol.Request, err error) {
+	code := searchErrorCode(ctx, err)
+	if code == http.StatusInternalServerError {
+		log.Printf("internal error serving %#+v: %s", p, err)
+	}
+	http.Error(w, err.Error(), code)
+}
+
+// searchErrorCode returns the HTTP status code describing err.
+func searchErrorCode(ctx context.Context, err error) int {
+	if isBadRequest(err) || ctx.Err() == context.Canceled {
+		return http.StatusBadRequest
+	} else if isTemporary(err) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// matchSender is called with each FileMatch as soon as it is found. Calls are
+// never concurrent.
+type matchSender func(protocol.FileMatch)
+
+func (s *Service) search(ctx context.Context, p *protocol.Request, send matchSender) (stats protocol.SearchStats, limitHit, deadlineHit bool, err error) {
 	tr := nettrace.New("search", fmt.Sprintf("%s@%s", p.Repo, p.Commit))
 	tr.LazyPrintf("%s", p.Pattern)
 
@@ -152,6 +206,7 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 	span.SetTag("patternMatchesPath", p.PatternMatchesPath)
 	span.SetTag("deadline", p.Deadline)
 	defer func(start time.Time) {
+		stats.Duration = time.Since(start)
 		code := "200"
 		// We often have canceled and timed out requests. We do not want to
 		// record them as errors to avoid noise
@@ -176,21 +231,21 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 				code = "500"
 			}
 		}
-		tr.LazyPrintf("code=%s matches=%d limitHit=%v deadlineHit=%v", code, len(matches), limitHit, deadlineHit)
+		tr.LazyPrintf("code=%s matches=%d limitHit=%v deadlineHit=%v", code, stats.MatchCount, limitHit, deadlineHit)
 		tr.Finish()
 		requestTotal.WithLabelValues(code).Inc()
-		span.LogFields(otlog.Int("matches.len", len(matches)))
+		span.LogFields(otlog.Int("matches.len", stats.MatchCount))
 		span.SetTag("limitHit", limitHit)
 		span.SetTag("deadlineHit", deadlineHit)
 		span.Finish()
 		if s.Log != nil {
-			s.Log.Debug("search request", "repo", p.Repo, "commit", p.Commit, "pattern", p.Pattern, "isRegExp", p.IsRegExp, "isStructuralPat", p.IsStructuralPat, "languages", p.Languages, "isWordMatch", p.IsWordMatch, "isCaseSensitive", p.IsCaseSensitive, "patternMatchesContent", p.PatternMatchesContent, "patternMatchesPath", p.PatternMatchesPath, "matches", len(matches), "code", code, "duration", time.Since(start), "err", err)
+			s.Log.Debug("search request", "repo", p.Repo, "commit", p.Commit, "pattern", p.Pattern, "isRegExp", p.IsRegExp, "isStructuralPat", p.IsStructuralPat, "languages", p.Languages, "isWordMatch", p.IsWordMatch, "isCaseSensitive", p.IsCaseSensitive, "patternMatchesContent", p.PatternMatchesContent, "patternMatchesPath", p.PatternMatchesPath, "matches", stats.MatchCount, "code", code, "duration", stats.Duration, "err", err)
 		}
 	}(time.Now())
 
 	rg, err := compile(&p.PatternInfo)
 	if err != nil {
-		return nil, false, false, badRequestError{err.Error()}
+		return stats, false, false, badRequestError{err.Error()}
 	}
 
 	if p.FetchTimeout == "" {
@@ -198,7 +253,7 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 	}
 	fetchTimeout, err := time.ParseDuration(p.FetchTimeout)
 	if err != nil {
-		return nil, false, false, err
+		return stats, false, false, err
 	}
 	prepareCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
 	defer cancel()
@@ -214,7 +269,7 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 
 	zipPath, zf, err := store.GetZipFileWithRetry(getZf)
 	if err != nil {
-		return nil, false, false, errors.Wrap(err, "failed to get archive")
+		return stats, false, false, errors.Wrap(err, "failed to get archive")
 	}
 	defer zf.Close()
 
@@ -228,11 +283,14 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 	archiveSize.Observe(float64(bytes))
 
 	if p.IsStructuralPat {
-		matches, limitHit, err = structuralSearch(ctx, zipPath, p.Pattern, p.CombyRule, p.Languages, p.IncludePatterns, p.Repo)
+		limitHit, err = structuralSearchStream(ctx, zipPath, p.Pattern, p.CombyRule, p.Languages, p.IncludePatterns, p.Repo, func(m protocol.FileMatch) {
+			stats.MatchCount++
+			send(m)
+		})
 	} else {
-		matches, limitHit, err = regexSearch(ctx, rg, zf, p.FileMatchLimit, p.PatternMatchesContent, p.PatternMatchesPath)
+		stats, limitHit, err = regexSearchStream(ctx, rg, zf, p.FileMatchLimit, p.PatternMatchesContent, p.PatternMatchesPath, send)
 	}
-	return matches, limitHit, false, err
+	return stats, limitHit, false, err
 }
 
 func validateParams(p *protocol.Request) error {
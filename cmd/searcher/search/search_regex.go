@@ -296,6 +296,16 @@ func (rg *readerGrep) FindZip(zf *store.ZipFile, f *store.SrcFile) (protocol.Fil
 
 // regexSearch concurrently searches files in zr looking for matches using rg.
 func regexSearch(ctx context.Context, rg *readerGrep, zf *store.ZipFile, fileMatchLimit int, patternMatchesContent, patternMatchesPaths bool) (fm []protocol.FileMatch, limitHit bool, err error) {
+	fm = []protocol.FileMatch{}
+	_, limitHit, err = regexSearchStream(ctx, rg, zf, fileMatchLimit, patternMatchesContent, patternMatchesPaths, func(m protocol.FileMatch) {
+		fm = append(fm, m)
+	})
+	return fm, limitHit, err
+}
+
+// regexSearchStream is like regexSearch, but calls send for each FileMatch as
+// soon as it is found instead of returning them.
+func regexSearchStream(ctx context.Context, rg *readerGrep, zf *store.ZipFile, fileMatchLimit int, patternMatchesContent, patternMatchesPaths bool, send matchSender) (stats protocol.SearchStats, limitHit bool, err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "RegexSearch")
 	ext.Component.Set(span, "regex_search")
 	if rg.re != nil {
@@ -331,26 +341,33 @@ func regexSearch(ctx context.Context, rg *readerGrep, zf *store.ZipFile, fileMat
 	defer cancel()
 
 	var (
-		filesmu   sync.Mutex // protects files
-		files     = zf.Files
-		matchesmu sync.Mutex // protects matches, limitHit
-		matches   = []protocol.FileMatch{}
+		filesmu    sync.Mutex // protects files
+		files      = zf.Files
+		matchesmu  sync.Mutex // protects send, matchCount, limitHit
+		matchCount int
 	)
 
 	if rg.re == nil || (patternMatchesPaths && !patternMatchesContent) {
 		// Fast path for only matching file paths (or with a nil pattern, which matches all files,
 		// so is effectively matching only on file paths).
 		for _, f := range files {
-			if rg.matchPath.MatchPath(f.Name) && rg.matchString(f.Name) {
-				if len(matches) < fileMatchLimit {
-					matches = append(matches, protocol.FileMatch{Path: f.Name})
+			if !rg.matchPath.MatchPath(f.Name) {
+				stats.FilesSkipped++
+				continue
+			}
+			stats.FilesSearched++
+			if rg.matchString(f.Name) {
+				if matchCount < fileMatchLimit {
+					matchCount++
+					send(protocol.FileMatch{Path: f.Name})
 				} else {
 					limitHit = true
 					break
 				}
 			}
 		}
-		return matches, limitHit, nil
+		stats.MatchCount = matchCount
+		return stats, limitHit, nil
 	}
 
 	var (
@@ -413,8 +430,9 @@ func regexSearch(ctx context.Context, rg *readerGrep, zf *store.ZipFile, fileMat
 				}
 				if match {
 					matchesmu.Lock()
-					if len(matches) < fileMatchLimit {
-						matches = append(matches, fm)
+					if matchCount < fileMatchLimit {
+						matchCount++
+						send(fm)
 					} else {
 						limitHit = true
 						cancel()
@@ -433,12 +451,15 @@ func regexSearch(ctx context.Context, rg *readerGrep, zf *store.ZipFile, fileMat
 		err = ctx.Err()
 	}
 
+	stats.MatchCount = matchCount
+	stats.FilesSkipped = int(atomic.LoadUint32(&filesSkipped))
+	stats.FilesSearched = int(atomic.LoadUint32(&filesSearched))
 	span.LogFields(
-		otlog.Int("filesSkipped", int(atomic.LoadUint32(&filesSkipped))),
-		otlog.Int("filesSearched", int(atomic.LoadUint32(&filesSearched))),
+		otlog.Int("filesSkipped", stats.FilesSkipped),
+		otlog.Int("filesSearched", stats.FilesSearched),
 	)
 
-	return matches, limitHit, err
+	return stats, limitHit, err
 }
 
 // lowerRegexpASCII lowers rune literals and expands char classes to include
@@ -67,21 +67,24 @@ func highlightMultipleLines(r *comby.Match) (matches []protocol.LineMatch) {
 
 func ToFileMatch(combyMatches []comby.FileMatch) (matches []protocol.FileMatch) {
 	for _, m := range combyMatches {
-		var lineMatches []protocol.LineMatch
-		for _, r := range m.Matches {
-			lineMatches = append(lineMatches, highlightMultipleLines(&r)...)
-		}
-		matches = append(matches,
-			protocol.FileMatch{
-				Path:        m.URI,
-				LineMatches: lineMatches,
-				MatchCount:  len(m.Matches),
-				LimitHit:    false,
-			})
+		matches = append(matches, toFileMatch(m))
 	}
 	return matches
 }
 
+func toFileMatch(m comby.FileMatch) protocol.FileMatch {
+	var lineMatches []protocol.LineMatch
+	for _, r := range m.Matches {
+		lineMatches = append(lineMatches, highlightMultipleLines(&r)...)
+	}
+	return protocol.FileMatch{
+		Path:        m.URI,
+		LineMatches: lineMatches,
+		MatchCount:  len(m.Matches),
+		LimitHit:    false,
+	}
+}
+
 // lookupMatcher looks up a key for specifying -matcher in comby. Comby accepts
 // a representative file extension to set a language, so this lookup does not
 // need to consider all possible file extensions for a language. There is a generic
@@ -181,6 +184,18 @@ func languageMetric(matcher string, includePatterns *[]string) string {
 }
 
 func structuralSearch(ctx context.Context, zipPath, pattern, rule string, languages, includePatterns []string, repo api.RepoName) (matches []protocol.FileMatch, limitHit bool, err error) {
+	limitHit, err = structuralSearchStream(ctx, zipPath, pattern, rule, languages, includePatterns, repo, func(m protocol.FileMatch) {
+		matches = append(matches, m)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return matches, limitHit, nil
+}
+
+// structuralSearchStream is like structuralSearch, but calls send for each
+// FileMatch as soon as comby reports it instead of returning them.
+func structuralSearchStream(ctx context.Context, zipPath, pattern, rule string, languages, includePatterns []string, repo api.RepoName, send matchSender) (limitHit bool, err error) {
 	log15.Info("structural search", "repo", string(repo))
 
 	// Cap the number of forked processes to limit the size of zip contents being mapped to memory. Resolving #7133 could help to lift this restriction.
@@ -207,16 +222,10 @@ func structuralSearch(ctx context.Context, zipPath, pattern, rule string, langua
 		NumWorkers:    numWorkers,
 	}
 
-	combyMatches, err := comby.Matches(ctx, args)
-	if err != nil {
-		return nil, false, err
-	}
-
-	matches = ToFileMatch(combyMatches)
-	if err != nil {
-		return nil, false, err
-	}
-	return matches, false, err
+	err = comby.StreamMatches(ctx, args, func(m comby.FileMatch) {
+		send(toFileMatch(m))
+	})
+	return false, err
 }
 
 var requestTotalStructuralSearch = prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -22,6 +22,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/searcher/search"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search/searcher"
 	"github.com/sourcegraph/sourcegraph/internal/store"
 	"github.com/sourcegraph/sourcegraph/internal/testutil"
 )
@@ -344,6 +345,61 @@ func TestSearch_badrequest(t *testing.T) {
 	}
 }
 
+func TestSearch_stream(t *testing.T) {
+	files := map[string]string{
+		"README.md": "# Hello World\n",
+		"main.go":   "package main\n\nfunc main() {\n\tprintln(\"Hello world\")\n}\n",
+		"abc.txt":   "w",
+	}
+
+	store, cleanup, err := newStore(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	ts := httptest.NewServer(&search.Service{Store: store})
+	defer ts.Close()
+
+	q := url.Values{
+		"Repo":                  []string{"foo"},
+		"URL":                   []string{"u"},
+		"Commit":                []string{"deadbeefdeadbeefdeadbeefdeadbeefdeadbeef"},
+		"Pattern":               []string{"world"},
+		"FetchTimeout":          []string{"2000ms"},
+		"PatternMatchesContent": []string{"true"},
+	}
+
+	var m []protocol.FileMatch
+	done, err := searcher.Stream(context.Background(), http.DefaultClient, ts.URL+"?"+q.Encode(), func(fm *protocol.FileMatch) {
+		m = append(m, *fm)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Sort(sortByPath(m))
+	want := `README.md:1:# Hello World
+main.go:4:	println("Hello world")
+`
+	if got := toString(m); got != want {
+		t.Fatalf("unexpected matches:\n%s", got)
+	}
+	if done.LimitHit || done.DeadlineHit || done.Error != "" {
+		t.Fatalf("unexpected trailer: %+v", done)
+	}
+	if done.Stats.MatchCount != 2 || done.Stats.FilesSearched != 3 {
+		t.Fatalf("unexpected stats: %+v", done.Stats)
+	}
+
+	// Errors before any match is sent are still reported as HTTP errors.
+	q.Set("Commit", "HEAD")
+	_, err = searcher.Stream(context.Background(), http.DefaultClient, ts.URL+"?"+q.Encode(), func(*protocol.FileMatch) {})
+	var e *searcher.Error
+	if !errors.As(err, &e) || !e.BadRequest() {
+		t.Fatalf("expected bad request error, got %v", err)
+	}
+}
+
 func doSearch(u string, p *protocol.Request) ([]protocol.FileMatch, error) {
 	form := url.Values{
 		"Repo":            []string{string(p.Repo)},
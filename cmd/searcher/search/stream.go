@@ -0,0 +1,65 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// eventWriter writes server-sent events (text/event-stream) to an
+// http.ResponseWriter, flushing after every event so the client sees it
+// immediately.
+//
+// The response headers are only written with the first event. This allows
+// callers to still respond with an error status code if they fail before
+// sending anything.
+type eventWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	started bool
+	err     error
+}
+
+func newEventWriter(w http.ResponseWriter) *eventWriter {
+	flusher, _ := w.(http.Flusher)
+	return &eventWriter{w: w, flusher: flusher}
+}
+
+// Started returns true if an event has been written.
+func (e *eventWriter) Started() bool {
+	return e.started
+}
+
+// Event writes an event named name with the JSON encoding of v as its data.
+//
+// Once a write fails (usually because the client went away) all further
+// events are dropped. We can't send an error response at that point, so the
+// error is ignored.
+func (e *eventWriter) Event(name string, v interface{}) {
+	if e.err != nil {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		e.err = err
+		return
+	}
+
+	if !e.started {
+		e.started = true
+		h := e.w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		e.w.WriteHeader(http.StatusOK)
+	}
+
+	// json.Marshal never emits newlines, so data fits on a single data line.
+	if _, err := fmt.Fprintf(e.w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+		e.err = err
+		return
+	}
+	if e.flusher != nil {
+		e.flusher.Flush()
+	}
+}
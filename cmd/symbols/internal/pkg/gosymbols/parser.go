@@ -0,0 +1,230 @@
+// Package gosymbols extracts symbols from Go source files using go/parser.
+//
+// Compared to universal-ctags it reports methods with their receiver type as
+// parent, and function signatures as printed by gofmt.
+package gosymbols
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+)
+
+// Language is the language reported for all symbols.
+const Language = "Go"
+
+// Parser is a symbols parser for Go source files. It is stateless and safe for
+// concurrent use.
+type Parser struct{}
+
+// Parse returns the symbols declared in the Go source file. If the file has
+// syntax errors, the symbols of the declarations that could be parsed are
+// returned together with the error.
+func (Parser) Parse(path string, content []byte) ([]ctags.Entry, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, content, 0)
+	if f == nil {
+		return nil, err
+	}
+
+	e := extractor{fset: fset, path: path, content: content, kinds: typeKinds(f)}
+	e.file(f)
+	return e.entries, err
+}
+
+type extractor struct {
+	fset    *token.FileSet
+	path    string
+	content []byte
+
+	// kinds maps the names of the types declared in the file to their kind.
+	kinds map[string]string
+
+	entries []ctags.Entry
+}
+
+func (e *extractor) add(name string, pos token.Pos, kind, parent, parentKind, signature string) {
+	if name == "" || name == "_" {
+		return
+	}
+	line := e.fset.Position(pos).Line
+	e.entries = append(e.entries, ctags.Entry{
+		Name:       name,
+		Path:       e.path,
+		Line:       line,
+		Kind:       kind,
+		Language:   Language,
+		Parent:     parent,
+		ParentKind: parentKind,
+		Pattern:    e.pattern(line),
+		Signature:  signature,
+	})
+}
+
+func (e *extractor) file(f *ast.File) {
+	e.add(f.Name.Name, f.Name.Pos(), "package", "", "", "")
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			e.funcDecl(decl)
+		case *ast.GenDecl:
+			e.genDecl(decl)
+		}
+	}
+}
+
+func (e *extractor) funcDecl(decl *ast.FuncDecl) {
+	signature := e.signature(decl.Type)
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		e.add(decl.Name.Name, decl.Name.Pos(), "func", "", "", signature)
+		return
+	}
+
+	recv := receiverTypeName(decl.Recv.List[0].Type)
+	e.add(decl.Name.Name, decl.Name.Pos(), "method", recv, e.typeKind(recv), signature)
+}
+
+func (e *extractor) genDecl(decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			kind := specKind(spec)
+			e.add(spec.Name.Name, spec.Name.Pos(), kind, "", "", "")
+			e.typeMembers(spec.Name.Name, kind, spec.Type)
+
+		case *ast.ValueSpec:
+			kind := "var"
+			if decl.Tok == token.CONST {
+				kind = "const"
+			}
+			for _, name := range spec.Names {
+				e.add(name.Name, name.Pos(), kind, "", "", "")
+			}
+		}
+	}
+}
+
+// typeMembers adds the fields of struct types and the methods of interface
+// types.
+func (e *extractor) typeMembers(parent, parentKind string, typ ast.Expr) {
+	switch typ := typ.(type) {
+	case *ast.StructType:
+		for _, field := range typ.Fields.List {
+			if len(field.Names) == 0 {
+				// Embedded field, named after its type.
+				e.add(receiverTypeName(field.Type), field.Type.Pos(), "field", parent, parentKind, "")
+				continue
+			}
+			for _, name := range field.Names {
+				e.add(name.Name, name.Pos(), "field", parent, parentKind, "")
+			}
+		}
+
+	case *ast.InterfaceType:
+		for _, method := range typ.Methods.List {
+			ft, ok := method.Type.(*ast.FuncType)
+			if !ok {
+				// Embedded interface.
+				continue
+			}
+			for _, name := range method.Names {
+				e.add(name.Name, name.Pos(), "methodSpec", parent, parentKind, e.signature(ft))
+			}
+		}
+	}
+}
+
+func (e *extractor) typeKind(name string) string {
+	if kind, ok := e.kinds[name]; ok {
+		return kind
+	}
+	// The type is declared in another file of the package.
+	return "type"
+}
+
+// signature returns the parameters and results of the function type as
+// formatted by gofmt, e.g. "(ctx context.Context) (int, error)".
+func (e *extractor) signature(ft *ast.FuncType) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, e.fset, ft); err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(buf.String(), "func")
+}
+
+// pattern returns an ex search command matching the line, as produced by
+// ctags.
+func (e *extractor) pattern(line int) string {
+	start := 0
+	for i := 1; i < line && start < len(e.content); i++ {
+		j := bytes.IndexByte(e.content[start:], '\n')
+		if j < 0 {
+			return ""
+		}
+		start += j + 1
+	}
+	end := bytes.IndexByte(e.content[start:], '\n')
+	if end < 0 {
+		end = len(e.content) - start
+	}
+	text := strings.TrimSuffix(string(e.content[start:start+end]), "\r")
+	text = strings.NewReplacer(`\`, `\\`, `/`, `\/`).Replace(text)
+	return "/^" + text + "$/"
+}
+
+// typeKinds returns the kinds of the types declared in the file by name.
+func typeKinds(f *ast.File) map[string]string {
+	kinds := map[string]string{}
+	for _, decl := range f.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range decl.Specs {
+			spec := spec.(*ast.TypeSpec)
+			kinds[spec.Name.Name] = specKind(spec)
+		}
+	}
+	return kinds
+}
+
+func specKind(spec *ast.TypeSpec) string {
+	if spec.Assign.IsValid() {
+		return "type"
+	}
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	default:
+		return "type"
+	}
+}
+
+// receiverTypeName returns the name of the (base) type of a method receiver or
+// embedded field, e.g. "T" for "*T", "pkg.T" or "T[K]".
+func receiverTypeName(expr ast.Expr) string {
+	for {
+		switch x := expr.(type) {
+		case *ast.Ident:
+			return x.Name
+		case *ast.StarExpr:
+			expr = x.X
+		case *ast.ParenExpr:
+			expr = x.X
+		case *ast.SelectorExpr:
+			return x.Sel.Name
+		case *ast.IndexExpr:
+			expr = x.X
+		default:
+			return ""
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package gosymbols
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+)
+
+func TestParser(t *testing.T) {
+	src := `package foo
+
+import "context"
+
+const A, B = 1, 2
+
+var c = "c/d"
+
+type Store interface {
+	Get(ctx context.Context, id int) (*Thing, error)
+	io.Closer
+}
+
+type Thing struct {
+	Name string
+	*Embedded
+}
+
+type ID = int
+
+func New() *Thing { return nil }
+
+func (t *Thing) String() string { return t.Name }
+
+func (s store) Get(ctx context.Context, id int) (*Thing, error) { return nil, nil }
+`
+	entry := func(name string, line int, kind, parent, parentKind, signature, pattern string) ctags.Entry {
+		return ctags.Entry{
+			Name:       name,
+			Path:       "foo/foo.go",
+			Line:       line,
+			Kind:       kind,
+			Language:   "Go",
+			Parent:     parent,
+			ParentKind: parentKind,
+			Signature:  signature,
+			Pattern:    pattern,
+		}
+	}
+	want := []ctags.Entry{
+		entry("foo", 1, "package", "", "", "", `/^package foo$/`),
+		entry("A", 5, "const", "", "", "", `/^const A, B = 1, 2$/`),
+		entry("B", 5, "const", "", "", "", `/^const A, B = 1, 2$/`),
+		entry("c", 7, "var", "", "", "", `/^var c = "c\/d"$/`),
+		entry("Store", 9, "interface", "", "", "", `/^type Store interface {$/`),
+		entry("Get", 10, "methodSpec", "Store", "interface", "(ctx context.Context, id int) (*Thing, error)", "/^\tGet(ctx context.Context, id int) (*Thing, error)$/"),
+		entry("Thing", 14, "struct", "", "", "", `/^type Thing struct {$/`),
+		entry("Name", 15, "field", "Thing", "struct", "", "/^\tName string$/"),
+		entry("Embedded", 16, "field", "Thing", "struct", "", "/^\t*Embedded$/"),
+		entry("ID", 19, "type", "", "", "", `/^type ID = int$/`),
+		entry("New", 21, "func", "", "", "() *Thing", `/^func New() *Thing { return nil }$/`),
+		entry("String", 23, "method", "Thing", "struct", "() string", `/^func (t *Thing) String() string { return t.Name }$/`),
+		entry("Get", 25, "method", "store", "type", "(ctx context.Context, id int) (*Thing, error)", `/^func (s store) Get(ctx context.Context, id int) (*Thing, error) { return nil, nil }$/`),
+	}
+
+	got, err := Parser{}.Parse("foo/foo.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+}
+
+func TestParser_syntaxError(t *testing.T) {
+	src := `package foo
+
+func A() {}
+
+func B( {
+`
+	got, err := Parser{}.Parse("foo.go", []byte(src))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	var names []string
+	for _, e := range got {
+		names = append(names, e.Name)
+	}
+	if diff := cmp.Diff([]string{"foo", "A", "B"}, names); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,214 @@
+package symbols
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	"github.com/inconshreveable/log15"
+	"github.com/jmoiron/sqlx"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/diskcache"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+const (
+	// maxAncestors is the number of ancestors of a commit that are checked
+	// for an already indexed database.
+	maxAncestors = 100
+
+	// maxChangedPaths is the maximum number of paths that may have changed
+	// since an indexed ancestor for us to build a database incrementally.
+	// Beyond this it is cheaper to parse the whole archive.
+	maxChangedPaths = 1000
+)
+
+// Changes are the paths that changed between two commits.
+type Changes struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+}
+
+// ParseGitDiffNameStatus parses the output of `git diff -z --name-status
+// --no-renames`.
+func ParseGitDiffNameStatus(out []byte) (Changes, error) {
+	var changes Changes
+	if len(out) == 0 {
+		return changes, nil
+	}
+
+	fields := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+	if len(fields)%2 != 0 {
+		return changes, errors.Errorf("unexpected git diff output %q", out)
+	}
+
+	for i := 0; i < len(fields); i += 2 {
+		status, path := fields[i], string(fields[i+1])
+		if len(status) == 0 {
+			return changes, errors.Errorf("missing git diff status for %q", path)
+		}
+		switch status[0] {
+		case 'A':
+			changes.Added = append(changes.Added, path)
+		case 'M', 'T':
+			changes.Modified = append(changes.Modified, path)
+		case 'D':
+			changes.Deleted = append(changes.Deleted, path)
+		default:
+			return changes, errors.Errorf("unexpected git diff status %q for %q", status, path)
+		}
+	}
+	return changes, nil
+}
+
+// writeSymbolsFromAncestor writes the symbols of repo@commitID to the blank
+// database file `dbFile` by copying the database of an already indexed
+// ancestor and only re-parsing the paths which changed since that ancestor.
+//
+// It returns false if the database could not be built incrementally, e.g.
+// because no ancestor has been indexed yet. Callers must then fall back to
+// writeAllSymbolsToNewDB.
+func (s *Service) writeSymbolsFromAncestor(ctx context.Context, dbFile string, repo api.RepoName, commitID api.CommitID) (ok bool, err error) {
+	if s.ListAncestors == nil || s.GitDiff == nil || s.FetchTarPaths == nil {
+		return false, nil
+	}
+
+	span, ctx := ot.StartSpanFromContext(ctx, "writeSymbolsFromAncestor")
+	span.SetTag("repo", string(repo))
+	span.SetTag("commit", string(commitID))
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.SetTag("ok", ok)
+		span.Finish()
+	}()
+
+	ancestors, err := s.ListAncestors(ctx, repo, commitID, maxAncestors)
+	if err != nil {
+		return false, errors.Wrap(err, "ListAncestors")
+	}
+
+	var (
+		ancestor api.CommitID
+		src      *diskcache.File
+	)
+	for _, c := range ancestors {
+		f, err := s.cache.OpenIfExists(symbolsDBCacheKey(repo, c))
+		if err != nil {
+			continue
+		}
+		ancestor, src = c, f
+		break
+	}
+	if src == nil {
+		return false, nil
+	}
+	defer src.Close()
+	span.SetTag("ancestor", string(ancestor))
+
+	changes, err := s.GitDiff(ctx, repo, ancestor, commitID)
+	if err != nil {
+		return false, errors.Wrap(err, "GitDiff")
+	}
+	changed := append(append([]string{}, changes.Added...), changes.Modified...)
+	span.LogFields(otlog.Int("changed", len(changed)), otlog.Int("deleted", len(changes.Deleted)))
+	if len(changed)+len(changes.Deleted) > maxChangedPaths {
+		return false, nil
+	}
+
+	if err := copyDBFile(dbFile, src.File); err != nil {
+		return false, err
+	}
+
+	db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Added paths should not have symbols in the ancestor's database, but
+	// clearing them as well is cheap and keeps us from duplicating symbols.
+	for _, paths := range [][]string{changed, changes.Deleted} {
+		for _, path := range paths {
+			if _, err := tx.Exec(`DELETE FROM symbols WHERE path = ?`, path); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if len(changed) > 0 {
+		insertStatement, err := prepareInsertSymbol(tx)
+		if err != nil {
+			return false, err
+		}
+
+		err = s.parseUncached(ctx, repo, commitID, changed, func(symbol protocol.Symbol) error {
+			symbolInDBValue := symbolToSymbolInDB(symbol)
+			_, err := insertStatement.Exec(&symbolInDBValue)
+			return err
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	incrementalIndexes.Inc()
+	log15.Debug("Incrementally indexed repository symbols", "repo", repo, "commit", commitID, "ancestor", ancestor, "changed", len(changed), "deleted", len(changes.Deleted))
+	return true, nil
+}
+
+// copyDBFile overwrites the database file dst with the contents of src.
+func copyDBFile(dst string, src io.Reader) error {
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		return errors.Wrap(err, "copying ancestor database")
+	}
+	return f.Close()
+}
+
+// resetDBFile truncates the database file path so that it can be written
+// from scratch after a failed incremental index.
+func resetDBFile(path string) error {
+	if err := os.Remove(path + "-journal"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Truncate(path, 0)
+}
+
+var incrementalIndexes = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "symbols",
+	Subsystem: "store",
+	Name:      "incremental_indexes",
+	Help:      "The total number of databases built incrementally from an ancestor's database.",
+})
+
+func init() {
+	prometheus.MustRegister(incrementalIndexes)
+}
@@ -3,6 +3,7 @@ package symbols
 import (
 	"context"
 	"fmt"
+	"path"
 	"runtime"
 	"strings"
 	"sync"
@@ -37,7 +38,9 @@ func (s *Service) startParsers() error {
 	return nil
 }
 
-func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, callback func(symbol protocol.Symbol) error) (err error) {
+// parseUncached parses the files of repo@commitID and calls callback for each
+// symbol. If paths is non-empty, only those paths are parsed.
+func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, paths []string, callback func(symbol protocol.Symbol) error) (err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "parseUncached")
 	defer func() {
 		if err != nil {
@@ -48,6 +51,7 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 	}()
 	span.SetTag("repo", string(repo))
 	span.SetTag("commit", string(commitID))
+	span.SetTag("paths", len(paths))
 
 	tr := nettrace.New("parseUncached", string(repo))
 	tr.LazyPrintf("commitID: %s", commitID)
@@ -63,7 +67,7 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 	}()
 
 	tr.LazyPrintf("fetch")
-	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID)
+	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID, paths)
 	tr.LazyPrintf("fetch (returned chans)")
 	if err != nil {
 		return err
@@ -123,8 +127,30 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 	return <-errChan
 }
 
-// parse gets a parser from the pool and uses it to satisfy the parse request.
-func (s *Service) parse(ctx context.Context, req parseRequest) (entries []ctags.Entry, err error) {
+// A FileParser extracts the symbols of a single file. Implementations must be
+// safe for concurrent use.
+type FileParser interface {
+	Parse(path string, content []byte) ([]ctags.Entry, error)
+}
+
+// parse parses the file with the native parser for its extension, if any, and
+// otherwise (or if the native parser fails) with ctags.
+func (s *Service) parse(ctx context.Context, req parseRequest) ([]ctags.Entry, error) {
+	if p, ok := s.Parsers[path.Ext(req.path)]; ok {
+		entries, err := p.Parse(req.path, req.data)
+		if err == nil {
+			nativeParsed.Inc()
+			return entries, nil
+		}
+		log15.Debug("Native parser failed, falling back to ctags.", "path", req.path, "error", err)
+		nativeParseFailed.Inc()
+	}
+	return s.parseCtags(ctx, req)
+}
+
+// parseCtags gets a ctags parser from the pool and uses it to satisfy the
+// parse request.
+func (s *Service) parseCtags(ctx context.Context, req parseRequest) (entries []ctags.Entry, err error) {
 	parseQueueSize.Inc()
 
 	select {
@@ -215,6 +241,18 @@ var (
 		Name:      "parse_failed",
 		Help:      "The total number of parse jobs that failed.",
 	})
+	nativeParsed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "symbols",
+		Subsystem: "parse",
+		Name:      "native_parsed",
+		Help:      "The total number of files parsed with a native parser.",
+	})
+	nativeParseFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "symbols",
+		Subsystem: "parse",
+		Name:      "native_parse_failed",
+		Help:      "The total number of files a native parser failed to parse, which were parsed with ctags instead.",
+	})
 )
 
 func init() {
@@ -222,4 +260,6 @@ func init() {
 	prometheus.MustRegister(parseQueueSize)
 	prometheus.MustRegister(parseQueueTimeouts)
 	prometheus.MustRegister(parseFailed)
+	prometheus.MustRegister(nativeParsed)
+	prometheus.MustRegister(nativeParseFailed)
 }
@@ -0,0 +1,63 @@
+package symbols
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+)
+
+type nativeParser struct {
+	err error
+}
+
+func (p nativeParser) Parse(path string, content []byte) ([]ctags.Entry, error) {
+	return []ctags.Entry{{Name: "native", Path: path}}, p.err
+}
+
+func TestService_parse(t *testing.T) {
+	newService := func(native FileParser) *Service {
+		s := &Service{
+			NewParser: func() (ctags.Parser, error) {
+				return mockParser{"ctags"}, nil
+			},
+			NumParserProcesses: 1,
+			Parsers:            map[string]FileParser{".go": native},
+		}
+		if err := s.startParsers(); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	names := func(entries []ctags.Entry) (names []string) {
+		for _, e := range entries {
+			names = append(names, e.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name   string
+		native FileParser
+		path   string
+		want   []string
+	}{
+		{name: "native", native: nativeParser{}, path: "a.go", want: []string{"native"}},
+		{name: "other extension", native: nativeParser{}, path: "a.js", want: []string{"ctags"}},
+		{name: "native failure", native: nativeParser{err: errors.New("syntax error")}, path: "a.go", want: []string{"ctags"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newService(test.native)
+			entries, err := s.parse(context.Background(), parseRequest{path: test.path})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(entries); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
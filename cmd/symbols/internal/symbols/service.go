@@ -22,12 +22,30 @@ type Service struct {
 	// determine if the error is a bad request (eg invalid repo).
 	FetchTar func(context.Context, gitserver.Repo, api.CommitID) (io.ReadCloser, error)
 
+	// FetchTarPaths is like FetchTar, but only includes the given paths in
+	// the archive. It is used when building a database incrementally.
+	FetchTarPaths func(context.Context, gitserver.Repo, api.CommitID, []string) (io.ReadCloser, error)
+
+	// ListAncestors returns up to n ancestors of the commit, nearest first.
+	// It is used to find an already indexed ancestor when building a
+	// database incrementally.
+	ListAncestors func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error)
+
+	// GitDiff returns the paths that changed between two commits. It is used
+	// when building a database incrementally.
+	GitDiff func(ctx context.Context, repo api.RepoName, from, to api.CommitID) (Changes, error)
+
 	// MaxConcurrentFetchTar is the maximum number of concurrent calls allowed
 	// to FetchTar. It defaults to 15.
 	MaxConcurrentFetchTar int
 
 	NewParser func() (ctags.Parser, error)
 
+	// Parsers are native parsers by file extension (e.g. ".go"). They are used
+	// instead of ctags for the files they support. If a native parser fails,
+	// the file is parsed with ctags.
+	Parsers map[string]FileParser
+
 	// NumParserProcesses is the maximum number of ctags parser child processes to run.
 	NumParserProcesses int
 
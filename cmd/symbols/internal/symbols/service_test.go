@@ -13,7 +13,9 @@ import (
 	"path"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
@@ -73,8 +75,14 @@ func TestIsLiteralEquality(t *testing.T) {
 	}
 }
 
+var registerSqlite3WithPcreOnce sync.Once
+
+func registerSqlite3WithPcre() {
+	registerSqlite3WithPcreOnce.Do(MustRegisterSqlite3WithPcre)
+}
+
 func TestService(t *testing.T) {
-	MustRegisterSqlite3WithPcre()
+	registerSqlite3WithPcre()
 
 	tmpDir, err := ioutil.TempDir("", "")
 	if err != nil {
@@ -160,6 +168,115 @@ func TestService(t *testing.T) {
 	}
 }
 
+func TestService_incremental(t *testing.T) {
+	registerSqlite3WithPcre()
+
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	const parent, child = api.CommitID("parent"), api.CommitID("child")
+	trees := map[api.CommitID]map[string]string{
+		parent: {"a.js": "x", "b.js": "y", "c.js": "z"},
+		child:  {"a.js": "x2", "b.js": "y", "d.js": "w"},
+	}
+
+	var (
+		mu           sync.Mutex
+		fullFetches  []api.CommitID
+		fetchedPaths []string
+	)
+	service := Service{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+			mu.Lock()
+			fullFetches = append(fullFetches, commit)
+			mu.Unlock()
+			return createTar(trees[commit])
+		},
+		FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			mu.Lock()
+			fetchedPaths = append(fetchedPaths, paths...)
+			mu.Unlock()
+			files := map[string]string{}
+			for _, p := range paths {
+				files[p] = trees[commit][p]
+			}
+			return createTar(files)
+		},
+		ListAncestors: func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error) {
+			if commit == child {
+				return []api.CommitID{parent}, nil
+			}
+			return nil, nil
+		},
+		GitDiff: func(ctx context.Context, repo api.RepoName, from, to api.CommitID) (Changes, error) {
+			if from != parent || to != child {
+				return Changes{}, fmt.Errorf("unexpected diff %s..%s", from, to)
+			}
+			return Changes{Added: []string{"d.js"}, Modified: []string{"a.js"}, Deleted: []string{"c.js"}}, nil
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return contentParser{}, nil
+		},
+		Path: tmpDir,
+	}
+
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(service.Handler())
+	defer server.Close()
+	client := symbolsclient.Client{URL: server.URL}
+
+	searchCommit := func(commit api.CommitID) []protocol.Symbol {
+		result, err := client.Search(context.Background(), search.SymbolsParameters{Repo: "r", CommitID: commit, First: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(result.Symbols, func(i, j int) bool { return result.Symbols[i].Name < result.Symbols[j].Name })
+		return result.Symbols
+	}
+
+	want := []protocol.Symbol{{Name: "x", Path: "a.js"}, {Name: "y", Path: "b.js"}, {Name: "z", Path: "c.js"}}
+	if got := searchCommit(parent); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	want = []protocol.Symbol{{Name: "w", Path: "d.js"}, {Name: "x2", Path: "a.js"}, {Name: "y", Path: "b.js"}}
+	if got := searchCommit(child); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if want := []api.CommitID{parent}; !reflect.DeepEqual(fullFetches, want) {
+		t.Errorf("got full fetches %v, want %v", fullFetches, want)
+	}
+	if want := []string{"d.js", "a.js"}; !reflect.DeepEqual(fetchedPaths, want) {
+		t.Errorf("got fetched paths %v, want %v", fetchedPaths, want)
+	}
+}
+
+func TestParseGitDiffNameStatus(t *testing.T) {
+	out := []byte("A\x00new.go\x00M\x00dir/changed.go\x00T\x00link\x00D\x00gone.go\x00")
+	want := Changes{
+		Added:    []string{"new.go"},
+		Modified: []string{"dir/changed.go", "link"},
+		Deleted:  []string{"gone.go"},
+	}
+	got, err := ParseGitDiffNameStatus(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseGitDiffNameStatus([]byte("R100\x00a\x00b\x00")); err == nil {
+		t.Error("expected error for rename status")
+	}
+}
+
 func createTar(files map[string]string) (io.ReadCloser, error) {
 	buf := new(bytes.Buffer)
 	w := tar.NewWriter(buf)
@@ -195,3 +312,16 @@ func (m mockParser) Parse(name string, content []byte) ([]ctags.Entry, error) {
 }
 
 func (mockParser) Close() {}
+
+// contentParser returns a symbol for each word in a file.
+type contentParser struct{}
+
+func (contentParser) Parse(name string, content []byte) ([]ctags.Entry, error) {
+	var entries []ctags.Entry
+	for _, word := range strings.Fields(string(content)) {
+		entries = append(entries, ctags.Entry{Name: word, Path: name})
+	}
+	return entries, nil
+}
+
+func (contentParser) Close() {}
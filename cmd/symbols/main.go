@@ -19,6 +19,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/gosymbols"
 	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/symbols"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/debugserver"
@@ -26,6 +27,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
 	"github.com/sourcegraph/sourcegraph/internal/tracer"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
 const port = "3184"
@@ -50,6 +52,29 @@ func main() {
 		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
 			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar"})
 		},
+		FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar", Paths: paths})
+		},
+		ListAncestors: func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error) {
+			commits, err := git.Commits(ctx, gitserver.Repo{Name: repo}, git.CommitsOptions{Range: string(commit), N: uint(n), Skip: 1})
+			if err != nil {
+				return nil, err
+			}
+			ancestors := make([]api.CommitID, 0, len(commits))
+			for _, c := range commits {
+				ancestors = append(ancestors, c.ID)
+			}
+			return ancestors, nil
+		},
+		GitDiff: func(ctx context.Context, repo api.RepoName, from, to api.CommitID) (symbols.Changes, error) {
+			cmd := gitserver.DefaultClient.Command("git", "diff", "-z", "--name-status", "--no-renames", string(from), string(to))
+			cmd.Repo = gitserver.Repo{Name: repo}
+			out, err := cmd.Output(ctx)
+			if err != nil {
+				return symbols.Changes{}, errors.WithMessage(err, fmt.Sprintf("git command %v failed", cmd.Args))
+			}
+			return symbols.ParseGitDiffNameStatus(out)
+		},
 		NewParser: func() (ctags.Parser, error) {
 			parser, err := ctags.NewParser(ctags.GetCommand())
 			if err != nil {
@@ -57,6 +82,9 @@ func main() {
 			}
 			return parser, nil
 		},
+		Parsers: map[string]symbols.FileParser{
+			".go": gosymbols.Parser{},
+		},
 		Path: cacheDir,
 	}
 	if mb, err := strconv.ParseInt(cacheSizeMB, 10, 64); err != nil {
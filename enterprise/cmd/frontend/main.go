@@ -74,7 +74,10 @@ func main() {
 	campaignsStore := campaigns.NewStoreWithClock(dbconn.Global, clock)
 	repositories := repos.NewDBStore(dbconn.Global, sql.TxOptions{})
 
+	httpapi.CampaignsExportHandler = campaignsResolvers.NewExportHandler(campaignsStore)
+
 	githubWebhook := campaigns.NewGitHubWebhook(campaignsStore, repositories, clock)
+	gitlabWebhook := campaigns.NewGitLabWebhook(campaignsStore, repositories, clock)
 
 	bitbucketWebhookName := "sourcegraph-" + globalState.SiteID
 	bitbucketServerWebhook := campaigns.NewBitbucketServerWebhook(
@@ -86,7 +89,7 @@ func main() {
 
 	go bitbucketServerWebhook.Upsert(30 * time.Second)
 
-	shared.Main(githubWebhook, bitbucketServerWebhook)
+	shared.Main(githubWebhook, gitlabWebhook, bitbucketServerWebhook)
 }
 
 func initLicensing() {
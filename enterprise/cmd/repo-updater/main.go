@@ -12,6 +12,7 @@ import (
 	ossAuthz "github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
 	ossDB "github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
 	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
 	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repoupdater"
 	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/shared"
@@ -61,6 +62,10 @@ func enterpriseInit(
 
 	sourcer := repos.NewSourcer(cf)
 	go campaigns.RunWorkers(ctx, campaignsStore, clock, gitserver.DefaultClient, sourcer, 5*time.Second)
+	go campaigns.RunPatchJobWorkers(ctx, campaignsStore, clock, graphqlbackend.ReplacerURL, 5*time.Second)
+	go campaigns.NewAutoMerger(campaignsStore, repoStore, cf, clock).Run(ctx, time.Minute)
+	go campaigns.NewRebaser(campaignsStore, repoStore, gitserver.DefaultClient, clock).Run(ctx, 10*time.Minute)
+	go campaigns.NewMetricsExporter(campaignsStore, clock).Run(ctx, 5*time.Minute)
 
 	// Set up expired patch set deletion
 	go func() {
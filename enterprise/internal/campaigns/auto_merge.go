@@ -0,0 +1,246 @@
+package campaigns
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	"github.com/sourcegraph/sourcegraph/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"golang.org/x/time/rate"
+)
+
+var (
+	autoMergeConcurrency = env.Get("CAMPAIGNS_AUTO_MERGE_CONCURRENCY", "2", "maximum number of changesets of auto-merge campaigns to merge in parallel")
+	autoMergeRate        = env.Get("CAMPAIGNS_AUTO_MERGE_RATE", "10", "maximum number of changesets of auto-merge campaigns to merge per minute")
+)
+
+const (
+	defaultAutoMergeConcurrency = 2
+	defaultAutoMergeRate        = 10
+)
+
+// autoMergeRetryInterval is how long the AutoMerger waits before it tries
+// again to merge a changeset that failed to merge and hasn't changed since.
+const autoMergeRetryInterval = time.Hour
+
+// An AutoMerger merges the changesets of open Campaigns with AutoMerge
+// enabled once they're open, approved and their checks passed, i.e. once
+// ComputeReviewState and ComputeCheckState say so.
+type AutoMerger struct {
+	Store       *Store
+	ReposStore  RepoStore
+	HTTPFactory *httpcli.Factory
+	Clock       func() time.Time
+
+	// Concurrency is the maximum number of changesets merged in parallel.
+	Concurrency int
+	// Limiter limits the rate of merges across all campaigns, so that
+	// merging hundreds of changesets doesn't flood the CI of the codehost.
+	Limiter *rate.Limiter
+}
+
+// NewAutoMerger returns an AutoMerger whose concurrency and rate are
+// configured by CAMPAIGNS_AUTO_MERGE_CONCURRENCY and CAMPAIGNS_AUTO_MERGE_RATE.
+func NewAutoMerger(store *Store, reposStore RepoStore, cf *httpcli.Factory, clock func() time.Time) *AutoMerger {
+	concurrency, err := strconv.Atoi(autoMergeConcurrency)
+	if err != nil || concurrency < 1 {
+		log15.Error("Parsing auto-merge concurrency failed. Falling back to default.", "default", defaultAutoMergeConcurrency, "value", autoMergeConcurrency, "err", err)
+		concurrency = defaultAutoMergeConcurrency
+	}
+
+	perMinute, err := strconv.Atoi(autoMergeRate)
+	if err != nil || perMinute < 1 {
+		log15.Error("Parsing auto-merge rate failed. Falling back to default.", "default", defaultAutoMergeRate, "value", autoMergeRate, "err", err)
+		perMinute = defaultAutoMergeRate
+	}
+
+	return &AutoMerger{
+		Store:       store,
+		ReposStore:  reposStore,
+		HTTPFactory: cf,
+		Clock:       clock,
+		Concurrency: concurrency,
+		Limiter:     rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), 1),
+	}
+}
+
+// Run merges the mergeable changesets every interval until ctx is canceled.
+// It should be executed in a background goroutine.
+func (m *AutoMerger) Run(ctx context.Context, interval time.Duration) {
+	for {
+		if err := m.MergeChangesets(ctx); err != nil {
+			log15.Error("Merging changesets of auto-merge campaigns", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// MergeChangesets merges the open, approved changesets whose checks passed
+// of all open Campaigns with AutoMerge enabled.
+func (m *AutoMerger) MergeChangesets(ctx context.Context) error {
+	cs, err := m.mergeableChangesets(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(cs) == 0 {
+		return nil
+	}
+
+	bySource, err := GroupChangesetsBySource(ctx, m.ReposStore, m.HTTPFactory, cs...)
+	if err != nil {
+		return err
+	}
+
+	return m.MergeChangesetsWithSources(ctx, bySource)
+}
+
+// mergeableChangesets returns the changesets of open Campaigns with AutoMerge
+// enabled that are open, approved and whose checks passed.
+func (m *AutoMerger) mergeableChangesets(ctx context.Context) ([]*campaigns.Changeset, error) {
+	var (
+		open     = campaigns.ChangesetStateOpen
+		approved = campaigns.ChangesetReviewStateApproved
+		passed   = campaigns.ChangesetCheckStatePassed
+	)
+
+	cs, _, err := m.Store.ListChangesets(ctx, ListChangesetsOpts{
+		Limit:               -1,
+		WithoutDeleted:      true,
+		ExternalState:       &open,
+		ExternalReviewState: &approved,
+		ExternalCheckState:  &passed,
+		AutoMerge:           true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing mergeable changesets")
+	}
+	return cs, nil
+}
+
+// MergeChangesetsWithSources merges the given changesets with the given
+// ChangesetSources, at most m.Concurrency at a time.
+func (m *AutoMerger) MergeChangesetsWithSources(ctx context.Context, bySource []*SourceChangesets) error {
+	type merge struct {
+		src repos.ChangesetSource
+		c   *repos.Changeset
+	}
+
+	merges := make(chan merge)
+	go func() {
+		defer close(merges)
+		for _, s := range bySource {
+			for _, c := range s.Changesets {
+				select {
+				case merges <- merge{src: s.ChangesetSource, c: c}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs *multierror.Error
+	)
+	for i := 0; i < m.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for mg := range merges {
+				if err := m.mergeChangeset(ctx, mg.src, mg.c); err != nil {
+					mu.Lock()
+					errs = multierror.Append(errs, errors.Wrapf(err, "merging changeset %d", mg.c.Changeset.ID))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errs.ErrorOrNil()
+}
+
+// mergeChangeset merges c on the codehost and updates it in the database. If
+// the codehost refuses to merge it, the failure is recorded as a
+// ChangesetEvent of c instead of being returned, and c isn't merged again
+// until it changes or autoMergeRetryInterval passed.
+func (m *AutoMerger) mergeChangeset(ctx context.Context, src repos.ChangesetSource, c *repos.Changeset) (err error) {
+	key, err := mergeFailedEventKey(c.Changeset)
+	if err != nil {
+		return err
+	}
+
+	failed, err := m.Store.GetChangesetEvent(ctx, GetChangesetEventOpts{
+		ChangesetID: c.Changeset.ID,
+		Kind:        campaigns.ChangesetEventKindMergeFailed,
+		Key:         key,
+	})
+	if err != nil && err != ErrNoResults {
+		return err
+	}
+	if failed != nil && m.Clock().Sub(failed.UpdatedAt) < autoMergeRetryInterval {
+		return nil
+	}
+
+	if err := m.Limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	if mergeErr := src.MergeChangeset(ctx, c); mergeErr != nil {
+		log15.Warn("Merging changeset of auto-merge campaign failed", "changeset", c.Changeset.ID, "err", mergeErr)
+		return m.Store.UpsertChangesetEvents(ctx, &campaigns.ChangesetEvent{
+			ChangesetID: c.Changeset.ID,
+			Kind:        campaigns.ChangesetEventKindMergeFailed,
+			Key:         key,
+			Metadata: &campaigns.ChangesetMergeFailedEvent{
+				Error:     mergeErr.Error(),
+				CreatedAt: m.Clock(),
+			},
+		})
+	}
+
+	events := c.Events()
+	SetDerivedState(c.Changeset, events)
+
+	tx, err := m.Store.Transact(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Done(&err)
+
+	if err = tx.UpdateChangesets(ctx, c.Changeset); err != nil {
+		return err
+	}
+
+	return tx.UpsertChangesetEvents(ctx, events...)
+}
+
+// mergeFailedEventKey returns the deduplication key of the
+// ChangesetMergeFailedEvent of c, which identifies the state of c that failed
+// to merge: its head commit or, if the codehost doesn't include it, the time
+// c was last updated on the codehost.
+func mergeFailedEventKey(c *campaigns.Changeset) (string, error) {
+	head, err := c.HeadRefOid()
+	if err != nil {
+		return "", err
+	}
+	if head != "" {
+		return head, nil
+	}
+	return c.ExternalUpdatedAt.UTC().Format(time.RFC3339Nano), nil
+}
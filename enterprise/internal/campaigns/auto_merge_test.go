@@ -0,0 +1,177 @@
+package campaigns
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	cmpgn "github.com/sourcegraph/sourcegraph/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtest"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"golang.org/x/time/rate"
+)
+
+func TestAutoMerger(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	clock := func() time.Time { return now.UTC().Truncate(time.Microsecond) }
+
+	dbtesting.SetupGlobalTestDB(t)
+
+	tx := dbtest.NewTx(t, dbconn.Global)
+	s := NewStoreWithClock(tx, clock)
+
+	repo, extSvc := createGitHubRepo(t, ctx, now, s)
+
+	createCampaign := func(name string, autoMerge bool, closedAt time.Time) *cmpgn.Campaign {
+		c := &cmpgn.Campaign{
+			Name:            name,
+			AuthorID:        888,
+			NamespaceUserID: 888,
+			AutoMerge:       autoMerge,
+			ClosedAt:        closedAt,
+		}
+		if err := s.CreateCampaign(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	autoMerge := createCampaign("auto-merge", true, time.Time{})
+	manual := createCampaign("manual", false, time.Time{})
+	closed := createCampaign("closed", true, now)
+
+	createChangeset := func(number int64, campaign *cmpgn.Campaign, review cmpgn.ChangesetReviewState) *cmpgn.Changeset {
+		c := &cmpgn.Changeset{
+			RepoID:              repo.ID,
+			CampaignIDs:         []int64{campaign.ID},
+			ExternalState:       cmpgn.ChangesetStateOpen,
+			ExternalReviewState: review,
+			ExternalCheckState:  cmpgn.ChangesetCheckStatePassed,
+		}
+		c.SetMetadata(&github.PullRequest{
+			ID:         "PR-" + string(rune('a'+number)),
+			Number:     number,
+			State:      "OPEN",
+			HeadRefOid: "head-" + string(rune('a'+number)),
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		})
+		if err := s.CreateChangesets(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	mergeable := createChangeset(1, autoMerge, cmpgn.ChangesetReviewStateApproved)
+	conflicting := createChangeset(2, autoMerge, cmpgn.ChangesetReviewStateApproved)
+	createChangeset(3, autoMerge, cmpgn.ChangesetReviewStatePending)
+	createChangeset(4, manual, cmpgn.ChangesetReviewStateApproved)
+	createChangeset(5, closed, cmpgn.ChangesetReviewStateApproved)
+
+	src := &fakeMergeSource{
+		fakeChangesetSource: fakeChangesetSource{svc: extSvc},
+		errs:                map[int64]error{conflicting.ID: errors.New("pull request has conflicts")},
+	}
+
+	m := &AutoMerger{
+		Store:       s,
+		Clock:       clock,
+		Concurrency: 2,
+		Limiter:     rate.NewLimiter(rate.Inf, 1),
+	}
+
+	run := func() {
+		t.Helper()
+
+		cs, err := m.mergeableChangesets(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bySource := []*SourceChangesets{{ChangesetSource: src}}
+		for _, c := range cs {
+			bySource[0].Changesets = append(bySource[0].Changesets, &repos.Changeset{Changeset: c, Repo: repo})
+		}
+
+		if err := m.MergeChangesetsWithSources(ctx, bySource); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run()
+
+	if have, want := src.attempted(), []int64{mergeable.ID, conflicting.ID}; !cmp.Equal(have, want) {
+		t.Fatalf("wrong merge attempts. have=%v, want=%v", have, want)
+	}
+
+	merged, err := s.GetChangeset(ctx, GetChangesetOpts{ID: mergeable.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.ExternalState != cmpgn.ChangesetStateMerged {
+		t.Errorf("changeset not merged. state=%s", merged.ExternalState)
+	}
+
+	failed, err := s.GetChangesetEvent(ctx, GetChangesetEventOpts{
+		ChangesetID: conflicting.ID,
+		Kind:        cmpgn.ChangesetEventKindMergeFailed,
+		Key:         "head-c",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &cmpgn.ChangesetMergeFailedEvent{Error: "pull request has conflicts", CreatedAt: now}
+	if diff := cmp.Diff(want, failed.Metadata); diff != "" {
+		t.Errorf("wrong merge failed event: %s", diff)
+	}
+
+	// The failed changeset isn't merged again until it changes or the retry
+	// interval passed.
+	run()
+	if have, want := len(src.attempted()), 2; have != want {
+		t.Fatalf("wrong number of merge attempts. have=%d, want=%d", have, want)
+	}
+
+	now = now.Add(autoMergeRetryInterval + time.Minute)
+	run()
+	if have, want := src.attempted(), []int64{mergeable.ID, conflicting.ID, conflicting.ID}; !cmp.Equal(have, want) {
+		t.Fatalf("wrong merge attempts. have=%v, want=%v", have, want)
+	}
+}
+
+type fakeMergeSource struct {
+	fakeChangesetSource
+
+	mu       sync.Mutex
+	attempts []int64
+	errs     map[int64]error
+}
+
+func (s *fakeMergeSource) MergeChangeset(ctx context.Context, c *repos.Changeset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts = append(s.attempts, c.Changeset.ID)
+	if err := s.errs[c.Changeset.ID]; err != nil {
+		return err
+	}
+
+	c.Changeset.Metadata.(*github.PullRequest).State = "MERGED"
+	return nil
+}
+
+func (s *fakeMergeSource) attempted() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts := append([]int64(nil), s.attempts...)
+	sort.Slice(attempts, func(i, j int) bool { return attempts[i] < attempts[j] })
+	return attempts
+}
@@ -1,6 +1,7 @@
 package campaigns
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"time"
@@ -134,6 +135,40 @@ func CalcCounts(start, end time.Time, cs []*campaigns.Changeset, es ...Event) ([
 	return counts, nil
 }
 
+// CalcCampaignCounts calculates the ChangesetCounts of all Changesets of the
+// Campaign with the given ID between start and end with CalcCounts, using the
+// Changesets and ChangesetEvents in the given Store.
+func CalcCampaignCounts(ctx context.Context, s *Store, campaignID int64, start, end time.Time) ([]*ChangesetCounts, error) {
+	cs, _, err := s.ListChangesets(ctx, ListChangesetsOpts{CampaignID: campaignID, Limit: -1})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cs) == 0 {
+		return CalcCounts(start, end, cs)
+	}
+
+	changesetIDs := make([]int64, len(cs))
+	for i, c := range cs {
+		changesetIDs[i] = c.ID
+	}
+
+	es, _, err := s.ListChangesetEvents(ctx, ListChangesetEventsOpts{
+		ChangesetIDs: changesetIDs,
+		Limit:        -1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, len(es))
+	for i, e := range es {
+		events[i] = e
+	}
+
+	return CalcCounts(start, end, cs, events...)
+}
+
 func computeCounts(c *ChangesetCounts, csEvents Events) error {
 	var (
 		// Since "Merged" and "Closed" are exclusive events and cancel each others
@@ -166,7 +201,8 @@ func computeCounts(c *ChangesetCounts, csEvents Events) error {
 
 		switch e.Type() {
 		case campaigns.ChangesetEventKindGitHubClosed,
-			campaigns.ChangesetEventKindBitbucketServerDeclined:
+			campaigns.ChangesetEventKindBitbucketServerDeclined,
+			campaigns.ChangesetEventKindGitLabClosed:
 
 			c.Open--
 			c.Closed++
@@ -175,7 +211,8 @@ func computeCounts(c *ChangesetCounts, csEvents Events) error {
 			c.AddReviewState(currentReviewState, -1)
 
 		case campaigns.ChangesetEventKindGitHubReopened,
-			campaigns.ChangesetEventKindBitbucketServerReopened:
+			campaigns.ChangesetEventKindBitbucketServerReopened,
+			campaigns.ChangesetEventKindGitLabReopened:
 
 			c.Open++
 			c.Closed--
@@ -184,7 +221,8 @@ func computeCounts(c *ChangesetCounts, csEvents Events) error {
 			c.AddReviewState(currentReviewState, 1)
 
 		case campaigns.ChangesetEventKindGitHubMerged,
-			campaigns.ChangesetEventKindBitbucketServerMerged:
+			campaigns.ChangesetEventKindBitbucketServerMerged,
+			campaigns.ChangesetEventKindGitLabMerged:
 
 			// If it was closed, all "review counts" have been updated by the
 			// closed events and we just need to reverse these two counts
@@ -205,7 +243,8 @@ func computeCounts(c *ChangesetCounts, csEvents Events) error {
 
 		case campaigns.ChangesetEventKindGitHubReviewed,
 			campaigns.ChangesetEventKindBitbucketServerApproved,
-			campaigns.ChangesetEventKindBitbucketServerReviewed:
+			campaigns.ChangesetEventKindBitbucketServerReviewed,
+			campaigns.ChangesetEventKindGitLabApproved:
 
 			s, err := reviewState(e)
 			if err != nil {
@@ -250,7 +289,8 @@ func computeCounts(c *ChangesetCounts, csEvents Events) error {
 				c.AddReviewState(newReviewState, 1)
 			}
 
-		case campaigns.ChangesetEventKindBitbucketServerUnapproved:
+		case campaigns.ChangesetEventKindBitbucketServerUnapproved,
+			campaigns.ChangesetEventKindGitLabUnapproved:
 			// We specifically ignore ChangesetEventKindGitHubReviewDismissed
 			// events since GitHub updates the original
 			// ChangesetEventKindGitHubReviewed event when a review has been
@@ -264,12 +304,13 @@ func computeCounts(c *ChangesetCounts, csEvents Events) error {
 				continue
 			}
 
-			if e.Type() == campaigns.ChangesetEventKindBitbucketServerUnapproved {
-				// A BitbucketServer Unapproved can only follow a previous Approved by
-				// the same author.
+			if e.Type() == campaigns.ChangesetEventKindBitbucketServerUnapproved ||
+				e.Type() == campaigns.ChangesetEventKindGitLabUnapproved {
+				// A BitbucketServer or GitLab Unapproved can only follow a
+				// previous Approved by the same author.
 				lastReview, ok := lastReviewByAuthor[author]
 				if !ok || lastReview != campaigns.ChangesetReviewStateApproved {
-					log15.Warn("Bitbucket Server Unapproval not following an Approval", "event", e)
+					log15.Warn("Unapproval not following an Approval", "event", e)
 					continue
 				}
 			}
@@ -0,0 +1,130 @@
+package campaigns
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sourcegraph/sourcegraph/internal/campaigns"
+)
+
+var (
+	campaignInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "src",
+		Subsystem: "campaigns",
+		Name:      "campaign_info",
+		Help:      "Always 1, labeled with the name of each open campaign",
+	}, []string{"campaign", "name"})
+
+	campaignChangesets = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "src",
+		Subsystem: "campaigns",
+		Name:      "changesets",
+		Help:      "The number of changesets of each open campaign by state",
+	}, []string{"campaign", "state"})
+
+	campaignOpenChangesets = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "src",
+		Subsystem: "campaigns",
+		Name:      "open_changesets",
+		Help:      "The number of open changesets of each open campaign by review state",
+	}, []string{"campaign", "review_state"})
+
+	campaignsLastExport = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "src",
+		Subsystem: "campaigns",
+		Name:      "metrics_last_export_time",
+		Help:      "The last time the campaign metrics were exported",
+	})
+)
+
+// A MetricsExporter periodically exports the current ChangesetCounts of all
+// open Campaigns as Prometheus gauges, labeled with the ID of the campaign.
+// They are the latest values of the burndown chart of each campaign.
+type MetricsExporter struct {
+	Store *Store
+	Clock func() time.Time
+}
+
+// NewMetricsExporter returns a MetricsExporter for the Campaigns in store.
+func NewMetricsExporter(store *Store, clock func() time.Time) *MetricsExporter {
+	return &MetricsExporter{Store: store, Clock: clock}
+}
+
+// Run exports the campaign metrics every interval until ctx is canceled.
+// It should be executed in a background goroutine.
+func (e *MetricsExporter) Run(ctx context.Context, interval time.Duration) {
+	for {
+		if err := e.Export(ctx); err != nil {
+			log15.Error("Exporting campaign metrics", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Export computes the ChangesetCounts of all open Campaigns and replaces the
+// exported gauges with them, so that closed and deleted campaigns are no
+// longer exported.
+func (e *MetricsExporter) Export(ctx context.Context) error {
+	var (
+		cs   []*campaigns.Campaign
+		opts = ListCampaignsOpts{State: campaigns.CampaignStateOpen, Limit: 500}
+	)
+	for {
+		page, next, err := e.Store.ListCampaigns(ctx, opts)
+		if err != nil {
+			return errors.Wrap(err, "listing campaigns")
+		}
+		cs = append(cs, page...)
+
+		if next == 0 {
+			break
+		}
+		opts.Cursor = next
+	}
+
+	now := e.Clock()
+	counts := make(map[*campaigns.Campaign]*ChangesetCounts, len(cs))
+	for _, c := range cs {
+		cc, err := CalcCampaignCounts(ctx, e.Store, c.ID, now, now)
+		if err != nil {
+			return errors.Wrapf(err, "calculating changeset counts of campaign %d", c.ID)
+		}
+		if len(cc) != 1 {
+			return errors.Errorf("unexpected number of changeset counts for campaign %d: %d", c.ID, len(cc))
+		}
+		counts[c] = cc[0]
+	}
+
+	// We only touch the gauges once all counts are computed, so that they're
+	// never scraped in a partially exported state.
+	campaignInfo.Reset()
+	campaignChangesets.Reset()
+	campaignOpenChangesets.Reset()
+
+	for c, cc := range counts {
+		id := strconv.FormatInt(c.ID, 10)
+
+		campaignInfo.WithLabelValues(id, c.Name).Set(1)
+
+		campaignChangesets.WithLabelValues(id, "open").Set(float64(cc.Open))
+		campaignChangesets.WithLabelValues(id, "merged").Set(float64(cc.Merged))
+		campaignChangesets.WithLabelValues(id, "closed").Set(float64(cc.Closed))
+
+		campaignOpenChangesets.WithLabelValues(id, "approved").Set(float64(cc.OpenApproved))
+		campaignOpenChangesets.WithLabelValues(id, "changes_requested").Set(float64(cc.OpenChangesRequested))
+		campaignOpenChangesets.WithLabelValues(id, "pending").Set(float64(cc.OpenPending))
+	}
+
+	campaignsLastExport.Set(float64(now.Unix()))
+	return nil
+}
@@ -0,0 +1,111 @@
+package campaigns
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	cmpgn "github.com/sourcegraph/sourcegraph/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+)
+
+func TestMetricsExporter(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	clock := func() time.Time { return now.UTC().Truncate(time.Microsecond) }
+
+	dbtesting.SetupGlobalTestDB(t)
+
+	s := NewStoreWithClock(dbconn.Global, clock)
+
+	repo, _ := createGitHubRepo(t, ctx, now, s)
+
+	var campaigns []*cmpgn.Campaign
+	for i := 0; i < 2; i++ {
+		campaign, _ := createCampaignPatch(t, ctx, now, s, repo)
+		campaign.Name = "campaign-" + strconv.Itoa(i)
+		campaign.ClosedAt = time.Time{}
+		if err := s.UpdateCampaign(ctx, campaign); err != nil {
+			t.Fatal(err)
+		}
+		campaigns = append(campaigns, campaign)
+	}
+
+	// The first campaign has an open and a merged changeset, the second one
+	// an open changeset.
+	var changesets []*cmpgn.Changeset
+	for i, campaign := range []*cmpgn.Campaign{campaigns[0], campaigns[0], campaigns[1]} {
+		changeset := testChangeset(repo.ID, campaign.ID, int64(i), cmpgn.ChangesetStateOpen)
+		changeset.Metadata = &github.PullRequest{CreatedAt: now.Add(-2 * time.Hour)}
+		if err := s.CreateChangesets(ctx, changeset); err != nil {
+			t.Fatal(err)
+		}
+		changesets = append(changesets, changeset)
+	}
+	merged := &cmpgn.ChangesetEvent{
+		ChangesetID: changesets[1].ID,
+		Kind:        cmpgn.ChangesetEventKindGitHubMerged,
+		Key:         "merged",
+		Metadata:    &github.MergedEvent{CreatedAt: now.Add(-time.Hour)},
+	}
+	if err := s.UpsertChangesetEvents(ctx, merged); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewMetricsExporter(s, clock)
+	if err := e.Export(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	id0 := strconv.FormatInt(campaigns[0].ID, 10)
+	id1 := strconv.FormatInt(campaigns[1].ID, 10)
+
+	for _, tc := range []struct {
+		name  string
+		value float64
+		want  float64
+	}{
+		{"info 0", testutil.ToFloat64(campaignInfo.WithLabelValues(id0, "campaign-0")), 1},
+		{"info 1", testutil.ToFloat64(campaignInfo.WithLabelValues(id1, "campaign-1")), 1},
+		{"open 0", testutil.ToFloat64(campaignChangesets.WithLabelValues(id0, "open")), 1},
+		{"merged 0", testutil.ToFloat64(campaignChangesets.WithLabelValues(id0, "merged")), 1},
+		{"closed 0", testutil.ToFloat64(campaignChangesets.WithLabelValues(id0, "closed")), 0},
+		{"open 1", testutil.ToFloat64(campaignChangesets.WithLabelValues(id1, "open")), 1},
+		{"pending 1", testutil.ToFloat64(campaignOpenChangesets.WithLabelValues(id1, "pending")), 1},
+		{"last export", testutil.ToFloat64(campaignsLastExport), float64(now.Unix())},
+	} {
+		if tc.value != tc.want {
+			t.Errorf("%s: have %v, want %v", tc.name, tc.value, tc.want)
+		}
+	}
+	if have, want := testutil.CollectAndCount(campaignInfo), 2; have != want {
+		t.Errorf("have %d campaigns exported, want %d", have, want)
+	}
+
+	// Closed campaigns are no longer exported.
+	campaigns[1].ClosedAt = now
+	if err := s.UpdateCampaign(ctx, campaigns[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Export(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := testutil.CollectAndCount(campaignInfo), 1; have != want {
+		t.Errorf("have %d campaigns exported, want %d", have, want)
+	}
+	if have, want := testutil.CollectAndCount(campaignChangesets), 3; have != want {
+		t.Errorf("have %d changeset gauges, want %d", have, want)
+	}
+	if have, want := testutil.CollectAndCount(campaignOpenChangesets), 3; have != want {
+		t.Errorf("have %d open changeset gauges, want %d", have, want)
+	}
+	if have := testutil.ToFloat64(campaignInfo.WithLabelValues(id0, "campaign-0")); have != 1 {
+		t.Errorf("info 0: have %v, want 1", have)
+	}
+}
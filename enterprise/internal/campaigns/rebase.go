@@ -0,0 +1,193 @@
+package campaigns
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+var rebaseInterval = env.Get("CAMPAIGNS_REBASE_INTERVAL", "24h", "minimum interval between two rebases of the branch of a campaign changeset on its base branch")
+
+const defaultRebaseInterval = 24 * time.Hour
+
+// A Rebaser keeps the branches of the open changesets of open Campaigns up to
+// date with their base branch: once the base branch moved, it applies the
+// Patch of the changeset on the new head of the base branch and force-pushes
+// the result to the changeset's branch.
+//
+// If the Patch doesn't apply anymore, the branch is left untouched and the
+// failure is recorded in the RebaseError of the ChangesetJob.
+type Rebaser struct {
+	Store      *Store
+	ReposStore RepoStore
+	GitClient  GitserverClient
+	Clock      func() time.Time
+
+	// MinInterval is the minimum time between two rebases of the same
+	// changeset, so that busy base branches don't rerun the CI of all
+	// changesets on every commit.
+	MinInterval time.Duration
+}
+
+// NewRebaser returns a Rebaser whose MinInterval is configured by
+// CAMPAIGNS_REBASE_INTERVAL.
+func NewRebaser(store *Store, reposStore RepoStore, gitClient GitserverClient, clock func() time.Time) *Rebaser {
+	interval, err := time.ParseDuration(rebaseInterval)
+	if err != nil || interval < 0 {
+		log15.Error("Parsing rebase interval failed. Falling back to default.", "default", defaultRebaseInterval, "value", rebaseInterval, "err", err)
+		interval = defaultRebaseInterval
+	}
+
+	return &Rebaser{
+		Store:       store,
+		ReposStore:  reposStore,
+		GitClient:   gitClient,
+		Clock:       clock,
+		MinInterval: interval,
+	}
+}
+
+// Run rebases the outdated changesets every interval until ctx is canceled.
+// It should be executed in a background goroutine.
+func (r *Rebaser) Run(ctx context.Context, interval time.Duration) {
+	for {
+		if err := r.RebaseChangesets(ctx); err != nil {
+			log15.Error("Rebasing campaign changesets", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// RebaseChangesets rebases the branches of the open changesets of all open
+// Campaigns whose base branch moved since they were last created or rebased.
+func (r *Rebaser) RebaseChangesets(ctx context.Context) error {
+	jobs, _, err := r.Store.ListChangesetJobs(ctx, ListChangesetJobsOpts{
+		Limit:    -1,
+		OnlyOpen: true,
+	})
+	if err != nil {
+		return errors.Wrap(err, "listing changeset jobs")
+	}
+
+	var (
+		errs *multierror.Error
+		cs   = map[int64]*campaigns.Campaign{}
+	)
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if r.Clock().Sub(job.UpdatedAt) < r.MinInterval {
+			continue
+		}
+
+		c, ok := cs[job.CampaignID]
+		if !ok {
+			c, err = r.Store.GetCampaign(ctx, GetCampaignOpts{ID: job.CampaignID})
+			if err != nil {
+				return errors.Wrap(err, "getting campaign")
+			}
+			cs[job.CampaignID] = c
+		}
+
+		if err := r.rebaseLocked(ctx, c, job); err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "rebasing changeset %d", job.ChangesetID))
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// rebaseLocked rebases job while holding the lock on its row, so that it
+// doesn't push over the branch while ExecChangesetJob executes the job, nor
+// while Service.UpdateCampaign changes its Patch. Jobs that are locked or that
+// changed since they were listed are skipped until the next pass.
+func (r *Rebaser) rebaseLocked(ctx context.Context, c *campaigns.Campaign, listed *campaigns.ChangesetJob) (err error) {
+	tx, err := r.Store.Transact(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Done(&err)
+
+	job, err := tx.LockChangesetJob(ctx, listed.ID)
+	if err == ErrNoResults {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if job.PatchID != listed.PatchID || !job.UpdatedAt.Equal(listed.UpdatedAt) {
+		return nil
+	}
+
+	return r.rebase(ctx, tx, c, job)
+}
+
+// rebase applies the Patch of job on the current head of its base branch and
+// force-pushes it to the branch of job, unless the base branch didn't move
+// since job.BaseCommit. Only the BaseCommit and RebaseError of job are updated
+// in the given store.
+func (r *Rebaser) rebase(ctx context.Context, tx *Store, c *campaigns.Campaign, job *campaigns.ChangesetJob) error {
+	patch, err := tx.GetPatch(ctx, GetPatchOpts{ID: job.PatchID})
+	if err != nil {
+		return err
+	}
+
+	rs, err := r.ReposStore.ListRepos(ctx, repos.StoreListReposArgs{IDs: []api.RepoID{patch.RepoID}})
+	if err != nil {
+		return err
+	}
+	if len(rs) != 1 {
+		return errors.Errorf("repo not found: %d", patch.RepoID)
+	}
+	repo := rs[0]
+
+	baseRef := "refs/heads/master"
+	if patch.BaseRef != "" {
+		baseRef = patch.BaseRef
+	}
+
+	head, err := git.ResolveRevision(ctx, gitserver.Repo{Name: api.RepoName(repo.Name)}, nil, baseRef, nil)
+	if err != nil {
+		return errors.Wrapf(err, "resolving base ref %q", baseRef)
+	}
+
+	base := job.BaseCommit
+	if base == "" {
+		base = patch.Rev
+	}
+	if head == base {
+		return nil
+	}
+
+	_, err = pushPatch(ctx, r.GitClient, repo, c, patch, head, job.Branch, false, r.Clock())
+	if err != nil {
+		msg, ok := describePatchError(err)
+		if !ok {
+			return err
+		}
+		log15.Warn("Patch of campaign changeset doesn't apply on its base branch anymore", "changeset", job.ChangesetID, "base", head)
+		job.RebaseError = msg
+	} else {
+		job.RebaseError = ""
+	}
+
+	job.BaseCommit = head
+	return tx.UpdateChangesetJobRebase(ctx, job)
+}
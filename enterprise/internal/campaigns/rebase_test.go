@@ -0,0 +1,213 @@
+package campaigns
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	cmpgn "github.com/sourcegraph/sourcegraph/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestRebaser(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	clock := func() time.Time { return now.UTC().Truncate(time.Microsecond) }
+
+	dbtesting.SetupGlobalTestDB(t)
+
+	// The Rebaser commits its own transactions.
+	s := NewStoreWithClock(dbconn.Global, clock)
+
+	repo, _ := createGitHubRepo(t, ctx, now, s)
+	campaign, patch := createCampaignPatch(t, ctx, now, s, repo)
+
+	campaign.ClosedAt = time.Time{}
+	if err := s.UpdateCampaign(ctx, campaign); err != nil {
+		t.Fatal(err)
+	}
+
+	changeset := &cmpgn.Changeset{
+		RepoID:        repo.ID,
+		CampaignIDs:   []int64{campaign.ID},
+		ExternalState: cmpgn.ChangesetStateOpen,
+	}
+	if err := s.CreateChangesets(ctx, changeset); err != nil {
+		t.Fatal(err)
+	}
+
+	job := &cmpgn.ChangesetJob{
+		CampaignID:  campaign.ID,
+		PatchID:     patch.ID,
+		ChangesetID: changeset.ID,
+		Branch:      campaign.Branch,
+		StartedAt:   now.Add(-48 * time.Hour),
+		FinishedAt:  now.Add(-48 * time.Hour),
+		CreatedAt:   now.Add(-48 * time.Hour),
+		UpdatedAt:   now.Add(-48 * time.Hour),
+	}
+	if err := s.CreateChangesetJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	var head api.CommitID
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		if spec != patch.BaseRef {
+			t.Fatalf("wrong base ref resolved. have=%q, want=%q", spec, patch.BaseRef)
+		}
+		return head, nil
+	}
+	defer git.ResetMocks()
+
+	gitClient := &recordingGitserverClient{}
+	r := &Rebaser{
+		Store:       s,
+		ReposStore:  repos.NewDBStore(s.DB(), sql.TxOptions{}),
+		GitClient:   gitClient,
+		Clock:       clock,
+		MinInterval: 24 * time.Hour,
+	}
+
+	rebase := func(wantErr bool) *cmpgn.ChangesetJob {
+		t.Helper()
+
+		gitClient.reqs = nil
+		if err := r.RebaseChangesets(ctx); (err != nil) != wantErr {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		job, err := s.GetChangesetJob(ctx, GetChangesetJobOpts{ID: job.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+
+	t.Run("base didn't move", func(t *testing.T) {
+		head = patch.Rev
+		rebase(false)
+		if len(gitClient.reqs) != 0 {
+			t.Fatalf("changeset rebased although base didn't move: %+v", gitClient.reqs)
+		}
+	})
+
+	t.Run("base moved", func(t *testing.T) {
+		head = "b4se2"
+		have := rebase(false)
+
+		if len(gitClient.reqs) != 1 {
+			t.Fatalf("wrong number of rebases. have=%d, want=1", len(gitClient.reqs))
+		}
+		req := gitClient.reqs[0]
+		if req.BaseCommit != head || req.TargetRef != campaign.Branch || req.UniqueRef || !req.Push {
+			t.Errorf("wrong rebase request: %+v", req)
+		}
+		if req.Patch != patch.Diff+"\n" {
+			t.Errorf("wrong patch. have=%q, want=%q", req.Patch, patch.Diff+"\n")
+		}
+
+		if diff := cmp.Diff([]string{string(head), ""}, []string{string(have.BaseCommit), have.RebaseError}); diff != "" {
+			t.Errorf("wrong changeset job: %s", diff)
+		}
+	})
+
+	t.Run("rebased recently", func(t *testing.T) {
+		head = "b4se3"
+		rebase(false)
+		if len(gitClient.reqs) != 0 {
+			t.Fatalf("changeset rebased within MinInterval: %+v", gitClient.reqs)
+		}
+	})
+
+	t.Run("patch doesn't apply", func(t *testing.T) {
+		now = now.Add(25 * time.Hour)
+		gitClient.err = &protocol.CreateCommitFromPatchError{
+			RepositoryName: repo.Name,
+			InternalError:  "applying patch failed",
+			Command:        "git apply -p0 --unidiff-zero",
+			CombinedOutput: "error: patch failed: README.md:1",
+		}
+		have := rebase(false)
+
+		if len(gitClient.reqs) != 1 {
+			t.Fatalf("wrong number of rebases. have=%d, want=1", len(gitClient.reqs))
+		}
+		if have.BaseCommit != head {
+			t.Errorf("wrong base commit. have=%q, want=%q", have.BaseCommit, head)
+		}
+		if !strings.Contains(have.RebaseError, "patch failed: README.md:1") {
+			t.Errorf("rebase error doesn't contain git output: %q", have.RebaseError)
+		}
+		if have.Error != "" {
+			t.Errorf("changeset job failed: %q", have.Error)
+		}
+	})
+
+	t.Run("job changed since listing", func(t *testing.T) {
+		now = now.Add(25 * time.Hour)
+		head = "b4se5"
+		gitClient.err = nil
+
+		listed, err := s.GetChangesetJob(ctx, GetChangesetJobOpts{ID: job.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The campaign is updated while the Rebaser works through its list.
+		updated := *listed
+		updated.FinishedAt = now
+		if err := s.UpdateChangesetJob(ctx, &updated); err != nil {
+			t.Fatal(err)
+		}
+
+		gitClient.reqs = nil
+		if err := r.rebaseLocked(ctx, campaign, listed); err != nil {
+			t.Fatal(err)
+		}
+		if len(gitClient.reqs) != 0 {
+			t.Fatalf("changeset rebased although its job changed: %+v", gitClient.reqs)
+		}
+
+		have, err := s.GetChangesetJob(ctx, GetChangesetJobOpts{ID: job.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(&updated, have); diff != "" {
+			t.Errorf("changeset job overwritten: %s", diff)
+		}
+	})
+
+	t.Run("gitserver unavailable", func(t *testing.T) {
+		now = now.Add(25 * time.Hour)
+		head = "b4se4"
+		gitClient.err = errors.New("gitserver unavailable")
+		have := rebase(true)
+
+		if have.BaseCommit != "b4se3" {
+			t.Errorf("base commit updated although rebase wasn't attempted: %q", have.BaseCommit)
+		}
+	})
+}
+
+type recordingGitserverClient struct {
+	reqs []protocol.CreateCommitFromPatchRequest
+	err  error
+}
+
+func (c *recordingGitserverClient) CreateCommitFromPatch(ctx context.Context, req protocol.CreateCommitFromPatchRequest) (string, error) {
+	c.reqs = append(c.reqs, req)
+	if c.err != nil {
+		return "", c.err
+	}
+	return req.TargetRef, nil
+}
@@ -139,6 +139,10 @@ func (r *campaignResolver) ClosedAt() *graphqlbackend.DateTime {
 	return &graphqlbackend.DateTime{Time: r.Campaign.ClosedAt}
 }
 
+func (r *campaignResolver) AutoMerge() bool {
+	return r.Campaign.AutoMerge
+}
+
 func (r *campaignResolver) PublishedAt(ctx context.Context) (*graphqlbackend.DateTime, error) {
 	if r.Campaign.PatchSetID == 0 {
 		return &graphqlbackend.DateTime{Time: r.Campaign.CreatedAt}, nil
@@ -199,55 +203,46 @@ func (r *campaignResolver) ChangesetCountsOverTime(
 
 	resolvers := []graphqlbackend.ChangesetCountsResolver{}
 
-	opts := ee.ListChangesetsOpts{CampaignID: r.Campaign.ID}
-	cs, _, err := r.store.ListChangesets(ctx, opts)
-	if err != nil {
-		return resolvers, err
-	}
-
-	weekAgo := time.Now().Add(-7 * 24 * time.Hour)
-	start := r.Campaign.CreatedAt.UTC()
-	if start.After(weekAgo) {
-		start = weekAgo
-	}
+	var from, to *time.Time
 	if args.From != nil {
-		start = args.From.Time.UTC()
+		from = &args.From.Time
 	}
-
-	end := time.Now().UTC()
-	if args.To != nil && args.To.Time.Before(end) {
-		end = args.To.Time.UTC()
+	if args.To != nil {
+		to = &args.To.Time
 	}
+	start, end := changesetCountsTimeframe(r.Campaign, from, to)
 
-	changesetIDs := make([]int64, len(cs))
-	for i, c := range cs {
-		changesetIDs[i] = c.ID
-	}
-
-	eventsOpts := ee.ListChangesetEventsOpts{
-		ChangesetIDs: changesetIDs,
-		Limit:        -1,
-	}
-	es, _, err := r.store.ListChangesetEvents(ctx, eventsOpts)
+	counts, err := ee.CalcCampaignCounts(ctx, r.store, r.Campaign.ID, start, end)
 	if err != nil {
 		return resolvers, err
 	}
 
-	events := make([]ee.Event, len(es))
-	for i, e := range es {
-		events[i] = e
+	for _, c := range counts {
+		resolvers = append(resolvers, &changesetCountsResolver{counts: c})
 	}
 
-	counts, err := ee.CalcCounts(start, end, cs, events...)
-	if err != nil {
-		return resolvers, err
+	return resolvers, nil
+}
+
+// changesetCountsTimeframe returns the timeframe of the ChangesetCounts of
+// the given Campaign. It defaults to the lifetime of the Campaign, but at
+// least the last week, and never ends in the future.
+func changesetCountsTimeframe(c *campaigns.Campaign, from, to *time.Time) (start, end time.Time) {
+	weekAgo := time.Now().Add(-7 * 24 * time.Hour)
+	start = c.CreatedAt.UTC()
+	if start.After(weekAgo) {
+		start = weekAgo
+	}
+	if from != nil {
+		start = from.UTC()
 	}
 
-	for _, c := range counts {
-		resolvers = append(resolvers, &changesetCountsResolver{counts: c})
+	end = time.Now().UTC()
+	if to != nil && to.Before(end) {
+		end = to.UTC()
 	}
 
-	return resolvers, nil
+	return start, end
 }
 
 func (r *campaignResolver) PatchSet(ctx context.Context) (graphqlbackend.PatchSetResolver, error) {
@@ -255,6 +255,20 @@ func (r *changesetResolver) Labels(ctx context.Context) ([]graphqlbackend.Change
 	return resolvers, nil
 }
 
+func (r *changesetResolver) RebaseError(ctx context.Context) (*string, error) {
+	job, err := r.store.GetChangesetJob(ctx, ee.GetChangesetJobOpts{ChangesetID: r.Changeset.ID})
+	if err == ee.ErrNoResults {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if job.RebaseError == "" {
+		return nil, nil
+	}
+	return &job.RebaseError, nil
+}
+
 func (r *changesetResolver) Events(ctx context.Context, args *struct {
 	graphqlutil.ConnectionArgs
 }) (graphqlbackend.ChangesetEventsConnectionResolver, error) {
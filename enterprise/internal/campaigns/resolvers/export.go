@@ -0,0 +1,209 @@
+package resolvers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	ee "github.com/sourcegraph/sourcegraph/enterprise/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+// NewExportHandler returns an http.Handler that exports a campaign as CSV.
+// It expects the GraphQL ID of the campaign in the "id" route variable and
+// the table to export in the "table" route variable:
+//
+//   - "counts": the ChangesetCounts over time of the campaign, as returned by
+//     the changesetCountsOverTime GraphQL field, including its optional "from"
+//     and "to" arguments as RFC 3339 URL query parameters.
+//   - "changesets": the current state of each changeset of the campaign.
+func NewExportHandler(store *ee.Store) http.Handler {
+	return &exportHandler{store: store}
+}
+
+type exportHandler struct {
+	store *ee.Store
+}
+
+func (h *exportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.serve(w, r); err != nil {
+		http.Error(w, err.Error(), errcode.HTTP(err))
+	}
+}
+
+func (h *exportHandler) serve(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	// 🚨 SECURITY: Only site admins or users when read-access is enabled may access campaigns.
+	if err := allowReadAccess(ctx); err != nil {
+		return &errcode.HTTPErr{Status: http.StatusForbidden, Err: err}
+	}
+
+	vars := mux.Vars(r)
+
+	id, err := unmarshalCampaignID(graphql.ID(vars["id"]))
+	if err != nil {
+		return &errcode.HTTPErr{Status: http.StatusBadRequest, Err: errors.Wrap(err, "invalid campaign ID")}
+	}
+
+	campaign, err := h.store.GetCampaign(ctx, ee.GetCampaignOpts{ID: id})
+	if err == ee.ErrNoResults {
+		return &errcode.HTTPErr{Status: http.StatusNotFound, Err: errors.Errorf("campaign %q not found", vars["id"])}
+	}
+	if err != nil {
+		return err
+	}
+
+	var records [][]string
+	switch table := vars["table"]; table {
+	case "counts":
+		records, err = h.countsRecords(ctx, r, campaign)
+	case "changesets":
+		records, err = h.changesetsRecords(ctx, campaign)
+	default:
+		return &errcode.HTTPErr{Status: http.StatusNotFound, Err: errors.Errorf("unknown campaign export %q", table)}
+	}
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=campaign-"+strconv.FormatInt(campaign.ID, 10)+"-"+vars["table"]+".csv")
+	return csv.NewWriter(w).WriteAll(records)
+}
+
+func (h *exportHandler) countsRecords(ctx context.Context, r *http.Request, c *campaigns.Campaign) ([][]string, error) {
+	from, err := timeParam(r, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := timeParam(r, "to")
+	if err != nil {
+		return nil, err
+	}
+	start, end := changesetCountsTimeframe(c, from, to)
+
+	counts, err := ee.CalcCampaignCounts(ctx, h.store, c.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	records := [][]string{{
+		"date",
+		"total",
+		"merged",
+		"closed",
+		"open",
+		"open_approved",
+		"open_changes_requested",
+		"open_pending",
+	}}
+	for _, c := range counts {
+		records = append(records, []string{
+			c.Time.Format(time.RFC3339),
+			strconv.Itoa(int(c.Total)),
+			strconv.Itoa(int(c.Merged)),
+			strconv.Itoa(int(c.Closed)),
+			strconv.Itoa(int(c.Open)),
+			strconv.Itoa(int(c.OpenApproved)),
+			strconv.Itoa(int(c.OpenChangesRequested)),
+			strconv.Itoa(int(c.OpenPending)),
+		})
+	}
+	return records, nil
+}
+
+func (h *exportHandler) changesetsRecords(ctx context.Context, c *campaigns.Campaign) ([][]string, error) {
+	cs, _, err := h.store.ListChangesets(ctx, ee.ListChangesetsOpts{CampaignID: c.ID, Limit: -1})
+	if err != nil {
+		return nil, err
+	}
+
+	reposStore := repos.NewDBStore(h.store.DB(), sql.TxOptions{})
+	repoIDs := make([]api.RepoID, len(cs))
+	for i, c := range cs {
+		repoIDs[i] = c.RepoID
+	}
+
+	rs, err := reposStore.ListRepos(ctx, repos.StoreListReposArgs{IDs: repoIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	reposByID := make(map[api.RepoID]*repos.Repo, len(rs))
+	for _, repo := range rs {
+		reposByID[repo.ID] = repo
+	}
+
+	records := [][]string{{
+		"repository",
+		"external_id",
+		"url",
+		"title",
+		"state",
+		"review_state",
+		"check_state",
+		"created_at",
+		"updated_at",
+	}}
+	for _, c := range cs {
+		repo, ok := reposByID[c.RepoID]
+		if !ok {
+			return nil, errors.Errorf("failed to load repo %d", c.RepoID)
+		}
+
+		title, err := c.Title()
+		if err != nil {
+			return nil, err
+		}
+
+		url, err := c.URL()
+		if err != nil {
+			return nil, err
+		}
+
+		var createdAt, updatedAt string
+		if t := c.ExternalCreatedAt(); !t.IsZero() {
+			createdAt = t.UTC().Format(time.RFC3339)
+		}
+		if t := c.ExternalUpdatedAt; !t.IsZero() {
+			updatedAt = t.UTC().Format(time.RFC3339)
+		}
+
+		records = append(records, []string{
+			repo.Name,
+			c.ExternalID,
+			url,
+			title,
+			string(c.ExternalState),
+			string(c.ExternalReviewState),
+			string(c.ExternalCheckState),
+			createdAt,
+			updatedAt,
+		})
+	}
+	return records, nil
+}
+
+// timeParam returns the RFC 3339 timestamp in the given URL query parameter
+// of r, or nil if it's not set.
+func timeParam(r *http.Request, name string) (*time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, &errcode.HTTPErr{Status: http.StatusBadRequest, Err: errors.Wrapf(err, "invalid %q parameter", name)}
+	}
+	return &t, nil
+}
@@ -0,0 +1,153 @@
+package resolvers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/mux"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	ee "github.com/sourcegraph/sourcegraph/enterprise/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestExportHandler(t *testing.T) {
+	ctx := backend.WithAuthzBypass(context.Background())
+	dbtesting.SetupGlobalTestDB(t)
+
+	user := createTestUser(ctx, t)
+	if err := db.Users.SetIsSiteAdmin(ctx, user.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	repoStore := repos.NewDBStore(dbconn.Global, sql.TxOptions{})
+	ext := &repos.ExternalService{
+		Kind:        github.ServiceType,
+		DisplayName: "GitHub",
+		Config: marshalJSON(t, &schema.GitHubConnection{
+			Url:   "https://github.com",
+			Token: "SECRETTOKEN",
+		}),
+	}
+	if err := repoStore.UpsertExternalServices(ctx, ext); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &repos.Repo{
+		Name: "github.com/sourcegraph/sourcegraph",
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          "external-id",
+			ServiceType: github.ServiceType,
+			ServiceID:   "https://github.com/",
+		},
+		Sources: map[string]*repos.SourceInfo{ext.URN(): {ID: ext.URN()}},
+	}
+	if err := repoStore.UpsertRepos(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	store := ee.NewStore(dbconn.Global)
+
+	campaign := &campaigns.Campaign{
+		Name:            "Export campaign",
+		AuthorID:        user.ID,
+		NamespaceUserID: user.ID,
+	}
+	if err := store.CreateCampaign(ctx, campaign); err != nil {
+		t.Fatal(err)
+	}
+
+	createdAt := parseJSONTime(t, "2020-03-02T10:00:00Z")
+	updatedAt := parseJSONTime(t, "2020-03-03T10:00:00Z")
+
+	changeset := &campaigns.Changeset{
+		RepoID:              repo.ID,
+		CampaignIDs:         []int64{campaign.ID},
+		ExternalState:       campaigns.ChangesetStateOpen,
+		ExternalReviewState: campaigns.ChangesetReviewStatePending,
+		ExternalCheckState:  campaigns.ChangesetCheckStatePassed,
+		ExternalUpdatedAt:   updatedAt,
+	}
+	changeset.SetMetadata(&github.PullRequest{
+		Number:    1234,
+		Title:     "Remove dead code",
+		URL:       "https://github.com/sourcegraph/sourcegraph/pull/1234",
+		State:     "OPEN",
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+	})
+	if err := store.CreateChangesets(ctx, changeset); err != nil {
+		t.Fatal(err)
+	}
+
+	r := mux.NewRouter()
+	r.Path("/campaigns/{id}/{table:counts|changesets}.csv").Handler(NewExportHandler(store))
+
+	get := func(ctx context.Context, url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	admin := actor.WithActor(ctx, actor.FromUser(user.ID))
+	id := string(marshalCampaignID(campaign.ID))
+
+	t.Run("changesets", func(t *testing.T) {
+		rec := get(admin, "/campaigns/"+id+"/changesets.csv")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body)
+		}
+
+		want := "repository,external_id,url,title,state,review_state,check_state,created_at,updated_at\n" +
+			"github.com/sourcegraph/sourcegraph,1234,https://github.com/sourcegraph/sourcegraph/pull/1234,Remove dead code,OPEN,PENDING,PASSED,2020-03-02T10:00:00Z,2020-03-03T10:00:00Z\n"
+		if diff := cmp.Diff(want, rec.Body.String()); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("counts", func(t *testing.T) {
+		rec := get(admin, "/campaigns/"+id+"/counts.csv?from=2020-03-01T12:00:00Z&to=2020-03-03T12:00:00Z")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body)
+		}
+
+		want := "date,total,merged,closed,open,open_approved,open_changes_requested,open_pending\n" +
+			"2020-03-01T12:00:00Z,0,0,0,0,0,0,0\n" +
+			"2020-03-02T12:00:00Z,1,0,0,1,0,0,1\n" +
+			"2020-03-03T12:00:00Z,1,0,0,1,0,0,1\n"
+		if diff := cmp.Diff(want, rec.Body.String()); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	for _, tc := range []struct {
+		name       string
+		ctx        context.Context
+		url        string
+		wantStatus int
+	}{
+		{"unauthenticated", ctx, "/campaigns/" + id + "/counts.csv", http.StatusForbidden},
+		{"unknown campaign", admin, "/campaigns/" + string(marshalCampaignID(campaign.ID+1)) + "/counts.csv", http.StatusNotFound},
+		{"invalid from", admin, "/campaigns/" + id + "/counts.csv?from=yesterday", http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := get(tc.ctx, tc.url)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("wrong status. have=%d, want=%d: %s", rec.Code, tc.wantStatus, strings.TrimSpace(rec.Body.String()))
+			}
+		})
+	}
+}
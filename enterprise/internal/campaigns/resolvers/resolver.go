@@ -15,6 +15,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
 	ee "github.com/sourcegraph/sourcegraph/enterprise/internal/campaigns"
 	"github.com/sourcegraph/sourcegraph/internal/api"
@@ -24,6 +25,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
 // Resolver is the GraphQL resolver of all things related to Campaigns.
@@ -230,6 +232,10 @@ func (r *Resolver) CreateCampaign(ctx context.Context, args *graphqlbackend.Crea
 		campaign.Branch = *args.Input.Branch
 	}
 
+	if args.Input.AutoMerge != nil {
+		campaign.AutoMerge = *args.Input.AutoMerge
+	}
+
 	if args.Input.PatchSet != nil {
 		patchSetID, err := unmarshalPatchSetID(*args.Input.PatchSet)
 		if err != nil {
@@ -286,6 +292,7 @@ func (r *Resolver) UpdateCampaign(ctx context.Context, args *graphqlbackend.Upda
 	updateArgs.Name = args.Input.Name
 	updateArgs.Description = args.Input.Description
 	updateArgs.Branch = args.Input.Branch
+	updateArgs.AutoMerge = args.Input.AutoMerge
 
 	if args.Input.PatchSet != nil {
 		patchSetID, err := unmarshalPatchSetID(*args.Input.PatchSet)
@@ -587,6 +594,99 @@ func (r *Resolver) CreatePatchSetFromPatches(ctx context.Context, args graphqlba
 	return &patchSetResolver{store: r.store, patchSet: patchSet}, nil
 }
 
+func (r *Resolver) CreatePatchSetFromSearchAndReplace(ctx context.Context, args graphqlbackend.CreatePatchSetFromSearchAndReplaceArgs) (graphqlbackend.PatchSetResolver, error) {
+	var err error
+	tr, ctx := trace.New(ctx, "Resolver.CreatePatchSetFromSearchAndReplace", fmt.Sprintf("Query: %q", args.Query))
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	// 🚨 SECURITY: Only site admins may create patch sets for now.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	user, err := backend.CurrentUser(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%v", backend.ErrNotAuthenticated)
+	}
+	if user == nil {
+		return nil, backend.ErrNotAuthenticated
+	}
+
+	patternType := args.PatternType
+	info, err := graphqlbackend.SearchPatternInfo("V2", &patternType, args.Query)
+	if err != nil {
+		return nil, err
+	}
+	sr := &campaigns.SearchAndReplace{
+		Query:         args.Query,
+		PatternType:   campaigns.SearchAndReplaceRegexp,
+		Pattern:       info.Pattern,
+		CaseSensitive: info.IsCaseSensitive,
+		Replacement:   args.Replacement,
+	}
+	if info.IsStructuralPat {
+		sr.PatternType = campaigns.SearchAndReplaceStructural
+	}
+
+	// The patches change the files that the search query matches, at the
+	// revisions that it searched. Matches are ordered by repository,
+	// revision and file.
+	type repoRev struct {
+		repo api.RepoID
+		rev  api.CommitID
+	}
+	var jobs []*campaigns.PatchJob
+	jobsByRepoRev := make(map[repoRev]*campaigns.PatchJob)
+	err = graphqlbackend.ExportSearch(ctx, "V2", &patternType, args.Query, func(m *graphqlbackend.SearchExportMatch) error {
+		job, ok := jobsByRepoRev[repoRev{m.RepoID, m.Revision}]
+		if !ok {
+			baseRef, err := searchBaseRef(ctx, m)
+			if err != nil {
+				return err
+			}
+			job = &campaigns.PatchJob{RepoID: m.RepoID, Rev: m.Revision, BaseRef: baseRef}
+			jobsByRepoRev[repoRev{m.RepoID, m.Revision}] = job
+			jobs = append(jobs, job)
+		}
+		if n := len(job.Paths); n == 0 || job.Paths[n-1] != m.Path {
+			job.Paths = append(job.Paths, m.Path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	svc := ee.NewService(r.store, gitserver.DefaultClient, r.httpFactory)
+	patchSet, err := svc.CreatePatchSetFromSearchAndReplace(ctx, sr, jobs, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &patchSetResolver{store: r.store, patchSet: patchSet}, nil
+}
+
+// searchBaseRef returns the ref that the patch of a search match is merged
+// into: the branch that the query searched, or the default branch.
+func searchBaseRef(ctx context.Context, m *graphqlbackend.SearchExportMatch) (string, error) {
+	if m.InputRev != "" && m.InputRev != "HEAD" {
+		return git.EnsureRefPrefix(m.InputRev), nil
+	}
+
+	repo := graphqlbackend.NewRepositoryResolver(&types.Repo{ID: m.RepoID, Name: m.Repo})
+	ref, err := repo.DefaultBranch(ctx)
+	if err != nil {
+		return "", err
+	}
+	if ref == nil {
+		return "", errors.Errorf("repository %q has no default branch", m.Repo)
+	}
+	return ref.Name(), nil
+}
+
 func (r *Resolver) CloseCampaign(ctx context.Context, args *graphqlbackend.CloseCampaignArgs) (_ graphqlbackend.CampaignResolver, err error) {
 	tr, ctx := trace.New(ctx, "Resolver.CloseCampaign", fmt.Sprintf("Campaign: %q", args.Campaign))
 	defer func() {
@@ -0,0 +1,267 @@
+package campaigns
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// maxSearchAndReplaceFileSize is the size of the largest file that a
+// SearchAndReplace changes. Larger files are skipped, just like searcher
+// skips them.
+const maxSearchAndReplaceFileSize = 1 << 20
+
+// diffContextLines is the number of unchanged lines around the changes of
+// the diffs generated for regexp replacements.
+const diffContextLines = 3
+
+// generatePatch returns the diff that applies sr to the files of job, or an
+// empty string if sr doesn't change any of them. Structural replacements are
+// computed by the replacer service at replacerURL.
+func generatePatch(ctx context.Context, replacerURL string, sr *campaigns.SearchAndReplace, repo api.RepoName, job *campaigns.PatchJob) (string, error) {
+	switch sr.PatternType {
+	case campaigns.SearchAndReplaceRegexp:
+		return replaceRegexp(ctx, sr, repo, job)
+	case campaigns.SearchAndReplaceStructural:
+		return replaceStructural(ctx, replacerURL, sr, repo, job)
+	default:
+		return "", errors.Errorf("unsupported search and replace pattern type %q", sr.PatternType)
+	}
+}
+
+// compileSearchAndReplaceRegexp compiles the regexp pattern of sr with the
+// flags that search uses for it.
+func compileSearchAndReplaceRegexp(sr *campaigns.SearchAndReplace) (*regexp.Regexp, error) {
+	flags := "(?m)"
+	if !sr.CaseSensitive {
+		flags = "(?im)"
+	}
+	return regexp.Compile(flags + sr.Pattern)
+}
+
+func replaceRegexp(ctx context.Context, sr *campaigns.SearchAndReplace, repo api.RepoName, job *campaigns.PatchJob) (string, error) {
+	re, err := compileSearchAndReplaceRegexp(sr)
+	if err != nil {
+		return "", errors.Wrap(err, "compiling pattern")
+	}
+
+	var diffs []string
+	for _, path := range job.Paths {
+		content, err := git.ReadFile(ctx, gitserver.Repo{Name: repo}, job.Rev, path, 0)
+		if err != nil {
+			return "", errors.Wrapf(err, "reading %q", path)
+		}
+		if len(content) > maxSearchAndReplaceFileSize || bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content) {
+			// Binary or huge file: search doesn't match them either.
+			continue
+		}
+
+		old := string(content)
+		new := re.ReplaceAllString(old, sr.Replacement)
+		if new == old {
+			continue
+		}
+
+		d, err := unifiedDiff(path, old, new)
+		if err != nil {
+			return "", err
+		}
+		diffs = append(diffs, d)
+	}
+	return strings.Join(diffs, "\n"), nil
+}
+
+// replacerResult is a line of the response of the replacer service.
+type replacerResult struct {
+	URI  string `json:"uri"`
+	Diff string `json:"diff"`
+}
+
+func replaceStructural(ctx context.Context, replacerURL string, sr *campaigns.SearchAndReplace, repo api.RepoName, job *campaigns.PatchJob) (string, error) {
+	u, err := url.Parse(replacerURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("repo", string(repo))
+	q.Set("commit", string(job.Rev))
+	q.Set("matchtemplate", sr.Pattern)
+	q.Set("rewritetemplate", sr.Replacement)
+	u.RawQuery = q.Encode()
+
+	resp, err := ctxhttp.Get(ctx, nil, u.String())
+	if err != nil {
+		return "", errors.Wrap(err, "replacer request failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return "", errors.Errorf("replacer request failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	// The replacer rewrites every file of the repository, but the patch only
+	// changes the files that the search query matched.
+	paths := make(map[string]bool, len(job.Paths))
+	for _, p := range job.Paths {
+		paths[p] = true
+	}
+
+	var diffs []string
+	scanner := bufio.NewScanner(resp.Body)
+	// Results are line encoded JSON, with a line per file. Allow lines as
+	// long as the diffs of the largest files we change.
+	scanner.Buffer(make([]byte, 100), 4*maxSearchAndReplaceFileSize)
+	for scanner.Scan() {
+		var r replacerResult
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return "", errors.Wrap(err, "decoding replacer result")
+		}
+		if !paths[r.URI] || r.Diff == "" {
+			continue
+		}
+		diffs = append(diffs, strings.TrimSuffix(r.Diff, "\n"))
+	}
+	if err := scanner.Err(); err != nil {
+		return "", errors.Wrap(err, "reading replacer results")
+	}
+	return strings.Join(diffs, "\n"), nil
+}
+
+// unifiedDiff returns the unified diff from old to new of the file at path,
+// in the format of the diffs of Patches: without a/ and b/ prefixes in the
+// file names, and without a trailing newline.
+func unifiedDiff(path, old, new string) (string, error) {
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(old, new)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	type line struct {
+		op   diffmatchpatch.Operation
+		text string
+	}
+	var ls []line
+	for _, d := range diffs {
+		for _, text := range splitLines(d.Text) {
+			ls = append(ls, line{op: d.Type, text: text})
+		}
+	}
+
+	fd := &diff.FileDiff{OrigName: path, NewName: path}
+
+	// origLine and newLine are the 1-based line numbers of ls[i] in old and
+	// new.
+	origLine, newLine := 1, 1
+	for i := 0; i < len(ls); {
+		if ls[i].op == diffmatchpatch.DiffEqual {
+			origLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// A hunk starts with up to diffContextLines unchanged lines before the
+		// change. It ends with up to diffContextLines unchanged lines after the
+		// last change that is followed by more than 2*diffContextLines
+		// unchanged lines, since the context of two hunks must not overlap.
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		h := &diff.Hunk{
+			OrigStartLine: int32(origLine - (i - start)),
+			NewStartLine:  int32(newLine - (i - start)),
+		}
+
+		end := i
+		for end < len(ls) {
+			if ls[end].op != diffmatchpatch.DiffEqual {
+				end++
+				continue
+			}
+			equal := 0
+			for end+equal < len(ls) && ls[end+equal].op == diffmatchpatch.DiffEqual {
+				equal++
+			}
+			if end+equal == len(ls) || equal > 2*diffContextLines {
+				if equal > diffContextLines {
+					equal = diffContextLines
+				}
+				end += equal
+				break
+			}
+			end += equal
+		}
+
+		var body bytes.Buffer
+		for _, l := range ls[start:end] {
+			switch l.op {
+			case diffmatchpatch.DiffEqual:
+				body.WriteByte(' ')
+				h.OrigLines++
+				h.NewLines++
+			case diffmatchpatch.DiffDelete:
+				body.WriteByte('-')
+				h.OrigLines++
+			case diffmatchpatch.DiffInsert:
+				body.WriteByte('+')
+				h.NewLines++
+			}
+			body.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				body.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		h.Body = body.Bytes()
+
+		// A hunk without lines of a side starts after the line before it.
+		if h.OrigLines == 0 {
+			h.OrigStartLine--
+		}
+		if h.NewLines == 0 {
+			h.NewStartLine--
+		}
+		fd.Hunks = append(fd.Hunks, h)
+
+		for _, l := range ls[i:end] {
+			if l.op != diffmatchpatch.DiffInsert {
+				origLine++
+			}
+			if l.op != diffmatchpatch.DiffDelete {
+				newLine++
+			}
+		}
+		i = end
+	}
+
+	out, err := diff.PrintFileDiff(fd)
+	if err != nil {
+		return "", errors.Wrapf(err, "printing diff of %q", path)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// splitLines splits s after each newline. The last line has no newline if s
+// doesn't end with one.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,186 @@
+package campaigns
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	cmpgn "github.com/sourcegraph/sourcegraph/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "single line",
+			old:  "a\nb\nc\n",
+			new:  "a\nB\nc\n",
+			want: "--- f.go\n+++ f.go\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c",
+		},
+		{
+			name: "context is limited",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- f.go\n+++ f.go\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8",
+		},
+		{
+			name: "close changes share a hunk",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:  "one\n2\n3\n4\n5\n6\n7\neight\n9\n",
+			want: "--- f.go\n+++ f.go\n@@ -1,9 +1,9 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+eight\n 9",
+		},
+		{
+			name: "distant changes get separate hunks",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			new:  "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			want: "--- f.go\n+++ f.go\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten",
+		},
+		{
+			name: "insertion and deletion",
+			old:  "a\nb\n",
+			new:  "a\nx\ny\n",
+			want: "--- f.go\n+++ f.go\n@@ -1,2 +1,3 @@\n a\n-b\n+x\n+y",
+		},
+		{
+			name: "deletion of all lines",
+			old:  "a\n",
+			new:  "",
+			want: "--- f.go\n+++ f.go\n@@ -1,1 +0,0 @@\n-a",
+		},
+		{
+			name: "no newline at end of file",
+			old:  "a\nb",
+			new:  "a\nc",
+			want: "--- f.go\n+++ f.go\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			have, err := unifiedDiff("f.go", tc.old, tc.new)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have != tc.want {
+				t.Errorf("wrong diff.\nhave:\n%s\nwant:\n%s", have, tc.want)
+			}
+		})
+	}
+}
+
+func TestReplaceRegexp(t *testing.T) {
+	files := map[string]string{
+		"a.go":    "package a\n\nfunc A() { fmt.Println(\"a\") }\n",
+		"b.go":    "package b\n",
+		"c.bin":   "fmt.Println\x00",
+		"d/FMT.c": "FMT.Println(\"d\")\n",
+	}
+	git.Mocks.ReadFile = func(commit api.CommitID, name string) ([]byte, error) {
+		if commit != "deadbeef" {
+			return nil, fmt.Errorf("wrong commit %q", commit)
+		}
+		content, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("file %q not found", name)
+		}
+		return []byte(content), nil
+	}
+	defer git.ResetMocks()
+
+	job := &cmpgn.PatchJob{Rev: "deadbeef", Paths: []string{"a.go", "b.go", "c.bin", "d/FMT.c"}}
+
+	t.Run("case-insensitive", func(t *testing.T) {
+		sr := &cmpgn.SearchAndReplace{
+			PatternType: cmpgn.SearchAndReplaceRegexp,
+			Pattern:     `fmt\.Print(ln)?\(`,
+			Replacement: "log.Print$1(",
+		}
+		have, err := replaceRegexp(context.Background(), sr, "repo", job)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := strings.Join([]string{
+			"--- a.go\n+++ a.go\n@@ -1,3 +1,3 @@\n package a\n \n-func A() { fmt.Println(\"a\") }\n+func A() { log.Println(\"a\") }",
+			"--- d/FMT.c\n+++ d/FMT.c\n@@ -1,1 +1,1 @@\n-FMT.Println(\"d\")\n+log.Println(\"d\")",
+		}, "\n")
+		if have != want {
+			t.Errorf("wrong diff.\nhave:\n%s\nwant:\n%s", have, want)
+		}
+	})
+
+	t.Run("case-sensitive", func(t *testing.T) {
+		sr := &cmpgn.SearchAndReplace{
+			PatternType:   cmpgn.SearchAndReplaceRegexp,
+			Pattern:       `FMT`,
+			CaseSensitive: true,
+			Replacement:   "fmt",
+		}
+		have, err := replaceRegexp(context.Background(), sr, "repo", job)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "--- d/FMT.c\n+++ d/FMT.c\n@@ -1,1 +1,1 @@\n-FMT.Println(\"d\")\n+fmt.Println(\"d\")"
+		if have != want {
+			t.Errorf("wrong diff.\nhave:\n%s\nwant:\n%s", have, want)
+		}
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		sr := &cmpgn.SearchAndReplace{
+			PatternType: cmpgn.SearchAndReplaceRegexp,
+			Pattern:     `nomatch`,
+			Replacement: "x",
+		}
+		have, err := replaceRegexp(context.Background(), sr, "repo", job)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have != "" {
+			t.Errorf("want empty diff, have:\n%s", have)
+		}
+	})
+}
+
+func TestReplaceStructural(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("repo") != "github.com/foo/bar" || q.Get("commit") != "deadbeef" || q.Get("matchtemplate") != "fmt.Println(:[x])" || q.Get("rewritetemplate") != "log.Println(:[x])" {
+			http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, `{"uri":"a.go","diff":"--- a.go\n+++ a.go\n@@ -1,1 +1,1 @@\n-fmt.Println(1)\n+log.Println(1)"}`)
+		fmt.Fprintln(w, `{"uri":"vendor/b.go","diff":"--- vendor/b.go\n+++ vendor/b.go\n@@ -1,1 +1,1 @@\n-fmt.Println(2)\n+log.Println(2)"}`)
+		fmt.Fprintln(w, `{"uri":"c.go","diff":"--- c.go\n+++ c.go\n@@ -1,1 +1,1 @@\n-fmt.Println(3)\n+log.Println(3)"}`)
+	}))
+	defer ts.Close()
+
+	sr := &cmpgn.SearchAndReplace{
+		PatternType: cmpgn.SearchAndReplaceStructural,
+		Pattern:     "fmt.Println(:[x])",
+		Replacement: "log.Println(:[x])",
+	}
+	// The search query didn't match vendor/b.go, so it isn't changed.
+	job := &cmpgn.PatchJob{Rev: "deadbeef", Paths: []string{"a.go", "c.go"}}
+
+	have, err := generatePatch(context.Background(), ts.URL, sr, "github.com/foo/bar", job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- a.go\n+++ a.go\n@@ -1,1 +1,1 @@\n-fmt.Println(1)\n+log.Println(1)\n--- c.go\n+++ c.go\n@@ -1,1 +1,1 @@\n-fmt.Println(3)\n+log.Println(3)"
+	if have != want {
+		t.Errorf("wrong diff.\nhave:\n%s\nwant:\n%s", have, want)
+	}
+
+	sr.Pattern = "other"
+	if _, err := generatePatch(context.Background(), ts.URL, sr, "github.com/foo/bar", job); err == nil {
+		t.Error("want error for failed replacer request, have nil")
+	}
+}
@@ -97,6 +97,89 @@ func (s *Service) CreatePatchSetFromPatches(ctx context.Context, patches []*camp
 	return patchSet, nil
 }
 
+// ErrNoSearchAndReplaceMatches is returned by
+// CreatePatchSetFromSearchAndReplace if no file of a repository supported by
+// campaigns matches the search query.
+var ErrNoSearchAndReplaceMatches = errors.New("the search query matches no files in repositories supported by campaigns")
+
+// ErrSearchAndReplacePatternBlank is returned by
+// CreatePatchSetFromSearchAndReplace if the search query has no pattern,
+// only filters.
+var ErrSearchAndReplacePatternBlank = errors.New("the search query has no pattern to replace")
+
+// CreatePatchSetFromSearchAndReplace creates a PatchSet whose Patches are
+// generated in the background by applying sr to the files of jobs, which
+// must be the files matched by the search query of sr. The progress of the
+// generation is reported by Store.GetPatchSetStatus.
+func (s *Service) CreatePatchSetFromSearchAndReplace(ctx context.Context, sr *campaigns.SearchAndReplace, jobs []*campaigns.PatchJob, userID int32) (*campaigns.PatchSet, error) {
+	if userID == 0 {
+		return nil, backend.ErrNotAuthenticated
+	}
+
+	if sr.Pattern == "" {
+		return nil, ErrSearchAndReplacePatternBlank
+	}
+	switch sr.PatternType {
+	case campaigns.SearchAndReplaceRegexp:
+		if _, err := compileSearchAndReplaceRegexp(sr); err != nil {
+			return nil, errors.Wrap(err, "invalid regexp pattern")
+		}
+	case campaigns.SearchAndReplaceStructural:
+	default:
+		return nil, errors.Errorf("unsupported search and replace pattern type %q", sr.PatternType)
+	}
+
+	// Look up all repositories
+	reposStore := repos.NewDBStore(s.store.DB(), sql.TxOptions{})
+	repoIDs := make([]api.RepoID, len(jobs))
+	for i, job := range jobs {
+		repoIDs[i] = job.RepoID
+	}
+	allRepos, err := reposStore.ListRepos(ctx, repos.StoreListReposArgs{IDs: repoIDs})
+	if err != nil {
+		return nil, err
+	}
+	reposByID := make(map[api.RepoID]*repos.Repo, len(jobs))
+	for _, repo := range allRepos {
+		reposByID[repo.ID] = repo
+	}
+
+	var supported []*campaigns.PatchJob
+	for _, job := range jobs {
+		repo := reposByID[job.RepoID]
+		if repo == nil {
+			return nil, fmt.Errorf("repository ID %d not found", job.RepoID)
+		}
+		if campaigns.IsRepoSupported(&repo.ExternalRepo) {
+			supported = append(supported, job)
+		}
+	}
+	if len(supported) == 0 {
+		return nil, ErrNoSearchAndReplaceMatches
+	}
+
+	tx, err := s.store.Transact(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Done(&err)
+
+	patchSet := &campaigns.PatchSet{UserID: userID, SearchAndReplace: sr}
+	err = tx.CreatePatchSet(ctx, patchSet)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, job := range supported {
+		job.PatchSetID = patchSet.ID
+		if err = tx.CreatePatchJob(ctx, job); err != nil {
+			return nil, err
+		}
+	}
+
+	return patchSet, nil
+}
+
 // CreateCampaign creates the Campaign. When a PatchSetID is set on the
 // Campaign and the Campaign is not created as a draft, it calls
 // CreateChangesetJobs inside the same transaction in which it creates the
@@ -458,6 +541,7 @@ type UpdateCampaignArgs struct {
 	Description *string
 	Branch      *string
 	PatchSet    *int64
+	AutoMerge   *bool
 }
 
 // ErrCampaignNameBlank is returned by CreateCampaign or UpdateCampaign if the
@@ -509,7 +593,7 @@ func (s *Service) UpdateCampaign(ctx context.Context, args UpdateCampaignArgs) (
 		return nil, nil, ErrUpdateClosedCampaign
 	}
 
-	var updateAttributes, updatePatchSetID, updateBranch bool
+	var updateAttributes, updatePatchSetID, updateBranch, updateAutoMerge bool
 
 	if args.Name != nil && campaign.Name != *args.Name {
 		if *args.Name == "" {
@@ -552,7 +636,17 @@ func (s *Service) UpdateCampaign(ctx context.Context, args UpdateCampaignArgs) (
 		updateBranch = true
 	}
 
+	if args.AutoMerge != nil && campaign.AutoMerge != *args.AutoMerge {
+		campaign.AutoMerge = *args.AutoMerge
+		updateAutoMerge = true
+	}
+
 	if !updateAttributes && !updatePatchSetID && !updateBranch {
+		if updateAutoMerge {
+			// AutoMerge doesn't change the changesets on the codehost, so we
+			// only need to update the Campaign itself.
+			return campaign, nil, tx.UpdateCampaign(ctx, campaign)
+		}
 		return campaign, nil, nil
 	}
 
@@ -11,6 +11,7 @@ import (
 	cmpgn "github.com/sourcegraph/sourcegraph/internal/campaigns"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 )
 
 // SetDerivedState will update the external state fields on the Changeset based
@@ -43,6 +44,9 @@ func ComputeCheckState(c *cmpgn.Changeset, events ChangesetEvents) cmpgn.Changes
 
 	case *bitbucketserver.PullRequest:
 		return computeBitbucketBuildStatus(c.UpdatedAt, m, events)
+
+	case *gitlab.MergeRequest:
+		return computeGitLabCheckState(c.UpdatedAt, m, events)
 	}
 
 	return cmpgn.ChangesetCheckStateUnknown
@@ -134,6 +138,63 @@ func parseBitbucketBuildState(s string) cmpgn.ChangesetCheckState {
 	}
 }
 
+func computeGitLabCheckState(lastSynced time.Time, mr *gitlab.MergeRequest, events []*cmpgn.ChangesetEvent) cmpgn.ChangesetCheckState {
+	// GitLab runs a single pipeline per push, and each pipeline already
+	// aggregates the state of all of its jobs, so we only need to look at
+	// the most recent pipeline.
+	var latest *gitlab.Pipeline
+	newer := func(p *gitlab.Pipeline) bool {
+		return latest == nil || p.CreatedAt.After(latest.CreatedAt) ||
+			(p.ID == latest.ID && p.UpdatedAt.After(latest.UpdatedAt))
+	}
+
+	// States from last sync
+	if mr.HeadPipeline != nil {
+		latest = mr.HeadPipeline
+	}
+	for _, p := range mr.Pipelines {
+		if newer(p) {
+			latest = p
+		}
+	}
+
+	// Add any events we've received since our last sync
+	for _, e := range events {
+		switch m := e.Metadata.(type) {
+		case *gitlab.Pipeline:
+			if m.UpdatedAt.Before(lastSynced) {
+				continue
+			}
+			if newer(m) {
+				latest = m
+			}
+		}
+	}
+
+	if latest == nil {
+		return cmpgn.ChangesetCheckStateUnknown
+	}
+	return parseGitLabPipelineStatus(latest.Status)
+}
+
+func parseGitLabPipelineStatus(status gitlab.PipelineStatus) cmpgn.ChangesetCheckState {
+	switch status {
+	case gitlab.PipelineStatusSuccess:
+		return cmpgn.ChangesetCheckStatePassed
+	case gitlab.PipelineStatusFailed, gitlab.PipelineStatusCanceled:
+		return cmpgn.ChangesetCheckStateFailed
+	case gitlab.PipelineStatusCreated,
+		gitlab.PipelineStatusWaitingForResource,
+		gitlab.PipelineStatusPreparing,
+		gitlab.PipelineStatusPending,
+		gitlab.PipelineStatusRunning,
+		gitlab.PipelineStatusScheduled:
+		return cmpgn.ChangesetCheckStatePending
+	default:
+		return cmpgn.ChangesetCheckStateUnknown
+	}
+}
+
 func computeGitHubCheckState(lastSynced time.Time, pr *github.PullRequest, events []*cmpgn.ChangesetEvent) cmpgn.ChangesetCheckState {
 	// We should only consider the latest commit. This could be from a sync or a webhook that
 	// has occurred later
@@ -306,6 +367,17 @@ func computeSingleChangesetState(c *cmpgn.Changeset) (s cmpgn.ChangesetState, er
 		} else {
 			s = cmpgn.ChangesetState(m.State)
 		}
+	case *gitlab.MergeRequest:
+		switch m.State {
+		case gitlab.MergeRequestStateClosed, gitlab.MergeRequestStateLocked:
+			s = cmpgn.ChangesetStateClosed
+		case gitlab.MergeRequestStateMerged:
+			s = cmpgn.ChangesetStateMerged
+		case gitlab.MergeRequestStateOpened:
+			s = cmpgn.ChangesetStateOpen
+		default:
+			s = cmpgn.ChangesetState(m.State)
+		}
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -342,6 +414,24 @@ func computeSingleChangesetReviewState(c *cmpgn.Changeset) (s cmpgn.ChangesetRev
 				states[cmpgn.ChangesetReviewStateApproved] = true
 			}
 		}
+
+	case *gitlab.MergeRequest:
+		// GitLab only records approvals as system notes, which are returned
+		// in chronological order. The last approval-related note of each
+		// user determines whether they currently approve the merge request.
+		approvedBy := map[string]bool{}
+		for _, n := range m.Notes {
+			switch n.ToEvent().(type) {
+			case *gitlab.ReviewApprovedEvent:
+				approvedBy[n.Author.Username] = true
+			case *gitlab.ReviewUnapprovedEvent:
+				delete(approvedBy, n.Author.Username)
+			}
+		}
+		if len(approvedBy) > 0 {
+			states[cmpgn.ChangesetReviewStateApproved] = true
+		}
+
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -8,6 +8,7 @@ import (
 	cmpgn "github.com/sourcegraph/sourcegraph/internal/campaigns"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 )
 
 func TestComputeGithubCheckState(t *testing.T) {
@@ -258,3 +259,150 @@ func TestComputeBitbucketBuildStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestComputeGitLabCheckState(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	lastSynced := now.Add(-1 * time.Minute)
+
+	pipeline := func(id int, minutesSinceSync int, status gitlab.PipelineStatus) *gitlab.Pipeline {
+		return &gitlab.Pipeline{
+			ID:        id,
+			Status:    status,
+			CreatedAt: lastSynced.Add(time.Duration(minutesSinceSync) * time.Minute),
+			UpdatedAt: lastSynced.Add(time.Duration(minutesSinceSync) * time.Minute),
+		}
+	}
+	pipelineEvent := func(p *gitlab.Pipeline) *cmpgn.ChangesetEvent {
+		return &cmpgn.ChangesetEvent{
+			Kind:     cmpgn.ChangesetEventKindGitLabPipeline,
+			Metadata: p,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mr     *gitlab.MergeRequest
+		events []*cmpgn.ChangesetEvent
+		want   cmpgn.ChangesetCheckState
+	}{
+		{
+			name: "no pipelines",
+			mr:   &gitlab.MergeRequest{},
+			want: cmpgn.ChangesetCheckStateUnknown,
+		},
+		{
+			name: "head pipeline only",
+			mr:   &gitlab.MergeRequest{HeadPipeline: pipeline(1, -10, gitlab.PipelineStatusRunning)},
+			want: cmpgn.ChangesetCheckStatePending,
+		},
+		{
+			name: "latest synced pipeline wins",
+			mr: &gitlab.MergeRequest{Pipelines: []*gitlab.Pipeline{
+				pipeline(2, -5, gitlab.PipelineStatusSuccess),
+				pipeline(1, -10, gitlab.PipelineStatusFailed),
+			}},
+			want: cmpgn.ChangesetCheckStatePassed,
+		},
+		{
+			name: "newer pipeline event wins",
+			mr: &gitlab.MergeRequest{Pipelines: []*gitlab.Pipeline{
+				pipeline(1, -10, gitlab.PipelineStatusSuccess),
+			}},
+			events: []*cmpgn.ChangesetEvent{
+				pipelineEvent(pipeline(2, 1, gitlab.PipelineStatusFailed)),
+			},
+			want: cmpgn.ChangesetCheckStateFailed,
+		},
+		{
+			name: "updated pipeline event wins",
+			mr: &gitlab.MergeRequest{Pipelines: []*gitlab.Pipeline{
+				pipeline(1, -10, gitlab.PipelineStatusRunning),
+			}},
+			events: []*cmpgn.ChangesetEvent{
+				pipelineEvent(&gitlab.Pipeline{
+					ID:        1,
+					Status:    gitlab.PipelineStatusSuccess,
+					CreatedAt: lastSynced.Add(-10 * time.Minute),
+					UpdatedAt: lastSynced.Add(1 * time.Minute),
+				}),
+			},
+			want: cmpgn.ChangesetCheckStatePassed,
+		},
+		{
+			name: "events before last sync are ignored",
+			mr: &gitlab.MergeRequest{Pipelines: []*gitlab.Pipeline{
+				pipeline(1, -10, gitlab.PipelineStatusSuccess),
+			}},
+			events: []*cmpgn.ChangesetEvent{
+				pipelineEvent(pipeline(1, -20, gitlab.PipelineStatusFailed)),
+			},
+			want: cmpgn.ChangesetCheckStatePassed,
+		},
+		{
+			name: "canceled pipeline",
+			mr:   &gitlab.MergeRequest{HeadPipeline: pipeline(1, -10, gitlab.PipelineStatusCanceled)},
+			want: cmpgn.ChangesetCheckStateFailed,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			have := computeGitLabCheckState(lastSynced, tc.mr, tc.events)
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
+
+func TestComputeGitLabReviewState(t *testing.T) {
+	note := func(user, body string) *gitlab.Note {
+		return &gitlab.Note{Author: gitlab.User{Username: user}, Body: body, System: true}
+	}
+
+	tests := []struct {
+		name  string
+		notes []*gitlab.Note
+		want  cmpgn.ChangesetReviewState
+	}{
+		{
+			name: "no notes",
+			want: cmpgn.ChangesetReviewStatePending,
+		},
+		{
+			name:  "approved",
+			notes: []*gitlab.Note{note("alice", "approved this merge request")},
+			want:  cmpgn.ChangesetReviewStateApproved,
+		},
+		{
+			name: "approved then unapproved",
+			notes: []*gitlab.Note{
+				note("alice", "approved this merge request"),
+				note("alice", "unapproved this merge request"),
+			},
+			want: cmpgn.ChangesetReviewStatePending,
+		},
+		{
+			name: "one of two approvals revoked",
+			notes: []*gitlab.Note{
+				note("alice", "approved this merge request"),
+				note("bob", "approved this merge request"),
+				note("alice", "unapproved this merge request"),
+			},
+			want: cmpgn.ChangesetReviewStateApproved,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &cmpgn.Changeset{Metadata: &gitlab.MergeRequest{Notes: tc.notes}}
+			have, err := ComputeReviewState(c, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, have); diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 )
 
 // Store exposes methods to read and write campaigns domain models
@@ -110,6 +111,57 @@ RETURNING j.id,
   j.changeset_id,
   j.branch,
   j.error,
+  j.base_commit,
+  j.rebase_error,
+  j.started_at,
+  j.finished_at,
+  j.created_at,
+  j.updated_at
+`
+
+// ProcessPendingPatchJobs attempts to fetch one pending patch job, with the
+// same guarantees as ProcessPendingChangesetJobs.
+// NOTE: It should not be called from within an existing transaction
+func (s *Store) ProcessPendingPatchJobs(ctx context.Context, process func(ctx context.Context, s *Store, job campaigns.PatchJob) error) (didRun bool, err error) {
+	tx, err := s.Transact(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Done(&err)
+	q := sqlf.Sprintf(getPendingPatchJobQuery)
+	var job campaigns.PatchJob
+	_, count, err := tx.query(ctx, q, func(sc scanner) (last, count int64, err error) {
+		err = scanPatchJob(&job, sc)
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "scanning patch job row")
+		}
+		return job.ID, 1, nil
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "querying for pending patch job")
+	}
+	if count == 0 {
+		return false, nil
+	}
+	err = process(ctx, tx, job)
+	return true, err
+}
+
+const getPendingPatchJobQuery = `
+UPDATE patch_jobs j SET started_at = now() WHERE id = (
+	SELECT j.id FROM patch_jobs j
+	WHERE j.started_at IS NULL
+	ORDER BY j.id ASC
+	FOR UPDATE SKIP LOCKED LIMIT 1
+)
+RETURNING j.id,
+  j.patch_set_id,
+  j.repo_id,
+  j.rev,
+  j.base_ref,
+  j.paths,
+  j.patch_id,
+  j.error,
   j.started_at,
   j.finished_at,
   j.created_at,
@@ -650,6 +702,9 @@ type ListChangesetsOpts struct {
 	ExternalState       *campaigns.ChangesetState
 	ExternalReviewState *campaigns.ChangesetReviewState
 	ExternalCheckState  *campaigns.ChangesetCheckState
+	// AutoMerge limits the results to Changesets of open Campaigns that
+	// have AutoMerge enabled.
+	AutoMerge bool
 }
 
 // ListChangesets lists Changesets with the given filters.
@@ -699,6 +754,15 @@ ORDER BY id ASC
 
 const defaultListLimit = 50
 
+var listChangesetsAutoMergeQueryFmtstr = `
+EXISTS (
+  SELECT 1 FROM campaigns
+  WHERE campaigns.auto_merge
+  AND campaigns.closed_at IS NULL
+  AND changesets.campaign_ids ? campaigns.id::text
+)
+`
+
 func listChangesetsQuery(opts *ListChangesetsOpts) *sqlf.Query {
 	if opts.Limit == 0 {
 		opts.Limit = defaultListLimit
@@ -743,6 +807,10 @@ func listChangesetsQuery(opts *ListChangesetsOpts) *sqlf.Query {
 		preds = append(preds, sqlf.Sprintf("changesets.external_check_state = %s", *opts.ExternalCheckState))
 	}
 
+	if opts.AutoMerge {
+		preds = append(preds, sqlf.Sprintf(listChangesetsAutoMergeQueryFmtstr))
+	}
+
 	return sqlf.Sprintf(
 		listChangesetsQueryFmtstr+limitClause,
 		sqlf.Join(preds, "\n AND "),
@@ -1150,9 +1218,10 @@ INSERT INTO campaigns (
   updated_at,
   changeset_ids,
   patch_set_id,
-  closed_at
+  closed_at,
+  auto_merge
 )
-VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s)
+VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s)
 RETURNING
   id,
   name,
@@ -1165,7 +1234,8 @@ RETURNING
   updated_at,
   changeset_ids,
   patch_set_id,
-  closed_at
+  closed_at,
+  auto_merge
 `
 
 func (s *Store) createCampaignQuery(c *campaigns.Campaign) (*sqlf.Query, error) {
@@ -1195,6 +1265,7 @@ func (s *Store) createCampaignQuery(c *campaigns.Campaign) (*sqlf.Query, error)
 		changesetIDs,
 		nullInt64Column(c.PatchSetID),
 		nullTimeColumn(c.ClosedAt),
+		c.AutoMerge,
 	), nil
 }
 
@@ -1252,8 +1323,9 @@ SET (
   updated_at,
   changeset_ids,
   patch_set_id,
-  closed_at
-) = (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s)
+  closed_at,
+  auto_merge
+) = (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s)
 WHERE id = %s
 RETURNING
   id,
@@ -1267,7 +1339,8 @@ RETURNING
   updated_at,
   changeset_ids,
   patch_set_id,
-  closed_at
+  closed_at,
+  auto_merge
 `
 
 func (s *Store) updateCampaignQuery(c *campaigns.Campaign) (*sqlf.Query, error) {
@@ -1290,6 +1363,7 @@ func (s *Store) updateCampaignQuery(c *campaigns.Campaign) (*sqlf.Query, error)
 		changesetIDs,
 		nullInt64Column(c.PatchSetID),
 		nullTimeColumn(c.ClosedAt),
+		c.AutoMerge,
 		c.ID,
 	), nil
 }
@@ -1401,7 +1475,8 @@ SELECT
   updated_at,
   changeset_ids,
   patch_set_id,
-  closed_at
+  closed_at,
+  auto_merge
 FROM campaigns
 WHERE %s
 LIMIT 1
@@ -1470,7 +1545,8 @@ SELECT
   updated_at,
   changeset_ids,
   patch_set_id,
-  closed_at
+  closed_at,
+  auto_merge
 FROM campaigns
 WHERE %s
 ORDER BY id ASC
@@ -1531,14 +1607,16 @@ var createPatchSetQueryFmtstr = `
 INSERT INTO patch_sets (
   created_at,
   updated_at,
-  user_id
+  user_id,
+  search_and_replace
 )
-VALUES (%s, %s, %s)
+VALUES (%s, %s, %s, %s)
 RETURNING
   id,
   created_at,
   updated_at,
-  user_id
+  user_id,
+  search_and_replace
 `
 
 func (s *Store) createPatchSetQuery(c *campaigns.PatchSet) (*sqlf.Query, error) {
@@ -1550,11 +1628,17 @@ func (s *Store) createPatchSetQuery(c *campaigns.PatchSet) (*sqlf.Query, error)
 		c.UpdatedAt = c.CreatedAt
 	}
 
+	searchAndReplace, err := searchAndReplaceColumn(c.SearchAndReplace)
+	if err != nil {
+		return nil, err
+	}
+
 	return sqlf.Sprintf(
 		createPatchSetQueryFmtstr,
 		c.CreatedAt,
 		c.UpdatedAt,
 		c.UserID,
+		searchAndReplace,
 	), nil
 }
 
@@ -1576,23 +1660,31 @@ var updatePatchSetQueryFmtstr = `
 UPDATE patch_sets
 SET (
   updated_at,
-  user_id
-) = (%s, %s)
+  user_id,
+  search_and_replace
+) = (%s, %s, %s)
 WHERE id = %s
 RETURNING
   id,
   created_at,
   updated_at,
-  user_id
+  user_id,
+  search_and_replace
 `
 
 func (s *Store) updatePatchSetQuery(c *campaigns.PatchSet) (*sqlf.Query, error) {
 	c.UpdatedAt = s.now()
 
+	searchAndReplace, err := searchAndReplaceColumn(c.SearchAndReplace)
+	if err != nil {
+		return nil, err
+	}
+
 	return sqlf.Sprintf(
 		updatePatchSetQueryFmtstr,
 		c.UpdatedAt,
 		c.UserID,
+		searchAndReplace,
 		c.ID,
 	), nil
 }
@@ -1616,6 +1708,7 @@ DELETE FROM patch_sets WHERE id = %s
 const PatchSetTTL = 1 * time.Hour
 
 // DeleteExpiredPatchSets deletes PatchSets that have not been attached to a Campaign within PatchSetTTL.
+// PatchSets whose Patches are still being generated by PatchJobs are kept.
 func (s *Store) DeleteExpiredPatchSets(ctx context.Context) error {
 	expirationTime := s.now().Add(-PatchSetTTL)
 	q := sqlf.Sprintf(deleteExpiredPatchSetsQueryFmtstr, expirationTime)
@@ -1650,6 +1743,16 @@ NOT EXISTS (
   JOIN changesets ON changesets.id = changeset_jobs.changeset_id
   WHERE
     (SELECT COUNT(*) FROM jsonb_object_keys(changesets.campaign_ids)) > 0
+)
+AND
+NOT EXISTS (
+  SELECT 1
+  FROM
+    patch_jobs
+  WHERE
+    patch_jobs.patch_set_id = patch_sets.id
+  AND
+    patch_jobs.finished_at IS NULL
 );
 `
 
@@ -1698,7 +1801,8 @@ SELECT
   id,
   created_at,
   updated_at,
-  user_id
+  user_id,
+  search_and_replace
 FROM patch_sets
 WHERE %s
 LIMIT 1
@@ -1725,6 +1829,15 @@ func (s *Store) GetCampaignStatus(ctx context.Context, id int64) (*campaigns.Bac
 	))
 }
 
+// GetPatchSetStatus gets the campaigns.BackgroundProcessStatus of the
+// PatchJobs generating the Patches of a PatchSet.
+func (s *Store) GetPatchSetStatus(ctx context.Context, id int64) (*campaigns.BackgroundProcessStatus, error) {
+	return s.queryBackgroundProcessStatus(ctx, sqlf.Sprintf(
+		getPatchSetStatusQueryFmtstr,
+		sqlf.Sprintf("patch_set_id = %s", id),
+	))
+}
+
 func (s *Store) queryBackgroundProcessStatus(ctx context.Context, q *sqlf.Query) (*campaigns.BackgroundProcessStatus, error) {
 	var status campaigns.BackgroundProcessStatus
 	err := s.exec(ctx, q, func(sc scanner) (_, _ int64, err error) {
@@ -1762,6 +1875,20 @@ WHERE %s
 LIMIT 1
 `
 
+var getPatchSetStatusQueryFmtstr = `
+-- source: enterprise/internal/campaigns/store.go:GetPatchSetStatus
+SELECT
+  -- canceled is here so that this can be used with scanBackgroundProcessStatus
+  false AS canceled,
+  COUNT(*) AS total,
+  COUNT(*) FILTER (WHERE finished_at IS NULL) AS pending,
+  COUNT(*) FILTER (WHERE finished_at IS NOT NULL) AS completed,
+  array_agg(error) FILTER (WHERE error != '') AS errors
+FROM patch_jobs
+WHERE %s
+LIMIT 1
+`
+
 // ListPatchSetsOpts captures the query options needed for
 // listing code mods.
 type ListPatchSetsOpts struct {
@@ -1797,7 +1924,8 @@ SELECT
   id,
   created_at,
   updated_at,
-  user_id
+  user_id,
+  search_and_replace
 FROM patch_sets
 WHERE %s
 ORDER BY id ASC
@@ -2169,12 +2297,14 @@ INSERT INTO changeset_jobs (
   changeset_id,
   branch,
   error,
+  base_commit,
+  rebase_error,
   started_at,
   finished_at,
   created_at,
   updated_at
 )
-VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s)
+VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s)
 RETURNING
   id,
   campaign_id,
@@ -2182,6 +2312,8 @@ RETURNING
   changeset_id,
   branch,
   error,
+  base_commit,
+  rebase_error,
   started_at,
   finished_at,
   created_at,
@@ -2204,6 +2336,8 @@ func (s *Store) createChangesetJobQuery(c *campaigns.ChangesetJob) (*sqlf.Query,
 		nullInt64Column(c.ChangesetID),
 		c.Branch,
 		nullStringColumn(c.Error),
+		nullStringColumn(string(c.BaseCommit)),
+		nullStringColumn(c.RebaseError),
 		nullTimeColumn(c.StartedAt),
 		nullTimeColumn(c.FinishedAt),
 		c.CreatedAt,
@@ -2233,10 +2367,12 @@ SET (
   changeset_id,
   branch,
   error,
+  base_commit,
+  rebase_error,
   started_at,
   finished_at,
   updated_at
-) = (%s, %s, %s, %s, %s, %s, %s, %s)
+) = (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s)
 WHERE id = %s
 RETURNING
   id,
@@ -2245,6 +2381,8 @@ RETURNING
   changeset_id,
   branch,
   error,
+  base_commit,
+  rebase_error,
   started_at,
   finished_at,
   created_at,
@@ -2261,6 +2399,8 @@ func (s *Store) updateChangesetJobQuery(c *campaigns.ChangesetJob) (*sqlf.Query,
 		nullInt64Column(c.ChangesetID),
 		c.Branch,
 		nullStringColumn(c.Error),
+		nullStringColumn(string(c.BaseCommit)),
+		nullStringColumn(c.RebaseError),
 		nullTimeColumn(c.StartedAt),
 		nullTimeColumn(c.FinishedAt),
 		c.UpdatedAt,
@@ -2268,6 +2408,50 @@ func (s *Store) updateChangesetJobQuery(c *campaigns.ChangesetJob) (*sqlf.Query,
 	), nil
 }
 
+// UpdateChangesetJobRebase updates only the BaseCommit and RebaseError of the
+// given ChangesetJob, so that it doesn't overwrite concurrent changes to its
+// Patch or execution state.
+func (s *Store) UpdateChangesetJobRebase(ctx context.Context, c *campaigns.ChangesetJob) error {
+	c.UpdatedAt = s.now()
+
+	q := sqlf.Sprintf(
+		updateChangesetJobRebaseQueryFmtstr,
+		nullStringColumn(string(c.BaseCommit)),
+		nullStringColumn(c.RebaseError),
+		c.UpdatedAt,
+		c.ID,
+	)
+
+	return s.exec(ctx, q, func(sc scanner) (last, count int64, err error) {
+		err = scanChangesetJob(c, sc)
+		return c.ID, 1, err
+	})
+}
+
+var updateChangesetJobRebaseQueryFmtstr = `
+-- source: enterprise/internal/campaigns/store.go:UpdateChangesetJobRebase
+UPDATE changeset_jobs
+SET (
+  base_commit,
+  rebase_error,
+  updated_at
+) = (%s, %s, %s)
+WHERE id = %s
+RETURNING
+  id,
+  campaign_id,
+  patch_id,
+  changeset_id,
+  branch,
+  error,
+  base_commit,
+  rebase_error,
+  started_at,
+  finished_at,
+  created_at,
+  updated_at
+`
+
 // DeleteChangesetJob deletes the ChangesetJob with the given ID.
 func (s *Store) DeleteChangesetJob(ctx context.Context, id int64) error {
 	q := sqlf.Sprintf(deleteChangesetJobQueryFmtstr, id)
@@ -2374,6 +2558,53 @@ func (s *Store) GetChangesetJob(ctx context.Context, opts GetChangesetJobOpts) (
 	return &c, nil
 }
 
+// LockChangesetJob gets the ChangesetJob with the given ID and locks its row
+// until the transaction of the Store ends. It returns ErrNoResults if the job
+// doesn't exist or is already locked, e.g. by ProcessPendingChangesetJobs.
+// It must be called from within a transaction or NoTransactionError is
+// returned.
+func (s *Store) LockChangesetJob(ctx context.Context, id int64) (*campaigns.ChangesetJob, error) {
+	if _, ok := s.db.(dbutil.Tx); !ok {
+		return nil, NoTransactionError
+	}
+
+	q := sqlf.Sprintf(lockChangesetJobQueryFmtstr, id)
+
+	var c campaigns.ChangesetJob
+	err := s.exec(ctx, q, func(sc scanner) (_, _ int64, err error) {
+		return 0, 0, scanChangesetJob(&c, sc)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if c.ID == 0 {
+		return nil, ErrNoResults
+	}
+
+	return &c, nil
+}
+
+var lockChangesetJobQueryFmtstr = `
+-- source: enterprise/internal/campaigns/store.go:LockChangesetJob
+SELECT
+  id,
+  campaign_id,
+  patch_id,
+  changeset_id,
+  branch,
+  error,
+  base_commit,
+  rebase_error,
+  started_at,
+  finished_at,
+  created_at,
+  updated_at
+FROM changeset_jobs
+WHERE id = %s
+FOR UPDATE SKIP LOCKED
+`
+
 var getChangesetJobsQueryFmtstr = `
 -- source: enterprise/internal/campaigns/store.go:GetChangesetJob
 SELECT
@@ -2383,6 +2614,8 @@ SELECT
   changeset_id,
   branch,
   error,
+  base_commit,
+  rebase_error,
   started_at,
   finished_at,
   created_at,
@@ -2424,6 +2657,10 @@ type ListChangesetJobsOpts struct {
 	PatchSetID int64
 	Cursor     int64
 	Limit      int
+
+	// OnlyOpen limits the results to successfully completed jobs whose
+	// campaign isn't closed and whose changeset is open on the codehost.
+	OnlyOpen bool
 }
 
 // ListChangesetJobs lists ChangesetJobs with the given filters.
@@ -2457,6 +2694,8 @@ SELECT
   changeset_jobs.changeset_id,
   changeset_jobs.branch,
   changeset_jobs.error,
+  changeset_jobs.base_commit,
+  changeset_jobs.rebase_error,
   changeset_jobs.started_at,
   changeset_jobs.finished_at,
   changeset_jobs.created_at,
@@ -2489,12 +2728,26 @@ func listChangesetJobsQuery(opts *ListChangesetJobsOpts) *sqlf.Query {
 	}
 
 	var joinClause string
-	if opts.PatchSetID != 0 {
+	if opts.PatchSetID != 0 || opts.OnlyOpen {
 		joinClause = "JOIN campaigns ON changeset_jobs.campaign_id = campaigns.id"
+	}
 
+	if opts.PatchSetID != 0 {
 		preds = append(preds, sqlf.Sprintf("campaigns.patch_set_id = %s", opts.PatchSetID))
 	}
 
+	if opts.OnlyOpen {
+		joinClause += "\nJOIN changesets ON changeset_jobs.changeset_id = changesets.id"
+
+		preds = append(preds,
+			sqlf.Sprintf("campaigns.closed_at IS NULL"),
+			sqlf.Sprintf("changesets.external_state = %s", campaigns.ChangesetStateOpen),
+			sqlf.Sprintf("changesets.external_deleted_at IS NULL"),
+			sqlf.Sprintf("changeset_jobs.finished_at IS NOT NULL"),
+			sqlf.Sprintf("COALESCE(changeset_jobs.error, '') = ''"),
+		)
+	}
+
 	queryTemplate := listChangesetJobsQueryFmtstrSelect + joinClause +
 		listChangesetJobsQueryFmtstrConditions + limitClause
 
@@ -2547,6 +2800,227 @@ SET
 WHERE %s
 `
 
+// CreatePatchJob creates the given PatchJob.
+func (s *Store) CreatePatchJob(ctx context.Context, c *campaigns.PatchJob) error {
+	q, err := s.createPatchJobQuery(c)
+	if err != nil {
+		return err
+	}
+
+	return s.exec(ctx, q, func(sc scanner) (last, count int64, err error) {
+		err = scanPatchJob(c, sc)
+		return c.ID, 1, err
+	})
+}
+
+var createPatchJobQueryFmtstr = `
+-- source: enterprise/internal/campaigns/store.go:CreatePatchJob
+INSERT INTO patch_jobs (
+  patch_set_id,
+  repo_id,
+  rev,
+  base_ref,
+  paths,
+  patch_id,
+  error,
+  started_at,
+  finished_at,
+  created_at,
+  updated_at
+)
+VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s)
+RETURNING
+  id,
+  patch_set_id,
+  repo_id,
+  rev,
+  base_ref,
+  paths,
+  patch_id,
+  error,
+  started_at,
+  finished_at,
+  created_at,
+  updated_at
+`
+
+func (s *Store) createPatchJobQuery(c *campaigns.PatchJob) (*sqlf.Query, error) {
+	paths, err := pathsColumn(c.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = s.now()
+	}
+
+	if c.UpdatedAt.IsZero() {
+		c.UpdatedAt = c.CreatedAt
+	}
+
+	return sqlf.Sprintf(
+		createPatchJobQueryFmtstr,
+		c.PatchSetID,
+		c.RepoID,
+		c.Rev,
+		c.BaseRef,
+		paths,
+		nullInt64Column(c.PatchID),
+		nullStringColumn(c.Error),
+		nullTimeColumn(c.StartedAt),
+		nullTimeColumn(c.FinishedAt),
+		c.CreatedAt,
+		c.UpdatedAt,
+	), nil
+}
+
+// UpdatePatchJob updates the given PatchJob.
+func (s *Store) UpdatePatchJob(ctx context.Context, c *campaigns.PatchJob) error {
+	q, err := s.updatePatchJobQuery(c)
+	if err != nil {
+		return err
+	}
+
+	return s.exec(ctx, q, func(sc scanner) (last, count int64, err error) {
+		err = scanPatchJob(c, sc)
+		return c.ID, 1, err
+	})
+}
+
+var updatePatchJobQueryFmtstr = `
+-- source: enterprise/internal/campaigns/store.go:UpdatePatchJob
+UPDATE patch_jobs
+SET (
+  patch_set_id,
+  repo_id,
+  rev,
+  base_ref,
+  paths,
+  patch_id,
+  error,
+  started_at,
+  finished_at,
+  updated_at
+) = (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s)
+WHERE id = %s
+RETURNING
+  id,
+  patch_set_id,
+  repo_id,
+  rev,
+  base_ref,
+  paths,
+  patch_id,
+  error,
+  started_at,
+  finished_at,
+  created_at,
+  updated_at
+`
+
+func (s *Store) updatePatchJobQuery(c *campaigns.PatchJob) (*sqlf.Query, error) {
+	paths, err := pathsColumn(c.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	c.UpdatedAt = s.now()
+
+	return sqlf.Sprintf(
+		updatePatchJobQueryFmtstr,
+		c.PatchSetID,
+		c.RepoID,
+		c.Rev,
+		c.BaseRef,
+		paths,
+		nullInt64Column(c.PatchID),
+		nullStringColumn(c.Error),
+		nullTimeColumn(c.StartedAt),
+		nullTimeColumn(c.FinishedAt),
+		c.UpdatedAt,
+		c.ID,
+	), nil
+}
+
+// ListPatchJobsOpts captures the query options needed for
+// listing patch jobs.
+type ListPatchJobsOpts struct {
+	PatchSetID int64
+	Cursor     int64
+	Limit      int
+}
+
+// ListPatchJobs lists PatchJobs with the given filters.
+func (s *Store) ListPatchJobs(ctx context.Context, opts ListPatchJobsOpts) (cs []*campaigns.PatchJob, next int64, err error) {
+	q := listPatchJobsQuery(&opts)
+
+	cs = make([]*campaigns.PatchJob, 0, opts.Limit)
+	_, _, err = s.query(ctx, q, func(sc scanner) (last, count int64, err error) {
+		var c campaigns.PatchJob
+		if err = scanPatchJob(&c, sc); err != nil {
+			return 0, 0, err
+		}
+		cs = append(cs, &c)
+		return c.ID, 1, err
+	})
+
+	if opts.Limit != 0 && len(cs) == opts.Limit {
+		next = cs[len(cs)-1].ID
+		cs = cs[:len(cs)-1]
+	}
+
+	return cs, next, err
+}
+
+var listPatchJobsQueryFmtstr = `
+-- source: enterprise/internal/campaigns/store.go:ListPatchJobs
+SELECT
+  id,
+  patch_set_id,
+  repo_id,
+  rev,
+  base_ref,
+  paths,
+  patch_id,
+  error,
+  started_at,
+  finished_at,
+  created_at,
+  updated_at
+FROM patch_jobs
+WHERE %s
+ORDER BY id ASC
+%s
+`
+
+func listPatchJobsQuery(opts *ListPatchJobsOpts) *sqlf.Query {
+	if opts.Limit == 0 {
+		opts.Limit = defaultListLimit
+	}
+	opts.Limit++
+
+	var limitClause *sqlf.Query
+	if opts.Limit > 0 {
+		limitClause = sqlf.Sprintf("LIMIT %s", opts.Limit)
+	} else {
+		limitClause = sqlf.Sprintf("")
+	}
+
+	preds := []*sqlf.Query{
+		sqlf.Sprintf("id >= %s", opts.Cursor),
+	}
+
+	if opts.PatchSetID != 0 {
+		preds = append(preds, sqlf.Sprintf("patch_set_id = %s", opts.PatchSetID))
+	}
+
+	return sqlf.Sprintf(
+		listPatchJobsQueryFmtstr,
+		sqlf.Join(preds, "\n AND "),
+		limitClause,
+	)
+}
+
 // GetChangesetExternalIDs allows us to find the external ids for pull requests based on
 // a slice of head refs. We need this in order to match incoming webhooks to pull requests as
 // the only information they provide is the remote branch
@@ -2667,6 +3141,8 @@ func scanChangeset(t *campaigns.Changeset, s scanner) error {
 		t.Metadata = new(github.PullRequest)
 	case bitbucketserver.ServiceType:
 		t.Metadata = new(bitbucketserver.PullRequest)
+	case gitlab.ServiceType:
+		t.Metadata = new(gitlab.MergeRequest)
 	default:
 		return errors.New("unknown external service type")
 	}
@@ -2720,11 +3196,25 @@ func scanCampaign(c *campaigns.Campaign, s scanner) error {
 		&dbutil.JSONInt64Set{Set: &c.ChangesetIDs},
 		&dbutil.NullInt64{N: &c.PatchSetID},
 		&dbutil.NullTime{Time: &c.ClosedAt},
+		&c.AutoMerge,
 	)
 }
 
 func scanPatchSet(c *campaigns.PatchSet, s scanner) error {
-	return s.Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt, &c.UserID)
+	var searchAndReplace []byte
+	err := s.Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt, &c.UserID, &searchAndReplace)
+	if err != nil {
+		return err
+	}
+
+	c.SearchAndReplace = nil
+	if len(searchAndReplace) > 0 {
+		c.SearchAndReplace = new(campaigns.SearchAndReplace)
+		if err = json.Unmarshal(searchAndReplace, c.SearchAndReplace); err != nil {
+			return errors.Wrap(err, "scanPatchSet: failed to unmarshal search and replace")
+		}
+	}
+	return nil
 }
 
 func scanPatch(c *campaigns.Patch, s scanner) error {
@@ -2748,6 +3238,8 @@ func scanChangesetJob(c *campaigns.ChangesetJob, s scanner) error {
 		&dbutil.NullInt64{N: &c.ChangesetID},
 		&c.Branch,
 		&dbutil.NullString{S: &c.Error},
+		&dbutil.NullString{S: (*string)(&c.BaseCommit)},
+		&dbutil.NullString{S: &c.RebaseError},
 		&dbutil.NullTime{Time: &c.StartedAt},
 		&dbutil.NullTime{Time: &c.FinishedAt},
 		&c.CreatedAt,
@@ -2755,6 +3247,28 @@ func scanChangesetJob(c *campaigns.ChangesetJob, s scanner) error {
 	)
 }
 
+func scanPatchJob(c *campaigns.PatchJob, s scanner) error {
+	var paths []byte
+	err := s.Scan(
+		&c.ID,
+		&c.PatchSetID,
+		&c.RepoID,
+		&c.Rev,
+		&c.BaseRef,
+		&paths,
+		&dbutil.NullInt64{N: &c.PatchID},
+		&dbutil.NullString{S: &c.Error},
+		&dbutil.NullTime{Time: &c.StartedAt},
+		&dbutil.NullTime{Time: &c.FinishedAt},
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(paths, &c.Paths)
+}
+
 func scanBackgroundProcessStatus(b *campaigns.BackgroundProcessStatus, s scanner) error {
 	return s.Scan(
 		&b.Canceled,
@@ -2781,6 +3295,22 @@ func metadataColumn(metadata interface{}) (msg json.RawMessage, err error) {
 	return
 }
 
+// searchAndReplaceColumn returns the value of the search_and_replace column
+// of a PatchSet, which is NULL if the PatchSet has no SearchAndReplace.
+func searchAndReplaceColumn(sr *campaigns.SearchAndReplace) (interface{}, error) {
+	if sr == nil {
+		return nil, nil
+	}
+	return json.Marshal(sr)
+}
+
+func pathsColumn(paths []string) ([]byte, error) {
+	if paths == nil {
+		paths = []string{}
+	}
+	return json.Marshal(paths)
+}
+
 func jsonSetColumn(ids []int64) ([]byte, error) {
 	set := make(map[int64]*struct{}, len(ids))
 	for _, id := range ids {
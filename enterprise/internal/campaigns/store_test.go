@@ -88,6 +88,9 @@ func testStore(db *sql.DB) func(*testing.T) {
 						// Don't close the first one
 						c.ClosedAt = time.Time{}
 					}
+					if i == 1 {
+						c.AutoMerge = true
+					}
 
 					if i%2 == 0 {
 						c.NamespaceOrgID = 23
@@ -2135,6 +2138,8 @@ func testStore(db *sql.DB) func(*testing.T) {
 						ChangesetID: int64(i + 1),
 						Branch:      "test-branch",
 						Error:       "only set on error",
+						BaseCommit:  "f00b4r",
+						RebaseError: "only set if rebase failed",
 						StartedAt:   now,
 						FinishedAt:  now,
 					}
@@ -2793,6 +2798,139 @@ func testStore(db *sql.DB) func(*testing.T) {
 			})
 
 		})
+
+		t.Run("PatchJobs", func(t *testing.T) {
+			patchSet := &cmpgn.PatchSet{
+				UserID: 999,
+				SearchAndReplace: &cmpgn.SearchAndReplace{
+					Query:       "repo:foo fmt.Println(",
+					PatternType: cmpgn.SearchAndReplaceRegexp,
+					Pattern:     `fmt\.Println\(`,
+					Replacement: "log.Println(",
+				},
+			}
+			if err := s.CreatePatchSet(ctx, patchSet); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Run("PatchSet", func(t *testing.T) {
+				have, err := s.GetPatchSet(ctx, GetPatchSetOpts{ID: patchSet.ID})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(have, patchSet); diff != "" {
+					t.Fatal(diff)
+				}
+			})
+
+			patchJobs := make([]*cmpgn.PatchJob, 0, 3)
+
+			t.Run("Create", func(t *testing.T) {
+				for i := 0; i < cap(patchJobs); i++ {
+					c := &cmpgn.PatchJob{
+						PatchSetID: patchSet.ID,
+						RepoID:     api.RepoID(i + 1),
+						Rev:        api.CommitID("deadbeef"),
+						BaseRef:    "refs/heads/master",
+						Paths:      []string{"a.go", fmt.Sprintf("b%d.go", i)},
+					}
+
+					want := c.Clone()
+					have := c
+
+					err := s.CreatePatchJob(ctx, have)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if have.ID == 0 {
+						t.Fatal("ID should not be zero")
+					}
+
+					want.ID = have.ID
+					want.CreatedAt = now
+					want.UpdatedAt = now
+
+					if diff := cmp.Diff(have, want); diff != "" {
+						t.Fatal(diff)
+					}
+
+					patchJobs = append(patchJobs, c)
+				}
+			})
+
+			t.Run("List", func(t *testing.T) {
+				have, next, err := s.ListPatchJobs(ctx, ListPatchJobsOpts{PatchSetID: patchSet.ID})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if next != 0 {
+					t.Fatalf("have next %d, want 0", next)
+				}
+				if diff := cmp.Diff(have, patchJobs); diff != "" {
+					t.Fatal(diff)
+				}
+
+				have, next, err = s.ListPatchJobs(ctx, ListPatchJobsOpts{PatchSetID: patchSet.ID, Limit: 1})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if next != patchJobs[1].ID {
+					t.Fatalf("have next %d, want %d", next, patchJobs[1].ID)
+				}
+				if diff := cmp.Diff(have, patchJobs[:1]); diff != "" {
+					t.Fatal(diff)
+				}
+			})
+
+			t.Run("Status", func(t *testing.T) {
+				status, err := s.GetPatchSetStatus(ctx, patchSet.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if status.Total != 3 || status.Pending != 3 || status.ProcessState != cmpgn.BackgroundProcessStateProcessing {
+					t.Fatalf("wrong status %+v", status)
+				}
+			})
+
+			t.Run("Update", func(t *testing.T) {
+				for i, c := range patchJobs {
+					c.StartedAt = clock()
+					c.FinishedAt = clock()
+					if i == 0 {
+						c.Error = "failed"
+					} else {
+						c.PatchID = int64(i)
+					}
+
+					want := c.Clone()
+					have := c
+
+					if err := s.UpdatePatchJob(ctx, have); err != nil {
+						t.Fatal(err)
+					}
+
+					want.UpdatedAt = clock()
+					if diff := cmp.Diff(have, want); diff != "" {
+						t.Fatal(diff)
+					}
+				}
+
+				status, err := s.GetPatchSetStatus(ctx, patchSet.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := &cmpgn.BackgroundProcessStatus{
+					Total:         3,
+					Completed:     3,
+					ProcessState:  cmpgn.BackgroundProcessStateErrored,
+					ProcessErrors: []string{"failed"},
+				}
+				if diff := cmp.Diff(status, want); diff != "" {
+					t.Fatal(diff)
+				}
+			})
+		})
 	}
 }
 
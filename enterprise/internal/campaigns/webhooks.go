@@ -2,6 +2,7 @@ package campaigns
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -22,6 +23,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	bbs "github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -79,6 +82,8 @@ func extractExternalServiceID(extSvc *repos.ExternalService) (string, error) {
 		serviceID = c.Url
 	case *schema.BitbucketServerConnection:
 		serviceID = c.Url
+	case *schema.GitLabConnection:
+		serviceID = c.Url
 	}
 	if serviceID == "" {
 		return "", errors.New("could not determine service id")
@@ -902,6 +907,193 @@ func (h *BitbucketServerWebhook) convertEvent(theirs interface{}) (prs []PR, our
 	return
 }
 
+// GitLabWebhook receives GitLab project and group webhook events that are
+// relevant to campaigns, normalizes those events into ChangesetEvents and
+// upserts them to the database.
+type GitLabWebhook struct {
+	*Webhook
+}
+
+func NewGitLabWebhook(store *Store, repos repos.Store, now func() time.Time) *GitLabWebhook {
+	return &GitLabWebhook{&Webhook{store, repos, now, gitlab.ServiceType}}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *GitLabWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e, extSvc, hErr := h.parseEvent(r)
+	if hErr != nil {
+		respond(w, hErr.code, hErr)
+		return
+	}
+
+	externalServiceID, err := extractExternalServiceID(extSvc)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if e, ok := e.(*gitlab.MergeRequestEvent); ok {
+		if !e.IsTrackedAction() {
+			respond(w, http.StatusOK, nil) // Nothing to do
+			return
+		}
+		// The event doesn't include the ID of the system note GitLab records
+		// for the action, so we sync the changeset to load the note instead
+		// of recording an event that would duplicate it.
+		pr := PR{ID: int64(e.ObjectAttributes.IID), RepoExternalID: strconv.Itoa(e.Project.ID)}
+		if err := h.enqueueChangesetSync(r.Context(), externalServiceID, pr); err != nil {
+			respond(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	prs, ev := h.convertEvent(r.Context(), externalServiceID, e)
+	if len(prs) == 0 || ev == nil {
+		respond(w, http.StatusOK, nil) // Nothing to do
+		return
+	}
+
+	m := new(multierror.Error)
+	for _, pr := range prs {
+		err := h.upsertChangesetEvent(r.Context(), externalServiceID, pr, ev)
+		if err != nil {
+			m = multierror.Append(m, err)
+		}
+	}
+	if m.ErrorOrNil() != nil {
+		respond(w, http.StatusInternalServerError, m)
+	}
+}
+
+func (h *GitLabWebhook) parseEvent(r *http.Request) (interface{}, *repos.ExternalService, *httpError) {
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	// 🚨 SECURITY: GitLab sends the secret token configured for the webhook
+	// verbatim in the X-Gitlab-Token header, so we authenticate the request by
+	// comparing it against the secrets of all GitLab external services.
+	args := repos.StoreListExternalServicesArgs{Kinds: []string{"GITLAB"}}
+	es, err := h.Repos.ListExternalServices(r.Context(), args)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusInternalServerError, err}
+	}
+
+	token := []byte(gitlab.WebhookToken(r))
+
+	var extSvc *repos.ExternalService
+	for _, e := range es {
+		c, _ := e.Configuration()
+		con, ok := c.(*schema.GitLabConnection)
+		if !ok {
+			continue
+		}
+
+		for _, hook := range con.Webhooks {
+			if hook.Secret == "" {
+				continue
+			}
+
+			if subtle.ConstantTimeCompare(token, []byte(hook.Secret)) == 1 {
+				extSvc = e
+				break
+			}
+		}
+		if extSvc != nil {
+			break
+		}
+	}
+
+	if extSvc == nil {
+		return nil, nil, &httpError{http.StatusUnauthorized, nil}
+	}
+
+	e, err := gitlab.ParseWebhookEvent(gitlab.WebhookEventType(r), payload)
+	if err != nil {
+		return nil, nil, &httpError{http.StatusBadRequest, err}
+	}
+	return e, extSvc, nil
+}
+
+// enqueueChangesetSync asks repo-updater to sync the changeset of pr, if
+// there is one.
+func (h *GitLabWebhook) enqueueChangesetSync(ctx context.Context, externalServiceID string, pr PR) error {
+	r, err := h.getRepoForPR(ctx, h.Store, pr, externalServiceID)
+	if err != nil {
+		log15.Debug("Webhook event could not be matched to repo", "err", err)
+		return nil
+	}
+
+	cs, err := h.Store.GetChangeset(ctx, GetChangesetOpts{
+		RepoID:              r.ID,
+		ExternalID:          strconv.FormatInt(pr.ID, 10),
+		ExternalServiceType: h.ServiceType,
+	})
+	if err != nil {
+		if err == ErrNoResults {
+			err = nil // Nothing to do
+		}
+		return err
+	}
+
+	return repoupdater.DefaultClient.EnqueueChangesetSync(ctx, []int64{cs.ID})
+}
+
+func (h *GitLabWebhook) convertEvent(ctx context.Context, externalServiceID string, theirs interface{}) (prs []PR, ours interface{ Key() string }) {
+	log15.Debug("GitLab webhook received", "type", fmt.Sprintf("%T", theirs))
+
+	switch e := theirs.(type) {
+	case *gitlab.NoteEvent:
+		if e.MergeRequest == nil {
+			return nil, nil
+		}
+		// Only system notes describe events we track.
+		ev, ok := e.Note().ToEvent().(interface{ Key() string })
+		if !ok {
+			return nil, nil
+		}
+		repoID := strconv.Itoa(e.Project.ID)
+		prs = append(prs, PR{ID: int64(e.MergeRequest.IID), RepoExternalID: repoID})
+		return prs, ev
+
+	case *gitlab.PipelineEvent:
+		repoID := strconv.Itoa(e.Project.ID)
+		if e.MergeRequest != nil {
+			prs = append(prs, PR{ID: int64(e.MergeRequest.IID), RepoExternalID: repoID})
+		} else {
+			spec := api.ExternalRepoSpec{
+				ID:          repoID,
+				ServiceID:   externalServiceID,
+				ServiceType: gitlab.ServiceType,
+			}
+
+			ids, err := h.Store.GetChangesetExternalIDs(ctx, spec, []string{e.ObjectAttributes.Ref})
+			if err != nil {
+				log15.Error("Error executing GetChangesetExternalIDs", "err", err)
+				return nil, nil
+			}
+
+			for _, id := range ids {
+				i, err := strconv.ParseInt(id, 10, 64)
+				if err != nil {
+					log15.Error("Error parsing external id", "err", err)
+					continue
+				}
+				prs = append(prs, PR{ID: i, RepoExternalID: repoID})
+			}
+		}
+
+		p := e.Pipeline()
+		// The check state only considers pipelines that were updated after
+		// the changeset was last synced, so we record when we received it.
+		p.UpdatedAt = h.Now()
+		return prs, p
+	}
+
+	return nil, nil
+}
+
 type httpError struct {
 	code int
 	err  error
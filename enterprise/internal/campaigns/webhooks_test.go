@@ -24,10 +24,14 @@ import (
 	gh "github.com/google/go-github/github"
 	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
 	"github.com/sourcegraph/sourcegraph/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/httptestutil"
 	"github.com/sourcegraph/sourcegraph/internal/rcache"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -375,3 +379,173 @@ func parseTimestamp(t testing.TB, ts string) time.Time {
 
 	return timestamp
 }
+
+func TestGitLabWebhook_convertEvent(t *testing.T) {
+	now := time.Date(2020, 3, 4, 10, 0, 0, 0, time.UTC)
+	h := NewGitLabWebhook(nil, nil, func() time.Time { return now })
+
+	parse := func(eventType, payload string) interface{} {
+		e, err := gitlab.ParseWebhookEvent(eventType, []byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	t.Run("merge request approved", func(t *testing.T) {
+		// Merge request events are handled by syncing the changeset.
+		e := parse(gitlab.WebhookEventTypeMergeRequest, `{"user":{"username":"alice"},"project":{"id":7},"object_attributes":{"iid":3,"action":"approved"}}`)
+		if prs, ev := h.convertEvent(context.Background(), "https://gitlab.com/", e); prs != nil || ev != nil {
+			t.Errorf("unexpected result: %v, %v", prs, ev)
+		}
+	})
+
+	t.Run("user note", func(t *testing.T) {
+		e := parse(gitlab.WebhookEventTypeNote, `{"project":{"id":7},"object_attributes":{"id":1,"note":"merged"},"merge_request":{"iid":3}}`)
+		if prs, ev := h.convertEvent(context.Background(), "https://gitlab.com/", e); prs != nil || ev != nil {
+			t.Errorf("unexpected result: %v, %v", prs, ev)
+		}
+	})
+
+	t.Run("system note", func(t *testing.T) {
+		e := parse(gitlab.WebhookEventTypeNote, `{"project":{"id":7},"object_attributes":{"id":1,"note":"closed","system":true},"merge_request":{"iid":3}}`)
+		prs, ev := h.convertEvent(context.Background(), "https://gitlab.com/", e)
+		if diff := cmp.Diff(prs, []PR{{ID: 3, RepoExternalID: "7"}}); diff != "" {
+			t.Error(diff)
+		}
+		if _, ok := ev.(*gitlab.MergeRequestClosedEvent); !ok {
+			t.Errorf("unexpected event type %T", ev)
+		}
+	})
+
+	t.Run("merge request pipeline", func(t *testing.T) {
+		e := parse(gitlab.WebhookEventTypePipeline, `{"project":{"id":7},"object_attributes":{"id":31,"status":"failed"},"merge_request":{"iid":3}}`)
+		prs, ev := h.convertEvent(context.Background(), "https://gitlab.com/", e)
+		if diff := cmp.Diff(prs, []PR{{ID: 3, RepoExternalID: "7"}}); diff != "" {
+			t.Error(diff)
+		}
+		p, ok := ev.(*gitlab.Pipeline)
+		if !ok {
+			t.Fatalf("unexpected event type %T", ev)
+		}
+		if p.Status != gitlab.PipelineStatusFailed || !p.UpdatedAt.Equal(now) {
+			t.Errorf("unexpected pipeline: %+v", p)
+		}
+	})
+}
+
+func TestGitLabWebhook_mergeRequestActionsAreNotDuplicated(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	clock := func() time.Time { return now }
+
+	dbtesting.SetupGlobalTestDB(t)
+
+	store := NewStoreWithClock(dbconn.Global, clock)
+	repoStore := repos.NewDBStore(dbconn.Global, sql.TxOptions{})
+
+	secret := "secret"
+	ext := &repos.ExternalService{
+		Kind:        "GITLAB",
+		DisplayName: "GitLab",
+		Config: marshalJSON(t, &schema.GitLabConnection{
+			Url:      "https://example.com",
+			Token:    "SECRETTOKEN",
+			Webhooks: []*schema.GitLabWebhook{{Secret: secret}},
+		}),
+	}
+	if err := repoStore.UpsertExternalServices(ctx, ext); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := testRepo(0, gitlab.ServiceType)
+	repo.ExternalRepo.ID = "7"
+	if err := repoStore.UpsertRepos(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	mr := &gitlab.MergeRequest{
+		IID:       3,
+		State:     gitlab.MergeRequestStateOpened,
+		CreatedAt: now.Add(-2 * time.Hour),
+	}
+	cs := &campaigns.Changeset{
+		RepoID:              repo.ID,
+		ExternalID:          "3",
+		ExternalServiceType: gitlab.ServiceType,
+		Metadata:            mr,
+	}
+	if err := store.CreateChangesets(ctx, cs); err != nil {
+		t.Fatal(err)
+	}
+
+	var enqueued []int64
+	repoupdater.MockEnqueueChangesetSync = func(ctx context.Context, ids []int64) error {
+		enqueued = append(enqueued, ids...)
+		return nil
+	}
+	defer func() { repoupdater.MockEnqueueChangesetSync = nil }()
+
+	hook := NewGitLabWebhook(store, repoStore, clock)
+	send := func(eventType, payload string) {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/", strings.NewReader(payload))
+		req.Header.Set("X-Gitlab-Event", eventType)
+		req.Header.Set("X-Gitlab-Token", secret)
+		rec := httptest.NewRecorder()
+		hook.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("have status code %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+		}
+	}
+
+	// GitLab sends an event for the merge and one for the system note it
+	// records for it.
+	mergedAt := now.Add(-time.Hour)
+	send(gitlab.WebhookEventTypeMergeRequest, `{"user":{"username":"alice"},"project":{"id":7},"object_attributes":{"iid":3,"state":"merged","action":"merge"}}`)
+	if diff := cmp.Diff([]int64{cs.ID}, enqueued); diff != "" {
+		t.Errorf("unexpected enqueued syncs (-want +have):\n%s", diff)
+	}
+	send(gitlab.WebhookEventTypeNote, `{"user":{"username":"alice"},"project":{"id":7},"object_attributes":{"id":42,"note":"merged","noteable_type":"MergeRequest","system":true,"created_at":"`+mergedAt.Format(time.RFC3339)+`"},"merge_request":{"iid":3}}`)
+
+	// The sync loads the same system note.
+	merged := *mr
+	merged.State = gitlab.MergeRequestStateMerged
+	merged.Notes = []*gitlab.Note{{
+		ID:        42,
+		Body:      "merged",
+		Author:    gitlab.User{Username: "alice"},
+		CreatedAt: mergedAt,
+		System:    true,
+	}}
+	err := SyncChangesetsWithSources(ctx, store, []*SourceChangesets{{
+		ChangesetSource: fakeChangesetSource{fakeMetadata: &merged},
+		Changesets:      []*repos.Changeset{{Changeset: cs, Repo: repo}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, _, err := store.ListChangesetEvents(ctx, ListChangesetEventsOpts{
+		ChangesetIDs: []int64{cs.ID},
+		Limit:        -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Kind != campaigns.ChangesetEventKindGitLabMerged || events[0].Key != "42" {
+		t.Fatalf("want a single merged event with key 42, have %+v", events)
+	}
+
+	es := make([]Event, len(events))
+	for i, e := range events {
+		es[i] = e
+	}
+	counts, err := CalcCounts(now.Add(-3*time.Hour), now, []*campaigns.Changeset{cs}, es...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := counts[len(counts)-1]; last.Total != 1 || last.Merged != 1 || last.Open != 0 || last.Closed != 0 {
+		t.Errorf("unexpected counts: %s", last)
+	}
+}
@@ -73,6 +73,137 @@ func RunWorkers(ctx context.Context, s *Store, clock func() time.Time, gitClient
 	}
 }
 
+// RunPatchJobWorkers should be executed in a background goroutine and is
+// responsible for finding pending PatchJobs and executing them. Structural
+// replacements are computed by the replacer service at replacerURL.
+// ctx should be canceled to terminate the function.
+func RunPatchJobWorkers(ctx context.Context, s *Store, clock func() time.Time, replacerURL string, backoffDuration time.Duration) {
+	workerCount, err := strconv.Atoi(maxWorkers)
+	if err != nil {
+		log15.Error("Parsing max worker count failed. Falling back to default.", "default", defaultWorkerCount, "err", err)
+		workerCount = defaultWorkerCount
+	}
+	process := func(ctx context.Context, s *Store, job campaigns.PatchJob) error {
+		ps, err := s.GetPatchSet(ctx, GetPatchSetOpts{
+			ID: job.PatchSetID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "getting patch set")
+		}
+
+		if runErr := ExecPatchJob(ctx, clock, s, replacerURL, ps, &job); runErr != nil {
+			log15.Error("ExecPatchJob", "jobID", job.ID, "err", runErr)
+		}
+		// We don't assign to err here so that we don't roll back the transaction
+		// ExecPatchJob will save the error in the job row
+		return nil
+	}
+	worker := func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				didRun, err := s.ProcessPendingPatchJobs(context.Background(), process)
+				if err != nil {
+					log15.Error("Running patch job", "err", err)
+				}
+				// Back off on error or when no jobs available
+				if err != nil || !didRun {
+					time.Sleep(backoffDuration)
+				}
+			}
+		}
+	}
+	for i := 0; i < workerCount; i++ {
+		go worker()
+	}
+}
+
+// ExecPatchJob will execute the given PatchJob of the given PatchSet: it
+// applies the SearchAndReplace of the PatchSet to the files of the job and
+// creates a Patch with the resulting diff, if it isn't empty. It is idempotent
+// and if the job has already been executed it will not be executed.
+func ExecPatchJob(
+	ctx context.Context,
+	clock func() time.Time,
+	store *Store,
+	replacerURL string,
+	ps *campaigns.PatchSet,
+	job *campaigns.PatchJob,
+) (err error) {
+	// Store should already have an open transaction but ensure here anyway
+	store, err = store.Transact(ctx)
+	if err != nil {
+		return errors.Wrap(err, "creating transaction")
+	}
+
+	tr, ctx := trace.New(ctx, "service.ExecPatchJob", fmt.Sprintf("job_id: %d", job.ID))
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+	tr.LogFields(log.Int64("job_id", job.ID), log.Int64("patch_set_id", ps.ID))
+
+	if !job.FinishedAt.IsZero() {
+		log15.Info("PatchJob already completed", "id", job.ID)
+		return nil
+	}
+
+	defer func() {
+		if err != nil {
+			job.Error = err.Error()
+		}
+		job.FinishedAt = clock()
+
+		if e := store.UpdatePatchJob(ctx, job); e != nil {
+			if err == nil {
+				err = e
+			} else {
+				err = multierror.Append(err, e)
+			}
+		}
+	}()
+
+	job.StartedAt = clock()
+
+	if ps.SearchAndReplace == nil {
+		return errors.Errorf("patch set %d has no search and replace", ps.ID)
+	}
+
+	reposStore := repos.NewDBStore(store.DB(), sql.TxOptions{})
+	rs, err := reposStore.ListRepos(ctx, repos.StoreListReposArgs{IDs: []api.RepoID{job.RepoID}})
+	if err != nil {
+		return err
+	}
+	if len(rs) != 1 {
+		return errors.Errorf("repo not found: %d", job.RepoID)
+	}
+	repo := rs[0]
+
+	diff, err := generatePatch(ctx, replacerURL, ps.SearchAndReplace, api.RepoName(repo.Name), job)
+	if err != nil {
+		return errors.Wrapf(err, "generating patch for repository %q", repo.Name)
+	}
+	if diff == "" {
+		// The replacement doesn't change any of the matched files.
+		return nil
+	}
+
+	patch := &campaigns.Patch{
+		PatchSetID: ps.ID,
+		RepoID:     job.RepoID,
+		Rev:        job.Rev,
+		BaseRef:    job.BaseRef,
+		Diff:       diff,
+	}
+	if err = store.CreatePatch(ctx, patch); err != nil {
+		return err
+	}
+	job.PatchID = patch.ID
+	return nil
+}
+
 // ExecChangesetJob will execute the given ChangesetJob for the given campaign.
 // It is idempotent and if the job has already been executed it will not be
 // executed.
@@ -155,37 +286,10 @@ func ExecChangesetJob(
 		ensureUniqueRef = false
 	}
 
-	ref, err := gitClient.CreateCommitFromPatch(ctx, protocol.CreateCommitFromPatchRequest{
-		Repo:       api.RepoName(repo.Name),
-		BaseCommit: patch.Rev,
-		// IMPORTANT: We add a trailing newline here, otherwise `git apply`
-		// will fail with "corrupt patch at line <N>" where N is the last line.
-		Patch:     patch.Diff + "\n",
-		TargetRef: branch,
-		UniqueRef: ensureUniqueRef,
-		CommitInfo: protocol.PatchCommitInfo{
-			Message:     c.Name,
-			AuthorName:  "Sourcegraph Bot",
-			AuthorEmail: "campaigns@sourcegraph.com",
-			Date:        job.CreatedAt,
-		},
-		// We use unified diffs, not git diffs, which means they're missing the
-		// `a/` and `/b` filename prefixes. `-p0` tells `git apply` to not
-		// expect and strip prefixes.
-		// Since we also produce diffs manually, we might not have context lines,
-		// so we need to disable that check with `--unidiff-zero`.
-		GitApplyArgs: []string{"-p0", "--unidiff-zero"},
-		Push:         true,
-	})
+	ref, err := pushPatch(ctx, gitClient, repo, c, patch, patch.Rev, branch, ensureUniqueRef, job.CreatedAt)
 	if err != nil {
-		if diffErr, ok := err.(*protocol.CreateCommitFromPatchError); ok {
-			return errors.Errorf(
-				"creating commit from patch for repository %q: %s\n"+
-					"```\n"+
-					"$ %s\n"+
-					"%s\n"+
-					"```",
-				diffErr.RepositoryName, diffErr.InternalError, diffErr.Command, strings.TrimSpace(diffErr.CombinedOutput))
+		if msg, ok := describePatchError(err); ok {
+			return errors.New(msg)
 		}
 		return err
 	}
@@ -193,6 +297,8 @@ func ExecChangesetJob(
 		return fmt.Errorf("ref %q doesn't match ChangesetJob's branch %q", ref, job.Branch)
 	}
 	job.Branch = ref
+	job.BaseCommit = patch.Rev
+	job.RebaseError = ""
 
 	var externalService *repos.ExternalService
 	{
@@ -328,3 +434,59 @@ func ExecChangesetJob(
 	runFinalUpdate(ctx, store)
 	return
 }
+
+// pushPatch applies the diff of the given Patch on top of base, commits it
+// with the name of the Campaign as the commit message and force-pushes the
+// commit to branch. It returns the name of the ref that was pushed, which
+// only differs from branch if uniqueRef is true and branch already exists.
+func pushPatch(
+	ctx context.Context,
+	gitClient GitserverClient,
+	repo *repos.Repo,
+	c *campaigns.Campaign,
+	patch *campaigns.Patch,
+	base api.CommitID,
+	branch string,
+	uniqueRef bool,
+	date time.Time,
+) (string, error) {
+	return gitClient.CreateCommitFromPatch(ctx, protocol.CreateCommitFromPatchRequest{
+		Repo:       api.RepoName(repo.Name),
+		BaseCommit: base,
+		// IMPORTANT: We add a trailing newline here, otherwise `git apply`
+		// will fail with "corrupt patch at line <N>" where N is the last line.
+		Patch:     patch.Diff + "\n",
+		TargetRef: branch,
+		UniqueRef: uniqueRef,
+		CommitInfo: protocol.PatchCommitInfo{
+			Message:     c.Name,
+			AuthorName:  "Sourcegraph Bot",
+			AuthorEmail: "campaigns@sourcegraph.com",
+			Date:        date,
+		},
+		// We use unified diffs, not git diffs, which means they're missing the
+		// `a/` and `/b` filename prefixes. `-p0` tells `git apply` to not
+		// expect and strip prefixes.
+		// Since we also produce diffs manually, we might not have context lines,
+		// so we need to disable that check with `--unidiff-zero`.
+		GitApplyArgs: []string{"-p0", "--unidiff-zero"},
+		Push:         true,
+	})
+}
+
+// describePatchError returns a description of err, including the output of
+// the failed git command, if err means that the patch couldn't be applied or
+// committed. ok is false for all other errors.
+func describePatchError(err error) (msg string, ok bool) {
+	diffErr, ok := err.(*protocol.CreateCommitFromPatchError)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf(
+		"creating commit from patch for repository %q: %s\n"+
+			"```\n"+
+			"$ %s\n"+
+			"%s\n"+
+			"```",
+		diffErr.RepositoryName, diffErr.InternalError, diffErr.Command, strings.TrimSpace(diffErr.CombinedOutput)), true
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	cmpgn "github.com/sourcegraph/sourcegraph/internal/campaigns"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbtest"
@@ -171,6 +172,119 @@ func TestExecChangesetJob(t *testing.T) {
 	}
 }
 
+func TestExecPatchJob(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	clock := func() time.Time { return now.UTC().Truncate(time.Microsecond) }
+
+	dbtesting.SetupGlobalTestDB(t)
+
+	git.Mocks.ReadFile = func(commit api.CommitID, name string) ([]byte, error) {
+		switch name {
+		case "a.go":
+			return []byte("fmt.Println(1)\n"), nil
+		case "b.go":
+			return []byte("log.Println(2)\n"), nil
+		}
+		return nil, fmt.Errorf("file %q not found", name)
+	}
+	defer git.ResetMocks()
+
+	tests := []struct {
+		name      string
+		paths     []string
+		wantDiff  string
+		wantError string
+	}{
+		{
+			name:     "Changed",
+			paths:    []string{"a.go", "b.go"},
+			wantDiff: "--- a.go\n+++ a.go\n@@ -1,1 +1,1 @@\n-fmt.Println(1)\n+log.Println(1)",
+		},
+		{
+			name:  "Unchanged",
+			paths: []string{"b.go"},
+		},
+		{
+			name:      "Error",
+			paths:     []string{"c.go"},
+			wantError: `generating patch for repository "repo-0": reading "c.go": file "c.go" not found`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tx := dbtest.NewTx(t, dbconn.Global)
+			s := NewStoreWithClock(tx, clock)
+
+			repo, _ := createGitHubRepo(t, ctx, now, s)
+
+			patchSet := &cmpgn.PatchSet{
+				UserID: 888,
+				SearchAndReplace: &cmpgn.SearchAndReplace{
+					PatternType: cmpgn.SearchAndReplaceRegexp,
+					Pattern:     `fmt\.`,
+					Replacement: "log.",
+				},
+			}
+			if err := s.CreatePatchSet(ctx, patchSet); err != nil {
+				t.Fatal(err)
+			}
+
+			job := &cmpgn.PatchJob{
+				PatchSetID: patchSet.ID,
+				RepoID:     repo.ID,
+				Rev:        "f00b4r",
+				BaseRef:    "refs/heads/master",
+				Paths:      tc.paths,
+			}
+			if err := s.CreatePatchJob(ctx, job); err != nil {
+				t.Fatal(err)
+			}
+
+			err := ExecPatchJob(ctx, clock, s, "", patchSet, job)
+			if have, want := fmt.Sprint(err), tc.wantError; want != "" && have != want {
+				t.Fatalf("have error %q, want %q", have, want)
+			} else if want == "" && err != nil {
+				t.Fatal(err)
+			}
+
+			jobs, _, err := s.ListPatchJobs(ctx, ListPatchJobsOpts{PatchSetID: patchSet.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(jobs) != 1 {
+				t.Fatalf("have %d patch jobs, want 1", len(jobs))
+			}
+			job = jobs[0]
+			if job.FinishedAt.IsZero() {
+				t.Fatal("PatchJob is not finished")
+			}
+			if job.Error != tc.wantError {
+				t.Fatalf("have job error %q, want %q", job.Error, tc.wantError)
+			}
+
+			if tc.wantDiff == "" {
+				if job.PatchID != 0 {
+					t.Fatalf("PatchJob has PatchID %d, want none", job.PatchID)
+				}
+				return
+			}
+			patch, err := s.GetPatch(ctx, GetPatchOpts{ID: job.PatchID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if patch.Diff != tc.wantDiff {
+				t.Fatalf("have diff %q, want %q", patch.Diff, tc.wantDiff)
+			}
+			if patch.BaseRef != job.BaseRef || patch.Rev != job.Rev || patch.RepoID != repo.ID {
+				t.Fatalf("wrong patch %+v for job %+v", patch, job)
+			}
+		})
+	}
+}
+
 const testDiff = `diff --git foobar.c foobar.c
 index d75b080..cf04b5b 100644
 --- foobar.c
@@ -232,11 +346,20 @@ func (s fakeChangesetSource) ExternalServices() repos.ExternalServices {
 	return repos.ExternalServices{s.svc}
 }
 func (s fakeChangesetSource) LoadChangesets(ctx context.Context, cs ...*repos.Changeset) error {
-	return fakeNotImplemented
+	if s.err != nil {
+		return s.err
+	}
+	for _, c := range cs {
+		c.SetMetadata(s.fakeMetadata)
+	}
+	return nil
 }
 func (s fakeChangesetSource) CloseChangeset(ctx context.Context, c *repos.Changeset) error {
 	return fakeNotImplemented
 }
+func (s fakeChangesetSource) MergeChangeset(ctx context.Context, c *repos.Changeset) error {
+	return fakeNotImplemented
+}
 
 func createGitHubRepo(t *testing.T, ctx context.Context, now time.Time, s *Store) (*repos.Repo, *repos.ExternalService) {
 	t.Helper()
@@ -92,14 +92,16 @@ type SavedQueryIDSpec struct {
 // ConfigSavedQuery is the JSON shape of a saved query entry in the JSON configuration
 // (i.e., an entry in the {"search.savedQueries": [...]} array).
 type ConfigSavedQuery struct {
-	Key             string  `json:"key,omitempty"`
-	Description     string  `json:"description"`
-	Query           string  `json:"query"`
-	Notify          bool    `json:"notify,omitempty"`
-	NotifySlack     bool    `json:"notifySlack,omitempty"`
-	UserID          *int32  `json:"userID"`
-	OrgID           *int32  `json:"orgID"`
-	SlackWebhookURL *string `json:"slackWebhookURL"`
+	Key             string   `json:"key,omitempty"`
+	Description     string   `json:"description"`
+	Query           string   `json:"query"`
+	Notify          bool     `json:"notify,omitempty"`
+	NotifySlack     bool     `json:"notifySlack,omitempty"`
+	UserID          *int32   `json:"userID"`
+	OrgID           *int32   `json:"orgID"`
+	SlackWebhookURL *string  `json:"slackWebhookURL"`
+	WebhookURLs     []string `json:"webhookURLs,omitempty"`
+	WebhookSecret   *string  `json:"webhookSecret,omitempty"`
 }
 
 func (sq ConfigSavedQuery) Equals(other ConfigSavedQuery) bool {
@@ -13,6 +13,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 )
 
 // SupportedExternalServices are the external service types currently supported
@@ -22,6 +23,7 @@ import (
 var SupportedExternalServices = map[string]struct{}{
 	github.ServiceType:          {},
 	bitbucketserver.ServiceType: {},
+	gitlab.ServiceType:          {},
 }
 
 // IsRepoSupported returns whether the given ExternalRepoSpec is supported by
@@ -37,6 +39,10 @@ type PatchSet struct {
 
 	UserID int32
 
+	// SearchAndReplace is set if the Patches of the PatchSet are generated on
+	// the server by PatchJobs, instead of being computed by the caller.
+	SearchAndReplace *SearchAndReplace
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -44,6 +50,71 @@ type PatchSet struct {
 // Clone returns a clone of a PatchSet.
 func (c *PatchSet) Clone() *PatchSet {
 	cc := *c
+	if c.SearchAndReplace != nil {
+		sr := *c.SearchAndReplace
+		cc.SearchAndReplace = &sr
+	}
+	return &cc
+}
+
+// SearchAndReplace is the specification of a PatchSet whose Patches replace
+// the matches of a search query in every repository the query matches.
+type SearchAndReplace struct {
+	// Query is the search query that selected the repositories and files.
+	Query string `json:"query"`
+	// PatternType is the type of Pattern: SearchAndReplaceRegexp or
+	// SearchAndReplaceStructural.
+	PatternType SearchAndReplacePatternType `json:"patternType"`
+	// Pattern is the search pattern of Query, without its filters.
+	Pattern string `json:"pattern"`
+	// CaseSensitive is whether a regexp Pattern matches case-sensitively.
+	CaseSensitive bool `json:"caseSensitive,omitempty"`
+	// Replacement replaces each match of Pattern. It can refer to submatches
+	// with $1 or ${name} for regexp patterns, and to holes with :[name] for
+	// structural patterns.
+	Replacement string `json:"replacement"`
+}
+
+// SearchAndReplacePatternType is the type of a SearchAndReplace pattern.
+type SearchAndReplacePatternType string
+
+// SearchAndReplacePatternType constants.
+const (
+	SearchAndReplaceRegexp     SearchAndReplacePatternType = "regexp"
+	SearchAndReplaceStructural SearchAndReplacePatternType = "structural"
+)
+
+// A PatchJob is the generation of the Patch of a PatchSet with a
+// SearchAndReplace in a specific repository at a specific revision.
+type PatchJob struct {
+	ID         int64
+	PatchSetID int64
+
+	RepoID  api.RepoID
+	Rev     api.CommitID
+	BaseRef string
+
+	// Paths are the paths of the files matched by the search query at Rev.
+	// Only these files are changed by the Patch.
+	Paths []string
+
+	// Only set once the PatchJob has successfully finished, and only if the
+	// replacement changed any file.
+	PatchID int64
+
+	Error string
+
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Clone returns a clone of a PatchJob.
+func (c *PatchJob) Clone() *PatchJob {
+	cc := *c
+	cc.Paths = append([]string(nil), c.Paths...)
 	return &cc
 }
 
@@ -83,6 +154,9 @@ type Campaign struct {
 	ChangesetIDs    []int64
 	PatchSetID      int64
 	ClosedAt        time.Time
+	// AutoMerge enables merging the Campaign's changesets once they're
+	// approved and their checks passed.
+	AutoMerge bool
 }
 
 // Clone returns a clone of a Campaign.
@@ -239,6 +313,13 @@ type ChangesetJob struct {
 
 	Error string
 
+	// BaseCommit is the commit of the base branch on which Branch was last
+	// created or rebased. If RebaseError is set, it's the commit on which
+	// the Patch failed to apply and Branch is still based on an older one.
+	BaseCommit api.CommitID
+	// RebaseError is set if the Patch couldn't be applied on BaseCommit.
+	RebaseError string
+
 	StartedAt  time.Time
 	FinishedAt time.Time
 
@@ -305,6 +386,12 @@ func (c *Changeset) SetMetadata(meta interface{}) error {
 		c.ExternalServiceType = bitbucketserver.ServiceType
 		c.ExternalBranch = git.AbbreviateRef(pr.FromRef.ID)
 		c.ExternalUpdatedAt = unixMilliToTime(int64(pr.UpdatedDate))
+	case *gitlab.MergeRequest:
+		c.Metadata = pr
+		c.ExternalID = strconv.Itoa(pr.IID)
+		c.ExternalServiceType = gitlab.ServiceType
+		c.ExternalBranch = pr.SourceBranch
+		c.ExternalUpdatedAt = pr.UpdatedAt
 	default:
 		return errors.New("unknown changeset type")
 	}
@@ -328,6 +415,8 @@ func (c *Changeset) Title() (string, error) {
 		return m.Title, nil
 	case *bitbucketserver.PullRequest:
 		return m.Title, nil
+	case *gitlab.MergeRequest:
+		return m.Title, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -342,6 +431,8 @@ func (c *Changeset) ExternalCreatedAt() time.Time {
 		return m.CreatedAt
 	case *bitbucketserver.PullRequest:
 		return unixMilliToTime(int64(m.CreatedDate))
+	case *gitlab.MergeRequest:
+		return m.CreatedAt
 	default:
 		return time.Time{}
 	}
@@ -354,6 +445,8 @@ func (c *Changeset) Body() (string, error) {
 		return m.Body, nil
 	case *bitbucketserver.PullRequest:
 		return m.Description, nil
+	case *gitlab.MergeRequest:
+		return m.Description, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -387,6 +480,17 @@ func (c *Changeset) state() (s ChangesetState, err error) {
 		} else {
 			s = ChangesetState(m.State)
 		}
+	case *gitlab.MergeRequest:
+		switch m.State {
+		case gitlab.MergeRequestStateClosed, gitlab.MergeRequestStateLocked:
+			s = ChangesetStateClosed
+		case gitlab.MergeRequestStateMerged:
+			s = ChangesetStateMerged
+		case gitlab.MergeRequestStateOpened:
+			s = ChangesetStateOpen
+		default:
+			s = ChangesetState(m.State)
+		}
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -409,6 +513,8 @@ func (c *Changeset) URL() (s string, err error) {
 		}
 		selfLink := m.Links.Self[0]
 		return selfLink.Href, nil
+	case *gitlab.MergeRequest:
+		return m.WebURL, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -461,6 +567,24 @@ func (c *Changeset) Events() (events []*ChangesetEvent) {
 			addEvent(s)
 		}
 
+	case *gitlab.MergeRequest:
+		events = make([]*ChangesetEvent, 0, len(m.Notes)+len(m.Pipelines))
+		addEvent := func(e Keyer) {
+			events = append(events, &ChangesetEvent{
+				ChangesetID: c.ID,
+				Key:         e.Key(),
+				Kind:        ChangesetEventKindFor(e),
+				Metadata:    e,
+			})
+		}
+		for _, n := range m.Notes {
+			if e, ok := n.ToEvent().(Keyer); ok {
+				addEvent(e)
+			}
+		}
+		for _, p := range m.Pipelines {
+			addEvent(p)
+		}
 	}
 	return events
 }
@@ -474,6 +598,8 @@ func (c *Changeset) HeadRefOid() (string, error) {
 		return m.HeadRefOid, nil
 	case *bitbucketserver.PullRequest:
 		return "", nil
+	case *gitlab.MergeRequest:
+		return m.DiffRefs.HeadSHA, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -487,6 +613,8 @@ func (c *Changeset) HeadRef() (string, error) {
 		return "refs/heads/" + m.HeadRefName, nil
 	case *bitbucketserver.PullRequest:
 		return m.FromRef.ID, nil
+	case *gitlab.MergeRequest:
+		return "refs/heads/" + m.SourceBranch, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -501,6 +629,8 @@ func (c *Changeset) BaseRefOid() (string, error) {
 		return m.BaseRefOid, nil
 	case *bitbucketserver.PullRequest:
 		return "", nil
+	case *gitlab.MergeRequest:
+		return m.DiffRefs.BaseSHA, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -514,6 +644,8 @@ func (c *Changeset) BaseRef() (string, error) {
 		return "refs/heads/" + m.BaseRefName, nil
 	case *bitbucketserver.PullRequest:
 		return m.ToRef.ID, nil
+	case *gitlab.MergeRequest:
+		return "refs/heads/" + m.TargetBranch, nil
 	default:
 		return "", errors.New("unknown changeset type")
 	}
@@ -585,6 +717,16 @@ func (e *ChangesetEvent) Actor() string {
 		a = e.Actor.Login
 	case *github.LabelEvent:
 		a = e.Actor.Login
+	case *gitlab.ReviewApprovedEvent:
+		a = e.Author.Username
+	case *gitlab.ReviewUnapprovedEvent:
+		a = e.Author.Username
+	case *gitlab.MergeRequestClosedEvent:
+		a = e.Author.Username
+	case *gitlab.MergeRequestReopenedEvent:
+		a = e.Author.Username
+	case *gitlab.MergeRequestMergedEvent:
+		a = e.Author.Username
 	}
 
 	return a
@@ -613,6 +755,21 @@ func (e *ChangesetEvent) ReviewAuthor() (string, error) {
 			return "", errors.New("activity user is blank")
 		}
 		return username, nil
+
+	case *gitlab.ReviewApprovedEvent:
+		username := meta.Author.Username
+		if username == "" {
+			return "", errors.New("review user is blank")
+		}
+		return username, nil
+
+	case *gitlab.ReviewUnapprovedEvent:
+		username := meta.Author.Username
+		if username == "" {
+			return "", errors.New("review user is blank")
+		}
+		return username, nil
+
 	default:
 		return "", nil
 	}
@@ -621,7 +778,8 @@ func (e *ChangesetEvent) ReviewAuthor() (string, error) {
 // ReviewState returns the review state of the ChangesetEvent if it is a review event.
 func (e *ChangesetEvent) ReviewState() (ChangesetReviewState, error) {
 	switch e.Kind {
-	case ChangesetEventKindBitbucketServerApproved:
+	case ChangesetEventKindBitbucketServerApproved,
+		ChangesetEventKindGitLabApproved:
 		return ChangesetReviewStateApproved, nil
 
 	// BitbucketServer's "REVIEWED" activity is created when someone clicks
@@ -644,7 +802,8 @@ func (e *ChangesetEvent) ReviewState() (ChangesetReviewState, error) {
 		return s, nil
 
 	case ChangesetEventKindGitHubReviewDismissed,
-		ChangesetEventKindBitbucketServerUnapproved:
+		ChangesetEventKindBitbucketServerUnapproved,
+		ChangesetEventKindGitLabUnapproved:
 		return ChangesetReviewStateDismissed, nil
 
 	default:
@@ -704,6 +863,20 @@ func (e *ChangesetEvent) Timestamp() time.Time {
 		t = unixMilliToTime(int64(e.CreatedDate))
 	case *bitbucketserver.CommitStatus:
 		t = unixMilliToTime(int64(e.Status.DateAdded))
+	case *gitlab.ReviewApprovedEvent:
+		t = e.CreatedAt
+	case *gitlab.ReviewUnapprovedEvent:
+		t = e.CreatedAt
+	case *gitlab.MergeRequestClosedEvent:
+		t = e.CreatedAt
+	case *gitlab.MergeRequestReopenedEvent:
+		t = e.CreatedAt
+	case *gitlab.MergeRequestMergedEvent:
+		t = e.CreatedAt
+	case *gitlab.Pipeline:
+		t = e.UpdatedAt
+	case *ChangesetMergeFailedEvent:
+		t = e.CreatedAt
 	}
 
 	return t
@@ -1025,6 +1198,35 @@ func (e *ChangesetEvent) Update(o *ChangesetEvent) {
 		}
 		e.CheckRuns = o.CheckRuns
 
+	case *gitlab.ReviewApprovedEvent:
+		o := o.Metadata.(*gitlab.ReviewApprovedEvent)
+		// We always get the full event, so safe to replace it
+		*e = *o
+
+	case *gitlab.ReviewUnapprovedEvent:
+		o := o.Metadata.(*gitlab.ReviewUnapprovedEvent)
+		*e = *o
+
+	case *gitlab.MergeRequestClosedEvent:
+		o := o.Metadata.(*gitlab.MergeRequestClosedEvent)
+		*e = *o
+
+	case *gitlab.MergeRequestReopenedEvent:
+		o := o.Metadata.(*gitlab.MergeRequestReopenedEvent)
+		*e = *o
+
+	case *gitlab.MergeRequestMergedEvent:
+		o := o.Metadata.(*gitlab.MergeRequestMergedEvent)
+		*e = *o
+
+	case *gitlab.Pipeline:
+		o := o.Metadata.(*gitlab.Pipeline)
+		*e = *o
+
+	case *ChangesetMergeFailedEvent:
+		o := o.Metadata.(*ChangesetMergeFailedEvent)
+		*e = *o
+
 	default:
 		panic(errors.Errorf("unknown changeset event metadata %T", e))
 	}
@@ -1156,6 +1358,20 @@ func ChangesetEventKindFor(e interface{}) ChangesetEventKind {
 		return ChangesetEventKind("bitbucketserver:" + strings.ToLower(string(e.Action)))
 	case *bitbucketserver.CommitStatus:
 		return ChangesetEventKindBitbucketServerCommitStatus
+	case *gitlab.ReviewApprovedEvent:
+		return ChangesetEventKindGitLabApproved
+	case *gitlab.ReviewUnapprovedEvent:
+		return ChangesetEventKindGitLabUnapproved
+	case *gitlab.MergeRequestClosedEvent:
+		return ChangesetEventKindGitLabClosed
+	case *gitlab.MergeRequestReopenedEvent:
+		return ChangesetEventKindGitLabReopened
+	case *gitlab.MergeRequestMergedEvent:
+		return ChangesetEventKindGitLabMerged
+	case *gitlab.Pipeline:
+		return ChangesetEventKindGitLabPipeline
+	case *ChangesetMergeFailedEvent:
+		return ChangesetEventKindMergeFailed
 	default:
 		panic(errors.Errorf("unknown changeset event kind for %T", e))
 	}
@@ -1211,6 +1427,23 @@ func NewChangesetEventMetadata(k ChangesetEventKind) (interface{}, error) {
 		case ChangesetEventKindCheckRun:
 			return new(github.CheckRun), nil
 		}
+	case strings.HasPrefix(string(k), "gitlab"):
+		switch k {
+		case ChangesetEventKindGitLabApproved:
+			return new(gitlab.ReviewApprovedEvent), nil
+		case ChangesetEventKindGitLabUnapproved:
+			return new(gitlab.ReviewUnapprovedEvent), nil
+		case ChangesetEventKindGitLabClosed:
+			return new(gitlab.MergeRequestClosedEvent), nil
+		case ChangesetEventKindGitLabReopened:
+			return new(gitlab.MergeRequestReopenedEvent), nil
+		case ChangesetEventKindGitLabMerged:
+			return new(gitlab.MergeRequestMergedEvent), nil
+		case ChangesetEventKindGitLabPipeline:
+			return new(gitlab.Pipeline), nil
+		}
+	case k == ChangesetEventKindMergeFailed:
+		return new(ChangesetMergeFailedEvent), nil
 	}
 	return nil, errors.Errorf("unknown changeset event kind %q", k)
 }
@@ -1252,8 +1485,25 @@ const (
 	ChangesetEventKindBitbucketServerCommented    ChangesetEventKind = "bitbucketserver:commented"
 	ChangesetEventKindBitbucketServerMerged       ChangesetEventKind = "bitbucketserver:merged"
 	ChangesetEventKindBitbucketServerCommitStatus ChangesetEventKind = "bitbucketserver:commit_status"
+
+	ChangesetEventKindGitLabApproved   ChangesetEventKind = "gitlab:approved"
+	ChangesetEventKindGitLabUnapproved ChangesetEventKind = "gitlab:unapproved"
+	ChangesetEventKindGitLabClosed     ChangesetEventKind = "gitlab:closed"
+	ChangesetEventKindGitLabReopened   ChangesetEventKind = "gitlab:reopened"
+	ChangesetEventKindGitLabMerged     ChangesetEventKind = "gitlab:merged"
+	ChangesetEventKindGitLabPipeline   ChangesetEventKind = "gitlab:pipeline"
+
+	ChangesetEventKindMergeFailed ChangesetEventKind = "campaigns:merge_failed"
 )
 
+// ChangesetMergeFailedEvent is the metadata of the ChangesetEvent recorded
+// when Sourcegraph fails to merge a changeset of a Campaign with AutoMerge
+// enabled, e.g. because it has conflicts.
+type ChangesetMergeFailedEvent struct {
+	Error     string
+	CreatedAt time.Time
+}
+
 // ChangesetSyncData represents data about the sync status of a changeset
 type ChangesetSyncData struct {
 	ChangesetID int64
@@ -7,6 +7,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 )
 
 func TestChangesetMetadata(t *testing.T) {
@@ -242,6 +243,67 @@ func TestChangesetEvents(t *testing.T) {
 		})
 	}
 
+	{ // GitLab
+
+		user := gitlab.User{Username: "john-doe"}
+		reviewer := gitlab.User{Username: "jane-doe"}
+
+		notes := []*gitlab.Note{
+			{ID: 1, Author: reviewer, Body: "looks good", System: false},
+			{ID: 2, Author: reviewer, Body: "approved this merge request", System: true},
+			{ID: 3, Author: reviewer, Body: "unapproved this merge request", System: true},
+			{ID: 4, Author: user, Body: "closed", System: true},
+			{ID: 5, Author: user, Body: "reopened", System: true},
+			{ID: 6, Author: user, Body: "merged", System: true},
+			{ID: 7, Author: user, Body: "added 1 commit", System: true},
+		}
+
+		pipelines := []*gitlab.Pipeline{
+			{ID: 11, Status: gitlab.PipelineStatusSuccess},
+		}
+
+		cases = append(cases, testCase{"gitlab",
+			Changeset{
+				ID: 25,
+				Metadata: &gitlab.MergeRequest{
+					Notes:     notes,
+					Pipelines: pipelines,
+				},
+			},
+			[]*ChangesetEvent{{
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabApproved,
+				Key:         notes[1].Key(),
+				Metadata:    &gitlab.ReviewApprovedEvent{Note: notes[1]},
+			}, {
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabUnapproved,
+				Key:         notes[2].Key(),
+				Metadata:    &gitlab.ReviewUnapprovedEvent{Note: notes[2]},
+			}, {
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabClosed,
+				Key:         notes[3].Key(),
+				Metadata:    &gitlab.MergeRequestClosedEvent{Note: notes[3]},
+			}, {
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabReopened,
+				Key:         notes[4].Key(),
+				Metadata:    &gitlab.MergeRequestReopenedEvent{Note: notes[4]},
+			}, {
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabMerged,
+				Key:         notes[5].Key(),
+				Metadata:    &gitlab.MergeRequestMergedEvent{Note: notes[5]},
+			}, {
+				ChangesetID: 25,
+				Kind:        ChangesetEventKindGitLabPipeline,
+				Key:         pipelines[0].Key(),
+				Metadata:    pipelines[0],
+			}},
+		})
+	}
+
 	for _, tc := range cases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
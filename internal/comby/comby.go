@@ -2,7 +2,6 @@ package comby
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -157,37 +156,50 @@ func PipeTo(ctx context.Context, args Args, w io.Writer) (err error) {
 
 // Matches returns all matches in all files for which comby finds matches.
 func Matches(ctx context.Context, args Args) (matches []FileMatch, err error) {
-	b := new(bytes.Buffer)
-	w := bufio.NewWriter(b)
-
-	args.MatchOnly = true
-
-	err = PipeTo(ctx, args, w)
+	err = StreamMatches(ctx, args, func(m FileMatch) {
+		matches = append(matches, m)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	scanner := bufio.NewScanner(b)
+	if len(matches) > 0 {
+		log15.Info("comby invocation", "num_matches", strconv.Itoa(len(matches)))
+	}
+	return matches, nil
+}
+
+// StreamMatches is like Matches, but calls send for each file match as soon
+// as comby outputs it rather than buffering all of comby's output.
+func StreamMatches(ctx context.Context, args Args, send func(FileMatch)) error {
+	args.MatchOnly = true
+
+	pr, pw := io.Pipe()
+	errC := make(chan error, 1)
+	go func() {
+		errC <- PipeTo(ctx, args, pw)
+		_ = pw.Close()
+	}()
+
+	scanner := bufio.NewScanner(pr)
 	// increase the scanner buffer size for potentially long lines
 	scanner.Buffer(make([]byte, 100), 10*bufio.MaxScanTokenSize)
 	for scanner.Scan() {
-		b := scanner.Bytes()
-		if err := scanner.Err(); err != nil {
-			// warn on scanner errors and skip
-			log15.Warn("comby error: skipping scanner error line: %v", err)
-			continue
-		}
 		var m *FileMatch
-		if err := json.Unmarshal(b, &m); err != nil {
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
 			// warn on decode errors and skip
-			log15.Warn("comby error: skipping unmarshaling error: %v", err)
+			log15.Warn("comby error: skipping unmarshaling error", "error", err)
 			continue
 		}
-		matches = append(matches, *m)
+		send(*m)
 	}
-
-	if len(matches) > 0 {
-		log15.Info("comby invocation", "num_matches", strconv.Itoa(len(matches)))
+	if err := scanner.Err(); err != nil {
+		log15.Warn("comby error: stopped scanning output", "error", err)
 	}
-	return matches, nil
+
+	// Drain the pipe so that comby's output copier does not block if we
+	// stopped scanning early.
+	_, _ = io.Copy(ioutil.Discard, pr)
+
+	return <-errC
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf/confdefaults"
@@ -112,6 +113,36 @@ func AccessTokensAllow() AccessTokAllow {
 	}
 }
 
+// AccessTokensDefaultLifetime returns the lifetime of new access tokens whose creator doesn't
+// choose an expiration date, or 0 if they don't expire.
+func AccessTokensDefaultLifetime() time.Duration {
+	cfg := Get().AuthAccessTokens
+	if cfg == nil {
+		return 0
+	}
+	return time.Duration(cfg.DefaultLifetimeDays) * 24 * time.Hour
+}
+
+// AccessTokensMaxLifetime returns the maximum lifetime of new access tokens, or 0 if there is no
+// maximum.
+func AccessTokensMaxLifetime() time.Duration {
+	cfg := Get().AuthAccessTokens
+	if cfg == nil {
+		return 0
+	}
+	return time.Duration(cfg.MaxLifetimeDays) * 24 * time.Hour
+}
+
+// AccessTokensExpiryReminder returns how long before an access token expires its owner is
+// reminded to replace it.
+func AccessTokensExpiryReminder() time.Duration {
+	days := 7
+	if cfg := Get().AuthAccessTokens; cfg != nil && cfg.ExpiryReminderDays > 0 {
+		days = cfg.ExpiryReminderDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
 // EmailVerificationRequired returns whether users must verify an email address before they
 // can perform most actions on this site.
 //
@@ -0,0 +1,220 @@
+// Package gerrit implements a Gerrit REST API client.
+package gerrit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("gerrit_requests_count", "Total number of requests sent to the Gerrit API.")
+
+// xssiPrefix is prepended by Gerrit to all JSON responses to prevent cross-site script
+// inclusion. It must be stripped before the response can be decoded.
+var xssiPrefix = []byte(")]}'")
+
+// Client access a Gerrit instance via its REST API.
+type Client struct {
+	// HTTP Client used to communicate with the API
+	httpClient httpcli.Doer
+
+	// URL is the base URL of Gerrit.
+	URL *url.URL
+
+	// The username and HTTP password credentials for accessing the server. If both are empty,
+	// requests are made anonymously.
+	Username, Password string
+}
+
+// NewClient creates a new Gerrit API client with the given base URL. If a nil httpClient is
+// provided, http.DefaultClient will be used.
+func NewClient(baseURL *url.URL, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// The first component of the path after the optional "/a" authentication prefix
+		// maps to the type of API request we are making.
+		parts := strings.Split(strings.TrimPrefix(u.Path, "/a/"), "/")
+		return strings.Trim(parts[0], "/")
+	})
+
+	return &Client{
+		httpClient: httpClient,
+		URL:        baseURL,
+	}
+}
+
+// Project is a Gerrit project, as returned by the projects REST API.
+type Project struct {
+	// ID is the URL encoded name of the project.
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Parent      string    `json:"parent,omitempty"`
+	Description string    `json:"description,omitempty"`
+	State       string    `json:"state,omitempty"`
+	WebLinks    []WebLink `json:"web_links,omitempty"`
+}
+
+// The possible states of a project.
+const (
+	ProjectStateActive   = "ACTIVE"
+	ProjectStateReadOnly = "READ_ONLY"
+	ProjectStateHidden   = "HIDDEN"
+)
+
+// GitilesURL returns the URL of the project in the Gitiles repository browser, or an empty
+// string if Gerrit doesn't link to one.
+func (p *Project) GitilesURL() string {
+	for _, l := range p.WebLinks {
+		if l.IsGitiles() {
+			return l.URL
+		}
+	}
+	return ""
+}
+
+// WebLink is a link to an external site, such as a repository browser, for a project.
+type WebLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// IsGitiles returns true if the link points to the Gitiles repository browser.
+func (l WebLink) IsGitiles() bool {
+	// The Gitiles plugin names its links "browse" since Gerrit 3.0 and "gitiles" before that.
+	return l.Name == "gitiles" || (l.Name == "browse" && strings.Contains(l.URL, "gitiles"))
+}
+
+// ListProjectsArgs are the pagination arguments of ListProjects.
+type ListProjectsArgs struct {
+	// Start is the number of projects to skip.
+	Start int
+	// Limit is the maximum number of projects to return.
+	Limit int
+}
+
+// ListProjects returns a page of the code projects visible to the client, sorted by name. The
+// returned boolean is true if there are more projects after this page.
+func (c *Client) ListProjects(ctx context.Context, args ListProjectsArgs) (projects []*Project, more bool, err error) {
+	qry := url.Values{
+		"d":    {""}, // include descriptions
+		"type": {"CODE"},
+	}
+	if args.Start > 0 {
+		qry.Set("S", strconv.Itoa(args.Start))
+	}
+	if args.Limit > 0 {
+		qry.Set("n", strconv.Itoa(args.Limit))
+	}
+
+	var page map[string]*struct {
+		Project
+		More bool `json:"_more_projects"`
+	}
+	if err := c.get(ctx, "projects/", qry, &page); err != nil {
+		return nil, false, err
+	}
+
+	projects = make([]*Project, 0, len(page))
+	for name, p := range page {
+		// The list API omits the name since it's the key of the result.
+		p.Name = name
+		more = more || p.More
+		projects = append(projects, &p.Project)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+
+	return projects, more, nil
+}
+
+// GetProject returns the project with the given name.
+func (c *Client) GetProject(ctx context.Context, name string) (*Project, error) {
+	var p Project
+	if err := c.get(ctx, "projects/"+url.PathEscape(name), nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, qry url.Values, result interface{}) error {
+	// Authenticated requests must be made to the "/a/" prefixed endpoints.
+	if c.Username != "" || c.Password != "" {
+		path = "a/" + path
+	}
+
+	// path is already escaped, since project names must be sent with their slashes encoded.
+	u, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	u.RawQuery = qry.Encode()
+
+	req, err := http.NewRequest("GET", c.URL.ResolveReference(u).String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Gerrit"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+
+	return json.Unmarshal(bytes.TrimPrefix(bs, xssiPrefix), result)
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("Gerrit API HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
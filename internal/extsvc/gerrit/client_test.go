@@ -0,0 +1,105 @@
+package gerrit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+)
+
+func TestClient_ListProjects(t *testing.T) {
+	var (
+		haveAuth  bool
+		haveQuery url.Values
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, haveAuth = r.BasicAuth()
+		haveQuery = r.URL.Query()
+
+		switch r.URL.Path {
+		case "/projects/", "/a/projects/":
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		fmt.Fprint(w, `)]}'
+{
+  "platform/build": {"id": "platform%2Fbuild", "description": "Build system", "state": "ACTIVE"},
+  "device/common": {"id": "device%2Fcommon", "state": "READ_ONLY", "_more_projects": true}
+}`)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	want := []*Project{
+		{ID: "device%2Fcommon", Name: "device/common", State: ProjectStateReadOnly},
+		{ID: "platform%2Fbuild", Name: "platform/build", Description: "Build system", State: ProjectStateActive},
+	}
+
+	t.Run("anonymous", func(t *testing.T) {
+		cli := NewClient(u, nil)
+
+		projects, more, err := cli.ListProjects(context.Background(), ListProjectsArgs{Start: 2, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !more {
+			t.Error("expected more projects")
+		}
+		if diff := cmp.Diff(want, projects); diff != "" {
+			t.Errorf("projects mismatch (-want +have):\n%s", diff)
+		}
+		if haveAuth {
+			t.Error("unexpected basic auth")
+		}
+		if s, n := haveQuery.Get("S"), haveQuery.Get("n"); s != "2" || n != "2" {
+			t.Errorf("unexpected pagination: S=%q n=%q", s, n)
+		}
+	})
+
+	t.Run("authenticated", func(t *testing.T) {
+		cli := NewClient(u, nil)
+		cli.Username, cli.Password = "alice", "secret"
+
+		if _, _, err := cli.ListProjects(context.Background(), ListProjectsArgs{}); err != nil {
+			t.Fatal(err)
+		}
+		if !haveAuth {
+			t.Error("expected basic auth")
+		}
+	})
+}
+
+func TestClient_GetProject(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/projects/platform%2Fbuild" {
+			http.Error(w, "Not found: "+r.URL.EscapedPath(), http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `)]}'
+{"id": "platform%2Fbuild", "name": "platform/build", "web_links": [{"name": "browse", "url": "https://gerrit.example.com/plugins/gitiles/platform/build"}]}`)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	cli := NewClient(u, nil)
+
+	p, err := cli.GetProject(context.Background(), "platform/build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := p.GitilesURL(), "https://gerrit.example.com/plugins/gitiles/platform/build"; have != want {
+		t.Errorf("GitilesURL: have %q, want %q", have, want)
+	}
+
+	_, err = cli.GetProject(context.Background(), "missing")
+	if e, ok := errors.Cause(err).(interface{ NotFound() bool }); !ok || !e.NotFound() {
+		t.Errorf("expected not found error, have %v", err)
+	}
+}
@@ -0,0 +1,5 @@
+package gerrit
+
+// ServiceType is the (api.ExternalRepoSpec).ServiceType value for Gerrit projects. The ServiceID
+// value is the normalized base URL of the Gerrit instance.
+const ServiceType = "gerrit"
@@ -588,6 +588,46 @@ func (c *Client) ClosePullRequest(ctx context.Context, pr *PullRequest) error {
 	return nil
 }
 
+// MergePullRequest merges the given PullRequest with a merge commit and
+// updates it with the merged pull request. If pr.HeadRefOid is set, GitHub
+// refuses to merge the pull request if its head commit changed.
+func (c *Client) MergePullRequest(ctx context.Context, pr *PullRequest) error {
+	var q strings.Builder
+	q.WriteString(pullRequestFragments)
+	q.WriteString(`mutation	MergePullRequest($input:MergePullRequestInput!) {
+  mergePullRequest(input:$input) {
+    pullRequest {
+      ... pr
+    }
+  }
+}`)
+
+	var result struct {
+		MergePullRequest struct {
+			PullRequest struct {
+				PullRequest
+				Participants  struct{ Nodes []Actor }
+				TimelineItems struct{ Nodes []TimelineItem }
+			} `json:"pullRequest"`
+		} `json:"mergePullRequest"`
+	}
+
+	input := map[string]interface{}{"input": struct {
+		ID              string `json:"pullRequestId"`
+		ExpectedHeadOid string `json:"expectedHeadOid,omitempty"`
+	}{ID: pr.ID, ExpectedHeadOid: pr.HeadRefOid}}
+	err := c.requestGraphQL(ctx, "", q.String(), input, &result)
+	if err != nil {
+		return err
+	}
+
+	*pr = result.MergePullRequest.PullRequest.PullRequest
+	pr.TimelineItems = result.MergePullRequest.PullRequest.TimelineItems.Nodes
+	pr.Participants = result.MergePullRequest.PullRequest.Participants.Nodes
+
+	return nil
+}
+
 // LoadPullRequests loads a list of PullRequests from Github.
 func (c *Client) LoadPullRequests(ctx context.Context, prs ...*PullRequest) error {
 	const batchSize = 15
@@ -0,0 +1,387 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/peterhellberg/link"
+	"github.com/pkg/errors"
+)
+
+// MergeRequestState is the state of a GitLab merge request.
+type MergeRequestState string
+
+const (
+	MergeRequestStateOpened MergeRequestState = "opened"
+	MergeRequestStateClosed MergeRequestState = "closed"
+	MergeRequestStateLocked MergeRequestState = "locked"
+	MergeRequestStateMerged MergeRequestState = "merged"
+)
+
+// MergeRequest is a GitLab merge request (equivalent to a GitHub pull request).
+type MergeRequest struct {
+	ID           int               `json:"id"`
+	IID          int               `json:"iid"`
+	ProjectID    int               `json:"project_id"`
+	Title        string            `json:"title"`
+	Description  string            `json:"description"`
+	State        MergeRequestState `json:"state"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	MergedAt     *time.Time        `json:"merged_at"`
+	ClosedAt     *time.Time        `json:"closed_at"`
+	HeadPipeline *Pipeline         `json:"head_pipeline"`
+	Labels       []string          `json:"labels"`
+	SourceBranch string            `json:"source_branch"`
+	TargetBranch string            `json:"target_branch"`
+	WebURL       string            `json:"web_url"`
+	SHA          string            `json:"sha"`
+	DiffRefs     DiffRefs          `json:"diff_refs"`
+	Author       User              `json:"author"`
+
+	// Notes and Pipelines are not returned by the merge request endpoints
+	// and have to be loaded separately with LoadMergeRequestNotes and
+	// LoadMergeRequestPipelines.
+	Notes     []*Note     `json:"notes"`
+	Pipelines []*Pipeline `json:"pipelines"`
+}
+
+// DiffRefs contains the commit SHAs a merge request diff is computed from.
+type DiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+// Note is a comment on a merge request. System notes are created by GitLab
+// itself to record actions such as approvals or state changes.
+type Note struct {
+	ID        int       `json:"id"`
+	Body      string    `json:"body"`
+	Author    User      `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	System    bool      `json:"system"`
+}
+
+// Key is a unique key identifying this note in the context of its merge request.
+func (n *Note) Key() string { return strconv.Itoa(n.ID) }
+
+// The bodies of the system notes GitLab creates for the merge request actions
+// we care about.
+const (
+	systemNoteApproved   = "approved this merge request"
+	systemNoteUnapproved = "unapproved this merge request"
+	systemNoteClosed     = "closed"
+	systemNoteReopened   = "reopened"
+	systemNoteMerged     = "merged"
+)
+
+// ToEvent returns the event described by the note, or nil if the note doesn't
+// describe an event we track.
+func (n *Note) ToEvent() interface{} {
+	if !n.System {
+		return nil
+	}
+
+	switch n.Body {
+	case systemNoteApproved:
+		return &ReviewApprovedEvent{Note: n}
+	case systemNoteUnapproved:
+		return &ReviewUnapprovedEvent{Note: n}
+	case systemNoteClosed:
+		return &MergeRequestClosedEvent{Note: n}
+	case systemNoteReopened:
+		return &MergeRequestReopenedEvent{Note: n}
+	case systemNoteMerged:
+		return &MergeRequestMergedEvent{Note: n}
+	}
+
+	return nil
+}
+
+// ReviewApprovedEvent is recorded when a user approves a merge request.
+type ReviewApprovedEvent struct{ *Note }
+
+// ReviewUnapprovedEvent is recorded when a user revokes their approval of a
+// merge request.
+type ReviewUnapprovedEvent struct{ *Note }
+
+// MergeRequestClosedEvent is recorded when a merge request is closed.
+type MergeRequestClosedEvent struct{ *Note }
+
+// MergeRequestReopenedEvent is recorded when a closed merge request is reopened.
+type MergeRequestReopenedEvent struct{ *Note }
+
+// MergeRequestMergedEvent is recorded when a merge request is merged.
+type MergeRequestMergedEvent struct{ *Note }
+
+// PipelineStatus is the status of a GitLab CI pipeline.
+type PipelineStatus string
+
+const (
+	PipelineStatusCreated            PipelineStatus = "created"
+	PipelineStatusWaitingForResource PipelineStatus = "waiting_for_resource"
+	PipelineStatusPreparing          PipelineStatus = "preparing"
+	PipelineStatusPending            PipelineStatus = "pending"
+	PipelineStatusRunning            PipelineStatus = "running"
+	PipelineStatusSuccess            PipelineStatus = "success"
+	PipelineStatusFailed             PipelineStatus = "failed"
+	PipelineStatusCanceled           PipelineStatus = "canceled"
+	PipelineStatusSkipped            PipelineStatus = "skipped"
+	PipelineStatusManual             PipelineStatus = "manual"
+	PipelineStatusScheduled          PipelineStatus = "scheduled"
+)
+
+// Pipeline is a GitLab CI pipeline.
+type Pipeline struct {
+	ID        int            `json:"id"`
+	SHA       string         `json:"sha"`
+	Ref       string         `json:"ref"`
+	Status    PipelineStatus `json:"status"`
+	WebURL    string         `json:"web_url"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// Key is a unique key identifying this pipeline in the context of its
+// project.
+func (p *Pipeline) Key() string { return strconv.Itoa(p.ID) }
+
+// ErrMergeRequestAlreadyExists is returned by Client.CreateMergeRequest when
+// an open merge request for the given source branch already exists.
+var ErrMergeRequestAlreadyExists = errors.New("merge request already exists")
+
+// ErrMergeRequestNotFound is returned by Client.GetOpenMergeRequestByRefs when
+// no matching merge request exists.
+var ErrMergeRequestNotFound = errors.New("merge request not found")
+
+// CreateMergeRequestOpts are the options to create a merge request.
+type CreateMergeRequestOpts struct {
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	Title        string `json:"title"`
+	Description  string `json:"description,omitempty"`
+}
+
+// CreateMergeRequest creates a merge request in the given project.
+func (c *Client) CreateMergeRequest(ctx context.Context, project *Project, opts CreateMergeRequestOpts) (*MergeRequest, error) {
+	if MockCreateMergeRequest != nil {
+		return MockCreateMergeRequest(c, ctx, project, opts)
+	}
+
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling options")
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("projects/%d/merge_requests", project.ID), bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+
+	resp := &MergeRequest{}
+	if _, err := c.do(ctx, req, resp); err != nil {
+		if HTTPErrorCode(err) == http.StatusConflict {
+			return nil, ErrMergeRequestAlreadyExists
+		}
+		return nil, errors.Wrap(err, "sending request to create a merge request")
+	}
+
+	return resp, nil
+}
+
+// GetMergeRequest retrieves the merge request with the given IID from the
+// given project.
+func (c *Client) GetMergeRequest(ctx context.Context, project *Project, iid int) (*MergeRequest, error) {
+	if MockGetMergeRequest != nil {
+		return MockGetMergeRequest(c, ctx, project, iid)
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("projects/%d/merge_requests/%d", project.ID, iid), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+
+	resp := &MergeRequest{}
+	if _, err := c.do(ctx, req, resp); err != nil {
+		return nil, errors.Wrap(err, "sending request to get a merge request")
+	}
+
+	return resp, nil
+}
+
+// GetOpenMergeRequestByRefs retrieves the open merge request in the given
+// project that merges source into target.
+func (c *Client) GetOpenMergeRequestByRefs(ctx context.Context, project *Project, source, target string) (*MergeRequest, error) {
+	if MockGetOpenMergeRequestByRefs != nil {
+		return MockGetOpenMergeRequestByRefs(c, ctx, project, source, target)
+	}
+
+	values := url.Values{
+		"state":         {string(MergeRequestStateOpened)},
+		"source_branch": {source},
+		"target_branch": {target},
+	}
+	u := url.URL{Path: fmt.Sprintf("projects/%d/merge_requests", project.ID), RawQuery: values.Encode()}
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+
+	var mrs []*MergeRequest
+	if _, err := c.do(ctx, req, &mrs); err != nil {
+		return nil, errors.Wrap(err, "sending request to list merge requests")
+	}
+
+	if len(mrs) == 0 {
+		return nil, ErrMergeRequestNotFound
+	}
+	if len(mrs) > 1 {
+		return nil, errors.Errorf("%d open merge requests found for %q -> %q", len(mrs), source, target)
+	}
+
+	// The list endpoint doesn't return everything the single merge request
+	// endpoint does (e.g. the head pipeline), so we fetch it again.
+	return c.GetMergeRequest(ctx, project, mrs[0].IID)
+}
+
+// UpdateMergeRequestStateEvent is used to transition a merge request between
+// states.
+type UpdateMergeRequestStateEvent string
+
+const (
+	UpdateMergeRequestStateEventClose  UpdateMergeRequestStateEvent = "close"
+	UpdateMergeRequestStateEventReopen UpdateMergeRequestStateEvent = "reopen"
+)
+
+// UpdateMergeRequestOpts are the options to update a merge request. Empty
+// fields are left unchanged.
+type UpdateMergeRequestOpts struct {
+	TargetBranch string                       `json:"target_branch,omitempty"`
+	Title        string                       `json:"title,omitempty"`
+	Description  string                       `json:"description,omitempty"`
+	StateEvent   UpdateMergeRequestStateEvent `json:"state_event,omitempty"`
+}
+
+// UpdateMergeRequest updates the given merge request in the given project.
+func (c *Client) UpdateMergeRequest(ctx context.Context, project *Project, mr *MergeRequest, opts UpdateMergeRequestOpts) (*MergeRequest, error) {
+	if MockUpdateMergeRequest != nil {
+		return MockUpdateMergeRequest(c, ctx, project, mr, opts)
+	}
+
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling options")
+	}
+
+	req, err := http.NewRequest("PUT", fmt.Sprintf("projects/%d/merge_requests/%d", project.ID, mr.IID), bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+
+	resp := &MergeRequest{}
+	if _, err := c.do(ctx, req, resp); err != nil {
+		return nil, errors.Wrap(err, "sending request to update a merge request")
+	}
+
+	return resp, nil
+}
+
+// AcceptMergeRequest merges the given merge request in the given project.
+// GitLab refuses to merge it if its head commit isn't mr.SHA anymore.
+func (c *Client) AcceptMergeRequest(ctx context.Context, project *Project, mr *MergeRequest) (*MergeRequest, error) {
+	if MockAcceptMergeRequest != nil {
+		return MockAcceptMergeRequest(c, ctx, project, mr)
+	}
+
+	data, err := json.Marshal(struct {
+		SHA string `json:"sha,omitempty"`
+	}{SHA: mr.SHA})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling options")
+	}
+
+	req, err := http.NewRequest("PUT", fmt.Sprintf("projects/%d/merge_requests/%d/merge", project.ID, mr.IID), bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+
+	resp := &MergeRequest{}
+	if _, err := c.do(ctx, req, resp); err != nil {
+		return nil, errors.Wrap(err, "sending request to accept a merge request")
+	}
+
+	return resp, nil
+}
+
+// LoadMergeRequestNotes loads all notes of the given merge request into its
+// Notes field.
+func (c *Client) LoadMergeRequestNotes(ctx context.Context, project *Project, mr *MergeRequest) error {
+	if MockLoadMergeRequestNotes != nil {
+		return MockLoadMergeRequestNotes(c, ctx, project, mr)
+	}
+
+	var notes []*Note
+	next := fmt.Sprintf("projects/%d/merge_requests/%d/notes?sort=asc&per_page=100", project.ID, mr.IID)
+	for next != "" {
+		req, err := http.NewRequest("GET", next, nil)
+		if err != nil {
+			return errors.Wrap(err, "creating request")
+		}
+
+		var page []*Note
+		header, err := c.do(ctx, req, &page)
+		if err != nil {
+			return errors.Wrap(err, "sending request to list merge request notes")
+		}
+		notes = append(notes, page...)
+
+		// See https://docs.gitlab.com/ee/api/README.html#pagination-link-header.
+		next = ""
+		if l := link.Parse(header.Get("Link"))["next"]; l != nil {
+			next = l.URI
+		}
+	}
+
+	mr.Notes = notes
+	return nil
+}
+
+// LoadMergeRequestPipelines loads all pipelines of the given merge request
+// into its Pipelines field.
+func (c *Client) LoadMergeRequestPipelines(ctx context.Context, project *Project, mr *MergeRequest) error {
+	if MockLoadMergeRequestPipelines != nil {
+		return MockLoadMergeRequestPipelines(c, ctx, project, mr)
+	}
+
+	var pipelines []*Pipeline
+	next := fmt.Sprintf("projects/%d/merge_requests/%d/pipelines?per_page=100", project.ID, mr.IID)
+	for next != "" {
+		req, err := http.NewRequest("GET", next, nil)
+		if err != nil {
+			return errors.Wrap(err, "creating request")
+		}
+
+		var page []*Pipeline
+		header, err := c.do(ctx, req, &page)
+		if err != nil {
+			return errors.Wrap(err, "sending request to list merge request pipelines")
+		}
+		pipelines = append(pipelines, page...)
+
+		next = ""
+		if l := link.Parse(header.Get("Link"))["next"]; l != nil {
+			next = l.URI
+		}
+	}
+
+	mr.Pipelines = pipelines
+	return nil
+}
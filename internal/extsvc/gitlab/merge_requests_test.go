@@ -0,0 +1,55 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestClient_CreateMergeRequest(t *testing.T) {
+	ctx := context.Background()
+	project := &Project{ProjectCommon: ProjectCommon{ID: 1}}
+	opts := CreateMergeRequestOpts{SourceBranch: "head", TargetBranch: "base", Title: "title"}
+
+	t.Run("created", func(t *testing.T) {
+		c := newTestClient(t)
+		c.httpClient = &mockHTTPResponseBody{responseBody: `{"id": 10, "iid": 2, "state": "opened", "source_branch": "head"}`}
+
+		mr, err := c.CreateMergeRequest(ctx, project, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mr.IID != 2 || mr.State != MergeRequestStateOpened || mr.SourceBranch != "head" {
+			t.Errorf("unexpected merge request: %+v", mr)
+		}
+	})
+
+	t.Run("already exists", func(t *testing.T) {
+		c := newTestClient(t)
+		c.httpClient = mockHTTPEmptyResponse{http.StatusConflict}
+
+		if _, err := c.CreateMergeRequest(ctx, project, opts); err != ErrMergeRequestAlreadyExists {
+			t.Errorf("unexpected error: have %v, want %v", err, ErrMergeRequestAlreadyExists)
+		}
+	})
+}
+
+func TestNote_ToEvent(t *testing.T) {
+	for _, tc := range []struct {
+		note *Note
+		want interface{}
+	}{
+		{note: &Note{Body: "approved this merge request"}, want: nil},
+		{note: &Note{Body: "approved this merge request", System: true}, want: &ReviewApprovedEvent{}},
+		{note: &Note{Body: "unapproved this merge request", System: true}, want: &ReviewUnapprovedEvent{}},
+		{note: &Note{Body: "closed", System: true}, want: &MergeRequestClosedEvent{}},
+		{note: &Note{Body: "reopened", System: true}, want: &MergeRequestReopenedEvent{}},
+		{note: &Note{Body: "merged", System: true}, want: &MergeRequestMergedEvent{}},
+		{note: &Note{Body: "added 1 commit", System: true}, want: nil},
+	} {
+		if have, want := reflect.TypeOf(tc.note.ToEvent()), reflect.TypeOf(tc.want); have != want {
+			t.Errorf("%q: have %v, want %v", tc.note.Body, have, want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	eventTypeHeader = "X-Gitlab-Event"
+	tokenHeader     = "X-Gitlab-Token"
+)
+
+// Webhook event types, as sent in the X-Gitlab-Event header.
+const (
+	WebhookEventTypeMergeRequest = "Merge Request Hook"
+	WebhookEventTypeNote         = "Note Hook"
+	WebhookEventTypePipeline     = "Pipeline Hook"
+)
+
+// WebhookEventType returns the type of the webhook event sent in r.
+func WebhookEventType(r *http.Request) string {
+	return r.Header.Get(eventTypeHeader)
+}
+
+// WebhookToken returns the secret token sent with the webhook event in r.
+func WebhookToken(r *http.Request) string {
+	return r.Header.Get(tokenHeader)
+}
+
+// ErrUnknownWebhookEvent is returned by ParseWebhookEvent for event types we
+// don't handle.
+var ErrUnknownWebhookEvent = errors.New("unknown webhook event type")
+
+// ParseWebhookEvent parses the payload of a webhook event of the given type.
+func ParseWebhookEvent(eventType string, payload []byte) (e interface{}, err error) {
+	switch eventType {
+	case WebhookEventTypeMergeRequest:
+		e = &MergeRequestEvent{}
+	case WebhookEventTypeNote:
+		e = &NoteEvent{}
+	case WebhookEventTypePipeline:
+		e = &PipelineEvent{}
+	default:
+		return nil, ErrUnknownWebhookEvent
+	}
+	return e, json.Unmarshal(payload, e)
+}
+
+// MergeRequestEvent is sent when a merge request is created, updated,
+// approved, closed, reopened or merged.
+type MergeRequestEvent struct {
+	User             User          `json:"user"`
+	Project          ProjectCommon `json:"project"`
+	ObjectAttributes struct {
+		ID        int               `json:"id"`
+		IID       int               `json:"iid"`
+		State     MergeRequestState `json:"state"`
+		Action    string            `json:"action"`
+		UpdatedAt WebhookTime       `json:"updated_at"`
+	} `json:"object_attributes"`
+}
+
+// The merge request event actions we care about. "approval" and "unapproval"
+// are sent for every single (un)approval, whereas "approved" and "unapproved"
+// are only sent once all required approvals have been given or one of them
+// was revoked.
+const (
+	MergeRequestActionApproved   = "approved"
+	MergeRequestActionApproval   = "approval"
+	MergeRequestActionUnapproved = "unapproved"
+	MergeRequestActionUnapproval = "unapproval"
+	MergeRequestActionClose      = "close"
+	MergeRequestActionReopen     = "reopen"
+	MergeRequestActionMerge      = "merge"
+)
+
+// IsTrackedAction reports whether the action of the event is one we track.
+// GitLab records a system note for each of them, but the event doesn't
+// include its ID, so the note has to be loaded from the API to not be
+// recorded twice.
+func (e *MergeRequestEvent) IsTrackedAction() bool {
+	switch e.ObjectAttributes.Action {
+	case MergeRequestActionApproved, MergeRequestActionApproval,
+		MergeRequestActionUnapproved, MergeRequestActionUnapproval,
+		MergeRequestActionClose, MergeRequestActionReopen, MergeRequestActionMerge:
+		return true
+	}
+	return false
+}
+
+// NoteEvent is sent when a comment is made on a commit, merge request, issue
+// or snippet.
+type NoteEvent struct {
+	User             User          `json:"user"`
+	Project          ProjectCommon `json:"project"`
+	ObjectAttributes struct {
+		ID           int         `json:"id"`
+		Note         string      `json:"note"`
+		NoteableType string      `json:"noteable_type"`
+		System       bool        `json:"system"`
+		CreatedAt    WebhookTime `json:"created_at"`
+	} `json:"object_attributes"`
+	MergeRequest *struct {
+		IID int `json:"iid"`
+	} `json:"merge_request"`
+}
+
+// Note returns the note the event was sent for.
+func (e *NoteEvent) Note() *Note {
+	return &Note{
+		ID:        e.ObjectAttributes.ID,
+		Body:      e.ObjectAttributes.Note,
+		Author:    e.User,
+		CreatedAt: e.ObjectAttributes.CreatedAt.Time,
+		System:    e.ObjectAttributes.System,
+	}
+}
+
+// PipelineEvent is sent when the status of a pipeline changes.
+type PipelineEvent struct {
+	Project          ProjectCommon `json:"project"`
+	ObjectAttributes struct {
+		ID         int            `json:"id"`
+		Ref        string         `json:"ref"`
+		SHA        string         `json:"sha"`
+		Status     PipelineStatus `json:"status"`
+		CreatedAt  WebhookTime    `json:"created_at"`
+		FinishedAt *WebhookTime   `json:"finished_at"`
+	} `json:"object_attributes"`
+	// MergeRequest is only set for merge request pipelines.
+	MergeRequest *struct {
+		IID int `json:"iid"`
+	} `json:"merge_request"`
+}
+
+// Pipeline returns the pipeline the event was sent for.
+func (e *PipelineEvent) Pipeline() *Pipeline {
+	p := &Pipeline{
+		ID:        e.ObjectAttributes.ID,
+		SHA:       e.ObjectAttributes.SHA,
+		Ref:       e.ObjectAttributes.Ref,
+		Status:    e.ObjectAttributes.Status,
+		CreatedAt: e.ObjectAttributes.CreatedAt.Time,
+		UpdatedAt: e.ObjectAttributes.CreatedAt.Time,
+	}
+	if e.ObjectAttributes.FinishedAt != nil {
+		p.UpdatedAt = e.ObjectAttributes.FinishedAt.Time
+	}
+	if e.Project.WebURL != "" {
+		p.WebURL = e.Project.WebURL + "/pipelines/" + p.Key()
+	}
+	return p
+}
+
+// WebhookTime is a timestamp in a webhook payload. Depending on the event and
+// GitLab version, timestamps are either formatted as RFC 3339 or as
+// "2006-01-02 15:04:05 MST".
+type WebhookTime struct {
+	time.Time
+}
+
+const webhookTimeLayout = "2006-01-02 15:04:05 MST"
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *WebhookTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		return nil
+	}
+
+	for _, layout := range []string{time.RFC3339, webhookTimeLayout} {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return errors.Errorf("invalid webhook timestamp %q", s)
+}
@@ -0,0 +1,129 @@
+package gitlab
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseWebhookEvent(t *testing.T) {
+	t.Run("merge request", func(t *testing.T) {
+		payload := `{
+			"object_kind": "merge_request",
+			"user": {"username": "alice"},
+			"project": {"id": 7, "path_with_namespace": "org/repo"},
+			"object_attributes": {"id": 99, "iid": 3, "state": "merged", "action": "merge", "updated_at": "2020-03-04 10:11:12 UTC"}
+		}`
+		e, err := ParseWebhookEvent(WebhookEventTypeMergeRequest, []byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mr := e.(*MergeRequestEvent)
+		if mr.Project.ID != 7 || mr.ObjectAttributes.IID != 3 || mr.ObjectAttributes.State != MergeRequestStateMerged {
+			t.Errorf("unexpected event: %+v", mr)
+		}
+		if !mr.IsTrackedAction() {
+			t.Error("expected merge to be a tracked action")
+		}
+		want := time.Date(2020, 3, 4, 10, 11, 12, 0, time.UTC)
+		if mr.User.Username != "alice" || !mr.ObjectAttributes.UpdatedAt.Equal(want) {
+			t.Errorf("unexpected event: %+v", mr)
+		}
+	})
+
+	t.Run("note", func(t *testing.T) {
+		payload := `{
+			"object_kind": "note",
+			"user": {"username": "bob"},
+			"project": {"id": 7},
+			"object_attributes": {"id": 12, "note": "approved this merge request", "noteable_type": "MergeRequest", "system": true, "created_at": "2020-03-04T10:11:12Z"},
+			"merge_request": {"iid": 3}
+		}`
+		e, err := ParseWebhookEvent(WebhookEventTypeNote, []byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := e.(*NoteEvent)
+		if n.MergeRequest == nil || n.MergeRequest.IID != 3 {
+			t.Errorf("unexpected merge request: %+v", n.MergeRequest)
+		}
+		if _, ok := n.Note().ToEvent().(*ReviewApprovedEvent); !ok {
+			t.Errorf("unexpected event type %T", n.Note().ToEvent())
+		}
+		if have, want := n.Note().Key(), "12"; have != want {
+			t.Errorf("unexpected key: have %q, want %q", have, want)
+		}
+	})
+
+	t.Run("pipeline", func(t *testing.T) {
+		payload := `{
+			"object_kind": "pipeline",
+			"project": {"id": 7, "web_url": "https://gitlab.com/org/repo"},
+			"object_attributes": {"id": 31, "ref": "feature", "sha": "abc", "status": "success", "created_at": "2020-03-04 10:11:12 UTC", "finished_at": null}
+		}`
+		e, err := ParseWebhookEvent(WebhookEventTypePipeline, []byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := e.(*PipelineEvent).Pipeline()
+		want := &Pipeline{
+			ID:        31,
+			SHA:       "abc",
+			Ref:       "feature",
+			Status:    PipelineStatusSuccess,
+			WebURL:    "https://gitlab.com/org/repo/pipelines/31",
+			CreatedAt: time.Date(2020, 3, 4, 10, 11, 12, 0, time.UTC),
+			UpdatedAt: time.Date(2020, 3, 4, 10, 11, 12, 0, time.UTC),
+		}
+		if !reflect.DeepEqual(p, want) {
+			t.Errorf("unexpected pipeline:\nhave %+v\nwant %+v", p, want)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		if _, err := ParseWebhookEvent("Push Hook", []byte(`{}`)); err != ErrUnknownWebhookEvent {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestMergeRequestEvent_IsTrackedAction(t *testing.T) {
+	for action, want := range map[string]bool{
+		MergeRequestActionApproved:   true,
+		MergeRequestActionApproval:   true,
+		MergeRequestActionUnapproved: true,
+		MergeRequestActionUnapproval: true,
+		MergeRequestActionClose:      true,
+		MergeRequestActionReopen:     true,
+		MergeRequestActionMerge:      true,
+		"update":                     false,
+		"open":                       false,
+	} {
+		e := &MergeRequestEvent{}
+		e.ObjectAttributes.Action = action
+		if have := e.IsTrackedAction(); have != want {
+			t.Errorf("action %q: have %v, want %v", action, have, want)
+		}
+	}
+}
+
+func TestWebhookTime_UnmarshalJSON(t *testing.T) {
+	want := time.Date(2020, 3, 4, 10, 11, 12, 0, time.UTC)
+	for _, in := range []string{`"2020-03-04T10:11:12Z"`, `"2020-03-04 10:11:12 UTC"`} {
+		var have WebhookTime
+		if err := have.UnmarshalJSON([]byte(in)); err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		if !have.Equal(want) {
+			t.Errorf("%s: have %v, want %v", in, have, want)
+		}
+	}
+
+	var zero WebhookTime
+	if err := zero.UnmarshalJSON([]byte(`null`)); err != nil || !zero.IsZero() {
+		t.Errorf("null: have %v, %v", zero, err)
+	}
+	if err := zero.UnmarshalJSON([]byte(`"yesterday"`)); err == nil {
+		t.Error("expected error for invalid timestamp")
+	}
+}
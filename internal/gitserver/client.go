@@ -22,6 +22,7 @@ import (
 	"github.com/inconshreveable/log15"
 	"github.com/neelance/parallel"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	otlog "github.com/opentracing/opentracing-go/log"
 	"github.com/pkg/errors"
@@ -60,6 +61,9 @@ func NewClient(cli httpcli.Doer) *Client {
 		Addrs: func(ctx context.Context) []string {
 			return conf.Get().ServiceConnections.GitServers
 		},
+		ReplicationFactor: func() int {
+			return conf.Get().GitReplicationFactor
+		},
 		HTTPClient:  cli,
 		HTTPLimiter: parallel.NewRun(500),
 		// Use the binary name for UserAgent. This should effectively identify
@@ -82,9 +86,17 @@ type Client struct {
 	// concurrent use. It may return different results at different times.
 	Addrs func(ctx context.Context) []string
 
+	// ReplicationFactor is a function which should return the number of
+	// gitservers that hold a copy of each repository. If nil or if it returns
+	// a value less than 1, repositories are not replicated.
+	ReplicationFactor func() int
+
 	// UserAgent is a string identifing who the client is. It will be logged in
 	// the telemetry in gitserver.
 	UserAgent string
+
+	unhealthyMu sync.Mutex
+	unhealthy   map[string]time.Time // addr -> time at which it was last unreachable
 }
 
 // AddrForRepo returns the gitserver address to use for the given repo name.
@@ -104,9 +116,83 @@ func (c *Client) addrForKey(ctx context.Context, key string) string {
 }
 
 func addrForKey(addrs []string, key string) string {
+	return addrs[addrIndexForKey(addrs, key)]
+}
+
+func addrIndexForKey(addrs []string, key string) int {
 	sum := md5.Sum([]byte(key))
-	serverIndex := binary.BigEndian.Uint64(sum[:]) % uint64(len(addrs))
-	return addrs[serverIndex]
+	return int(binary.BigEndian.Uint64(sum[:]) % uint64(len(addrs)))
+}
+
+// AddrsForRepo returns the addresses of the gitservers that hold a copy of
+// the given repo. The first address is the repo's primary gitserver (the one
+// returned by AddrForRepo), and the rest are its replicas.
+func (c *Client) AddrsForRepo(ctx context.Context, repo api.RepoName) []string {
+	repo = protocol.NormalizeRepo(repo) // in case the caller didn't already normalize it
+	addrs := c.Addrs(ctx)
+	if len(addrs) == 0 {
+		panic("unexpected state: no gitserver addresses")
+	}
+	n := 1
+	if c.ReplicationFactor != nil {
+		n = c.ReplicationFactor()
+	}
+	return addrsForKey(addrs, string(repo), n)
+}
+
+// addrsForKey returns the n addresses to use for the given key. The replicas
+// of a key are stored on the addresses following its primary address, so
+// that adding or removing a replica doesn't move any other copies.
+func addrsForKey(addrs []string, key string, n int) []string {
+	if n < 1 {
+		n = 1
+	}
+	if n > len(addrs) {
+		n = len(addrs)
+	}
+	i := addrIndexForKey(addrs, key)
+	keyAddrs := make([]string, 0, n)
+	for j := 0; j < n; j++ {
+		keyAddrs = append(keyAddrs, addrs[(i+j)%len(addrs)])
+	}
+	return keyAddrs
+}
+
+// unhealthyTimeout is how long a gitserver that could not be reached is
+// tried only after the other gitservers holding a copy of a repo.
+const unhealthyTimeout = 30 * time.Second
+
+// healthyAddrsFirst returns addrs with the gitservers that were recently
+// unreachable moved to the end. Otherwise the order is preserved, so the
+// primary gitserver is used whenever it is healthy.
+func (c *Client) healthyAddrsFirst(addrs []string) []string {
+	c.unhealthyMu.Lock()
+	defer c.unhealthyMu.Unlock()
+
+	healthy := make([]string, 0, len(addrs))
+	var unhealthy []string
+	for _, addr := range addrs {
+		if t, ok := c.unhealthy[addr]; ok && time.Since(t) < unhealthyTimeout {
+			unhealthy = append(unhealthy, addr)
+		} else {
+			healthy = append(healthy, addr)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (c *Client) setHealthy(addr string, healthy bool) {
+	c.unhealthyMu.Lock()
+	defer c.unhealthyMu.Unlock()
+
+	if healthy {
+		delete(c.unhealthy, addr)
+		return
+	}
+	if c.unhealthy == nil {
+		c.unhealthy = make(map[string]time.Time)
+	}
+	c.unhealthy[addr] = time.Now()
 }
 
 // ArchiveOptions contains options for the Archive func.
@@ -182,7 +268,7 @@ func (c *Client) Archive(ctx context.Context, repo Repo, opt ArchiveOptions) (_
 	}
 
 	u := c.ArchiveURL(ctx, repo, opt)
-	resp, err := c.do(ctx, repo.Name, "GET", u.String(), nil)
+	resp, err := c.do(ctx, repo.Name, "GET", "archive?"+u.RawQuery, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -280,8 +366,16 @@ var deadlineExceededCounter = prometheus.NewCounter(prometheus.CounterOpts{
 	Help:      "Times that Client.sendExec() returned context.DeadlineExceeded",
 })
 
+var replicaFallbackCounter = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "gitserver",
+	Name:      "client_replica_fallback",
+	Help:      "Times that a request was retried on a replica because a gitserver could not be reached or responded with a server error",
+})
+
 func init() {
 	prometheus.MustRegister(deadlineExceededCounter)
+	prometheus.MustRegister(replicaFallbackCounter)
 }
 
 // Cmd represents a command to be executed remotely.
@@ -562,10 +656,21 @@ func (c *Client) doListOne(ctx context.Context, urlSuffix, addr string) ([]strin
 // recently (within the Since duration specified in the request), the
 // update won't happen.
 func (c *Client) RequestRepoUpdate(ctx context.Context, repo Repo, since time.Duration) (*protocol.RepoUpdateResponse, error) {
+	return c.RequestRepoUpdateWithOptions(ctx, repo, since, 0, nil)
+}
+
+// RequestRepoUpdateWithOptions is like RequestRepoUpdate, but if the
+// repository is not cloned yet, the clone is aborted if it exceeds
+// maxCloneSize bytes (if non-zero). If cloneOptions is non-nil, the
+// repository is cloned with them, or they replace the options of an existing
+// clone (see protocol.RepoUpdateRequest).
+func (c *Client) RequestRepoUpdateWithOptions(ctx context.Context, repo Repo, since time.Duration, maxCloneSize int64, cloneOptions *protocol.CloneOptions) (*protocol.RepoUpdateResponse, error) {
 	req := &protocol.RepoUpdateRequest{
-		Repo:  repo.Name,
-		URL:   repo.URL,
-		Since: since,
+		Repo:         repo.Name,
+		URL:          repo.URL,
+		Since:        since,
+		MaxCloneSize: maxCloneSize,
+		CloneOptions: cloneOptions,
 	}
 	resp, err := c.httpPost(ctx, repo.Name, "repo-update", req)
 	if err != nil {
@@ -582,6 +687,75 @@ func (c *Client) RequestRepoUpdate(ctx context.Context, repo Repo, since time.Du
 	return info, err
 }
 
+// RequestReplicaUpdate requests that the gitserver at addr clone or update
+// its replica of the repository. It is used by gitservers to maintain the
+// replicas of their repositories. A replica that is not cloned yet is cloned
+// with cloneOptions, and aborted if it exceeds maxCloneSize bytes (if
+// non-zero).
+func (c *Client) RequestReplicaUpdate(ctx context.Context, addr string, repo Repo, maxCloneSize int64, cloneOptions protocol.CloneOptions) error {
+	req := &protocol.RepoUpdateRequest{
+		Repo:         repo.Name,
+		URL:          repo.URL,
+		MaxCloneSize: maxCloneSize,
+		CloneOptions: &cloneOptions,
+		Replica:      true,
+	}
+	resp, err := c.httpPost(ctx, repo.Name, "http://"+addr+"/repo-update", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return &url.Error{URL: resp.Request.URL.String(), Op: "RepoUpdate", Err: fmt.Errorf("RepoUpdate: http status %d: %s", resp.StatusCode, body)}
+	}
+
+	var info protocol.RepoUpdateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return err
+	}
+	if info.Error != "" {
+		return errors.New(info.Error)
+	}
+	return nil
+}
+
+// RequestRepoTransfer requests that the gitserver at addr, which a repository
+// is now assigned to, copies the repository from the gitserver at
+// req.SourceAddr. It is used by gitservers to move repositories when the list
+// of gitservers changes.
+func (c *Client) RequestRepoTransfer(ctx context.Context, addr string, req *protocol.RepoTransferRequest) (*protocol.RepoTransferResponse, error) {
+	resp, err := c.httpPost(ctx, req.Repo, "http://"+addr+"/repo-transfer", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return nil, &url.Error{URL: resp.Request.URL.String(), Op: "RepoTransfer", Err: fmt.Errorf("RepoTransfer: http status %d: %s", resp.StatusCode, body)}
+	}
+
+	var res protocol.RepoTransferResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	return &res, err
+}
+
+// Bundle returns a Git bundle of all refs of the repository on the gitserver
+// at addr. It is used by gitservers to copy repositories from each other.
+func (c *Client) Bundle(ctx context.Context, addr string, repo api.RepoName) (io.ReadCloser, error) {
+	q := url.Values{"repo": {string(repo)}}
+	resp, err := c.do(ctx, repo, "GET", "http://"+addr+"/bundle?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return nil, &url.Error{URL: resp.Request.URL.String(), Op: "Bundle", Err: fmt.Errorf("Bundle: http status %d: %s", resp.StatusCode, body)}
+	}
+	return resp.Body, nil
+}
+
 // MockIsRepoCloneable mocks (*Client).IsRepoCloneable for tests.
 var MockIsRepoCloneable func(Repo) error
 
@@ -667,6 +841,26 @@ func (c *Client) IsRepoCloned(ctx context.Context, repo api.RepoName) (bool, err
 // If multiple errors occurred, an incomplete result is returned along with a
 // *multierror.Error.
 func (c *Client) RepoInfo(ctx context.Context, repos ...api.RepoName) (*protocol.RepoInfoResponse, error) {
+	return c.repoInfo(ctx, false, repos...)
+}
+
+// RepoSizes returns the size in bytes of the clones of the given
+// repositories. Repositories that are not cloned are omitted.
+func (c *Client) RepoSizes(ctx context.Context, repos ...api.RepoName) (map[api.RepoName]int64, error) {
+	res, err := c.repoInfo(ctx, true, repos...)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[api.RepoName]int64, len(res.Results))
+	for repo, info := range res.Results {
+		if info.Cloned {
+			sizes[repo] = info.Size
+		}
+	}
+	return sizes, nil
+}
+
+func (c *Client) repoInfo(ctx context.Context, size bool, repos ...api.RepoName) (*protocol.RepoInfoResponse, error) {
 	numPossibleShards := len(c.Addrs(ctx))
 	shards := make(map[string]*protocol.RepoInfoRequest, (len(repos)/numPossibleShards)*2) // 2x because it may not be a perfect division
 
@@ -675,7 +869,7 @@ func (c *Client) RepoInfo(ctx context.Context, repos ...api.RepoName) (*protocol
 		shard := shards[addr]
 
 		if shard == nil {
-			shard = new(protocol.RepoInfoRequest)
+			shard = &protocol.RepoInfoRequest{Size: size}
 			shards[addr] = shard
 		}
 
@@ -736,12 +930,50 @@ func (c *Client) RepoInfo(ctx context.Context, repos ...api.RepoName) (*protocol
 	return &res, err.ErrorOrNil()
 }
 
-// Remove removes the repository clone from gitserver.
+// ReplicaRepoInfo is like RepoInfo, except that it retrieves information about
+// the repositories from the gitserver at addr instead of from their primary
+// gitservers.
+func (c *Client) ReplicaRepoInfo(ctx context.Context, addr string, repos ...api.RepoName) (*protocol.RepoInfoResponse, error) {
+	if len(repos) == 0 {
+		return &protocol.RepoInfoResponse{Results: map[api.RepoName]*protocol.RepoInfo{}}, nil
+	}
+	resp, err := c.httpPost(ctx, repos[0], "http://"+addr+"/repos", &protocol.RepoInfoRequest{Repos: repos})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &url.Error{
+			URL: resp.Request.URL.String(),
+			Op:  "RepoInfo",
+			Err: errors.Errorf("RepoInfo: http status %d", resp.StatusCode),
+		}
+	}
+
+	var res protocol.RepoInfoResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	return &res, err
+}
+
+// Remove removes the repository clone from gitserver, and its replicas from
+// the gitservers holding them. Removal from all of them is attempted even if
+// it fails on some, and the errors are returned as a *multierror.Error.
 func (c *Client) Remove(ctx context.Context, repo api.RepoName) error {
+	err := new(multierror.Error)
+	for _, addr := range c.AddrsForRepo(ctx, repo) {
+		if e := c.removeFrom(ctx, addr, repo); e != nil {
+			err = multierror.Append(err, e)
+		}
+	}
+	return err.ErrorOrNil()
+}
+
+// removeFrom removes the copy of the repository on the gitserver at addr.
+func (c *Client) removeFrom(ctx context.Context, addr string, repo api.RepoName) error {
 	req := &protocol.RepoDeleteRequest{
 		Repo: repo,
 	}
-	resp, err := c.httpPost(ctx, repo, "delete", req)
+	resp, err := c.httpPost(ctx, repo, "http://"+addr+"/delete", req)
 	if err != nil {
 		return err
 	}
@@ -758,8 +990,22 @@ func (c *Client) httpPost(ctx context.Context, repo api.RepoName, op string, pay
 	return c.do(ctx, repo, "POST", op, payload)
 }
 
+// replicaFallbackOps are the gitserver endpoints whose requests may be sent
+// to a replica of the repo when its primary gitserver fails. They only read
+// the repo, so that the replicas never diverge from the primary.
+var replicaFallbackOps = map[string]bool{
+	"archive":           true,
+	"exec":              true,
+	"is-repo-cloneable": true,
+	"is-repo-cloned":    true,
+	"repos":             true,
+}
+
 // do performs a request to a gitserver, sharding based on the given
-// repo name (the repo name is otherwise not used).
+// repo name (the repo name is otherwise not used). If op is one of
+// replicaFallbackOps and the repo's primary gitserver can't be reached or
+// responds with a server error, the request is retried on the repo's
+// replicas.
 func (c *Client) do(ctx context.Context, repo api.RepoName, method, op string, payload interface{}) (resp *http.Response, err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "Client.do")
 	defer func() {
@@ -776,11 +1022,42 @@ func (c *Client) do(ctx context.Context, repo api.RepoName, method, op string, p
 		return nil, err
 	}
 
-	uri := op
-	if !strings.HasPrefix(op, "http") {
-		uri = "http://" + c.AddrForRepo(ctx, repo) + "/" + op
+	if strings.HasPrefix(op, "http") {
+		return c.doOnce(ctx, span, method, op, reqBody)
 	}
 
+	addrs := c.AddrsForRepo(ctx, repo)
+	if endpoint := strings.SplitN(op, "?", 2)[0]; replicaFallbackOps[endpoint] {
+		addrs = c.healthyAddrsFirst(addrs)
+	} else {
+		addrs = addrs[:1]
+	}
+	for i, addr := range addrs {
+		last := i+1 == len(addrs)
+		resp, err = c.doOnce(ctx, span, method, "http://"+addr+"/"+op, reqBody)
+		if err == nil && resp.StatusCode < 500 {
+			c.setHealthy(addr, true)
+			return resp, nil
+		}
+		if err == nil {
+			if last {
+				return resp, nil
+			}
+			resp.Body.Close()
+			err = fmt.Errorf("http status %d", resp.StatusCode)
+		} else if ctx.Err() != nil {
+			return nil, err
+		}
+		c.setHealthy(addr, false)
+		if !last {
+			log15.Warn("gitserver unavailable, trying replica", "repo", repo, "addr", addr, "replica", addrs[i+1], "error", err)
+			replicaFallbackCounter.Inc()
+		}
+	}
+	return nil, err
+}
+
+func (c *Client) doOnce(ctx context.Context, span opentracing.Span, method, uri string, reqBody []byte) (*http.Response, error) {
 	req, err := http.NewRequest(method, uri, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, err
@@ -57,6 +57,130 @@ func TestClient_ListCloned(t *testing.T) {
 	}
 }
 
+func TestClient_AddrsForRepo(t *testing.T) {
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2"}
+	replicationFactor := 1
+	cli := &gitserver.Client{
+		Addrs:             func(ctx context.Context) []string { return addrs },
+		ReplicationFactor: func() int { return replicationFactor },
+	}
+
+	ctx := context.Background()
+	for _, repo := range []api.RepoName{"a", "b", "c", "d", "github.com/foo/bar"} {
+		primary := cli.AddrForRepo(ctx, repo)
+		if got := cli.AddrsForRepo(ctx, repo); !cmp.Equal(got, []string{primary}) {
+			t.Errorf("%s: got addrs %v without replication, want %v", repo, got, []string{primary})
+		}
+
+		replicationFactor = 2
+		got := cli.AddrsForRepo(ctx, repo)
+		replicationFactor = 1
+		if len(got) != 2 || got[0] != primary || got[1] == primary {
+			t.Errorf("%s: got addrs %v with replication, want primary %s and one replica", repo, got, primary)
+		}
+	}
+
+	// The replication factor is capped at the number of gitservers.
+	replicationFactor = 10
+	got := cli.AddrsForRepo(ctx, "a")
+	sort.Strings(got)
+	if !cmp.Equal(got, addrs) {
+		t.Errorf("got addrs %v, want %v", got, addrs)
+	}
+}
+
+func TestClient_replicaFallback(t *testing.T) {
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2"}
+	var requested []string
+	newClient := func(primaryResponse func() (*http.Response, error)) (*gitserver.Client, []string) {
+		requested = nil
+		cli := &gitserver.Client{
+			Addrs:             func(ctx context.Context) []string { return addrs },
+			ReplicationFactor: func() int { return 2 },
+		}
+		repoAddrs := cli.AddrsForRepo(context.Background(), "foo")
+		cli.HTTPClient = httpcli.DoerFunc(func(r *http.Request) (*http.Response, error) {
+			requested = append(requested, r.URL.Host)
+			if r.URL.Host == repoAddrs[0] {
+				return primaryResponse()
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+		})
+		return cli, repoAddrs
+	}
+	unreachable := func() (*http.Response, error) { return nil, errors.New("connection refused") }
+
+	cli, repoAddrs := newClient(unreachable)
+	cloned, err := cli.IsRepoCloned(context.Background(), "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cloned {
+		t.Error("want cloned to be true")
+	}
+	if want := repoAddrs; !cmp.Equal(requested, want) {
+		t.Errorf("got requests to %v, want %v", requested, want)
+	}
+
+	// The unavailable primary is tried last until it has had time to recover.
+	requested = nil
+	if _, err := cli.IsRepoCloned(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if want := repoAddrs[1:]; !cmp.Equal(requested, want) {
+		t.Errorf("got requests to %v, want %v", requested, want)
+	}
+
+	// Server errors of the primary are retried on a replica as well.
+	cli, repoAddrs = newClient(func() (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+	})
+	cloned, err = cli.IsRepoCloned(context.Background(), "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cloned {
+		t.Error("want cloned to be true")
+	}
+	if want := repoAddrs; !cmp.Equal(requested, want) {
+		t.Errorf("got requests to %v, want %v", requested, want)
+	}
+
+	// Requests that change the repo are only sent to the primary.
+	cli, repoAddrs = newClient(unreachable)
+	if _, err := cli.RequestRepoUpdate(context.Background(), gitserver.Repo{Name: "foo"}, 0); err == nil {
+		t.Error("want error updating the repo on an unavailable primary")
+	}
+	if want := repoAddrs[:1]; !cmp.Equal(requested, want) {
+		t.Errorf("got requests to %v, want %v", requested, want)
+	}
+}
+
+func TestClient_Remove(t *testing.T) {
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2"}
+	var requested []string
+	cli := &gitserver.Client{
+		Addrs:             func(ctx context.Context) []string { return addrs },
+		ReplicationFactor: func() int { return 2 },
+	}
+	repoAddrs := cli.AddrsForRepo(context.Background(), "foo")
+	cli.HTTPClient = httpcli.DoerFunc(func(r *http.Request) (*http.Response, error) {
+		requested = append(requested, r.URL.Host)
+		if r.URL.Host == repoAddrs[0] {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+	})
+
+	// The replicas are removed as well, even if the primary is unavailable.
+	if err := cli.Remove(context.Background(), "foo"); err == nil {
+		t.Error("want error from the unavailable primary")
+	}
+	if want := repoAddrs; !cmp.Equal(requested, want) {
+		t.Errorf("got requests to %v, want %v", requested, want)
+	}
+}
+
 func TestClient_Archive(t *testing.T) {
 	root, err := ioutil.TempDir("", t.Name())
 	if err != nil {
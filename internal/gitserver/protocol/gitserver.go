@@ -48,6 +48,114 @@ type RepoUpdateRequest struct {
 	Repo  api.RepoName  `json:"repo"`  // identifying URL for repo
 	URL   string        `json:"url"`   // repo's remote URL
 	Since time.Duration `json:"since"` // debounce interval for queries, used only with request-repo-update
+
+	// MaxCloneSize, if non-zero, is the maximum size in bytes of a clone of
+	// the repository. A clone exceeding it is aborted and removed. If
+	// CloneOptions is non-nil, gitserver also records it as the limit of all
+	// later clones of the repository, including on-demand clones. Like
+	// CloneOptions, it is only known to the senders that know how the
+	// repository is configured.
+	MaxCloneSize int64 `json:"maxCloneSize,omitempty"`
+
+	// CloneOptions are the options for the clone of the repository, as
+	// configured by its external services. If the repository is already
+	// cloned, they replace the options it was cloned with, and a changed
+	// depth applies to its next fetch.
+	//
+	// If nil, the sender doesn't know the configured options: the repository
+	// is cloned in full, and the options of an existing clone are kept.
+	CloneOptions *CloneOptions `json:"cloneOptions,omitempty"`
+
+	// Replica is true if the request is sent by the repository's primary
+	// gitserver to update a replica. Replicas are not replicated further.
+	Replica bool `json:"replica,omitempty"`
+}
+
+// CloneOptions configure a partial or shallow clone of a repository, which
+// is much smaller than a full clone of a large repository. The zero value is
+// a full clone.
+type CloneOptions struct {
+	// Partial is a blob-less partial clone. File contents are fetched from
+	// the code host when they are first needed.
+	Partial bool `json:"partial,omitempty"`
+
+	// Depth, if non-zero, is the number of commits of history to clone and
+	// fetch from the tip of each branch and tag.
+	Depth int `json:"depth,omitempty"`
+}
+
+// RepoTransferRequest is a request to the gitserver that a repository is
+// assigned to, to copy the repository from the gitserver it was previously
+// assigned to. It is sent by the previous gitserver, which removes its copy
+// once the transfer succeeded.
+type RepoTransferRequest struct {
+	Repo       api.RepoName `json:"repo"`
+	URL        string       `json:"url"`        // repo's remote URL
+	SourceAddr string       `json:"sourceAddr"` // address of the gitserver that holds the repository
+
+	// MaxCloneSize is the maximum clone size recorded for the repository, if
+	// any. See RepoUpdateRequest.MaxCloneSize.
+	MaxCloneSize int64 `json:"maxCloneSize,omitempty"`
+
+	// CloneOptions are the options the repository was cloned with. Partial
+	// and shallow clones are cloned from URL instead of the other gitserver.
+	CloneOptions CloneOptions `json:"cloneOptions"`
+
+	// Empty is true if the repository has no refs. Git can't bundle empty
+	// repositories, so they are cloned from URL instead of the other
+	// gitserver.
+	Empty bool `json:"empty,omitempty"`
+}
+
+// RepoTransferResponse is the response to a RepoTransferRequest.
+type RepoTransferResponse struct {
+	Cloned bool   // whether the repository is cloned on the gitserver it is assigned to
+	Error  string // an error reported by the transfer, as opposed to a protocol error
+}
+
+// BackupRequest is a request to export repositories as Git bundles.
+type BackupRequest struct {
+	// Dir is the directory to write the bundles to, relative to the
+	// gitserver's backup directory ($SRC_REPOS_BACKUP_DIR).
+	Dir string `json:"dir"`
+
+	// Repos are the repositories to back up. If empty, all repositories on
+	// the gitserver (except replicas) are backed up.
+	Repos []api.RepoName `json:"repos,omitempty"`
+}
+
+// RestoreRequest is a request to clone repositories from the Git bundles
+// written for a BackupRequest.
+type RestoreRequest struct {
+	// Dir is the directory to read the bundles from, relative to the
+	// gitserver's backup directory ($SRC_REPOS_BACKUP_DIR).
+	Dir string `json:"dir"`
+
+	// Repos are the repositories to restore. If empty, all repositories in
+	// Dir that are assigned to the gitserver are restored.
+	Repos []api.RepoName `json:"repos,omitempty"`
+}
+
+// BackupResponse is the result of a BackupRequest or a RestoreRequest.
+type BackupResponse struct {
+	Repos   []api.RepoName          `json:"repos"`             // repositories that were backed up or restored
+	Skipped map[api.RepoName]string `json:"skipped,omitempty"` // repositories that were skipped, with the reason
+	Errors  map[api.RepoName]string `json:"errors,omitempty"`  // repositories that failed, with the error
+}
+
+// BackupStatus is the status of the last backup or restore of a gitserver.
+// Backups and restores run in the background, one at a time, and their
+// progress is reported in BackupResponse while they run.
+type BackupStatus struct {
+	Operation  string    `json:"operation"` // "backup" or "restore"
+	Dir        string    `json:"dir"`
+	Running    bool      `json:"running"`
+	Total      int       `json:"total"` // number of repositories to back up or restore, once known
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+	Error      string    `json:"error,omitempty"` // an error that aborted the operation
+
+	BackupResponse
 }
 
 // RepoUpdateResponse returns meta information of the repo enqueued for
@@ -100,6 +208,10 @@ type IsRepoClonedRequest struct {
 type RepoInfoRequest struct {
 	// Repos are the repositories to get information about.
 	Repos []api.RepoName
+
+	// Size, if true, computes the size on disk of cloned repositories. This
+	// walks the repository directories, so only request it if needed.
+	Size bool
 }
 
 // RepoDeleteRequest is a request to delete a repository clone on gitserver
@@ -122,6 +234,15 @@ type RepoInfo struct {
 	// recloned automatically, so this time is likely to move forward
 	// periodically.
 	CloneTime *time.Time
+
+	// TransferProgress is a progress message of a running transfer of the
+	// repository to or from another gitserver, if any. Repositories are
+	// transferred when the list of gitservers changes.
+	TransferProgress string `json:",omitempty"`
+
+	// Size is the size in bytes of the clone on disk. It is only set if
+	// requested (see RepoInfoRequest.Size) and the repository is cloned.
+	Size int64
 }
 
 // RepoInfoResponse is the response to a repository information request
@@ -0,0 +1,80 @@
+// Package lfs parses Git LFS pointer files.
+//
+// Repositories that use Git LFS store pointer files in Git instead of the
+// contents of large files. The contents are stored on an LFS server and are
+// addressed by the SHA-256 hash in the pointer. See
+// https://github.com/git-lfs/git-lfs/blob/master/docs/spec.md.
+package lfs
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// MaxPointerSize is the maximum size in bytes of a pointer file. Larger files
+// are never pointers.
+const MaxPointerSize = 1024
+
+const specVersion = "https://git-lfs.github.com/spec/v1"
+
+// A Pointer is a parsed Git LFS pointer file.
+type Pointer struct {
+	// OID is the hex-encoded SHA-256 hash of the file contents.
+	OID string
+
+	// Size is the size of the file contents in bytes.
+	Size int64
+}
+
+// ParsePointer parses the contents of a file as a Git LFS pointer. It returns
+// false if the file is not a pointer.
+func ParsePointer(b []byte) (p *Pointer, ok bool) {
+	if len(b) > MaxPointerSize || !bytes.HasPrefix(b, []byte("version ")) || !bytes.HasSuffix(b, []byte("\n")) {
+		return nil, false
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if lines[0] != "version "+specVersion {
+		return nil, false
+	}
+
+	var hasOID, hasSize bool
+	p = &Pointer{}
+	for _, line := range lines[1:] {
+		i := strings.IndexByte(line, ' ')
+		if i < 0 {
+			return nil, false
+		}
+		switch key, value := line[:i], line[i+1:]; key {
+		case "oid":
+			oid := strings.TrimPrefix(value, "sha256:")
+			if oid == value || !isSHA256(oid) {
+				return nil, false
+			}
+			p.OID, hasOID = oid, true
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return nil, false
+			}
+			p.Size, hasSize = size, true
+		}
+	}
+	if !hasOID || !hasSize {
+		return nil, false
+	}
+	return p, true
+}
+
+func isSHA256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package lfs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePointer(t *testing.T) {
+	const oid = "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+	tests := map[string]*Pointer{
+		"version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 12345\n": {OID: oid, Size: 12345},
+
+		// Extensions are allowed.
+		"version https://git-lfs.github.com/spec/v1\next-0-foo sha256:" + oid + "\noid sha256:" + oid + "\nsize 0\n": {OID: oid, Size: 0},
+
+		// Not pointers.
+		"":            nil,
+		"hello world": nil,
+		"version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 12345":                                            nil, // no trailing newline
+		"version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\n":                                                      nil, // no size
+		"version https://git-lfs.github.com/spec/v1\nsize 12345\n":                                                                  nil, // no oid
+		"version https://git-lfs.github.com/spec/v1\noid md5:" + oid + "\nsize 12345\n":                                             nil,
+		"version https://git-lfs.github.com/spec/v1\noid sha256:" + oid[1:] + "\nsize 12345\n":                                      nil,
+		"version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize -1\n":                                             nil,
+		"version https://example.com/v2\noid sha256:" + oid + "\nsize 12345\n":                                                      nil,
+		"version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 1\n" + strings.Repeat("x", MaxPointerSize) + "\n": nil,
+	}
+	for input, want := range tests {
+		got, ok := ParsePointer([]byte(input))
+		if ok != (want != nil) || !reflect.DeepEqual(got, want) {
+			t.Errorf("ParsePointer(%q) = %+v, %v, want %+v", input, got, ok, want)
+		}
+	}
+}
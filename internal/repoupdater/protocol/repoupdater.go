@@ -26,6 +26,7 @@ type RepoScheduleState struct {
 	Total           int
 	IntervalSeconds int
 	Due             time.Time
+	LastChanged     *time.Time `json:",omitempty"`
 }
 
 type RepoQueueState struct {
@@ -198,10 +199,16 @@ type SyncError struct {
 	Message string
 }
 
+type ExternalServiceQuotaExceeded struct {
+	Message           string
+	ExternalServiceId int64
+}
+
 type StatusMessage struct {
-	Cloning                  *CloningProgress          `json:"cloning"`
-	ExternalServiceSyncError *ExternalServiceSyncError `json:"external_service_sync_error"`
-	SyncError                *SyncError                `json:"sync_error"`
+	Cloning                      *CloningProgress              `json:"cloning"`
+	ExternalServiceSyncError     *ExternalServiceSyncError     `json:"external_service_sync_error"`
+	SyncError                    *SyncError                    `json:"sync_error"`
+	ExternalServiceQuotaExceeded *ExternalServiceQuotaExceeded `json:"external_service_quota_exceeded"`
 }
 
 type StatusMessagesResponse struct {
@@ -0,0 +1,114 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PatternExprKind is the kind of a PatternExpr.
+type PatternExprKind int
+
+const (
+	// PatternLeaf is a single search pattern.
+	PatternLeaf PatternExprKind = iota
+	// PatternAnd matches files that match all of its operands.
+	PatternAnd
+	// PatternOr matches files that match any of its operands.
+	PatternOr
+)
+
+// PatternExpr is an and/or expression of search patterns, such as
+// `(a or b) and not c`. The search backends evaluate it as a whole for each
+// file, instead of evaluating each pattern separately over all repositories.
+type PatternExpr struct {
+	Kind PatternExprKind
+
+	// Pattern is the pattern of a leaf. Besides the pattern it contains the
+	// same file filters and limits as the PatternInfo of the TextParameters
+	// it belongs to.
+	Pattern *TextPatternInfo
+
+	// Negated is true if the leaf matches files that do not match Pattern.
+	// Negated leaves are only valid as operands of a PatternAnd that has at
+	// least one operand that is not negated.
+	Negated bool
+
+	// Operands are the operands of a PatternAnd or PatternOr.
+	Operands []*PatternExpr
+}
+
+// Leaves returns the leaves of the expression in order.
+func (e *PatternExpr) Leaves() []*PatternExpr {
+	if e.Kind == PatternLeaf {
+		return []*PatternExpr{e}
+	}
+	var leaves []*PatternExpr
+	for _, o := range e.Operands {
+		leaves = append(leaves, o.Leaves()...)
+	}
+	return leaves
+}
+
+// Validate returns an error if the expression cannot be evaluated, for
+// example because it negates a pattern that isn't intersected with another
+// pattern.
+func (e *PatternExpr) Validate() error {
+	if e.Kind == PatternLeaf && e.Negated {
+		return errors.New("a negated pattern must be combined with another pattern using and")
+	}
+	return e.validate()
+}
+
+func (e *PatternExpr) validate() error {
+	switch e.Kind {
+	case PatternLeaf:
+		if e.Pattern == nil {
+			return errors.New("pattern expression leaf has no pattern")
+		}
+		return e.Pattern.Validate()
+	case PatternAnd:
+		positive := false
+		for _, o := range e.Operands {
+			if o.Kind != PatternLeaf || !o.Negated {
+				positive = true
+			}
+		}
+		if !positive {
+			return errors.New("a negated pattern must be combined with another pattern using and")
+		}
+	case PatternOr:
+		for _, o := range e.Operands {
+			if o.Kind == PatternLeaf && o.Negated {
+				return errors.New("a negated pattern cannot be combined with another pattern using or")
+			}
+		}
+	}
+	for _, o := range e.Operands {
+		if err := o.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *PatternExpr) String() string {
+	switch e.Kind {
+	case PatternLeaf:
+		if e.Negated {
+			return fmt.Sprintf("(not %q)", e.Pattern.Pattern)
+		}
+		return fmt.Sprintf("%q", e.Pattern.Pattern)
+	case PatternAnd, PatternOr:
+		kind := "and"
+		if e.Kind == PatternOr {
+			kind = "or"
+		}
+		operands := make([]string, 0, len(e.Operands))
+		for _, o := range e.Operands {
+			operands = append(operands, o.String())
+		}
+		return fmt.Sprintf("(%s %s)", kind, strings.Join(operands, " "))
+	}
+	return "<unknown>"
+}
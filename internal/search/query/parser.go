@@ -19,6 +19,10 @@ OrTerm     → AndTerm { OR AndTerm }
 AndTerm    → Term { AND Term }
 Term       → (OrTerm) | Parameters
 Parameters → Parameter { " " Parameter }
+Parameter  → NOT (OrTerm) | NOT Parameter | Parameter
+
+NOT negates the term that follows it. Negation is pushed down to parameters, so
+that only parameters are negated in the resulting tree.
 */
 
 type Node interface {
@@ -54,6 +58,8 @@ type Operator struct {
 func (node Parameter) String() string {
 	var v string
 	switch {
+	case node.Field == "" && node.Negated:
+		return fmt.Sprintf("(not %s)", strconv.Quote(node.Value))
 	case node.Field == "":
 		v = node.Value
 	case node.Negated:
@@ -88,6 +94,7 @@ type keyword string
 const (
 	AND    keyword = "and"
 	OR     keyword = "or"
+	NOT    keyword = "not"
 	LPAREN keyword = "("
 	RPAREN keyword = ")"
 )
@@ -169,6 +176,30 @@ func (p *parser) expect(keyword keyword) bool {
 	return true
 }
 
+// matchKeyword is like match, but only matches and, or and not keywords that
+// are followed by whitespace, a parenthesis or the end of the input. This
+// avoids interpreting patterns like "order" as keywords.
+func (p *parser) matchKeyword(keyword keyword) bool {
+	if !p.match(keyword) {
+		return false
+	}
+	end := p.pos + len(string(keyword))
+	if end >= len(p.buf) {
+		return true
+	}
+	return isSpace(p.buf[end:]) || p.buf[end] == '(' || p.buf[end] == ')'
+}
+
+// expectKeyword returns the result of matchKeyword, and advances the position
+// if it succeeds.
+func (p *parser) expectKeyword(keyword keyword) bool {
+	if !p.matchKeyword(keyword) {
+		return false
+	}
+	p.pos += len(string(keyword))
+	return true
+}
+
 // skipSpaces advances the input and places the parser position at the next
 // non-space value.
 func (p *parser) skipSpaces() error {
@@ -383,9 +414,15 @@ loop:
 				}
 			}
 			break loop
-		case p.match(AND), p.match(OR):
+		case p.matchKeyword(AND), p.matchKeyword(OR):
 			// Caller advances.
 			break loop
+		case p.expectKeyword(NOT):
+			negated, err := p.parseNot()
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, negated...)
 		default:
 			// First try parse a parameter as a search pattern containing parens.
 			if parameter, ok := p.ParseSearchPatternHeuristic(); ok {
@@ -399,6 +436,65 @@ loop:
 	return partitionParameters(nodes), nil
 }
 
+// parseNot parses the term following a NOT keyword and returns its negation.
+func (p *parser) parseNot() ([]Node, error) {
+	if err := p.skipSpaces(); err != nil {
+		return nil, err
+	}
+	if p.done() {
+		return nil, fmt.Errorf("expected operand after not at %d", p.pos)
+	}
+	if p.expectKeyword(NOT) {
+		nodes, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return negate(nodes)
+	}
+	if p.expect(LPAREN) {
+		p.balanced++
+		nodes, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return negate(nodes)
+	}
+	if parameter, ok := p.ParseSearchPatternHeuristic(); ok {
+		return negate([]Node{parameter})
+	}
+	return negate([]Node{p.ParseParameter()})
+}
+
+// negate returns the negation of nodes. Negation is pushed down to parameters
+// following De Morgan's laws:
+// not (a and b) => (not a) or (not b)
+// not (a or b)  => (not a) and (not b)
+// A sequence of concatenated patterns cannot be negated.
+func negate(nodes []Node) ([]Node, error) {
+	negated := make([]Node, 0, len(nodes))
+	for _, node := range nodes {
+		switch v := node.(type) {
+		case Parameter:
+			v.Negated = !v.Negated
+			negated = append(negated, v)
+		case Operator:
+			operands, err := negate(v.Operands)
+			if err != nil {
+				return nil, err
+			}
+			switch v.Kind {
+			case And:
+				negated = append(negated, newOperator(operands, Or)...)
+			case Or:
+				negated = append(negated, newOperator(operands, And)...)
+			case Concat:
+				return nil, errors.New("cannot negate a sequence of patterns, combine them with and or or instead")
+			}
+		}
+	}
+	return negated, nil
+}
+
 // reduce takes lists of left and right nodes and reduces them if possible. For example,
 // (and a (b and c))       => (and a b c)
 // (((a and b) or c) or d) => (or (and a b) c d)
@@ -467,7 +563,7 @@ func (p *parser) parseAnd() ([]Node, error) {
 	if left == nil {
 		return nil, fmt.Errorf("expected operand at %d", p.pos)
 	}
-	if !p.expect(AND) {
+	if !p.expectKeyword(AND) {
 		return left, nil
 	}
 	right, err := p.parseAnd()
@@ -487,7 +583,7 @@ func (p *parser) parseOr() ([]Node, error) {
 	if left == nil {
 		return nil, fmt.Errorf("expected operand at %d", p.pos)
 	}
-	if !p.expect(OR) {
+	if !p.expectKeyword(OR) {
 		return left, nil
 	}
 	right, err := p.parseOr()
@@ -233,7 +233,54 @@ func Test_Parse(t *testing.T) {
 			WantGrammar:   `(and "repo:b" "repo:c" (concat "a" (and "repo:e" "repo:f" (concat "d" "e"))))`,
 			WantHeuristic: Same,
 		},
+		// Keywords.
+		{
+			Name:          "Keywords require a word boundary",
+			Input:         "order and android or nothing",
+			WantGrammar:   `(or (and "order" "android") "nothing")`,
+			WantHeuristic: Same,
+		},
+		// Negation.
+		{
+			Input:         "a and not b",
+			WantGrammar:   `(and "a" (not "b"))`,
+			WantHeuristic: Same,
+		},
+		{
+			Input:         "(a or b) and not c",
+			WantGrammar:   `(and (or "a" "b") (not "c"))`,
+			WantHeuristic: Same,
+		},
+		{
+			Name:          "Negation follows De Morgan's laws",
+			Input:         "a and not (b or c)",
+			WantGrammar:   `(and "a" (not "b") (not "c"))`,
+			WantHeuristic: Same,
+		},
+		{
+			Input:         "a and not (b and c)",
+			WantGrammar:   `(and "a" (or (not "b") (not "c")))`,
+			WantHeuristic: Same,
+		},
+		{
+			Name:          "Negated parameters",
+			Input:         "a not repo:foo",
+			WantGrammar:   `(and "-repo:foo" "a")`,
+			WantHeuristic: Same,
+		},
+		{
+			Name:          "Double negation",
+			Input:         "a and not not b",
+			WantGrammar:   `(and "a" "b")`,
+			WantHeuristic: Same,
+		},
 		// Errors.
+		{
+			Name:          "Negated sequence of patterns",
+			Input:         "a and not (b c)",
+			WantGrammar:   "cannot negate a sequence of patterns, combine them with and or or instead",
+			WantHeuristic: Same,
+		},
 		{
 			Name:          "Unbalanced",
 			Input:         "(foo) (bar",
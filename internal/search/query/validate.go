@@ -4,9 +4,9 @@ import (
 	"errors"
 )
 
-// isPatternExpression returns true if every leaf node in a tree root at node is
+// IsPatternExpression returns true if every leaf node in a tree root at node is
 // a search pattern.
-func isPatternExpression(nodes []Node) bool {
+func IsPatternExpression(nodes []Node) bool {
 	result := true
 	VisitParameter(nodes, func(field, _ string, _ bool) {
 		if field != "" && field != "content" {
@@ -21,9 +21,9 @@ func isPatternExpression(nodes []Node) bool {
 // otherwise for nested parameters.
 func processTopLevel(nodes []Node) ([]Node, error) {
 	if term, ok := nodes[0].(Operator); ok {
-		if term.Kind == And && isPatternExpression([]Node{term}) {
+		if term.Kind == And && IsPatternExpression([]Node{term}) {
 			return nodes, nil
-		} else if term.Kind == Or && isPatternExpression([]Node{term}) {
+		} else if term.Kind == Or && IsPatternExpression([]Node{term}) {
 			return nodes, nil
 		} else if term.Kind == And {
 			return term.Operands, nil
@@ -51,7 +51,7 @@ func PartitionSearchPattern(nodes []Node) (parameters []Node, pattern Node, err
 
 	var patterns []Node
 	for _, node := range nodes {
-		if isPatternExpression([]Node{node}) {
+		if IsPatternExpression([]Node{node}) {
 			patterns = append(patterns, node)
 		} else if term, ok := node.(Parameter); ok {
 			parameters = append(parameters, term)
@@ -88,10 +88,26 @@ func isPureSearchPattern(buf []byte) bool {
 		// so it cannot be interpreted as a search pattern.
 		return false
 	}
-	if !isPatternExpression(newOperator(result, Concat)) {
+	if containsNegation(result) {
+		// The balanced string negates terms with not, so it cannot be
+		// interpreted as a search pattern.
+		return false
+	}
+	if !IsPatternExpression(newOperator(result, Concat)) {
 		// The balanced string contains other parameters, like
 		// "repo:foo", which are not search patterns.
 		return false
 	}
 	return true
 }
+
+// containsNegation returns true if any parameter in nodes is negated.
+func containsNegation(nodes []Node) bool {
+	var result bool
+	VisitParameter(nodes, func(_, _ string, negated bool) {
+		if negated {
+			result = true
+		}
+	})
+	return result
+}
@@ -3,6 +3,7 @@ package search
 import (
 	"context"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 
@@ -170,6 +171,16 @@ func (r *RepositoryRevisions) RevSpecs() []string {
 	return revspecs
 }
 
+// HasRefGlob returns whether any of r's revision specifiers is a ref glob.
+func (r *RepositoryRevisions) HasRefGlob() bool {
+	for _, rev := range r.Revs {
+		if rev.RefGlob != "" || rev.ExcludeRefGlob != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // ExpandedRevSpecs evaluates all of r's ref glob expressions and returns the full, current list of
 // refs matched or resolved by them, plus the explicitly listed Git revspecs. See
 // git.CompileRefGlobs for information on how ref include/exclude globs are handled.
@@ -178,14 +189,56 @@ func (r *RepositoryRevisions) RevSpecs() []string {
 // command-line args to `git` directly (e.g., to `git log --glob ... --exclude ...`), it does not
 // need to use this function.
 func (r *RepositoryRevisions) ExpandedRevSpecs(ctx context.Context) ([]string, error) {
+	revs, err := r.ExpandedRevisions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	revSpecs := map[string]struct{}{}
+	for _, rev := range revs {
+		if len(rev.Refs) == 0 {
+			revSpecs[rev.RevSpec] = struct{}{}
+		}
+		for _, ref := range rev.Refs {
+			revSpecs[ShortRefName(ref)] = struct{}{}
+		}
+	}
+
+	revSpecsList := make([]string, 0, len(revSpecs))
+	for revSpec := range revSpecs {
+		revSpecsList = append(revSpecsList, revSpec)
+	}
+	return revSpecsList, nil
+}
+
+// ExpandedRevision is a revision of a repository to search.
+type ExpandedRevision struct {
+	// RevSpec is the explicitly listed Git revspec ("" for the default branch). It is only set if
+	// Refs is empty, and the caller must resolve it.
+	RevSpec string
+
+	// Commit is the commit that all of Refs point to. It is only set if Refs is non-empty.
+	Commit api.CommitID
+
+	// Refs are the full names of the refs matched by ref globs that point to Commit (e.g.,
+	// "refs/heads/release/3.0"), in sorted order.
+	Refs []string
+}
+
+// ExpandedRevisions is like ExpandedRevSpecs, except that it groups the refs matched by ref globs by
+// the commit they point to, so that callers can search each distinct commit only once. The
+// explicitly listed Git revspecs come first, followed by the matched commits in order of their
+// first ref's name.
+func (r *RepositoryRevisions) ExpandedRevisions(ctx context.Context) ([]ExpandedRevision, error) {
 	listRefs := r.ListRefs
 	if listRefs == nil {
 		listRefs = git.ListRefs
 	}
 
 	var (
-		revSpecs = map[string]struct{}{}
-		globs    []git.RefGlob
+		revs  []ExpandedRevision
+		seen  = map[string]struct{}{}
+		globs []git.RefGlob
 	)
 	for _, rev := range r.Revs {
 		switch {
@@ -194,30 +247,57 @@ func (r *RepositoryRevisions) ExpandedRevSpecs(ctx context.Context) ([]string, e
 		case rev.ExcludeRefGlob != "":
 			globs = append(globs, git.RefGlob{Exclude: rev.ExcludeRefGlob})
 		default:
-			revSpecs[rev.RevSpec] = struct{}{}
+			if _, ok := seen[rev.RevSpec]; !ok {
+				seen[rev.RevSpec] = struct{}{}
+				revs = append(revs, ExpandedRevision{RevSpec: rev.RevSpec})
+			}
 		}
 	}
-	if len(globs) > 0 {
-		allRefs, err := listRefs(ctx, r.GitserverRepo())
-		if err != nil {
-			return nil, err
-		}
+	if len(globs) == 0 {
+		return revs, nil
+	}
 
-		rg, err := git.CompileRefGlobs(globs)
-		if err != nil {
-			return nil, err
-		}
+	allRefs, err := listRefs(ctx, r.GitserverRepo())
+	if err != nil {
+		return nil, err
+	}
 
-		for _, ref := range allRefs {
-			if rg.Match(ref.Name) {
-				revSpecs[strings.TrimPrefix(ref.Name, "refs/heads/")] = struct{}{}
-			}
-		}
+	rg, err := git.CompileRefGlobs(globs)
+	if err != nil {
+		return nil, err
 	}
 
-	revSpecsList := make([]string, 0, len(revSpecs))
-	for revSpec := range revSpecs {
-		revSpecsList = append(revSpecsList, revSpec)
+	var (
+		byCommit = map[api.CommitID]*ExpandedRevision{}
+		commits  []*ExpandedRevision
+	)
+	for _, ref := range allRefs {
+		if !rg.Match(ref.Name) {
+			continue
+		}
+		if _, ok := seen[ShortRefName(ref.Name)]; ok {
+			continue // already searched as an explicitly listed revspec
+		}
+		rev, ok := byCommit[ref.CommitID]
+		if !ok {
+			rev = &ExpandedRevision{Commit: ref.CommitID}
+			byCommit[ref.CommitID] = rev
+			commits = append(commits, rev)
+		}
+		rev.Refs = append(rev.Refs, ref.Name)
 	}
-	return revSpecsList, nil
+	for _, rev := range commits {
+		sort.Strings(rev.Refs)
+	}
+	sort.Slice(commits, func(i, j int) bool { return commits[i].Refs[0] < commits[j].Refs[0] })
+	for _, rev := range commits {
+		revs = append(revs, *rev)
+	}
+	return revs, nil
+}
+
+// ShortRefName returns the name of the ref without the "refs/heads/" prefix for branches, which is
+// how users usually refer to branches in revspecs.
+func ShortRefName(ref string) string {
+	return strings.TrimPrefix(ref, "refs/heads/")
 }
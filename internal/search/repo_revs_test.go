@@ -1,11 +1,16 @@
 package search
 
 import (
+	"context"
 	"reflect"
+	"sort"
 	"sync"
 	"testing"
 
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
 func TestParseRepositoryRevisions(t *testing.T) {
@@ -65,3 +70,65 @@ func TestRepositoryRevisions(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+func TestRepositoryRevisions_ExpandedRevisions(t *testing.T) {
+	listRefs := func(context.Context, gitserver.Repo) ([]git.Ref, error) {
+		return []git.Ref{
+			{Name: "refs/heads/master", CommitID: "a"},
+			{Name: "refs/heads/release/3.1", CommitID: "c"},
+			{Name: "refs/heads/release/3.0", CommitID: "b"},
+			{Name: "refs/heads/release/3.0-rc", CommitID: "b"},
+			{Name: "refs/heads/release/old", CommitID: "d"},
+			{Name: "refs/tags/v3.0.0", CommitID: "b"},
+		}, nil
+	}
+
+	tests := map[string]struct {
+		revs         []RevisionSpecifier
+		want         []ExpandedRevision
+		wantRevSpecs []string
+	}{
+		"default branch": {
+			revs:         []RevisionSpecifier{{RevSpec: ""}},
+			want:         []ExpandedRevision{{RevSpec: ""}},
+			wantRevSpecs: []string{""},
+		},
+		"ref glob grouped by commit": {
+			revs: []RevisionSpecifier{{RefGlob: "refs/heads/release/*"}, {ExcludeRefGlob: "refs/heads/release/old"}},
+			want: []ExpandedRevision{
+				{Commit: "b", Refs: []string{"refs/heads/release/3.0", "refs/heads/release/3.0-rc"}},
+				{Commit: "c", Refs: []string{"refs/heads/release/3.1"}},
+			},
+			wantRevSpecs: []string{"release/3.0", "release/3.0-rc", "release/3.1"},
+		},
+		"explicit revspec and overlapping ref glob": {
+			revs: []RevisionSpecifier{{RevSpec: "release/3.1"}, {RefGlob: "refs/heads/release/3.*"}, {RefGlob: "refs/tags/*"}},
+			want: []ExpandedRevision{
+				{RevSpec: "release/3.1"},
+				{Commit: "b", Refs: []string{"refs/heads/release/3.0", "refs/heads/release/3.0-rc", "refs/tags/v3.0.0"}},
+			},
+			wantRevSpecs: []string{"refs/tags/v3.0.0", "release/3.0", "release/3.0-rc", "release/3.1"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rr := &RepositoryRevisions{Repo: &types.Repo{Name: "r"}, Revs: test.revs, ListRefs: listRefs}
+			got, err := rr.ExpandedRevisions(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+
+			gotRevSpecs, err := rr.ExpandedRevSpecs(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(gotRevSpecs)
+			if !reflect.DeepEqual(gotRevSpecs, test.wantRevSpecs) {
+				t.Errorf("got revspecs %q, want %q", gotRevSpecs, test.wantRevSpecs)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Package searcher is a client for the streaming API of the searcher
+// service.
+package searcher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+// Error is returned by Stream when searcher reports that the search failed,
+// either with a non-200 response or in the trailer of the stream. In the
+// latter case, StatusCode is the status code searcher would have responded
+// with (see protocol.StreamDone.ErrorCode).
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("searcher request failed: code=%d body=%s", e.StatusCode, e.Message)
+}
+
+func (e *Error) BadRequest() bool {
+	return e.StatusCode == http.StatusBadRequest
+}
+
+func (e *Error) Temporary() bool {
+	return e.StatusCode == http.StatusServiceUnavailable
+}
+
+// Stream sends a streaming search request to searcherURL, which must already
+// contain the encoded protocol.Request as its query. onMatch is called with
+// each FileMatch as soon as it is received.
+//
+// The returned StreamDone is the trailer sent by searcher. If searcher
+// reported an error in the trailer, it is returned alongside the trailer.
+func Stream(ctx context.Context, doer httpcli.Doer, searcherURL string, onMatch func(*protocol.FileMatch)) (*protocol.StreamDone, error) {
+	u, err := url.Parse(searcherURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("Stream", "true")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := doer.Do(req)
+	if err != nil {
+		// If we failed due to cancellation or timeout, return just that.
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, errors.Wrap(err, "searcher request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.WithStack(&Error{StatusCode: resp.StatusCode, Message: string(body)})
+	}
+
+	var done *protocol.StreamDone
+	err = readEvents(resp.Body, func(name string, data []byte) error {
+		switch name {
+		case protocol.StreamEventMatch:
+			var m protocol.FileMatch
+			if err := json.Unmarshal(data, &m); err != nil {
+				return errors.Wrap(err, "searcher response invalid")
+			}
+			onMatch(&m)
+		case protocol.StreamEventDone:
+			done = &protocol.StreamDone{}
+			if err := json.Unmarshal(data, done); err != nil {
+				return errors.Wrap(err, "searcher response invalid")
+			}
+		}
+		// Unknown events are ignored so that searcher can add new ones.
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, errors.Wrap(err, "reading searcher response")
+	}
+
+	if done == nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errors.New("searcher response ended before it was done")
+	}
+	if done.Error != "" {
+		return done, errors.WithStack(&Error{StatusCode: done.ErrorCode, Message: done.Error})
+	}
+	return done, nil
+}
+
+// readEvents reads server-sent events from r, calling f with the name and
+// data of each event. It stops at the end of r or when f returns an error.
+func readEvents(r io.Reader, f func(name string, data []byte) error) error {
+	var (
+		br   = bufio.NewReader(r)
+		name string
+		data []byte
+	)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			return nil
+		} else if err != nil && err != io.EOF {
+			return err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		switch {
+		case len(line) == 0:
+			// A blank line dispatches the event.
+			if data != nil {
+				if err := f(name, data); err != nil {
+					return err
+				}
+			}
+			name, data = "", nil
+		case line[0] == ':':
+			// Comment
+		case bytes.HasPrefix(line, []byte("event:")):
+			name = string(bytes.TrimSpace(line[len("event:"):]))
+		case bytes.HasPrefix(line, []byte("data:")):
+			d := bytes.TrimPrefix(line[len("data:"):], []byte(" "))
+			if data == nil {
+				data = []byte{}
+			} else {
+				data = append(data, '\n')
+			}
+			data = append(data, d...)
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantMatches   []string
+		wantDone      *protocol.StreamDone
+		wantErr       string
+		wantTemporary bool
+	}{
+		{
+			name:   "matches",
+			status: http.StatusOK,
+			body: `: comment
+
+event: match
+data: {"Path":"a.go","MatchCount":1}
+
+event: unknown
+data: {}
+
+event: match
+data: {"Path":"b.go","MatchCount":2}
+
+event: done
+data: {"LimitHit":true,"Stats":{"MatchCount":2,"FilesSearched":3}}
+
+`,
+			wantMatches: []string{"a.go", "b.go"},
+			wantDone: &protocol.StreamDone{
+				LimitHit: true,
+				Stats:    protocol.SearchStats{MatchCount: 2, FilesSearched: 3},
+			},
+		},
+		{
+			name:   "error in trailer",
+			status: http.StatusOK,
+			body: "event: match\ndata: {\"Path\":\"a.go\"}\n\n" +
+				"event: done\ndata: {\"Error\":\"boom\"}\n\n",
+			wantMatches: []string{"a.go"},
+			wantDone:    &protocol.StreamDone{Error: "boom"},
+			wantErr:     "searcher request failed: code=0 body=boom",
+		},
+		{
+			name:   "temporary error in trailer",
+			status: http.StatusOK,
+			body:   "event: done\ndata: {\"Error\":\"boom\",\"ErrorCode\":503}\n\n",
+			wantDone: &protocol.StreamDone{
+				Error:     "boom",
+				ErrorCode: http.StatusServiceUnavailable,
+			},
+			wantErr:       "searcher request failed: code=503 body=boom",
+			wantTemporary: true,
+		},
+		{
+			name:        "truncated",
+			status:      http.StatusOK,
+			body:        "event: match\ndata: {\"Path\":\"a.go\"}\n\n",
+			wantMatches: []string{"a.go"},
+			wantErr:     "searcher response ended before it was done",
+		},
+		{
+			name:    "bad request",
+			status:  http.StatusBadRequest,
+			body:    "invalid pattern",
+			wantErr: "searcher request failed: code=400 body=invalid pattern",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("Stream") != "true" {
+					t.Errorf("expected Stream=true, got query %q", r.URL.RawQuery)
+				}
+				if r.URL.Query().Get("Repo") != "foo" {
+					t.Errorf("expected Repo=foo, got query %q", r.URL.RawQuery)
+				}
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer ts.Close()
+
+			var matches []string
+			done, err := Stream(context.Background(), http.DefaultClient, ts.URL+"?Repo=foo", func(m *protocol.FileMatch) {
+				matches = append(matches, m.Path)
+			})
+			if have := errString(err); have != tc.wantErr {
+				t.Fatalf("have err %q, want %q", have, tc.wantErr)
+			}
+			if have := errcode.IsTemporary(err); have != tc.wantTemporary {
+				t.Errorf("have temporary %v, want %v", have, tc.wantTemporary)
+			}
+			if !reflect.DeepEqual(matches, tc.wantMatches) {
+				t.Errorf("have matches %v, want %v", matches, tc.wantMatches)
+			}
+			if !reflect.DeepEqual(done, tc.wantDone) {
+				t.Errorf("have done %+v, want %+v", done, tc.wantDone)
+			}
+		})
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
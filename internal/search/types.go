@@ -75,6 +75,11 @@ type TextParameters struct {
 	PatternInfo *TextPatternInfo
 	Repos       []*RepositoryRevisions
 
+	// PatternExpr, if non-nil, is an and/or expression of patterns that file
+	// contents must match instead of PatternInfo.Pattern. PatternInfo still
+	// holds the file filters and limits of the search.
+	PatternExpr *PatternExpr
+
 	// Query is the parsed query from the user. You should be using Pattern
 	// instead, but Query is useful for checking extra fields that are set and
 	// ignored by Pattern, such as index:no
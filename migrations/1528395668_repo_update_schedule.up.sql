@@ -0,0 +1,11 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS repo_update_schedule (
+  repo_id integer PRIMARY KEY REFERENCES repo(id) ON DELETE CASCADE,
+  interval_seconds integer NOT NULL,
+  due_at timestamp with time zone NOT NULL,
+  last_changed_at timestamp with time zone,
+  updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS webhook_urls;
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS webhook_secret;
+
+COMMIT;
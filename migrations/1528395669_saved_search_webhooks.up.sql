@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS webhook_urls text[] NOT NULL DEFAULT '{}';
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS webhook_secret text;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS access_tokens_expires_at;
+
+ALTER TABLE access_tokens DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE access_tokens DROP COLUMN IF EXISTS expiry_reminder_sent_at;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE access_tokens ADD COLUMN IF NOT EXISTS expires_at timestamp with time zone;
+ALTER TABLE access_tokens ADD COLUMN IF NOT EXISTS expiry_reminder_sent_at timestamp with time zone;
+
+CREATE INDEX IF NOT EXISTS access_tokens_expires_at ON access_tokens (expires_at) WHERE deleted_at IS NULL AND expiry_reminder_sent_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_prevent_modification();
+
+COMMIT;
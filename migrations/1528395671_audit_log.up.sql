@@ -0,0 +1,30 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id bigserial PRIMARY KEY,
+    actor_user_id integer,
+    action text NOT NULL,
+    subject text NOT NULL DEFAULT '',
+    data json NOT NULL DEFAULT '{}',
+    created_at timestamp with time zone NOT NULL,
+    hash bytea NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_actor_user_id ON audit_log (actor_user_id);
+CREATE INDEX IF NOT EXISTS audit_log_action ON audit_log (action);
+CREATE INDEX IF NOT EXISTS audit_log_created_at ON audit_log (created_at);
+
+-- The audit log is append-only. Each row's hash also covers the previous row's hash, so rows that
+-- are modified or removed by other means (e.g., after dropping this trigger) can be detected.
+CREATE OR REPLACE FUNCTION audit_log_prevent_modification() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+    BEGIN
+        RAISE EXCEPTION 'audit_log is append-only';
+    END;
+$$;
+
+DROP TRIGGER IF EXISTS trig_audit_log_prevent_modification ON audit_log;
+CREATE TRIGGER trig_audit_log_prevent_modification BEFORE UPDATE OR DELETE ON audit_log FOR EACH ROW EXECUTE PROCEDURE audit_log_prevent_modification();
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+
+ALTER TABLE patch_sets ADD COLUMN IF NOT EXISTS search_and_replace jsonb;
+
+CREATE TABLE IF NOT EXISTS patch_jobs (
+    id bigserial PRIMARY KEY,
+    patch_set_id bigint NOT NULL REFERENCES patch_sets(id) ON DELETE CASCADE DEFERRABLE,
+    repo_id bigint NOT NULL REFERENCES repo(id) ON DELETE CASCADE DEFERRABLE,
+    rev text NOT NULL,
+    base_ref text NOT NULL CHECK (base_ref != ''),
+    paths jsonb NOT NULL DEFAULT '[]',
+    patch_id bigint REFERENCES patches(id) ON DELETE SET NULL DEFERRABLE,
+    error text,
+    started_at timestamp with time zone,
+    finished_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    UNIQUE (patch_set_id, repo_id, rev)
+);
+
+CREATE INDEX IF NOT EXISTS patch_jobs_started_at ON patch_jobs (started_at);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE changeset_jobs DROP COLUMN IF EXISTS base_commit;
+ALTER TABLE changeset_jobs DROP COLUMN IF EXISTS rebase_error;
+
+COMMIT;
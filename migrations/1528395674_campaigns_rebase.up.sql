@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE changeset_jobs ADD COLUMN IF NOT EXISTS base_commit text;
+ALTER TABLE changeset_jobs ADD COLUMN IF NOT EXISTS rebase_error text;
+
+COMMIT;
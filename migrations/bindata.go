@@ -102,6 +102,20 @@
 // 1528395666_lsif_filename.up.sql (289B)
 // 1528395667_index_boolean_fields_on_repo.down.sql (120B)
 // 1528395667_index_boolean_fields_on_repo.up.sql (187B)
+// 1528395668_repo_update_schedule.down.sql (60B)
+// 1528395668_repo_update_schedule.up.sql (325B)
+// 1528395669_saved_search_webhooks.down.sql (145B)
+// 1528395669_saved_search_webhooks.up.sql (185B)
+// 1528395670_access_token_expiry.down.sql (198B)
+// 1528395670_access_token_expiry.up.sql (351B)
+// 1528395671_audit_log.down.sql (107B)
+// 1528395671_audit_log.up.sql (1.119kB)
+// 1528395672_patch_jobs.down.sql (115B)
+// 1528395672_patch_jobs.up.sql (864B)
+// 1528395673_campaigns_auto_merge.down.sql (73B)
+// 1528395673_campaigns_auto_merge.up.sql (107B)
+// 1528395674_campaigns_rebase.down.sql (142B)
+// 1528395674_campaigns_rebase.up.sql (158B)
 
 package migrations
 
@@ -2210,6 +2224,286 @@ func _1528395667_index_boolean_fields_on_repoUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __1528395668_repo_update_scheduleDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3c\x00\xc3\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x70\x6f\x5f\x75\x70\x64\x61\x74\x65\x5f\x73\x63\x68\x65\x64\x75\x6c\x65\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xb0\xbf\x92\xc4\x3c\x00\x00\x00")
+
+func _1528395668_repo_update_scheduleDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395668_repo_update_scheduleDownSql,
+		"1528395668_repo_update_schedule.down.sql",
+	)
+}
+
+func _1528395668_repo_update_scheduleDownSql() (*asset, error) {
+	bytes, err := _1528395668_repo_update_scheduleDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395668_repo_update_schedule.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2c, 0xaa, 0x7f, 0x73, 0x93, 0x72, 0x71, 0xe2, 0x24, 0x16, 0x69, 0xa3, 0x6b, 0x30, 0xe0, 0x65, 0x9c, 0x45, 0x81, 0x40, 0x5e, 0x47, 0xc6, 0x54, 0x97, 0x9a, 0x20, 0x4f, 0xfc, 0x94, 0x65, 0xa8}}
+	return a, nil
+}
+
+var __1528395668_repo_update_scheduleUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x8f\xcf\x6a\x84\x30\x10\x87\xef\x79\x8a\xdf\x71\x85\xbe\x81\xa7\xac\x8e\x45\xea\x9f\xa2\x59\xe8\x9e\x42\x30\xc3\x1a\x70\xa3\x98\xd8\x85\x3e\x7d\x71\x85\x42\x2f\xa5\xc7\x61\xbe\xef\x63\xe6\x4c\xaf\x65\x93\x0a\x91\x75\x24\x15\x41\xc9\x73\x45\x28\x0b\x34\xad\x02\x7d\x94\xbd\xea\xb1\xf2\x32\xeb\x6d\xb1\x26\xb2\x0e\xc3\xc8\x76\x9b\x18\x27\x81\x63\xe1\x2c\x9c\x8f\x7c\xe3\x15\xef\x5d\x59\xcb\xee\x8a\x37\xba\xa2\xa3\x82\x3a\x6a\x32\x3a\xfc\x93\xb3\x09\xda\x06\x39\x55\xa4\x08\x99\xec\x33\x99\xd3\x8b\xc0\x53\x5e\x3f\xcd\xa4\x03\x0f\xb3\xb7\xe1\xa7\xb6\x5f\xd0\x5c\xaa\x6a\x87\xec\xc6\xda\x44\x44\x77\xe7\x10\xcd\x7d\xc1\xc3\xc5\xf1\x39\xe2\x6b\xf6\xfc\x8b\x9d\x4c\x88\x7a\x18\x8d\xbf\xb1\xfd\x4b\xda\xbb\xc7\x57\xf6\x5f\x6d\xe4\x54\xc8\x4b\xa5\xe0\xe7\xc7\x29\x11\x49\x2a\x44\xd6\xd6\x75\xa9\x52\xf1\x3d\x00\xfb\x0e\xbf\x26\x45\x01\x00\x00")
+
+func _1528395668_repo_update_scheduleUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395668_repo_update_scheduleUpSql,
+		"1528395668_repo_update_schedule.up.sql",
+	)
+}
+
+func _1528395668_repo_update_scheduleUpSql() (*asset, error) {
+	bytes, err := _1528395668_repo_update_scheduleUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395668_repo_update_schedule.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb0, 0x15, 0xca, 0x18, 0xb5, 0x0, 0xfb, 0x1c, 0xdf, 0x5b, 0x22, 0xd9, 0x96, 0x6f, 0xb8, 0x52, 0xcc, 0x1, 0x6f, 0xd5, 0xdf, 0x97, 0xe, 0xfe, 0xd2, 0xc6, 0x7b, 0x67, 0xe7, 0x7f, 0x91, 0xcb}}
+	return a, nil
+}
+
+var __1528395669_saved_search_webhooksDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x2c\x4b\x4d\x89\x2f\x4e\x4d\x2c\x4a\xce\x48\x2d\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4f\x4d\xca\xc8\xcf\xcf\x8e\x2f\x2d\xca\x29\xb6\x26\x5f\x7b\x71\x6a\x72\x51\x6a\x89\x35\x17\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x60\x00\xcb\x3c\x38\x5b\x91\x00\x00\x00")
+
+func _1528395669_saved_search_webhooksDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395669_saved_search_webhooksDownSql,
+		"1528395669_saved_search_webhooks.down.sql",
+	)
+}
+
+func _1528395669_saved_search_webhooksDownSql() (*asset, error) {
+	bytes, err := _1528395669_saved_search_webhooksDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395669_saved_search_webhooks.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe6, 0xc, 0x81, 0x5d, 0x29, 0xd3, 0xac, 0xcb, 0x25, 0x9e, 0x50, 0x62, 0xb3, 0x97, 0xa6, 0xf1, 0x5a, 0x7b, 0xec, 0xd, 0x62, 0x6c, 0x46, 0xdd, 0x6, 0x84, 0xfb, 0xee, 0xda, 0xc9, 0xbf, 0x1a}}
+	return a, nil
+}
+
+var __1528395669_saved_search_webhooksUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x2c\x4b\x4d\x89\x2f\x4e\x4d\x2c\x4a\xce\x48\x2d\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4f\x4d\xca\xc8\xcf\xcf\x8e\x2f\x2d\xca\x29\x56\x28\x49\xad\x28\x89\x8e\x05\x4b\xfb\x85\xfa\xf8\x28\xb8\xb8\xba\x39\x86\xfa\x84\x28\xa8\x57\xd7\xaa\x5b\x53\x64\x7c\x71\x6a\x72\x51\x6a\x09\xd8\x02\x6b\x2e\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\xc0\x00\xc2\xcb\x80\x08\xb9\x00\x00\x00")
+
+func _1528395669_saved_search_webhooksUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395669_saved_search_webhooksUpSql,
+		"1528395669_saved_search_webhooks.up.sql",
+	)
+}
+
+func _1528395669_saved_search_webhooksUpSql() (*asset, error) {
+	bytes, err := _1528395669_saved_search_webhooksUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395669_saved_search_webhooks.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xd1, 0x16, 0x31, 0xfa, 0x40, 0xb4, 0xc9, 0xae, 0xac, 0xb0, 0x2, 0xe6, 0xe, 0xad, 0x9b, 0x88, 0x11, 0x97, 0x10, 0xd5, 0xaf, 0xe4, 0xfb, 0xd7, 0xc8, 0xcf, 0x59, 0xad, 0x8b, 0x63, 0xf6, 0x61}}
+	return a, nil
+}
+
+var __1528395670_access_token_expiryDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x4c\x4e\x4e\x2d\x2e\x8e\x2f\xc9\xcf\x4e\xcd\x2b\x8e\x4f\xad\x28\xc8\x2c\x4a\x2d\x8e\x4f\x2c\xb1\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x45\x55\xa6\x00\x36\xc9\xd9\xdf\x27\xd4\xd7\x0f\xc9\x28\x64\xcd\x64\xe9\xad\x8c\x2f\x4a\xcd\xcd\xcc\x4b\x49\x2d\x8a\x2f\x4e\xcd\x2b\x81\xb8\xc2\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x30\x00\x8f\x6f\xce\xf8\xc6\x00\x00\x00")
+
+func _1528395670_access_token_expiryDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395670_access_token_expiryDownSql,
+		"1528395670_access_token_expiry.down.sql",
+	)
+}
+
+func _1528395670_access_token_expiryDownSql() (*asset, error) {
+	bytes, err := _1528395670_access_token_expiryDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395670_access_token_expiry.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x87, 0xd, 0x42, 0xd9, 0x5b, 0x32, 0x60, 0x69, 0x5a, 0x7, 0x58, 0xf, 0x41, 0xda, 0xca, 0x39, 0x4e, 0xb4, 0xf2, 0x50, 0xef, 0x2c, 0xcc, 0xd9, 0xdb, 0x51, 0x10, 0xf1, 0x80, 0xc6, 0xed, 0x6e}}
+	return a, nil
+}
+
+var __1528395670_access_token_expiryUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x8e\xcd\x4a\xc5\x30\x10\x46\xf7\x79\x8a\x6f\xa9\xcf\x90\x55\x6e\x33\x6a\x20\x4d\xa0\xcd\xc5\xee\x42\x69\x07\x0c\xda\xb4\x34\x01\x7f\x9e\x5e\x14\x41\x2d\xe8\xe2\x2e\x87\xf9\x38\xe7\x9c\xe8\xd6\x38\x29\x84\xb2\x81\x3a\x04\x75\xb2\x84\x71\x9a\xb8\x94\x58\xd7\x47\xce\x05\x4a\x6b\x34\xde\x9e\x5b\x07\x73\x03\xe7\x03\x68\x30\x7d\xe8\xc1\x2f\x5b\xda\xb9\xc4\xb1\xa2\xa6\x85\x4b\x1d\x97\x0d\xcf\xa9\x3e\x7c\x9e\x78\x5b\x33\xcb\x8b\xb9\xaf\x71\xe7\x25\xe5\x99\xf7\x58\x38\xd7\xff\x25\xa2\xe9\x48\x05\x82\x71\x9a\x86\x03\xed\x97\x34\xfe\x68\xf6\xee\x10\x74\xf5\xfd\xbc\xc6\xfd\x1d\x75\x84\x99\x9f\xb8\xf2\xfc\x61\x37\x3d\xdc\xd9\x5a\x28\xa7\xff\x2c\xfc\xda\x48\x21\x1a\xdf\xb6\x26\x48\xf1\x3e\x00\x2f\x18\xaf\x3a\x5f\x01\x00\x00")
+
+func _1528395670_access_token_expiryUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395670_access_token_expiryUpSql,
+		"1528395670_access_token_expiry.up.sql",
+	)
+}
+
+func _1528395670_access_token_expiryUpSql() (*asset, error) {
+	bytes, err := _1528395670_access_token_expiryUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395670_access_token_expiry.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb1, 0xec, 0x96, 0x2c, 0xde, 0x5, 0x76, 0x90, 0xb1, 0x65, 0x2f, 0xed, 0xfd, 0x76, 0xf0, 0xc6, 0xfc, 0x67, 0x20, 0x2a, 0xc2, 0xd4, 0x58, 0x4e, 0xab, 0x9, 0xfc, 0x3b, 0xb7, 0x4d, 0xa, 0x60}}
+	return a, nil
+}
+
+var __1528395671_audit_logDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x6b\x00\x94\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x61\x75\x64\x69\x74\x5f\x6c\x6f\x67\x3b\x0a\x44\x52\x4f\x50\x20\x46\x55\x4e\x43\x54\x49\x4f\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x61\x75\x64\x69\x74\x5f\x6c\x6f\x67\x5f\x70\x72\x65\x76\x65\x6e\x74\x5f\x6d\x6f\x64\x69\x66\x69\x63\x61\x74\x69\x6f\x6e\x28\x29\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x92\x79\x9b\xcf\x6b\x00\x00\x00")
+
+func _1528395671_audit_logDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395671_audit_logDownSql,
+		"1528395671_audit_log.down.sql",
+	)
+}
+
+func _1528395671_audit_logDownSql() (*asset, error) {
+	bytes, err := _1528395671_audit_logDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395671_audit_log.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc6, 0x32, 0x70, 0x55, 0xb0, 0x6b, 0xa, 0x9e, 0x35, 0xb4, 0xbc, 0x36, 0xa8, 0x87, 0x2e, 0x42, 0x42, 0x1d, 0x7f, 0xa3, 0x26, 0xc7, 0xa3, 0xac, 0x4d, 0x66, 0x28, 0x42, 0xee, 0x3c, 0x78, 0xbe}}
+	return a, nil
+}
+
+var __1528395671_audit_logUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x93\x51\x6f\x9b\x30\x10\xc7\xdf\xf9\x14\xff\x87\x48\x49\xa4\x26\x5f\x20\x4f\x94\x5c\x32\x34\x0a\x91\x03\x5a\xfa\x84\x1c\xb8\x82\xab\x04\x33\xdb\x69\xd7\x4d\xfb\xee\x13\xa4\x4d\x83\xb6\x55\x95\x78\xb0\xf9\xdf\xfd\x7d\xf7\xf3\xf9\x96\xd6\x61\xbc\xf0\xbc\x40\x90\x9f\x12\x52\xff\x36\x22\x84\x2b\xc4\x49\x0a\xda\x85\xdb\x74\x0b\x79\x2a\x95\xcb\x0f\xba\xc2\xc4\x03\x00\x55\x62\xaf\x2a\xcb\x46\xc9\x03\x36\x22\xbc\xf3\xc5\x3d\xbe\xd2\xfd\x4d\xaf\xca\xc2\x69\x93\x9f\x2c\x9b\x5c\x95\x50\x8d\xe3\x8a\xcd\x45\x52\xba\x81\xe3\x1f\xae\xf7\x8f\xb3\x28\x3a\x2b\xf6\xb4\x7f\xe4\xc2\x0d\x25\x2c\x69\xe5\x67\x51\x8a\xf1\xf8\x1c\x55\x4a\x27\xf1\x68\x75\xf3\x8f\x90\x5f\xbf\x5f\x83\x0a\xc3\xd2\x71\x99\x4b\x07\xa7\x8e\x6c\x9d\x3c\xb6\x78\x56\xae\xee\xb7\xf8\xa9\x1b\xbe\xa4\x9f\x33\x6a\x69\x6b\xec\x5f\x1c\xcb\x8b\xe0\x4d\xdf\x91\x84\xf1\x92\x76\xff\x43\x92\x0f\xdb\x4d\xe2\x6b\x5a\x03\x6d\xba\xf8\xb4\x61\x07\xe9\x2f\x27\xa5\x9b\xcf\x5a\x5c\x21\x18\xda\xbc\x0b\x5d\x7f\xb3\x19\xd2\x9a\xcf\x3a\xba\xeb\x55\x16\xb2\x6d\xb9\x29\x67\xba\x39\xbc\xcc\x41\xb2\xa8\x61\xf4\xf3\xd8\x9e\x19\xc9\x83\xd5\x28\xf4\x13\x1b\x0b\x57\x33\x5a\xc3\x4f\x4a\x9f\xec\x55\xcc\x0d\xac\xee\xb6\x5d\x80\x74\xde\x6c\x06\x69\x18\x47\x5d\xaa\x07\xc5\x25\xb4\x81\xe1\xa3\x7e\xe2\x12\xfb\x17\x68\x57\xb3\xc1\x91\x65\x63\x31\xe1\x79\x35\xbf\x81\x7c\x70\x6c\x50\x1a\xdd\xb6\xaa\xa9\xe0\x6a\x65\xe1\x8c\xaa\x2a\x36\x53\x14\xb2\xc1\x9e\x51\xb2\xe3\xc2\x71\x39\x7f\xa3\x91\x08\x08\xda\x44\x7e\x40\x58\x65\x71\x90\x86\xd7\x5d\xe7\x5d\x95\xdc\xb8\xfc\x5c\x44\x21\x9d\xd2\xcd\x64\x0a\x41\x69\x26\xe2\xed\x9b\x7b\x3f\x09\x91\x1f\xaf\x33\x7f\x4d\x68\x0f\x6d\x65\xbf\x1f\xfa\x9f\xfe\x16\xa3\x51\xbf\xea\xdf\x4a\xbf\xea\x3e\xe1\x87\x5b\x02\xed\x02\xda\xf4\x27\x8e\xdf\x41\x0f\x41\x8e\x17\x7d\x0e\xc5\xcb\x85\x37\x1a\x2d\x3c\x6f\x29\x92\x0d\x52\x11\xae\xd7\x24\xba\xc1\x7a\xbd\xc0\xae\x92\xfc\xe3\xba\x07\x63\x71\x99\x86\x37\xab\xcf\x18\xdc\xd2\x2a\x11\x84\x6c\xb3\x7c\x25\xb7\xa4\x88\x52\x1a\x18\x63\x95\x08\x90\x1f\x7c\x81\x48\xbe\x81\x76\x14\x64\x29\x61\x23\x92\x80\x96\x99\x20\x7c\x7c\xc4\xa4\x9b\xad\x20\xb9\xbb\x0b\xd3\x85\xf7\x67\x00\x0b\x49\xe7\x4a\x5f\x04\x00\x00")
+
+func _1528395671_audit_logUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395671_audit_logUpSql,
+		"1528395671_audit_log.up.sql",
+	)
+}
+
+func _1528395671_audit_logUpSql() (*asset, error) {
+	bytes, err := _1528395671_audit_logUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395671_audit_log.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xdb, 0xea, 0xcc, 0x8f, 0xcd, 0xd1, 0x70, 0xf4, 0x2, 0xb1, 0xfb, 0xb9, 0x33, 0x55, 0x7e, 0xaf, 0xdf, 0x17, 0xfd, 0xa6, 0xd6, 0xd6, 0x8b, 0x39, 0x4a, 0xa4, 0x6e, 0x64, 0x32, 0xa2, 0x3e, 0x68}}
+	return a, nil
+}
+
+var __1528395672_patch_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x73\x00\x8c\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x61\x74\x63\x68\x5f\x6a\x6f\x62\x73\x3b\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x70\x61\x74\x63\x68\x5f\x73\x65\x74\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x73\x65\x61\x72\x63\x68\x5f\x61\x6e\x64\x5f\x72\x65\x70\x6c\x61\x63\x65\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x59\xab\x8f\xf6\x73\x00\x00\x00")
+
+func _1528395672_patch_jobsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395672_patch_jobsDownSql,
+		"1528395672_patch_jobs.down.sql",
+	)
+}
+
+func _1528395672_patch_jobsDownSql() (*asset, error) {
+	bytes, err := _1528395672_patch_jobsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395672_patch_jobs.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x15, 0x57, 0x84, 0xec, 0x3d, 0x67, 0x51, 0x2d, 0x53, 0x59, 0xc2, 0x6b, 0xcb, 0xd1, 0xc5, 0xc9, 0xc1, 0xb8, 0xda, 0xe9, 0xdf, 0x16, 0x99, 0x3, 0x9a, 0xd6, 0x1, 0xec, 0x92, 0xb0, 0xc7, 0x3c}}
+	return a, nil
+}
+
+var __1528395672_patch_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x92\x51\x6b\x9d\x40\x14\x84\xdf\xfd\x15\xd3\x27\x15\xf2\x0f\x2e\x7d\xd8\xe8\xb9\xad\x44\xd7\x56\x57\x48\x28\x45\x56\xdd\xd4\x0d\x89\xca\xee\x36\x29\xfd\xf5\x45\x4d\xae\xde\x94\x92\x40\xdf\x94\x99\xf3\xed\x9c\xe1\x5c\xd2\xa7\x84\x1f\x3c\x8f\xa5\x82\x0a\x08\x76\x99\x12\x26\xe9\xda\xbe\xb6\xca\x59\xb0\x38\x46\x94\xa7\x55\xc6\x91\x1c\xc1\x73\x01\xba\x4e\x4a\x51\xc2\x2a\x69\xda\xbe\x96\x43\x57\x1b\x35\xdd\xcb\x56\xe1\xce\x8e\x43\x73\xf0\xbc\xa8\x20\x26\xe8\x99\x75\x3e\xb5\x92\xef\xc6\xc6\x22\xf0\x00\x40\x77\x68\xf4\x0f\xab\x8c\x96\xf7\xf8\x52\x24\x19\x2b\x6e\x70\x45\x37\x17\x8b\x7a\x0a\x52\xaf\x3e\x3d\xb8\x85\xc6\xab\x34\x45\x41\x47\x2a\x88\x47\xf4\x82\x9d\x03\x07\xba\x0b\x91\x73\xc4\x94\x92\x20\x44\xac\x8c\x58\x4c\x88\x67\x6f\x31\x07\x5a\xc1\x46\x4d\xe3\x1b\xcc\xd9\xf2\x6e\xda\x23\x9c\xfa\xb5\x71\xd6\x47\x1a\x69\x55\x6d\xd4\xed\xb9\x86\xe8\x33\x45\x57\x08\x4e\xea\x87\x8f\xf0\xfd\xf0\xb4\x70\x6f\xd7\x22\xb7\x81\x98\x8e\xac\x4a\x05\xfc\x6f\xdf\xfd\x7d\x2f\x5b\xfe\xd7\x55\xa8\xd7\x3d\x94\xb4\xb1\xce\xa2\x2b\x63\x46\xb3\x04\x5c\xc9\xd6\x49\xe3\x54\x57\x4b\x07\xa7\x1f\x94\x75\xf2\x61\xc2\x93\x76\xfd\xf2\x8b\xdf\xe3\xa0\x56\xe3\xad\x1e\xb4\xed\xdf\xe3\x6c\x8d\x92\x6f\x20\xff\xde\x75\x18\x9f\x82\xe7\x4e\x7e\x4e\xdd\x7f\xcd\x57\x3c\xf9\x5a\x11\x82\xfd\x31\x5d\xbc\x5c\xc0\xfc\xf1\x18\x7a\xe1\x76\xb6\x09\x8f\xe9\xfa\x9f\x67\x5b\xef\x0a\xca\xf9\x4e\x40\xb0\x29\x0b\x2d\xcf\xb2\x44\x1c\xbc\x3f\x03\x00\xf3\x88\x57\x96\x60\x03\x00\x00")
+
+func _1528395672_patch_jobsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395672_patch_jobsUpSql,
+		"1528395672_patch_jobs.up.sql",
+	)
+}
+
+func _1528395672_patch_jobsUpSql() (*asset, error) {
+	bytes, err := _1528395672_patch_jobsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395672_patch_jobs.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x4b, 0xba, 0x23, 0x5, 0x56, 0x58, 0x66, 0xcd, 0x0, 0x58, 0xf0, 0x88, 0xa3, 0x64, 0xeb, 0x96, 0xbd, 0x6b, 0x60, 0x4f, 0xcd, 0x5e, 0x80, 0xdc, 0x56, 0x3, 0x82, 0x1f, 0x6b, 0x36, 0x9c, 0xf2}}
+	return a, nil
+}
+
+var __1528395673_campaigns_auto_mergeDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x49\x00\xb6\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x61\x75\x74\x6f\x5f\x6d\x65\x72\x67\x65\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x98\x25\x68\x98\x49\x00\x00\x00")
+
+func _1528395673_campaigns_auto_mergeDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395673_campaigns_auto_mergeDownSql,
+		"1528395673_campaigns_auto_merge.down.sql",
+	)
+}
+
+func _1528395673_campaigns_auto_mergeDownSql() (*asset, error) {
+	bytes, err := _1528395673_campaigns_auto_mergeDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395673_campaigns_auto_merge.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb5, 0x4e, 0x4, 0x62, 0x10, 0xda, 0x54, 0x76, 0x35, 0x26, 0x1a, 0xef, 0xa3, 0x91, 0xdc, 0x68, 0xe2, 0x5d, 0xeb, 0x4a, 0xb2, 0xc7, 0xe2, 0xc, 0xae, 0x5b, 0xd9, 0x90, 0x7c, 0x76, 0x54, 0xa0}}
+	return a, nil
+}
+
+var __1528395673_campaigns_auto_mergeUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x6b\x00\x94\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x4e\x4f\x54\x20\x45\x58\x49\x53\x54\x53\x20\x61\x75\x74\x6f\x5f\x6d\x65\x72\x67\x65\x20\x62\x6f\x6f\x6c\x65\x61\x6e\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x66\x61\x6c\x73\x65\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x68\xda\x7a\xca\x6b\x00\x00\x00")
+
+func _1528395673_campaigns_auto_mergeUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395673_campaigns_auto_mergeUpSql,
+		"1528395673_campaigns_auto_merge.up.sql",
+	)
+}
+
+func _1528395673_campaigns_auto_mergeUpSql() (*asset, error) {
+	bytes, err := _1528395673_campaigns_auto_mergeUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395673_campaigns_auto_merge.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x4, 0x3c, 0x4b, 0x10, 0xb1, 0x51, 0xe1, 0x9e, 0x97, 0xf1, 0x49, 0xc4, 0x28, 0x79, 0xf3, 0x40, 0x6, 0x6c, 0xe2, 0x96, 0x4a, 0x92, 0xae, 0x8, 0x4d, 0x14, 0x51, 0x6f, 0xa8, 0xb5, 0xf9, 0xbf}}
+	return a, nil
+}
+
+var __1528395674_campaigns_rebaseDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x89\xcf\xca\x4f\x2a\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x4a\x2c\x4e\x8d\x4f\xce\xcf\xcd\xcd\x2c\xb1\x26\x5d\x77\x51\x2a\x58\x7f\x6a\x51\x51\x7e\x91\x35\x17\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x60\x00\xb9\x98\xee\x3a\x8e\x00\x00\x00")
+
+func _1528395674_campaigns_rebaseDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395674_campaigns_rebaseDownSql,
+		"1528395674_campaigns_rebase.down.sql",
+	)
+}
+
+func _1528395674_campaigns_rebaseDownSql() (*asset, error) {
+	bytes, err := _1528395674_campaigns_rebaseDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395674_campaigns_rebase.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xcf, 0xda, 0x20, 0xa6, 0xe7, 0x17, 0x36, 0x77, 0x6f, 0xe4, 0xdf, 0x91, 0x0, 0xac, 0xe0, 0x5e, 0x29, 0x61, 0x8b, 0xc, 0x39, 0xc9, 0xe5, 0xaf, 0xb6, 0xb3, 0xc5, 0x7b, 0x91, 0xd3, 0x2b, 0x6d}}
+	return a, nil
+}
+
+var __1528395674_campaigns_rebaseUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x89\xcf\xca\x4f\x2a\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x4a\x2c\x4e\x8d\x4f\xce\xcf\xcd\xcd\x2c\x51\x28\x49\xad\x28\xb1\x26\xcb\x94\xa2\x54\xb0\x39\xa9\x45\x45\xf9\x45\x50\x63\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x03\x00\x65\xc6\x11\xa0\x9e\x00\x00\x00")
+
+func _1528395674_campaigns_rebaseUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395674_campaigns_rebaseUpSql,
+		"1528395674_campaigns_rebase.up.sql",
+	)
+}
+
+func _1528395674_campaigns_rebaseUpSql() (*asset, error) {
+	bytes, err := _1528395674_campaigns_rebaseUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395674_campaigns_rebase.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x86, 0x84, 0xad, 0xf3, 0x1d, 0xab, 0xa4, 0x7d, 0x7c, 0x3c, 0xca, 0x88, 0xbc, 0x77, 0x1d, 0x1d, 0x47, 0x52, 0xd1, 0x4a, 0xec, 0x3, 0x8e, 0x55, 0xca, 0x55, 0x86, 0xc1, 0x7a, 0xa6, 0x80, 0x51}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -2403,6 +2697,20 @@ var _bindata = map[string]func() (*asset, error){
 	"1528395666_lsif_filename.up.sql":                                         _1528395666_lsif_filenameUpSql,
 	"1528395667_index_boolean_fields_on_repo.down.sql":                        _1528395667_index_boolean_fields_on_repoDownSql,
 	"1528395667_index_boolean_fields_on_repo.up.sql":                          _1528395667_index_boolean_fields_on_repoUpSql,
+	"1528395668_repo_update_schedule.down.sql":                                _1528395668_repo_update_scheduleDownSql,
+	"1528395668_repo_update_schedule.up.sql":                                  _1528395668_repo_update_scheduleUpSql,
+	"1528395669_saved_search_webhooks.down.sql":                               _1528395669_saved_search_webhooksDownSql,
+	"1528395669_saved_search_webhooks.up.sql":                                 _1528395669_saved_search_webhooksUpSql,
+	"1528395670_access_token_expiry.down.sql":                                 _1528395670_access_token_expiryDownSql,
+	"1528395670_access_token_expiry.up.sql":                                   _1528395670_access_token_expiryUpSql,
+	"1528395671_audit_log.down.sql":                                           _1528395671_audit_logDownSql,
+	"1528395671_audit_log.up.sql":                                             _1528395671_audit_logUpSql,
+	"1528395672_patch_jobs.down.sql":                                          _1528395672_patch_jobsDownSql,
+	"1528395672_patch_jobs.up.sql":                                            _1528395672_patch_jobsUpSql,
+	"1528395673_campaigns_auto_merge.down.sql":                                _1528395673_campaigns_auto_mergeDownSql,
+	"1528395673_campaigns_auto_merge.up.sql":                                  _1528395673_campaigns_auto_mergeUpSql,
+	"1528395674_campaigns_rebase.down.sql":                                    _1528395674_campaigns_rebaseDownSql,
+	"1528395674_campaigns_rebase.up.sql":                                      _1528395674_campaigns_rebaseUpSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -2548,6 +2856,20 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"1528395666_lsif_filename.up.sql":                                         {_1528395666_lsif_filenameUpSql, map[string]*bintree{}},
 	"1528395667_index_boolean_fields_on_repo.down.sql":                        {_1528395667_index_boolean_fields_on_repoDownSql, map[string]*bintree{}},
 	"1528395667_index_boolean_fields_on_repo.up.sql":                          {_1528395667_index_boolean_fields_on_repoUpSql, map[string]*bintree{}},
+	"1528395668_repo_update_schedule.down.sql":                                {_1528395668_repo_update_scheduleDownSql, map[string]*bintree{}},
+	"1528395668_repo_update_schedule.up.sql":                                  {_1528395668_repo_update_scheduleUpSql, map[string]*bintree{}},
+	"1528395669_saved_search_webhooks.down.sql":                               {_1528395669_saved_search_webhooksDownSql, map[string]*bintree{}},
+	"1528395669_saved_search_webhooks.up.sql":                                 {_1528395669_saved_search_webhooksUpSql, map[string]*bintree{}},
+	"1528395670_access_token_expiry.down.sql":                                 {_1528395670_access_token_expiryDownSql, map[string]*bintree{}},
+	"1528395670_access_token_expiry.up.sql":                                   {_1528395670_access_token_expiryUpSql, map[string]*bintree{}},
+	"1528395671_audit_log.down.sql":                                           {_1528395671_audit_logDownSql, map[string]*bintree{}},
+	"1528395671_audit_log.up.sql":                                             {_1528395671_audit_logUpSql, map[string]*bintree{}},
+	"1528395672_patch_jobs.down.sql":                                          {_1528395672_patch_jobsDownSql, map[string]*bintree{}},
+	"1528395672_patch_jobs.up.sql":                                            {_1528395672_patch_jobsUpSql, map[string]*bintree{}},
+	"1528395673_campaigns_auto_merge.down.sql":                                {_1528395673_campaigns_auto_mergeDownSql, map[string]*bintree{}},
+	"1528395673_campaigns_auto_merge.up.sql":                                  {_1528395673_campaigns_auto_mergeUpSql, map[string]*bintree{}},
+	"1528395674_campaigns_rebase.down.sql":                                    {_1528395674_campaigns_rebaseDownSql, map[string]*bintree{}},
+	"1528395674_campaigns_rebase.up.sql":                                      {_1528395674_campaigns_rebaseUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
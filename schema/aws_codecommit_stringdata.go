@@ -99,6 +99,53 @@ const AWSCodeCommitSchemaJSON = `{
         [{ "name": "go-monorepo" }, { "id": "f001337a-3450-46fd-b7d2-650c0EXAMPLE" }],
         [{ "name": "go-monorepo" }, { "name": "go-client" }]
       ]
+    },
+    "cloneOptions": {
+      "description": "Options for cloning huge repositories from this code host more cheaply. Each entry applies to the repositories whose name matches its pattern; the first matching entry is used.",
+      "type": "array",
+      "items": {
+        "title": "ExternalServiceCloneOptions",
+        "description": "Options for the clones of the repositories whose name matches pattern.",
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["pattern"],
+        "properties": {
+          "pattern": {
+            "description": "Regular expression that matches the names of the repositories (such as \"github.com/myorg/monorepo\") these options apply to.",
+            "type": "string",
+            "format": "regex"
+          },
+          "partial": {
+            "description": "Clone without file contents (a blob-less partial clone). File contents are fetched from the code host when they are first needed, which makes the first search of a repository slower. The code host must support partial clones.",
+            "type": "boolean",
+            "default": false
+          },
+          "depth": {
+            "description": "Only clone and fetch this many commits of history from the tip of each branch and tag (a shallow clone). Blame and commit and diff searches need the full history and return an error for shallow clones.",
+            "type": "integer",
+            "minimum": 1
+          }
+        }
+      },
+      "examples": [[{ "pattern": "^github\\.com/myorg/monorepo$", "partial": true }]]
+    },
+    "quota": {
+      "title": "ExternalServiceQuota",
+      "description": "Limits on the repositories mirrored from this code host. Repositories over a limit are not synced, and site admins are shown a status message.",
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "maxRepos": {
+          "description": "The maximum number of repositories to mirror from this code host. Repositories beyond the limit are not synced. Repositories that are already mirrored are kept in favor of new ones.",
+          "type": "integer",
+          "minimum": 1
+        },
+        "maxTotalCloneSizeMB": {
+          "description": "The maximum total size in megabytes of the clones of the repositories mirrored from this code host. Once it is reached, no more repositories are cloned. Clones that would exceed it are aborted.",
+          "type": "integer",
+          "minimum": 1
+        }
+      }
     }
   }
 }
@@ -12,6 +12,8 @@ import (
 type AWSCodeCommitConnection struct {
 	// AccessKeyID description: The AWS access key ID to use when listing and updating repositories from AWS CodeCommit. Must have the AWSCodeCommitReadOnly IAM policy.
 	AccessKeyID string `json:"accessKeyID"`
+	// CloneOptions description: Options for cloning huge repositories from this code host more cheaply. Each entry applies to the repositories whose name matches its pattern; the first matching entry is used.
+	CloneOptions []*ExternalServiceCloneOptions `json:"cloneOptions,omitempty"`
 	// Exclude description: A list of repositories to never mirror from AWS CodeCommit.
 	//
 	// Supports excluding by name ({"name": "git-codecommit.us-west-1.amazonaws.com/repo-name"}) or by ARN ({"id": "arn:aws:codecommit:us-west-1:999999999999:name"}).
@@ -23,6 +25,8 @@ type AWSCodeCommitConnection struct {
 	GitCredentials AWSCodeCommitGitCredentials `json:"gitCredentials"`
 	// InitialRepositoryEnablement description: Deprecated and ignored field which will be removed entirely in the next release. AWS CodeCommit repositories can no longer be enabled or disabled explicitly. Configure which repositories should not be mirrored via "exclude" instead.
 	InitialRepositoryEnablement bool `json:"initialRepositoryEnablement,omitempty"`
+	// Quota description: Limits on the repositories mirrored from this code host. Repositories over a limit are not synced, and site admins are shown a status message.
+	Quota *ExternalServiceQuota `json:"quota,omitempty"`
 	// Region description: The AWS region in which to access AWS CodeCommit. See the list of supported regions at https://docs.aws.amazon.com/codecommit/latest/userguide/regions.html#regions-git.
 	Region string `json:"region"`
 	// RepositoryPathPattern description: The pattern used to generate a the corresponding Sourcegraph repository name for an AWS CodeCommit repository. In the pattern, the variable "{name}" is replaced with the repository's name.
@@ -50,6 +54,12 @@ type AWSCodeCommitGitCredentials struct {
 type AuthAccessTokens struct {
 	// Allow description: Allow or restrict the use of access tokens. The default is "all-users-create", which enables all users to create access tokens. Use "none" to disable access tokens entirely. Use "site-admin-create" to restrict creation of new tokens to admin users (existing tokens will still work until revoked).
 	Allow string `json:"allow,omitempty"`
+	// DefaultLifetimeDays description: The number of days after which new access tokens expire if their creator doesn't choose an expiration date. The default (0) is that they don't expire.
+	DefaultLifetimeDays int `json:"defaultLifetimeDays,omitempty"`
+	// ExpiryReminderDays description: The number of days before an access token expires when its owner is sent an email reminding them to replace it.
+	ExpiryReminderDays int `json:"expiryReminderDays,omitempty"`
+	// MaxLifetimeDays description: The maximum number of days that new access tokens may be valid for. The default (0) is no maximum. Existing tokens are not affected.
+	MaxLifetimeDays int `json:"maxLifetimeDays,omitempty"`
 }
 
 // AuthProviderCommon description: Common properties for authentication providers.
@@ -117,6 +127,8 @@ type BitbucketCloudConnection struct {
 	ApiURL string `json:"apiURL,omitempty"`
 	// AppPassword description: The app password to use when authenticating to the Bitbucket Cloud. Also set the corresponding "username" field.
 	AppPassword string `json:"appPassword"`
+	// CloneOptions description: Options for cloning huge repositories from this code host more cheaply. Each entry applies to the repositories whose name matches its pattern; the first matching entry is used.
+	CloneOptions []*ExternalServiceCloneOptions `json:"cloneOptions,omitempty"`
 	// Exclude description: A list of repositories to never mirror from Bitbucket Cloud. Takes precedence over "teams" configuration.
 	//
 	// Supports excluding by name ({"name": "myorg/myrepo"}) or by UUID ({"uuid": "{fceb73c7-cef6-4abe-956d-e471281126bd}"}).
@@ -127,6 +139,8 @@ type BitbucketCloudConnection struct {
 	//
 	// If "ssh", Sourcegraph will access Bitbucket Cloud repositories using Git URLs of the form git@bitbucket.org:myteam/myproject.git. See the documentation for how to provide SSH private keys and known_hosts: https://docs.sourcegraph.com/admin/repo/auth#repositories-that-need-http-s-or-ssh-authentication.
 	GitURLType string `json:"gitURLType,omitempty"`
+	// Quota description: Limits on the repositories mirrored from this code host. Repositories over a limit are not synced, and site admins are shown a status message.
+	Quota *ExternalServiceQuota `json:"quota,omitempty"`
 	// RateLimit description: Rate limit applied when making background API requests to Bitbucket Cloud.
 	RateLimit *BitbucketCloudRateLimit `json:"rateLimit,omitempty"`
 	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for a Bitbucket Cloud repository.
@@ -175,6 +189,8 @@ type BitbucketServerConnection struct {
 	Authorization *BitbucketServerAuthorization `json:"authorization,omitempty"`
 	// Certificate description: TLS certificate of the Bitbucket Server instance. This is only necessary if the certificate is self-signed or signed by an internal CA. To get the certificate run `openssl s_client -connect HOST:443 -showcerts < /dev/null 2> /dev/null | openssl x509 -outform PEM`. To escape the value into a JSON string, you may want to use a tool like https://json-escape-text.now.sh.
 	Certificate string `json:"certificate,omitempty"`
+	// CloneOptions description: Options for cloning huge repositories from this code host more cheaply. Each entry applies to the repositories whose name matches its pattern; the first matching entry is used.
+	CloneOptions []*ExternalServiceCloneOptions `json:"cloneOptions,omitempty"`
 	// Exclude description: A list of repositories to never mirror from this Bitbucket Server instance. Takes precedence over "repos" and "repositoryQuery".
 	//
 	// Supports excluding by name ({"name": "projectKey/repositorySlug"}) or by ID ({"id": 42}).
@@ -195,6 +211,8 @@ type BitbucketServerConnection struct {
 	Password string `json:"password,omitempty"`
 	// Plugin description: Configuration for Bitbucket Server Sourcegraph plugin
 	Plugin *BitbucketServerPlugin `json:"plugin,omitempty"`
+	// Quota description: Limits on the repositories mirrored from this code host. Repositories over a limit are not synced, and site admins are shown a status message.
+	Quota *ExternalServiceQuota `json:"quota,omitempty"`
 	// RateLimit description: Rate limit applied when making background API requests to BitbucketServer.
 	RateLimit *BitbucketServerRateLimit `json:"rateLimit,omitempty"`
 	// Repos description: An array of repository "projectKey/repositorySlug" strings specifying repositories to mirror on Sourcegraph.
@@ -360,6 +378,12 @@ type ExcludedBitbucketServerRepo struct {
 	// Pattern description: Regular expression which matches against the name of a Bitbucket Server repo.
 	Pattern string `json:"pattern,omitempty"`
 }
+type ExcludedGerritProject struct {
+	// Name description: The name of a Gerrit project ("platform/build") to exclude from mirroring.
+	Name string `json:"name,omitempty"`
+	// Pattern description: Regular expression which matches against the name of a Gerrit project.
+	Pattern string `json:"pattern,omitempty"`
+}
 type ExcludedGitHubRepo struct {
 	// Archived description: If set to true, archived repositories will be excluded.
 	Archived bool `json:"archived,omitempty"`
@@ -428,6 +452,74 @@ type ExternalIdentity struct {
 	Type           string `json:"type"`
 }
 
+// ExternalServiceCloneOptions description: Options for the clones of the repositories whose name matches pattern.
+type ExternalServiceCloneOptions struct {
+	// Depth description: Only clone and fetch this many commits of history from the tip of each branch and tag (a shallow clone). Blame and commit and diff searches need the full history and return an error for shallow clones.
+	Depth int `json:"depth,omitempty"`
+	// Partial description: Clone without file contents (a blob-less partial clone). File contents are fetched from the code host when they are first needed, which makes the first search of a repository slower. The code host must support partial clones.
+	Partial bool `json:"partial,omitempty"`
+	// Pattern description: Regular expression that matches the names of the repositories (such as "github.com/myorg/monorepo") these options apply to.
+	Pattern string `json:"pattern"`
+}
+
+// ExternalServiceQuota description: Limits on the repositories mirrored from this code host. Repositories over a limit are not synced, and site admins are shown a status message.
+type ExternalServiceQuota struct {
+	// MaxRepos description: The maximum number of repositories to mirror from this code host. Repositories beyond the limit are not synced. Repositories that are already mirrored are kept in favor of new ones.
+	MaxRepos int `json:"maxRepos,omitempty"`
+	// MaxTotalCloneSizeMB description: The maximum total size in megabytes of the clones of the repositories mirrored from this code host. Once it is reached, no more repositories are cloned. Clones that would exceed it are aborted.
+	MaxTotalCloneSizeMB int `json:"maxTotalCloneSizeMB,omitempty"`
+}
+
+// GerritConnection description: Configuration for a connection to Gerrit.
+type GerritConnection struct {
+	// CloneOptions description: Options for cloning huge repositories from this code host more cheaply. Each entry applies to the repositories whose name matches its pattern; the first matching entry is used.
+	CloneOptions []*ExternalServiceCloneOptions `json:"cloneOptions,omitempty"`
+	// Exclude description: A list of Gerrit projects to never mirror. Takes precedence over "projects" configuration.
+	//
+	// Supports excluding by exact name ({"name": "platform/build"}) or by regular expression ({"pattern": "^device/.*"}).
+	Exclude []*ExcludedGerritProject `json:"exclude,omitempty"`
+	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this Gerrit instance.
+	//
+	// If "http", Sourcegraph will access Gerrit projects using Git URLs of the form https://gerrit.example.com/a/myproject.
+	//
+	// If "ssh", Sourcegraph will access Gerrit projects using Git URLs of the form ssh://username@gerrit.example.com:29418/myproject. See the documentation for how to provide SSH private keys and known_hosts: https://docs.sourcegraph.com/admin/repo/auth#repositories-that-need-http-s-or-ssh-authentication.
+	GitURLType string `json:"gitURLType,omitempty"`
+	// Password description: The HTTP password of the user (as generated on the user's "HTTP Credentials" settings page in Gerrit). Also set the corresponding "username" field.
+	Password string `json:"password,omitempty"`
+	// Projects description: A list of Gerrit projects to mirror. If empty, all projects visible to the configured user are mirrored.
+	Projects []string `json:"projects,omitempty"`
+	// Quota description: Limits on the repositories mirrored from this code host. Repositories over a limit are not synced, and site admins are shown a status message.
+	Quota *ExternalServiceQuota `json:"quota,omitempty"`
+	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for a Gerrit project.
+	//
+	//  - "{host}" is replaced with the Gerrit URL's host (such as gerrit.example.com), and "{name}" is replaced with the Gerrit project's name (such as "platform/build").
+	//
+	// For example, if your Gerrit is https://gerrit.example.com and your Sourcegraph is https://src.example.com, then a repositoryPathPattern of "{host}/{name}" would mean that the Gerrit project "platform/build" is available on Sourcegraph at https://src.example.com/gerrit.example.com/platform/build.
+	//
+	// It is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
+	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
+	// SshPort description: The port of Gerrit's SSH daemon. Only used if "gitURLType" is "ssh".
+	SshPort int `json:"sshPort,omitempty"`
+	// Url description: URL of a Gerrit instance, such as https://gerrit.example.com.
+	Url string `json:"url"`
+	// Username description: The username to use when authenticating to the Gerrit REST API and when cloning repositories over HTTP(S). Also set the corresponding "password" field.
+	//
+	// If not set, only projects visible to anonymous users are mirrored.
+	Username string `json:"username,omitempty"`
+}
+
+// GitCommitSigning description: Sign the commits that Sourcegraph creates, such as the commits of campaign changesets, so that they are accepted by code hosts that require signed commits. The private key must be encrypted with a passphrase, which gitserver reads from its SRC_GIT_COMMIT_SIGNING_PASSPHRASE environment variable.
+type GitCommitSigning struct {
+	// CommitterEmail description: The committer email of signed commits. Defaults to the author email. Code hosts only verify a signature if its key belongs to the user with the committer email, so set this to an email of the code host user that the public key was added to.
+	CommitterEmail string `json:"committerEmail,omitempty"`
+	// CommitterName description: The committer name of signed commits. Defaults to the author name.
+	CommitterName string `json:"committerName,omitempty"`
+	// Format description: The type of the signing key: "openpgp" for a GPG key or "ssh" for an SSH key. Verifying SSH signatures requires Git 2.34 or later.
+	Format string `json:"format"`
+	// PrivateKey description: The passphrase-protected private key. For "openpgp", an ASCII-armored RSA key as exported by `gpg --armor --export-secret-keys <key-id>`. For "ssh", an OpenSSH private key as generated by `ssh-keygen -N <passphrase>`.
+	PrivateKey string `json:"privateKey"`
+}
+
 // GitHubAuthProvider description: Configures the GitHub (or GitHub Enterprise) OAuth authentication provider for SSO. In addition to specifying this configuration object, you must also create a OAuth App on your GitHub instance: https://developer.github.com/apps/building-oauth-apps/creating-an-oauth-app/. When a user signs into Sourcegraph or links their GitHub account to their existing Sourcegraph account, GitHub will prompt the user for the repo scope.
 type GitHubAuthProvider struct {
 	// AllowOrgs description: Restricts new logins to members of these GitHub organizations. Existing sessions won't be invalidated. Leave empty or unset for no org restrictions.
@@ -462,6 +554,8 @@ type GitHubConnection struct {
 	Authorization *GitHubAuthorization `json:"authorization,omitempty"`
 	// Certificate description: TLS certificate of the GitHub Enterprise instance. This is only necessary if the certificate is self-signed or signed by an internal CA. To get the certificate run `openssl s_client -connect HOST:443 -showcerts < /dev/null 2> /dev/null | openssl x509 -outform PEM`. To escape the value into a JSON string, you may want to use a tool like https://json-escape-text.now.sh.
 	Certificate string `json:"certificate,omitempty"`
+	// CloneOptions description: Options for cloning huge repositories from this code host more cheaply. Each entry applies to the repositories whose name matches its pattern; the first matching entry is used.
+	CloneOptions []*ExternalServiceCloneOptions `json:"cloneOptions,omitempty"`
 	// Exclude description: A list of repositories to never mirror from this GitHub instance. Takes precedence over "orgs", "repos", and "repositoryQuery" configuration.
 	//
 	// Supports excluding by name ({"name": "owner/name"}) or by ID ({"id": "MDEwOlJlcG9zaXRvcnkxMTczMDM0Mg=="}).
@@ -478,6 +572,8 @@ type GitHubConnection struct {
 	InitialRepositoryEnablement bool `json:"initialRepositoryEnablement,omitempty"`
 	// Orgs description: An array of organization names identifying GitHub organizations whose repositories should be mirrored on Sourcegraph.
 	Orgs []string `json:"orgs,omitempty"`
+	// Quota description: Limits on the repositories mirrored from this code host. Repositories over a limit are not synced, and site admins are shown a status message.
+	Quota *ExternalServiceQuota `json:"quota,omitempty"`
 	// RateLimit description: Rate limit applied when making background API requests to GitHub.
 	RateLimit *GitHubRateLimit `json:"rateLimit,omitempty"`
 	// Repos description: An array of repository "owner/name" strings specifying which GitHub or GitHub Enterprise repositories to mirror on Sourcegraph.
@@ -527,6 +623,16 @@ type GitHubWebhook struct {
 	Secret string `json:"secret"`
 }
 
+// GitLFS description: Fetch Git LFS objects for cloned repositories, so that search and symbols see the contents of LFS-tracked files instead of LFS pointer files. Objects are fetched for the files in each repository's default branch, from the LFS server of the repository's HTTP(S) clone URL.
+type GitLFS struct {
+	// Enabled description: Whether to fetch Git LFS objects.
+	Enabled bool `json:"enabled,omitempty"`
+	// MaxFileSizeMB description: LFS objects larger than this size (in megabytes) are not fetched.
+	MaxFileSizeMB int `json:"maxFileSizeMB,omitempty"`
+	// MaxRepoSizeMB description: The maximum total size (in megabytes) of the LFS objects fetched for a repository.
+	MaxRepoSizeMB int `json:"maxRepoSizeMB,omitempty"`
+}
+
 // GitLabAuthProvider description: Configures the GitLab OAuth authentication provider for SSO. In addition to specifying this configuration object, you must also create a OAuth App on your GitLab instance: https://docs.gitlab.com/ee/integration/oauth_provider.html. The application should have `api` and `read_user` scopes and the callback URL set to the concatenation of your Sourcegraph instance URL and "/.auth/gitlab/callback".
 type GitLabAuthProvider struct {
 	// ClientID description: The Client ID of the GitLab OAuth app, accessible from https://gitlab.com/oauth/applications (or the same path on your private GitLab instance).
@@ -559,6 +665,8 @@ type GitLabConnection struct {
 	Authorization *GitLabAuthorization `json:"authorization,omitempty"`
 	// Certificate description: TLS certificate of the GitLab instance. This is only necessary if the certificate is self-signed or signed by an internal CA. To get the certificate run `openssl s_client -connect HOST:443 -showcerts < /dev/null 2> /dev/null | openssl x509 -outform PEM`. To escape the value into a JSON string, you may want to use a tool like https://json-escape-text.now.sh.
 	Certificate string `json:"certificate,omitempty"`
+	// CloneOptions description: Options for cloning huge repositories from this code host more cheaply. Each entry applies to the repositories whose name matches its pattern; the first matching entry is used.
+	CloneOptions []*ExternalServiceCloneOptions `json:"cloneOptions,omitempty"`
 	// Exclude description: A list of projects to never mirror from this GitLab instance. Takes precedence over "projects" and "projectQuery" configuration. Supports excluding by name ({"name": "group/name"}) or by ID ({"id": 42}).
 	Exclude []*ExcludedGitLabProject `json:"exclude,omitempty"`
 	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this GitLab instance.
@@ -577,6 +685,8 @@ type GitLabConnection struct {
 	ProjectQuery []string `json:"projectQuery"`
 	// Projects description: A list of projects to mirror from this GitLab instance. Supports including by name ({"name": "group/name"}) or by ID ({"id": 42}).
 	Projects []*GitLabProject `json:"projects,omitempty"`
+	// Quota description: Limits on the repositories mirrored from this code host. Repositories over a limit are not synced, and site admins are shown a status message.
+	Quota *ExternalServiceQuota `json:"quota,omitempty"`
 	// RateLimit description: Rate limit applied when making background API requests to GitLab.
 	RateLimit *GitLabRateLimit `json:"rateLimit,omitempty"`
 	// RepositoryPathPattern description: The pattern used to generate a the corresponding Sourcegraph repository name for a GitLab project. In the pattern, the variable "{host}" is replaced with the GitLab URL's host (such as gitlab.example.com), and "{pathWithNamespace}" is replaced with the GitLab project's "namespace/path" (such as "myteam/myproject").
@@ -589,6 +699,8 @@ type GitLabConnection struct {
 	Token string `json:"token"`
 	// Url description: URL of a GitLab instance, such as https://gitlab.example.com or (for GitLab.com) https://gitlab.com.
 	Url string `json:"url"`
+	// Webhooks description: An array of secret tokens of GitLab webhooks that send merge request, note and pipeline events back to Sourcegraph. Webhooks must be configured to send to the /.api/gitlab-webhooks endpoint of this Sourcegraph instance.
+	Webhooks []*GitLabWebhook `json:"webhooks,omitempty"`
 }
 type GitLabNameTransformation struct {
 	// Regex description: The regex to match for the occurrences of its replacement.
@@ -596,6 +708,7 @@ type GitLabNameTransformation struct {
 	// Replacement description: The replacement used to replace all matched occurrences by the regex.
 	Replacement string `json:"replacement,omitempty"`
 }
+
 type GitLabProject struct {
 	// Id description: The ID of a GitLab project (as returned by the GitLab instance's API) to mirror.
 	Id int `json:"id,omitempty"`
@@ -610,11 +723,17 @@ type GitLabRateLimit struct {
 	// RequestsPerHour description: Requests per hour permitted. This is an average, calculated per second.
 	RequestsPerHour float64 `json:"requestsPerHour"`
 }
+type GitLabWebhook struct {
+	// Secret description: The secret token used when creating the webhook
+	Secret string `json:"secret"`
+}
 
 // GitoliteConnection description: Configuration for a connection to Gitolite.
 type GitoliteConnection struct {
 	// Blacklist description: Regular expression to filter repositories from auto-discovery, so they will not get cloned automatically.
 	Blacklist string `json:"blacklist,omitempty"`
+	// CloneOptions description: Options for cloning huge repositories from this code host more cheaply. Each entry applies to the repositories whose name matches its pattern; the first matching entry is used.
+	CloneOptions []*ExternalServiceCloneOptions `json:"cloneOptions,omitempty"`
 	// Exclude description: A list of repositories to never mirror from this Gitolite instance. Supports excluding by exact name ({"name": "foo"}).
 	Exclude []*ExcludedGitoliteRepo `json:"exclude,omitempty"`
 	// Host description: Gitolite host that stores the repositories (e.g., git@gitolite.example.com, ssh://git@gitolite.example.com:2222/).
@@ -627,6 +746,8 @@ type GitoliteConnection struct {
 	//
 	// It is important that the Sourcegraph repository name generated with this prefix be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
 	Prefix string `json:"prefix"`
+	// Quota description: Limits on the repositories mirrored from this code host. Repositories over a limit are not synced, and site admins are shown a status message.
+	Quota *ExternalServiceQuota `json:"quota,omitempty"`
 }
 
 // HTTPHeaderAuthProvider description: Configures the HTTP header authentication provider (which authenticates users by consulting an HTTP request header set by an authentication proxy such as https://github.com/bitly/oauth2_proxy).
@@ -740,7 +861,11 @@ type OpenIDConnectAuthProvider struct {
 
 // OtherExternalServiceConnection description: Configuration for a Connection to Git repositories for which an external service integration isn't yet available.
 type OtherExternalServiceConnection struct {
-	Repos []string `json:"repos"`
+	// CloneOptions description: Options for cloning huge repositories from this code host more cheaply. Each entry applies to the repositories whose name matches its pattern; the first matching entry is used.
+	CloneOptions []*ExternalServiceCloneOptions `json:"cloneOptions,omitempty"`
+	// Quota description: Limits on the repositories mirrored from this code host. Repositories over a limit are not synced, and site admins are shown a status message.
+	Quota *ExternalServiceQuota `json:"quota,omitempty"`
+	Repos []string              `json:"repos"`
 	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for the repositories. In the pattern, the variable "{base}" is replaced with the Git clone base URL host and path, and "{repo}" is replaced with the repository path taken from the `repos` field.
 	//
 	// For example, if your Git clone base URL is https://git.example.com/repos and `repos` contains the value "my/repo", then a repositoryPathPattern of "{base}/{repo}" would mean that a repository at https://git.example.com/repos/my/repo is available on Sourcegraph at https://sourcegraph.example.com/git.example.com/repos/my/repo.
@@ -779,6 +904,10 @@ type Phabricator struct {
 
 // PhabricatorConnection description: Configuration for a connection to Phabricator.
 type PhabricatorConnection struct {
+	// CloneOptions description: Options for cloning huge repositories from this code host more cheaply. Each entry applies to the repositories whose name matches its pattern; the first matching entry is used.
+	CloneOptions []*ExternalServiceCloneOptions `json:"cloneOptions,omitempty"`
+	// Quota description: Limits on the repositories mirrored from this code host. Repositories over a limit are not synced, and site admins are shown a status message.
+	Quota *ExternalServiceQuota `json:"quota,omitempty"`
 	// Repos description: The list of repositories available on Phabricator.
 	Repos []*Repos `json:"repos,omitempty"`
 	// Token description: API token for the Phabricator instance.
@@ -991,8 +1120,14 @@ type SiteConfiguration struct {
 	ExternalURL string `json:"externalURL,omitempty"`
 	// GitCloneURLToRepositoryName description: JSON array of configuration that maps from Git clone URL to repository name. Sourcegraph automatically resolves remote clone URLs to their proper code host. However, there may be non-remote clone URLs (e.g., in submodule declarations) that Sourcegraph cannot automatically map to a code host. In this case, use this field to specify the mapping. The mappings are tried in the order they are specified and take precedence over automatic mappings.
 	GitCloneURLToRepositoryName []*CloneURLToRepositoryName `json:"git.cloneURLToRepositoryName,omitempty"`
+	// GitCommitSigning description: Sign the commits that Sourcegraph creates, such as the commits of campaign changesets, so that they are accepted by code hosts that require signed commits. The private key must be encrypted with a passphrase, which gitserver reads from its SRC_GIT_COMMIT_SIGNING_PASSPHRASE environment variable.
+	GitCommitSigning *GitCommitSigning `json:"gitCommitSigning,omitempty"`
+	// GitLFS description: Fetch Git LFS objects for cloned repositories, so that search and symbols see the contents of LFS-tracked files instead of LFS pointer files. Objects are fetched for the files in each repository's default branch, from the LFS server of the repository's HTTP(S) clone URL.
+	GitLFS *GitLFS `json:"gitLFS,omitempty"`
 	// GitMaxConcurrentClones description: Maximum number of git clone processes that will be run concurrently to update repositories.
 	GitMaxConcurrentClones int `json:"gitMaxConcurrentClones,omitempty"`
+	// GitReplicationFactor description: Number of gitserver shards that keep a copy of each repository. The default of 1 stores each repository only on its primary shard. With a higher value, each repository is also cloned to the following shards in the gitserver list, and requests fall back to a replica when the primary shard is unavailable.
+	GitReplicationFactor int `json:"gitReplicationFactor,omitempty"`
 	// GithubClientID description: Client ID for GitHub. (DEPRECATED)
 	GithubClientID string `json:"githubClientID,omitempty"`
 	// GithubClientSecret description: Client secret for GitHub. (DEPRECATED)
@@ -262,6 +262,64 @@ const SiteSchemaJSON = `{
       "default": 5,
       "group": "External services"
     },
+    "gitLFS": {
+      "description": "Fetch Git LFS objects for cloned repositories, so that search and symbols see the contents of LFS-tracked files instead of LFS pointer files. Objects are fetched for the files in each repository's default branch, from the LFS server of the repository's HTTP(S) clone URL.",
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "enabled": {
+          "description": "Whether to fetch Git LFS objects.",
+          "type": "boolean",
+          "default": false
+        },
+        "maxFileSizeMB": {
+          "description": "LFS objects larger than this size (in megabytes) are not fetched.",
+          "type": "integer",
+          "minimum": 1,
+          "default": 10
+        },
+        "maxRepoSizeMB": {
+          "description": "The maximum total size (in megabytes) of the LFS objects fetched for a repository.",
+          "type": "integer",
+          "minimum": 1,
+          "default": 500
+        }
+      },
+      "group": "External services"
+    },
+    "gitCommitSigning": {
+      "description": "Sign the commits that Sourcegraph creates, such as the commits of campaign changesets, so that they are accepted by code hosts that require signed commits. The private key must be encrypted with a passphrase, which gitserver reads from its SRC_GIT_COMMIT_SIGNING_PASSPHRASE environment variable.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["format", "privateKey"],
+      "properties": {
+        "format": {
+          "description": "The type of the signing key: \"openpgp\" for a GPG key or \"ssh\" for an SSH key. Verifying SSH signatures requires Git 2.34 or later.",
+          "type": "string",
+          "enum": ["openpgp", "ssh"]
+        },
+        "privateKey": {
+          "description": "The passphrase-protected private key. For \"openpgp\", an ASCII-armored RSA key as exported by ` + "`" + `gpg --armor --export-secret-keys <key-id>` + "`" + `. For \"ssh\", an OpenSSH private key as generated by ` + "`" + `ssh-keygen -N <passphrase>` + "`" + `.",
+          "type": "string"
+        },
+        "committerName": {
+          "description": "The committer name of signed commits. Defaults to the author name.",
+          "type": "string"
+        },
+        "committerEmail": {
+          "description": "The committer email of signed commits. Defaults to the author email. Code hosts only verify a signature if its key belongs to the user with the committer email, so set this to an email of the code host user that the public key was added to.",
+          "type": "string"
+        }
+      },
+      "group": "External services"
+    },
+    "gitReplicationFactor": {
+      "description": "Number of gitserver shards that keep a copy of each repository. The default of 1 stores each repository only on its primary shard. With a higher value, each repository is also cloned to the following shards in the gitserver list, and requests fall back to a replica when the primary shard is unavailable.",
+      "type": "integer",
+      "minimum": 1,
+      "default": 1,
+      "group": "External services"
+    },
     "repoListUpdateInterval": {
       "description": "Interval (in minutes) for checking code hosts (such as GitHub, Gitolite, etc.) for new repositories.",
       "type": "integer",
@@ -296,6 +354,24 @@ const SiteSchemaJSON = `{
           "type": "string",
           "enum": ["all-users-create", "site-admin-create", "none"],
           "default": "all-users-create"
+        },
+        "defaultLifetimeDays": {
+          "description": "The number of days after which new access tokens expire if their creator doesn't choose an expiration date. The default (0) is that they don't expire.",
+          "type": "integer",
+          "minimum": 0,
+          "default": 0
+        },
+        "maxLifetimeDays": {
+          "description": "The maximum number of days that new access tokens may be valid for. The default (0) is no maximum. Existing tokens are not affected.",
+          "type": "integer",
+          "minimum": 0,
+          "default": 0
+        },
+        "expiryReminderDays": {
+          "description": "The number of days before an access token expires when its owner is sent an email reminding them to replace it.",
+          "type": "integer",
+          "minimum": 1,
+          "default": 7
         }
       },
       "default": {
@@ -305,7 +381,13 @@ const SiteSchemaJSON = `{
         {
           "allow": "site-admin-create"
         },
-        { "allow": "none" }
+        { "allow": "none" },
+        {
+          "allow": "all-users-create",
+          "defaultLifetimeDays": 30,
+          "maxLifetimeDays": 90,
+          "expiryReminderDays": 7
+        }
       ],
       "group": "Security"
     },
@@ -268,6 +268,7 @@ export const queryChangesets = (
                                 state
                                 reviewState
                                 checkState
+                                rebaseError
                                 labels {
                                     text
                                     description
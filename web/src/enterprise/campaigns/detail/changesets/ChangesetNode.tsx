@@ -29,6 +29,7 @@ import { ActionItemAction } from '../../../../../../shared/src/actions/ActionIte
 import { FileDiffConnection } from '../../../../components/diff/FileDiffConnection'
 import { FilteredConnectionQueryArgs } from '../../../../components/FilteredConnection'
 import { ChangesetLastSynced } from './ChangesetLastSynced'
+import AlertCircleIcon from 'mdi-react/AlertCircleIcon'
 
 export interface ChangesetNodeProps extends ThemeProps {
     node: IExternalChangeset
@@ -93,6 +94,14 @@ export const ChangesetNode: React.FunctionComponent<ChangesetNodeProps> = ({
                                 />
                             </small>
                         )}
+                        {node.rebaseError && (
+                            <small>
+                                <AlertCircleIcon
+                                    className="ml-1 icon-inline text-danger e2e-changeset-rebase-error"
+                                    data-tooltip="The patch of this changeset no longer applies on its base branch, so its branch can't be rebased"
+                                />
+                            </small>
+                        )}
                         {node.labels.length > 0 && (
                             <span className="ml-2">
                                 {node.labels.map(label => (
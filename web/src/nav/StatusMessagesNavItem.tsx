@@ -35,6 +35,14 @@ export function fetchAllStatusMessages(): Observable<GQL.StatusMessage[]> {
                             displayName
                         }
                     }
+
+                    ... on ExternalServiceQuotaExceeded {
+                        message
+                        externalService {
+                            id
+                            displayName
+                        }
+                    }
                 }
             }
         `
@@ -171,6 +179,19 @@ export class StatusMessagesNavItem extends React.PureComponent<Props, State> {
                         entryType="warning"
                     />
                 )
+            case 'ExternalServiceQuotaExceeded':
+                return (
+                    <StatusMessagesNavItemEntry
+                        key={key}
+                        title={`External service "${message.externalService.displayName}" exceeded its quota:`}
+                        text={message.message}
+                        showLink={this.props.isSiteAdmin}
+                        linkTo={`/site-admin/external-services/${message.externalService.id}`}
+                        linkText={`Edit "${message.externalService.displayName}"`}
+                        linkOnClick={this.toggleIsOpen}
+                        entryType="warning"
+                    />
+                )
             case 'SyncError':
                 return (
                     <StatusMessagesNavItemEntry
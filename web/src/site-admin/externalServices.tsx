@@ -9,6 +9,7 @@ import React from 'react'
 import awsCodeCommitSchemaJSON from '../../../schema/aws_codecommit.schema.json'
 import bitbucketCloudSchemaJSON from '../../../schema/bitbucket_cloud.schema.json'
 import bitbucketServerSchemaJSON from '../../../schema/bitbucket_server.schema.json'
+import gerritSchemaJSON from '../../../schema/gerrit.schema.json'
 import githubSchemaJSON from '../../../schema/github.schema.json'
 import gitlabSchemaJSON from '../../../schema/gitlab.schema.json'
 import gitoliteSchemaJSON from '../../../schema/gitolite.schema.json'
@@ -885,6 +886,76 @@ const GITLAB_SELF_MANAGED: AddExternalServiceOptions = {
 }`,
 }
 
+const GERRIT: AddExternalServiceOptions = {
+    kind: GQL.ExternalServiceKind.GERRIT,
+    title: 'Gerrit',
+    icon: GitIcon,
+    jsonSchema: gerritSchemaJSON,
+    defaultDisplayName: 'Gerrit',
+    defaultConfig: `{
+  "url": "https://gerrit.example.com",
+  "username": "<username>",
+  "password": "<HTTP password>"
+}`,
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    In the configuration below, set <Field>url</Field> to be the URL of your Gerrit instance.
+                </li>
+                <li>
+                    Generate an HTTP password on the <b>HTTP Credentials</b> page of your Gerrit user settings. Set{' '}
+                    <Field>username</Field> to be your username and <Field>password</Field> to be the generated
+                    password.
+                </li>
+                <li>
+                    Optionally, set the <Field>projects</Field> field to the list of projects Sourcegraph should index.
+                    By default, all projects visible to the user are indexed.
+                </li>
+            </ol>
+            <p>
+                See{' '}
+                <a
+                    rel="noopener noreferrer"
+                    target="_blank"
+                    href="https://docs.sourcegraph.com/admin/external_service/gerrit#configuration"
+                >
+                    the docs for more advanced options
+                </a>
+                , or try one of the buttons below.
+            </p>
+        </div>
+    ),
+    editorActions: [
+        {
+            id: 'setPassword',
+            label: 'Set HTTP password',
+            run: config => {
+                const value = '<HTTP password>'
+                const edits = setProperty(config, ['password'], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addProject',
+            label: 'Add a project',
+            run: config => {
+                const value = '<project>'
+                const edits = setProperty(config, ['projects', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'excludeProject',
+            label: 'Exclude a project',
+            run: config => {
+                const value = { name: '<project>' }
+                const edits = setProperty(config, ['exclude', -1], value, defaultFormattingOptions)
+                return { edits, selectText: '<project>' }
+            },
+        },
+    ],
+}
 const GITOLITE: AddExternalServiceOptions = {
     kind: GQL.ExternalServiceKind.GITOLITE,
     title: 'Gitolite',
@@ -1055,6 +1126,7 @@ export const codeHostExternalServices: Record<string, AddExternalServiceOptions>
     bitbucket: BITBUCKET_CLOUD,
     bitbucketserver: BITBUCKET_SERVER,
     aws_codecommit: AWS_CODE_COMMIT,
+    gerrit: GERRIT,
     gitolite: GITOLITE,
     git: GENERIC_GIT,
 }
@@ -1073,6 +1145,7 @@ export const defaultExternalServices: Record<GQL.ExternalServiceKind, AddExterna
     [GQL.ExternalServiceKind.BITBUCKETCLOUD]: BITBUCKET_CLOUD,
     [GQL.ExternalServiceKind.BITBUCKETSERVER]: BITBUCKET_SERVER,
     [GQL.ExternalServiceKind.GITLAB]: GITLAB_DOTCOM,
+    [GQL.ExternalServiceKind.GERRIT]: GERRIT,
     [GQL.ExternalServiceKind.GITOLITE]: GITOLITE,
     [GQL.ExternalServiceKind.PHABRICATOR]: PHABRICATOR_SERVICE,
     [GQL.ExternalServiceKind.OTHER]: GENERIC_GIT,
@@ -17,17 +17,22 @@ import { eventLogger } from '../../../tracking/eventLogger'
 import { UserAreaRouteContext } from '../../area/UserArea'
 import { ErrorAlert } from '../../../components/alerts'
 
-function createAccessToken(user: GQL.ID, scopes: string[], note: string): Observable<GQL.ICreateAccessTokenResult> {
+function createAccessToken(
+    user: GQL.ID,
+    scopes: string[],
+    note: string,
+    expiresAt: string | null
+): Observable<GQL.ICreateAccessTokenResult> {
     return mutateGraphQL(
         gql`
-            mutation CreateAccessToken($user: ID!, $scopes: [String!]!, $note: String!) {
-                createAccessToken(user: $user, scopes: $scopes, note: $note) {
+            mutation CreateAccessToken($user: ID!, $scopes: [String!]!, $note: String!, $expiresAt: DateTime) {
+                createAccessToken(user: $user, scopes: $scopes, note: $note, expiresAt: $expiresAt) {
                     id
                     token
                 }
             }
         `,
-        { user, scopes, note }
+        { user, scopes, note, expiresAt }
     ).pipe(
         map(({ data, errors }) => {
             if (!data || !data.createAccessToken || (errors && errors.length > 0)) {
@@ -40,6 +45,14 @@ function createAccessToken(user: GQL.ID, scopes: string[], note: string): Observ
     )
 }
 
+/** The access token scopes that are narrower than "user:all". */
+const NARROW_SCOPES: { scope: AccessTokenScopes; description: string }[] = [
+    { scope: AccessTokenScopes.SearchRead, description: 'Ability to run searches' },
+    { scope: AccessTokenScopes.RepoRead, description: 'Read access to repositories and their contents' },
+    { scope: AccessTokenScopes.CampaignsWrite, description: 'Ability to view, create, update and delete campaigns' },
+    { scope: AccessTokenScopes.SettingsWrite, description: 'Ability to view and edit settings' },
+]
+
 interface Props extends UserAreaRouteContext, RouteComponentProps<{}> {
     /** Called when a new access token is created and should be temporarily displayed to the user. */
     onDidCreateAccessToken: (result: GQL.ICreateAccessTokenResult) => void
@@ -52,6 +65,9 @@ interface State {
     /** The selected scopes checkboxes. */
     scopes: string[]
 
+    /** The contents of the expiration date input field (YYYY-MM-DD), or empty for the site default. */
+    expiresAt: string
+
     creationOrError?: 'loading' | GQL.ICreateAccessTokenResult | ErrorLike
 }
 
@@ -62,6 +78,7 @@ export class UserSettingsCreateAccessTokenPage extends React.PureComponent<Props
     public state: State = {
         note: '',
         scopes: [AccessTokenScopes.UserAll],
+        expiresAt: '',
     }
 
     private submits = new Subject<React.FormEvent<HTMLFormElement>>()
@@ -77,7 +94,12 @@ export class UserSettingsCreateAccessTokenPage extends React.PureComponent<Props
                     concatMap(() =>
                         concat(
                             [{ creationOrError: 'loading' }],
-                            createAccessToken(this.props.user.id, this.state.scopes, this.state.note).pipe(
+                            createAccessToken(
+                                this.props.user.id,
+                                this.state.scopes,
+                                this.state.note,
+                                this.state.expiresAt ? new Date(this.state.expiresAt).toISOString() : null
+                            ).pipe(
                                 tap(result => {
                                     // Go back to access tokens list page and display the token secret value.
                                     this.props.history.push(`${this.props.match.url.replace(/\/new$/, '')}`)
@@ -138,7 +160,8 @@ export class UserSettingsCreateAccessTokenPage extends React.PureComponent<Props
                         </label>
                         <div>
                             <small className="form-help text-muted">
-                                Tokens with limited user scopes are not yet supported.
+                                Tokens with narrower scopes than <strong>{AccessTokenScopes.UserAll}</strong> can only
+                                be used for the operations their scopes permit.
                             </small>
                         </div>
                         <div className="form-check">
@@ -146,10 +169,9 @@ export class UserSettingsCreateAccessTokenPage extends React.PureComponent<Props
                                 className="form-check-input"
                                 type="checkbox"
                                 id="user-settings-create-access-token-page__scope-user:all"
-                                checked={true}
+                                checked={this.state.scopes.includes(AccessTokenScopes.UserAll)}
                                 value={AccessTokenScopes.UserAll}
                                 onChange={this.onScopesChange}
-                                disabled={true}
                             />
                             <label
                                 className="form-check-label"
@@ -159,6 +181,24 @@ export class UserSettingsCreateAccessTokenPage extends React.PureComponent<Props
                                 to the user account
                             </label>
                         </div>
+                        {NARROW_SCOPES.map(({ scope, description }) => (
+                            <div className="form-check" key={scope}>
+                                <input
+                                    className="form-check-input"
+                                    type="checkbox"
+                                    id={`user-settings-create-access-token-page__scope-${scope}`}
+                                    checked={this.state.scopes.includes(scope)}
+                                    value={scope}
+                                    onChange={this.onScopesChange}
+                                />
+                                <label
+                                    className="form-check-label"
+                                    htmlFor={`user-settings-create-access-token-page__scope-${scope}`}
+                                >
+                                    <strong>{scope}</strong> — {description}
+                                </label>
+                            </div>
+                        ))}
                         {this.props.user.siteAdmin && (
                             <div className="form-check">
                                 <input
@@ -179,6 +219,19 @@ export class UserSettingsCreateAccessTokenPage extends React.PureComponent<Props
                             </div>
                         )}
                     </div>
+                    <div className="form-group">
+                        <label htmlFor="user-settings-create-access-token-page__expires-at">Expiration date</label>
+                        <input
+                            type="date"
+                            className="form-control"
+                            id="user-settings-create-access-token-page__expires-at"
+                            value={this.state.expiresAt}
+                            onChange={this.onExpiresAtChange}
+                        />
+                        <small className="form-help text-muted">
+                            Optional. If left blank, the site's default access token lifetime applies (if any).
+                        </small>
+                    </div>
                     <button
                         type="submit"
                         disabled={this.state.creationOrError === 'loading'}
@@ -208,6 +261,9 @@ export class UserSettingsCreateAccessTokenPage extends React.PureComponent<Props
     private onNoteChange: React.ChangeEventHandler<HTMLInputElement> = e =>
         this.setState({ note: e.currentTarget.value })
 
+    private onExpiresAtChange: React.ChangeEventHandler<HTMLInputElement> = e =>
+        this.setState({ expiresAt: e.currentTarget.value })
+
     private onScopesChange: React.ChangeEventHandler<HTMLInputElement> = e => {
         const checked = e.currentTarget.checked
         const value = e.currentTarget.value